@@ -0,0 +1,79 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codeaction
+
+import (
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/ide/converter"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/vulnmap"
+	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/cli"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/lsp"
+)
+
+// settingsDocumentationUrl documents the settings that configure the environment of the CLI
+const settingsDocumentationUrl = "https://github.com/khulnasoft-lab/vulnmap-ls#lsp-initialization-options"
+
+var rescanCommand = vulnmap.CommandData{Title: "Rescan workspace", CommandId: vulnmap.WorkspaceScanCommand}
+
+// cliErrorCommands contains the commands that help to fix the CLI errors of a type
+var cliErrorCommands = map[cli.ErrorType][]vulnmap.CommandData{
+	cli.ErrorTypeAuthFailed: {{Title: "Re-authenticate", CommandId: vulnmap.LoginCommand}},
+	cli.ErrorTypeOrgNotFound: {
+		{Title: "Use the default organization", CommandId: vulnmap.SetOrganizationCommand},
+		rescanCommand,
+	},
+	cli.ErrorTypeMissingBuildTool: {rescanCommand},
+}
+
+// cliErrorActions returns the code actions for the CLI error diagnostics the client sent with the code action request
+func cliErrorActions(diagnostics []lsp.Diagnostic) (actions []lsp.CodeAction) {
+	for _, diagnostic := range diagnostics {
+		code, ok := diagnostic.Code.(string)
+		if !ok {
+			continue
+		}
+		errorType := cli.ErrorType(code)
+		commands := cliErrorCommands[errorType]
+		if errorType == cli.ErrorTypeMissingBuildTool && missingTool(diagnostic) == "java" {
+			commands = append([]vulnmap.CommandData{{
+				Title:     "Set JAVA_HOME (opens the settings documentation)",
+				CommandId: vulnmap.OpenBrowserCommand,
+				Arguments: []any{settingsDocumentationUrl},
+			}}, commands...)
+		}
+		for i := range commands {
+			actions = append(actions, lsp.CodeAction{
+				Title:       commands[i].Title,
+				Kind:        lsp.QuickFix,
+				Diagnostics: []lsp.Diagnostic{diagnostic},
+				Command:     converter.ToCommand(&commands[i]),
+			})
+		}
+	}
+	return actions
+}
+
+func missingTool(diagnostic lsp.Diagnostic) string {
+	switch data := diagnostic.Data.(type) {
+	case map[string]string:
+		return data["tool"]
+	case map[string]any:
+		tool, _ := data["tool"].(string)
+		return tool
+	default:
+		return ""
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -34,6 +36,7 @@ type CodeActionsService struct {
 	// actionsCache holds all the issues that were returns by the GetCodeActions method.
 	// This is used to resolve the code actions later on in ResolveCodeAction.
 	actionsCache  map[uuid.UUID]cachedAction
+	cacheMutex    sync.Mutex
 	logger        zerolog.Logger
 	fileWatcher   dirtyFilesWatcher
 	notifier      noti.Notifier
@@ -68,11 +71,14 @@ func (c *CodeActionsService) GetCodeActions(params lsp.CodeActionParams) []lsp.C
 	logMsg := fmt.Sprint("Found ", len(issues), " issues for path ", path, " and range ", r)
 	c.logger.Info().Msg(logMsg)
 	actions := converter.ToCodeActions(issues)
+	actions = append(actions, cliErrorActions(params.Context.Diagnostics)...)
 
 	// The cache is cleared every time AddCodeActions is called, because the assumed workflow is:
 	// 1. User gets multiple code action options for a given path/range via textDocument/codeAction
 	// 2. User selects an action and the action is resolved via codeAction/resolve
 	// So there is no reason to store issues for longer than that.
+	c.cacheMutex.Lock()
+	defer c.cacheMutex.Unlock()
 	for key := range c.actionsCache {
 		delete(c.actionsCache, key)
 	}
@@ -111,7 +117,9 @@ func (c *CodeActionsService) ResolveCodeAction(
 	}
 
 	key := uuid.UUID(*action.Data)
+	c.cacheMutex.Lock()
 	cached, found := c.actionsCache[key]
+	c.cacheMutex.Unlock()
 	if !found {
 		return lsp.CodeAction{}, errors.New(fmt.Sprint("could not find cached action for uuid ", key))
 	}
@@ -127,6 +135,28 @@ func (c *CodeActionsService) ResolveCodeAction(
 	return codeAction, nil
 }
 
+// MoveCodeActions moves the cached code actions of a renamed or moved file or folder to the new path, so that they are
+// resolved to edits of the new path
+func (c *CodeActionsService) MoveCodeActions(oldPath string, newPath string) {
+	c.cacheMutex.Lock()
+	defer c.cacheMutex.Unlock()
+	for key, cached := range c.actionsCache {
+		filePath := cached.issue.AffectedFilePath
+		if !uri.FolderContains(oldPath, filePath) {
+			continue
+		}
+		relativePath, err := filepath.Rel(oldPath, filePath)
+		if err != nil {
+			continue
+		}
+		newFilePath := filepath.Join(newPath, relativePath)
+		c.actionsCache[key] = cachedAction{
+			issue:  cached.issue.Moved(newFilePath),
+			action: cached.action.Moved(filePath, newFilePath),
+		}
+	}
+}
+
 func (c *CodeActionsService) handleCommand(
 	action lsp.CodeAction,
 	server lsp.Server,
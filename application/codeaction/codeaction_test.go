@@ -1,12 +1,14 @@
 package codeaction_test
 
 import (
+	"path/filepath"
 	"testing"
 
 	"github.com/google/uuid"
 	sglsp "github.com/sourcegraph/go-lsp"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
 	"github.com/khulnasoft-lab/vulnmap-ls/application/codeaction"
 	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
@@ -14,6 +16,7 @@ import (
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/ide/command"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/ide/converter"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/vulnmap"
+	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/cli"
 	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/code"
 	"github.com/khulnasoft-lab/vulnmap-ls/internal/lsp"
 	"github.com/khulnasoft-lab/vulnmap-ls/internal/notification"
@@ -110,6 +113,55 @@ func Test_GetCodeActions_NoIssues_ReturnsNil(t *testing.T) {
 	assert.Nil(t, actions)
 }
 
+func Test_GetCodeActions_CliErrorDiagnostics_ReturnsRemedyActions(t *testing.T) {
+	testutil.UnitTest(t)
+	var issues []vulnmap.Issue
+	providerMock := new(mockIssuesProvider)
+	providerMock.On("IssuesFor", mock.Anything, mock.Anything).Return(issues)
+	service := codeaction.NewService(config.CurrentConfig(), providerMock, watcher.NewFileWatcher(), notification.NewNotifier(), &code.FakeVulnmapCodeClient{})
+	codeActionsParam := lsp.CodeActionParams{
+		TextDocument: sglsp.TextDocumentIdentifier{URI: documentUriExample},
+		Range:        exampleRange,
+		Context: lsp.CodeActionContext{Diagnostics: []lsp.Diagnostic{
+			{Code: string(cli.ErrorTypeAuthFailed)},
+			// data is sent back by the client as a JSON object
+			{Code: string(cli.ErrorTypeMissingBuildTool), Data: map[string]any{"tool": "java"}},
+			{Code: "Vulnmap Error"},
+		}},
+	}
+
+	actions := service.GetCodeActions(codeActionsParam)
+
+	assert.Len(t, actions, 3)
+	assert.Equal(t, vulnmap.LoginCommand, actions[0].Command.Command)
+	assert.Equal(t, vulnmap.OpenBrowserCommand, actions[1].Command.Command)
+	assert.Contains(t, actions[1].Title, "JAVA_HOME")
+	assert.Equal(t, vulnmap.WorkspaceScanCommand, actions[2].Command.Command)
+	assert.Equal(t, lsp.QuickFix, actions[2].Kind)
+}
+
+func Test_GetCodeActions_OrgNotFound_OffersDefaultOrganization(t *testing.T) {
+	testutil.UnitTest(t)
+	var issues []vulnmap.Issue
+	providerMock := new(mockIssuesProvider)
+	providerMock.On("IssuesFor", mock.Anything, mock.Anything).Return(issues)
+	service := codeaction.NewService(config.CurrentConfig(), providerMock, watcher.NewFileWatcher(), notification.NewNotifier(), &code.FakeVulnmapCodeClient{})
+	codeActionsParam := lsp.CodeActionParams{
+		TextDocument: sglsp.TextDocumentIdentifier{URI: documentUriExample},
+		Range:        exampleRange,
+		Context: lsp.CodeActionContext{Diagnostics: []lsp.Diagnostic{
+			{Code: string(cli.ErrorTypeOrgNotFound)},
+		}},
+	}
+
+	actions := service.GetCodeActions(codeActionsParam)
+
+	assert.Len(t, actions, 2)
+	assert.Equal(t, vulnmap.SetOrganizationCommand, actions[0].Command.Command)
+	assert.Empty(t, actions[0].Command.Arguments)
+	assert.Equal(t, vulnmap.WorkspaceScanCommand, actions[1].Command.Command)
+}
+
 func Test_ResolveCodeAction_ReturnsCorrectEdit(t *testing.T) {
 	testutil.UnitTest(t)
 	// Arrange
@@ -153,6 +205,30 @@ func Test_ResolveCodeAction_ReturnsCorrectEdit(t *testing.T) {
 	assert.NotNil(t, resolvedAction.Edit)
 }
 
+func Test_ResolveCodeAction_AfterRename_ReturnsEditOfNewPath(t *testing.T) {
+	testutil.UnitTest(t)
+	oldPath := uri.PathFromUri(documentUriExample)
+	newPath := filepath.Join(filepath.Dir(oldPath), "renamed")
+	deferredEdit := func() *vulnmap.WorkspaceEdit {
+		return &vulnmap.WorkspaceEdit{Changes: map[string][]vulnmap.TextEdit{oldPath: {{NewText: "fixed"}}}}
+	}
+	id := uuid.New()
+	issue := vulnmap.Issue{
+		AffectedFilePath: oldPath,
+		CodeActions:      []vulnmap.CodeAction{{Title: "Fix this", DeferredEdit: &deferredEdit, Uuid: &id}},
+	}
+	service, codeActionsParam, _ := setupWithSingleIssue(issue)
+	actions := service.GetCodeActions(codeActionsParam)
+
+	service.MoveCodeActions(oldPath, newPath)
+	resolvedAction, err := service.ResolveCodeAction(actions[0], nil, nil, nil)
+
+	require.NoError(t, err)
+	require.NotNil(t, resolvedAction.Edit)
+	assert.Contains(t, resolvedAction.Edit.Changes, string(uri.PathToUri(newPath)))
+	assert.NotContains(t, resolvedAction.Edit.Changes, string(uri.PathToUri(oldPath)))
+}
+
 func Test_ResolveCodeAction_KeyDoesNotExist_ReturnError(t *testing.T) {
 	testutil.UnitTest(t)
 	// Arrange
@@ -178,7 +254,7 @@ func Test_ResolveCodeAction_UnknownCommandIsReported(t *testing.T) {
 	testutil.UnitTest(t)
 	// Arrange
 	service := setupService()
-	command.SetService(command.NewService(nil, nil, nil, nil, nil))
+	command.SetService(command.NewService(nil, nil, nil, nil, nil, nil))
 
 	id := lsp.CodeActionData(uuid.New())
 	c := &sglsp.Command{
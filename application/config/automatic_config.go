@@ -17,15 +17,208 @@
 package config
 
 import (
+	"bytes"
+	"context"
 	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strings"
+	"time"
 
 	"github.com/rs/zerolog/log"
 )
 
+// pythonManifests mark a folder as Python project that needs an interpreter for Open Source scans
+var pythonManifests = []string{"requirements.txt", "Pipfile", "pyproject.toml", "setup.py"}
+
+// pythonToolTimeout limits the runtime of poetry and pyenv when looking up the interpreter of a folder
+const pythonToolTimeout = 10 * time.Second
+
+// pythonMissTtl is how long a lookup that found no interpreter is reused, so an environment that is created later,
+// e.g. by poetry install, is found without restarting the language server
+const pythonMissTtl = time.Minute
+
+// pythonLookup is a cached result of poetry and pyenv
+type pythonLookup struct {
+	tool      Tool
+	checkedAt time.Time
+}
+
+// runPythonTool runs a Python tool in dir and returns its trimmed output
+func runPythonTool(dir string, name string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), pythonToolTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	return string(bytes.TrimSpace(output)), err
+}
+
+// PythonInterpreter returns the interpreter of the Python project in folderPath. It looks for a virtual environment
+// in the folder (.venv or venv), the poetry environment, the pyenv version of the folder and the VIRTUAL_ENV the
+// language server was started in, in this order. It returns an empty string for folders without Python project or
+// if no specific interpreter was found, so the CLI uses the python on the path.
+func (c *Config) PythonInterpreter(folderPath string) string {
+	interpreter, _ := c.pythonInterpreter(folderPath)
+	return interpreter
+}
+
+// pythonInterpreter returns the interpreter of PythonInterpreter and where it was found
+func (c *Config) pythonInterpreter(folderPath string) (string, ToolSource) {
+	return c.findPythonInterpreter(folderPath, true)
+}
+
+// findPythonInterpreter looks for the interpreter like pythonInterpreter, poetry and pyenv are only run if runTools
+// is true
+func (c *Config) findPythonInterpreter(folderPath string, runTools bool) (string, ToolSource) {
+	if folderPath == "" || !isPythonProject(folderPath) {
+		return "", ToolSourcePath
+	}
+	for _, venv := range []string{".venv", "venv"} {
+		if interpreter := venvInterpreter(filepath.Join(folderPath, venv)); interpreter != "" {
+			return interpreter, ToolSourceVenv
+		}
+	}
+	if runTools {
+		if interpreter, source := c.toolPythonInterpreter(folderPath); interpreter != "" {
+			return interpreter, source
+		}
+	}
+	if virtualEnv := os.Getenv("VIRTUAL_ENV"); virtualEnv != "" {
+		return venvInterpreter(virtualEnv), ToolSourceEnv
+	}
+	return "", ToolSourcePath
+}
+
+// toolPythonInterpreter asks poetry and pyenv for the interpreter of the folder. The result is cached, as running
+// the tools is slow, and is used as long as the interpreter exists. If no interpreter was found, the tools are asked
+// again after pythonMissTtl. The tools run without holding the lock, so a slow tool doesn't block the lookups of
+// other folders.
+func (c *Config) toolPythonInterpreter(folderPath string) (string, ToolSource) {
+	c.pythonMutex.Lock()
+	cached, ok := c.pythonInterpreters[folderPath]
+	c.pythonMutex.Unlock()
+	if ok {
+		found := cached.tool.Path != "" && fileExists(cached.tool.Path)
+		missed := cached.tool.Path == "" && time.Since(cached.checkedAt) < pythonMissTtl
+		if found || missed {
+			return cached.tool.Path, cached.tool.Source
+		}
+	}
+
+	interpreter, source := poetryInterpreter(folderPath), ToolSourcePoetry
+	if interpreter == "" {
+		interpreter, source = pyenvInterpreter(folderPath), ToolSourcePyenv
+	}
+	if interpreter != "" {
+		log.Info().Str("method", "toolPythonInterpreter").Msgf("detected python interpreter %s for %s", interpreter, folderPath)
+	}
+	c.pythonMutex.Lock()
+	defer c.pythonMutex.Unlock()
+	if c.pythonInterpreters == nil {
+		c.pythonInterpreters = map[string]pythonLookup{}
+	}
+	c.pythonInterpreters[folderPath] = pythonLookup{
+		tool:      Tool{Name: "python", Path: interpreter, Source: source},
+		checkedAt: time.Now(),
+	}
+	return interpreter, source
+}
+
+func poetryInterpreter(folderPath string) string {
+	pyproject, err := os.ReadFile(filepath.Join(folderPath, "pyproject.toml"))
+	if !fileExists(filepath.Join(folderPath, "poetry.lock")) && (err != nil || !bytes.Contains(pyproject, []byte("[tool.poetry]"))) {
+		return ""
+	}
+	poetry, err := exec.LookPath("poetry")
+	if err != nil {
+		return ""
+	}
+	venvPath, err := runPythonTool(folderPath, poetry, "env", "info", "--path")
+	if err != nil || venvPath == "" {
+		log.Debug().Err(err).Str("method", "poetryInterpreter").Msg("poetry has no environment for " + folderPath)
+		return ""
+	}
+	return venvInterpreter(venvPath)
+}
+
+func pyenvInterpreter(folderPath string) string {
+	if !fileExists(filepath.Join(folderPath, ".python-version")) {
+		return ""
+	}
+	pyenvRoot := os.Getenv("PYENV_ROOT")
+	if pyenvRoot == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		pyenvRoot = filepath.Join(home, ".pyenv")
+	}
+	pyenv, err := exec.LookPath("pyenv")
+	if err != nil {
+		pyenv = filepath.Join(pyenvRoot, "bin", "pyenv")
+	}
+	if interpreter, err := runPythonTool(folderPath, pyenv, "which", "python"); err == nil && fileExists(interpreter) {
+		return interpreter
+	}
+	// the shim selects the version of .python-version, as the CLI runs in the folder
+	shim := filepath.Join(pyenvRoot, "shims", getPythonBinaryName())
+	if fileExists(shim) {
+		return shim
+	}
+	return ""
+}
+
+// venvInterpreter returns the interpreter of the virtual environment in venvPath, if there is one
+func venvInterpreter(venvPath string) string {
+	candidates := []string{filepath.Join(venvPath, "bin", "python"), filepath.Join(venvPath, "bin", "python3")}
+	if //goland:noinspection GoBoolExpressions
+	runtime.GOOS == windows {
+		candidates = []string{filepath.Join(venvPath, "Scripts", "python.exe")}
+	}
+	for _, candidate := range candidates {
+		if fileExists(candidate) {
+			return candidate
+		}
+	}
+	return ""
+}
+
+func isPythonProject(folderPath string) bool {
+	for _, manifest := range pythonManifests {
+		if fileExists(filepath.Join(folderPath, manifest)) {
+			return true
+		}
+	}
+	entries, err := os.ReadDir(folderPath)
+	if err != nil {
+		return false
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, "requirements") && strings.HasSuffix(name, ".txt") {
+			return true
+		}
+	}
+	return false
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+func getPythonBinaryName() string {
+	pythonBinary := "python"
+	if //goland:noinspection GoBoolExpressions
+	runtime.GOOS == windows {
+		pythonBinary += ".exe"
+	}
+	return pythonBinary
+}
+
 func (c *Config) determineJavaHome() {
 	javaHome := os.Getenv("JAVA_HOME")
 	if javaHome != "" {
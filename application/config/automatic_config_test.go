@@ -21,9 +21,11 @@ import (
 	"path/filepath"
 	"runtime"
 	"testing"
+	"time"
 
 	"github.com/adrg/xdg"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_updatePathWithDefaults(t *testing.T) {
@@ -166,3 +168,144 @@ func Test_FindBinaries(t *testing.T) {
 		assert.Contains(t, os.Getenv("PATH"), binDir)
 	})
 }
+
+func createVenv(t *testing.T, venvPath string) string {
+	t.Helper()
+	interpreter := filepath.Join(venvPath, "bin", "python")
+	if //goland:noinspection GoBoolExpressions
+	runtime.GOOS == windows {
+		interpreter = filepath.Join(venvPath, "Scripts", "python.exe")
+	}
+	assert.NoError(t, os.MkdirAll(filepath.Dir(interpreter), 0o700))
+	assert.NoError(t, os.WriteFile(interpreter, []byte{}, 0o700))
+	return interpreter
+}
+
+func Test_PythonInterpreter(t *testing.T) {
+	t.Setenv("VIRTUAL_ENV", "")
+	t.Setenv("PYENV_ROOT", t.TempDir())
+
+	t.Run("no interpreter for folders without python project", func(t *testing.T) {
+		folder := t.TempDir()
+		createVenv(t, filepath.Join(folder, ".venv"))
+
+		assert.Empty(t, New().PythonInterpreter(folder))
+	})
+
+	t.Run("virtual environment in the folder", func(t *testing.T) {
+		folder := t.TempDir()
+		assert.NoError(t, os.WriteFile(filepath.Join(folder, "requirements-dev.txt"), []byte("flask"), 0o600))
+		interpreter := createVenv(t, filepath.Join(folder, "venv"))
+
+		path, source := New().pythonInterpreter(folder)
+		assert.Equal(t, interpreter, path)
+		assert.Equal(t, ToolSourceVenv, source)
+	})
+
+	t.Run("poetry environment", func(t *testing.T) {
+		if //goland:noinspection GoBoolExpressions
+		runtime.GOOS == windows {
+			t.Skipf("uses a shell script as fake poetry")
+		}
+		folder := t.TempDir()
+		assert.NoError(t, os.WriteFile(filepath.Join(folder, "pyproject.toml"), []byte("[tool.poetry]\nname = \"app\""), 0o600))
+		venvPath := t.TempDir()
+		interpreter := createVenv(t, venvPath)
+		binDir := t.TempDir()
+		poetry := "#!/bin/sh\necho " + venvPath + "\n"
+		assert.NoError(t, os.WriteFile(filepath.Join(binDir, "poetry"), []byte(poetry), 0o700))
+		t.Setenv("PATH", binDir)
+
+		path, source := New().pythonInterpreter(folder)
+		assert.Equal(t, interpreter, path)
+		assert.Equal(t, ToolSourcePoetry, source)
+	})
+
+	t.Run("poetry environment created later", func(t *testing.T) {
+		if //goland:noinspection GoBoolExpressions
+		runtime.GOOS == windows {
+			t.Skipf("uses a shell script as fake poetry")
+		}
+		folder := t.TempDir()
+		assert.NoError(t, os.WriteFile(filepath.Join(folder, "pyproject.toml"), []byte("[tool.poetry]\nname = \"app\""), 0o600))
+		binDir := t.TempDir()
+		assert.NoError(t, os.WriteFile(filepath.Join(binDir, "poetry"), []byte("#!/bin/sh\nexit 1\n"), 0o700))
+		t.Setenv("PATH", binDir)
+		c := New()
+		path, _ := c.pythonInterpreter(folder)
+		require.Empty(t, path)
+
+		venvPath := t.TempDir()
+		interpreter := createVenv(t, venvPath)
+		poetry := "#!/bin/sh\necho " + venvPath + "\n"
+		assert.NoError(t, os.WriteFile(filepath.Join(binDir, "poetry"), []byte(poetry), 0o700))
+		path, _ = c.pythonInterpreter(folder)
+		assert.Empty(t, path, "a miss is reused until it expires")
+
+		lookup := c.pythonInterpreters[folder]
+		lookup.checkedAt = time.Now().Add(-pythonMissTtl)
+		c.pythonInterpreters[folder] = lookup
+		path, source := c.pythonInterpreter(folder)
+		assert.Equal(t, interpreter, path)
+		assert.Equal(t, ToolSourcePoetry, source)
+	})
+
+	t.Run("slow poetry doesn't block other folders", func(t *testing.T) {
+		if //goland:noinspection GoBoolExpressions
+		runtime.GOOS == windows {
+			t.Skipf("uses a shell script as fake poetry")
+		}
+		slowFolder, folder := t.TempDir(), t.TempDir()
+		for _, f := range []string{slowFolder, folder} {
+			assert.NoError(t, os.WriteFile(filepath.Join(f, "pyproject.toml"), []byte("[tool.poetry]\nname = \"app\""), 0o600))
+		}
+		assert.NoError(t, os.WriteFile(filepath.Join(slowFolder, "slow"), []byte{}, 0o600))
+		venvPath := t.TempDir()
+		interpreter := createVenv(t, venvPath)
+		binDir := t.TempDir()
+		// in the slow folder, poetry waits until the test releases it
+		poetry := "#!/bin/sh\nif [ -e slow ]; then echo > started; while [ ! -e release ]; do sleep 0.05; done; fi\necho " +
+			venvPath + "\n"
+		assert.NoError(t, os.WriteFile(filepath.Join(binDir, "poetry"), []byte(poetry), 0o700))
+		t.Setenv("PATH", binDir+pathListSeparator+os.Getenv("PATH"))
+		c := New()
+
+		slowDone := make(chan struct{})
+		go func() {
+			defer close(slowDone)
+			c.pythonInterpreter(slowFolder)
+		}()
+		t.Cleanup(func() {
+			_ = os.WriteFile(filepath.Join(slowFolder, "release"), []byte{}, 0o600)
+			<-slowDone
+		})
+		assert.Eventually(t, func() bool {
+			return fileExists(filepath.Join(slowFolder, "started"))
+		}, 5*time.Second, 10*time.Millisecond)
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			path, source := c.pythonInterpreter(folder)
+			assert.Equal(t, interpreter, path)
+			assert.Equal(t, ToolSourcePoetry, source)
+		}()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("lookup blocked by the poetry call of another folder")
+		}
+	})
+
+	t.Run("virtual environment of the language server", func(t *testing.T) {
+		folder := t.TempDir()
+		assert.NoError(t, os.WriteFile(filepath.Join(folder, "setup.py"), []byte{}, 0o600))
+		venvPath := t.TempDir()
+		interpreter := createVenv(t, venvPath)
+		t.Setenv("VIRTUAL_ENV", venvPath)
+
+		path, source := New().pythonInterpreter(folder)
+		assert.Equal(t, interpreter, path)
+		assert.Equal(t, ToolSourceEnv, source)
+	})
+}
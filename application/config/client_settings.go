@@ -32,6 +32,13 @@ const (
 	SendErrorReportsKey      = "SEND_ERROR_REPORTS"
 	Organization             = "VULNMAP_CFG_ORG"
 	EnableTelemetry          = "VULNMAP_CFG_DISABLE_ANALYTICS"
+	TracingEndpointKey       = "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"
+	TracingFileKey           = "VULNMAP_TRACING_FILE"
+	MetricsEndpointKey       = "VULNMAP_METRICS_ENDPOINT"
+	AuditLogKey              = "VULNMAP_AUDIT_LOG"
+	OfflineModeKey           = "VULNMAP_OFFLINE"
+	ErrorSpoolKey            = "VULNMAP_ERROR_SPOOL"
+	RpcTraceFileKey          = "VULNMAP_RPC_TRACE_FILE"
 )
 
 func (c *Config) clientSettingsFromEnv() {
@@ -39,9 +46,24 @@ func (c *Config) clientSettingsFromEnv() {
 	c.errorReportsEnablementFromEnv()
 	c.orgFromEnv()
 	c.telemetryEnablementFromEnv()
+	c.tracingFromEnv()
+	c.SetMetricsEndpoint(os.Getenv(MetricsEndpointKey))
+	c.SetAuditLogPath(os.Getenv(AuditLogKey))
+	c.offlineModeFromEnv()
+	c.SetErrorSpoolPath(os.Getenv(ErrorSpoolKey))
+	c.SetRpcTraceFile(os.Getenv(RpcTraceFileKey))
 	c.path = os.Getenv("PATH")
 }
 
+func (c *Config) tracingFromEnv() {
+	c.SetTracingSettings(TracingSettings{Endpoint: os.Getenv(TracingEndpointKey), File: os.Getenv(TracingFileKey)})
+}
+
+func (c *Config) offlineModeFromEnv() {
+	offline, err := strconv.ParseBool(os.Getenv(OfflineModeKey))
+	c.SetOfflineMode(err == nil && offline)
+}
+
 func (c *Config) orgFromEnv() {
 	org := os.Getenv(Organization)
 	if org != "" {
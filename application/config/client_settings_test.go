@@ -161,3 +161,19 @@ func TestGetEnabledProducts_Advisor(t *testing.T) {
 	CurrentConfig().clientSettingsFromEnv()
 	assert.Equal(t, true, CurrentConfig().IsVulnmapAdvisorEnabled())
 }
+
+func TestConfig_OfflineModeFromEnv(t *testing.T) {
+	t.Setenv(OfflineModeKey, "true")
+	SetCurrentConfig(New())
+	CurrentConfig().clientSettingsFromEnv()
+
+	assert.True(t, CurrentConfig().IsOfflineMode())
+}
+
+func TestConfig_OfflineModeFromEnv_Error(t *testing.T) {
+	t.Setenv(OfflineModeKey, "hurz")
+	SetCurrentConfig(New())
+	CurrentConfig().clientSettingsFromEnv()
+
+	assert.False(t, CurrentConfig().IsOfflineMode())
+}
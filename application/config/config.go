@@ -51,6 +51,7 @@ import (
 	"github.com/khulnasoft-lab/vulnmap-ls/internal/concurrency"
 	"github.com/khulnasoft-lab/vulnmap-ls/internal/lsp"
 	"github.com/khulnasoft-lab/vulnmap-ls/internal/product"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/uri"
 	"github.com/khulnasoft-lab/vulnmap-ls/internal/util"
 )
 
@@ -72,21 +73,90 @@ var (
 	currentConfig      *Config
 	mutex              = &sync.Mutex{}
 	LicenseInformation = "License information\n FILLED DURING BUILD"
+	// CliSigningPublicKey is the base64 encoded Ed25519 key that verifies downloaded CLI binaries, set during build
+	CliSigningPublicKey = ""
+)
+
+const (
+	// DefaultCliScanTimeout is used for CLI scans of products without a configured timeout
+	DefaultCliScanTimeout = 90 * time.Minute
+	// DefaultCliResultCacheTTL is the time the output of CLI scans is reused for unchanged manifests
+	DefaultCliResultCacheTTL = time.Hour
+	// maxDefaultCliConcurrency caps the CPU-aware default, as every CLI run starts its own build tools
+	maxDefaultCliConcurrency = 4
 )
 
 type CliSettings struct {
 	Insecure                bool
 	AdditionalOssParameters []string
-	cliPath                 string
-	cliPathAccessMutex      sync.Mutex
+	// Options are the validated typed options of Open Source and IaC scans
+	Options lsp.CliOptions
+	// Concurrency is the number of CLI processes that may run at the same time, 0 for a CPU-aware default
+	Concurrency int
+	// ScanTimeouts contains the configured timeouts of CLI scans per product
+	ScanTimeouts map[product.Product]time.Duration
+	// ReleaseSource is an HTTPS mirror, a file:// URL or a local directory to install the CLI from
+	ReleaseSource string
+	// ReleaseVersion pins the CLI to an exact version, the latest release of ReleaseChannel is used if empty
+	ReleaseVersion string
+	// ReleaseChannel is the channel (stable or preview) of the latest release to install
+	ReleaseChannel string
+	// FolderReleaseVersions pins the CLI to an exact version for scans in a folder (or its subfolders)
+	FolderReleaseVersions map[string]string
+	// EnvAllowlist contains additional patterns of environment variables that are passed to the CLI
+	EnvAllowlist []string
+	// EnvDenylist contains additional patterns of environment variables that are never passed to the CLI
+	EnvDenylist []string
+	// AdditionalEnv contains the variables of the additionalEnv setting in the form of "key=value"
+	AdditionalEnv []string
+	// FolderEnv contains variables in the form of "key=value" for CLI runs in a folder (or its subfolders)
+	FolderEnv map[string][]string
+	// ResultCacheTTL is the time the output of CLI scans is reused if nothing relevant changed, 0 disables the cache
+	ResultCacheTTL     time.Duration
+	cliPath            string
+	cliPathAccessMutex sync.Mutex
+	// version is the version of the CLI in use, as reported by the CLI when it was initialized
+	version      string
+	versionMutex sync.Mutex
 }
 
 func NewCliSettings() *CliSettings {
-	settings := &CliSettings{}
+	settings := &CliSettings{ResultCacheTTL: DefaultCliResultCacheTTL}
 	settings.SetPath("")
 	return settings
 }
 
+// MaxConcurrency returns the configured CLI concurrency, or half of the CPUs (at least 1, at most 4) if not configured
+func (c *CliSettings) MaxConcurrency() int {
+	if c.Concurrency > 0 {
+		return c.Concurrency
+	}
+	return min(max(runtime.NumCPU()/2, 1), maxDefaultCliConcurrency)
+}
+
+// FolderReleaseVersion returns the CLI version pinned for workingDir, the pin of the innermost folder wins. It is empty
+// if no folder containing workingDir has a pinned version.
+func (c *CliSettings) FolderReleaseVersion(workingDir string) string {
+	if workingDir == "" {
+		return ""
+	}
+	version, pinnedFolder := "", ""
+	for folder, folderVersion := range c.FolderReleaseVersions {
+		if uri.FolderContains(folder, workingDir) && len(folder) > len(pinnedFolder) {
+			version, pinnedFolder = folderVersion, folder
+		}
+	}
+	return version
+}
+
+// ScanTimeout returns the timeout for a CLI scan of the given product
+func (c *CliSettings) ScanTimeout(p product.Product) time.Duration {
+	if timeout, ok := c.ScanTimeouts[p]; ok && timeout > 0 {
+		return timeout
+	}
+	return DefaultCliScanTimeout
+}
+
 func (c *CliSettings) Installed() bool {
 	c.cliPathAccessMutex.Lock()
 	defer c.cliPathAccessMutex.Unlock()
@@ -126,6 +196,19 @@ func (c *CliSettings) SetPath(path string) {
 	c.cliPath = path
 }
 
+// Version returns the version of the CLI in use, empty if the CLI wasn't initialized yet
+func (c *CliSettings) Version() string {
+	c.versionMutex.Lock()
+	defer c.versionMutex.Unlock()
+	return c.version
+}
+
+func (c *CliSettings) SetVersion(version string) {
+	c.versionMutex.Lock()
+	defer c.versionMutex.Unlock()
+	c.version = version
+}
+
 func (c *CliSettings) DefaultBinaryInstallPath() string {
 	lsPath := filepath.Join(xdg.DataHome, "vulnmap-ls")
 	err := os.MkdirAll(lsPath, 0755)
@@ -141,7 +224,6 @@ type Config struct {
 	configLoaded                 concurrency.AtomicBool
 	cliSettings                  *CliSettings
 	configFile                   string
-	format                       string
 	isErrorReportingEnabled      concurrency.AtomicBool
 	isVulnmapCodeEnabled            concurrency.AtomicBool
 	isVulnmapOssEnabled             concurrency.AtomicBool
@@ -179,6 +261,14 @@ type Config struct {
 	storage                      StorageWithCallbacks
 	m                            sync.Mutex
 	analyticsEnabled             bool
+	pythonInterpreters           map[string]pythonLookup
+	tracingSettings              TracingSettings
+	metricsEndpoint              string
+	auditLogPath                 string
+	offlineMode                  bool
+	errorSpoolPath               string
+	rpcTraceFile                 string
+	pythonMutex                  sync.Mutex
 }
 
 func CurrentConfig() *Config {
@@ -209,7 +299,6 @@ func New() *Config {
 	c.cliSettings = NewCliSettings()
 	c.automaticAuthentication = true
 	c.configFile = ""
-	c.format = "md"
 	c.isErrorReportingEnabled.Set(true)
 	c.isVulnmapOssEnabled.Set(true)
 	c.isVulnmapIacEnabled.Set(true)
@@ -253,6 +342,7 @@ func (c *Config) AddBinaryLocationsToPath(searchDirectories []string) {
 	c.defaultDirs = searchDirectories
 	c.determineJavaHome()
 	c.determineMavenHome()
+	c.determineToolchainHomes()
 }
 
 func (c *Config) determineDeviceId() string {
@@ -319,11 +409,6 @@ func (c *Config) CliSettings() *CliSettings {
 	return c.cliSettings
 }
 
-func (c *Config) Format() string {
-	c.m.Lock()
-	defer c.m.Unlock()
-	return c.format
-}
 func (c *Config) CLIDownloadLockFileName() string {
 	return filepath.Join(c.cliSettings.DefaultBinaryInstallPath(), "vulnmap-cli-download.lock")
 }
@@ -471,10 +556,111 @@ func (c *Config) SetToken(token string) {
 	c.m.Unlock()
 }
 
-func (c *Config) SetFormat(format string) {
+// TracingSettings configure the export of performance traces with OpenTelemetry. Traces are exported to an OTLP/HTTP
+// collector, to a local file, or both. Tracing is disabled if neither is configured.
+type TracingSettings struct {
+	// Endpoint is the URL of the OTLP/HTTP collector, e.g. http://localhost:4318
+	Endpoint string
+	// File is the path of a file the spans are appended to as JSON
+	File string
+}
+
+func (t TracingSettings) Enabled() bool {
+	return t.Endpoint != "" || t.File != ""
+}
+
+func (c *Config) TracingSettings() TracingSettings {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.tracingSettings
+}
+
+func (c *Config) SetTracingSettings(settings TracingSettings) {
 	c.m.Lock()
 	defer c.m.Unlock()
-	c.format = format
+	c.tracingSettings = settings
+}
+
+// MetricsEndpoint returns the loopback address the Prometheus metrics are served on, empty if they aren't served
+func (c *Config) MetricsEndpoint() string {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.metricsEndpoint
+}
+
+func (c *Config) SetMetricsEndpoint(address string) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.metricsEndpoint = address
+}
+
+// AuditLogPath returns the path of the JSON-lines audit log of scans, fixes, ignores and trust decisions, empty if
+// no audit log is written
+func (c *Config) AuditLogPath() string {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.auditLogPath
+}
+
+func (c *Config) SetAuditLogPath(path string) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.auditLogPath = path
+}
+
+// IsOfflineMode returns true if no outbound network calls must be made except to the configured scan endpoints
+func (c *Config) IsOfflineMode() bool {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.offlineMode
+}
+
+func (c *Config) SetOfflineMode(enabled bool) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.offlineMode = enabled
+}
+
+// ErrorSpoolPath returns the directory that errors are spooled to if they can't be reported
+func (c *Config) ErrorSpoolPath() string {
+	c.m.Lock()
+	path := c.errorSpoolPath
+	c.m.Unlock()
+	if path == "" {
+		path = filepath.Join(c.CliSettings().DefaultBinaryInstallPath(), "error-spool")
+	}
+	return path
+}
+
+func (c *Config) SetErrorSpoolPath(path string) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.errorSpoolPath = path
+}
+
+// RpcTraceFile returns the file that JSON-RPC messages are recorded to while the client switched tracing on with
+// $/setTrace, empty if messages aren't recorded
+func (c *Config) RpcTraceFile() string {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.rpcTraceFile
+}
+
+func (c *Config) SetRpcTraceFile(path string) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.rpcTraceFile = path
+}
+
+// ScrubbingDict returns a copy of the terms that are removed from the logs
+func (c *Config) ScrubbingDict() map[string]bool {
+	c.m.Lock()
+	defer c.m.Unlock()
+	scrubDict := make(map[string]bool, len(c.scrubDict))
+	for term, scrub := range c.scrubDict {
+		scrubDict[term] = scrub
+	}
+	return scrubDict
 }
 
 func (c *Config) SetLogPath(logPath string) {
@@ -543,11 +729,18 @@ func (c *Config) ConfigureLogging(server lsp.Server) {
 	c.logger = &log.Logger
 }
 
+// ConfigureStderrLogging sets the global logger to a logger that writes redacted messages to stderr, e.g. before the
+// LSP connection exists
+func (c *Config) ConfigureStderrLogging() {
+	log.Logger = zerolog.New(logging.NewScrubbingWriter(zerolog.MultiLevelWriter(os.Stderr), c.scrubDict)).
+		With().Timestamp().Logger()
+}
+
 // DisableLoggingToFile closes the open log file and sets the global logger back to it's default
 func (c *Config) DisableLoggingToFile() {
 	log.Info().Msgf("Disabling file logging to %v", c.logPath)
 	c.logPath = ""
-	log.Logger = zerolog.New(os.Stderr).With().Timestamp().Logger()
+	c.ConfigureStderrLogging()
 	if c.logFile != nil {
 		_ = c.logFile.Close()
 	}
@@ -707,6 +900,7 @@ func (c *Config) addDefaults() {
 	}
 	c.determineJavaHome()
 	c.determineMavenHome()
+	c.determineToolchainHomes()
 }
 
 func (c *Config) SetIntegrationName(integrationName string) {
@@ -19,6 +19,7 @@ package config
 import (
 	"encoding/json"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -70,7 +71,6 @@ func TestConfigDefaults(t *testing.T) {
 	assert.True(t, c.IsVulnmapOssEnabled(), "Vulnmap Open Source should be enabled by default")
 	assert.True(t, c.IsVulnmapIacEnabled(), "Vulnmap IaC should be enabled by default")
 	assert.Equal(t, "", c.LogPath(), "Logpath should be empty by default")
-	assert.Equal(t, "md", c.Format(), "Output format should be md by default")
 	assert.Equal(t, lsp.DefaultSeverityFilter(), c.FilterSeverity(), "All severities should be enabled by default")
 	assert.Empty(t, c.trustedFolders)
 	assert.Equal(t, lsp.TokenAuthentication, c.authenticationMethod)
@@ -281,3 +281,24 @@ func Test_IsTelemetryEnabled(t *testing.T) {
 	assert.True(t, c.Engine().GetConfiguration().GetBool(configuration.ANALYTICS_DISABLED))
 
 }
+
+func TestErrorSpoolPath(t *testing.T) {
+	c := New()
+	assert.Equal(t, filepath.Join(c.CliSettings().DefaultBinaryInstallPath(), "error-spool"), c.ErrorSpoolPath())
+
+	c.SetErrorSpoolPath("/tmp/spool")
+	assert.Equal(t, "/tmp/spool", c.ErrorSpoolPath())
+}
+
+func TestCliSettings_FolderReleaseVersion_InnermostFolderWins(t *testing.T) {
+	settings := NewCliSettings()
+	settings.FolderReleaseVersions = map[string]string{
+		filepath.Join("/", "project"):           "1.1200.0",
+		filepath.Join("/", "project", "module"): "1.1100.0",
+	}
+
+	assert.Equal(t, "1.1100.0", settings.FolderReleaseVersion(filepath.Join("/", "project", "module", "src")))
+	assert.Equal(t, "1.1200.0", settings.FolderReleaseVersion(filepath.Join("/", "project", "other")))
+	assert.Empty(t, settings.FolderReleaseVersion(filepath.Join("/", "elsewhere")))
+	assert.Empty(t, settings.FolderReleaseVersion(""))
+}
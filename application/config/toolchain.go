@@ -0,0 +1,462 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/adrg/xdg"
+	"github.com/rs/zerolog/log"
+)
+
+// ToolSource describes where a tool of the toolchain was found
+type ToolSource string
+
+const (
+	ToolSourceWrapper  ToolSource = "wrapper"
+	ToolSourceEnv      ToolSource = "env"
+	ToolSourceNvm      ToolSource = "nvm"
+	ToolSourceVolta    ToolSource = "volta"
+	ToolSourceCorepack ToolSource = "corepack"
+	ToolSourceVenv     ToolSource = "venv"
+	ToolSourcePoetry   ToolSource = "poetry"
+	ToolSourcePyenv    ToolSource = "pyenv"
+	ToolSourcePath     ToolSource = "path"
+)
+
+// Tool is a build tool or runtime the CLI needs to resolve the dependencies of a project
+type Tool struct {
+	Name   string     `json:"name"`
+	Path   string     `json:"path"`
+	Source ToolSource `json:"source"`
+}
+
+// ToolchainInfo lists the tools found for a workspace folder
+type ToolchainInfo struct {
+	Folder string `json:"folder"`
+	Tools  []Tool `json:"tools"`
+}
+
+// toolHomes map tools to the environment variables that point to their installation
+var toolHomes = map[string]string{
+	"gradle": "GRADLE_HOME",
+	"dotnet": "DOTNET_ROOT",
+	"go":     "GOROOT",
+}
+
+// determineToolchainHomes adds the installations of tools that are configured by environment variables to the path,
+// the same way determineMavenHome does for maven. Directories that are already on the path are not added again.
+func (c *Config) determineToolchainHomes() {
+	for _, name := range []string{"gradle", "dotnet", "go"} {
+		home := os.Getenv(toolHomes[name])
+		if home == "" {
+			continue
+		}
+		binDir := filepath.Join(home, "bin")
+		if name == "dotnet" {
+			binDir = home
+		}
+		log.Info().Str("method", "determineToolchainHomes").Msgf("using %s from %s", name, binDir)
+		c.updatePathOnce(binDir)
+	}
+	if voltaHome := voltaHome(); voltaHome != "" {
+		c.updatePathOnce(filepath.Join(voltaHome, "bin"))
+	}
+	if gradlePath := c.findBinary(getBinaryName("gradle")); gradlePath != "" && os.Getenv("GRADLE_HOME") == "" {
+		if path, done := c.normalizePath(gradlePath); !done {
+			c.updatePathOnce(filepath.Dir(path))
+			log.Info().Str("method", "determineToolchainHomes").Msgf("detected gradle binary at %s", path)
+		}
+	}
+}
+
+// updatePathOnce adds dir to the path, unless it is already on it
+func (c *Config) updatePathOnce(dir string) {
+	if slices.Contains(filepath.SplitList(os.Getenv("PATH")), dir) {
+		return
+	}
+	c.updatePath(dir)
+}
+
+// Toolchain returns the tools the CLI uses for the projects in folderPath. Wrapper scripts and version managers
+// configured in the folder take precedence over the tools on the path.
+func (c *Config) Toolchain(folderPath string) ToolchainInfo {
+	return c.toolchain(folderPath, true)
+}
+
+// UntrustedToolchain returns the Toolchain of folderPath without running tools in the folder, i.e. poetry and pyenv
+// aren't asked for the Python interpreter, so it can be used for folders that aren't trusted
+func (c *Config) UntrustedToolchain(folderPath string) ToolchainInfo {
+	return c.toolchain(folderPath, false)
+}
+
+func (c *Config) toolchain(folderPath string, runTools bool) ToolchainInfo {
+	info := ToolchainInfo{Folder: folderPath}
+	add := func(name string, path string, source ToolSource) {
+		if path != "" {
+			info.Tools = append(info.Tools, Tool{Name: name, Path: path, Source: source})
+		}
+	}
+
+	add(wrapperOrTool(folderPath, "gradle", "gradlew"))
+	add(wrapperOrTool(folderPath, "maven", "mvnw"))
+	add(envOrPathTool("java", "JAVA_HOME", "bin"))
+
+	nodePath, nodeSource := nodeInterpreter(folderPath)
+	add("node", nodePath, nodeSource)
+	if nodePath != "" && nodeSource != ToolSourcePath {
+		add("npm", binaryInDir(filepath.Dir(nodePath), "npm"), nodeSource)
+	} else {
+		add("npm", lookPath("npm"), ToolSourcePath)
+	}
+	for _, manager := range []string{"yarn", "pnpm"} {
+		add(packageManager(folderPath, manager, nodePath))
+	}
+
+	add(envOrPathTool("dotnet", "DOTNET_ROOT", ""))
+	add(envOrPathTool("go", "GOROOT", "bin"))
+	pythonPath, pythonSource := c.findPythonInterpreter(folderPath, runTools)
+	add("python", pythonPath, pythonSource)
+	add("ruby", lookPath("ruby"), ToolSourcePath)
+	add(wrapperOrTool(folderPath, "bundler", filepath.Join("bin", "bundle")))
+	return info
+}
+
+// ToolchainPath returns the directories of the tools that are selected by the configuration of folderPath, e.g. the
+// node version of .nvmrc or the gradle and maven wrappers. They are prepended to the path of CLI runs in that folder.
+func (c *Config) ToolchainPath(folderPath string) []string {
+	if folderPath == "" {
+		return nil
+	}
+	var dirs []string
+	if shimDir := wrapperShimDir(folderPath); shimDir != "" {
+		dirs = append(dirs, shimDir)
+	}
+	if nodePath, source := nodeInterpreter(folderPath); nodePath != "" && source != ToolSourcePath {
+		dirs = append(dirs, filepath.Dir(nodePath))
+	}
+	return dirs
+}
+
+// wrapperShims maps the binaries the CLI runs to the wrapper scripts that replace them
+var wrapperShims = []struct{ name, binary, wrapper string }{
+	{"gradle", "gradle", "gradlew"},
+	{"maven", "mvn", "mvnw"},
+}
+
+// wrapperShimDir returns a directory with gradle and mvn scripts that run the wrappers of folderPath, as the CLI
+// calls gradle and mvn and would not use the versions the project pins otherwise. It returns an empty string if the
+// folder has no wrapper.
+func wrapperShimDir(folderPath string) string {
+	dir := shimDirOf(folderPath)
+	found := false
+	for _, shim := range wrapperShims {
+		_, wrapperPath, source := wrapperOrTool(folderPath, shim.name, shim.wrapper)
+		if source != ToolSourceWrapper {
+			// the wrapper may have been removed since the shim was written
+			_ = os.Remove(shimPath(dir, shim.binary))
+			continue
+		}
+		if err := writeShim(dir, shim.binary, wrapperPath); err != nil {
+			log.Err(err).Str("method", "wrapperShimDir").Msgf("couldn't create %s shim for %s", shim.binary, wrapperPath)
+			continue
+		}
+		found = true
+	}
+	if !found {
+		return ""
+	}
+	return dir
+}
+
+// RemoveToolchainShims removes the wrapper scripts written for folderPath, e.g. when the folder is removed from the
+// workspace.
+func (c *Config) RemoveToolchainShims(folderPath string) {
+	if err := os.RemoveAll(shimDirOf(folderPath)); err != nil {
+		log.Err(err).Str("method", "RemoveToolchainShims").Msgf("couldn't remove the wrapper shims of %s", folderPath)
+	}
+}
+
+func shimDirOf(folderPath string) string {
+	hash := sha256.Sum256([]byte(folderPath))
+	return filepath.Join(xdg.DataHome, "vulnmap-ls", "wrappers", hex.EncodeToString(hash[:8]))
+}
+
+// writeShim writes a script named binary to dir that runs target with the given arguments. Unchanged scripts are
+// not rewritten.
+func writeShim(dir string, binary string, target string) error {
+	path := shimPath(dir, binary)
+	content := []byte("#!/bin/sh\nexec " + shQuote(target) + " \"$@\"\n")
+	if //goland:noinspection GoBoolExpressions
+	runtime.GOOS == windows {
+		content = []byte("@" + cmdQuote(target) + " %*\r\n")
+	}
+	if existing, err := os.ReadFile(path); err == nil && bytes.Equal(existing, content) {
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0o700)
+}
+
+// shQuote quotes s for sh, so that the shell doesn't expand or execute anything in the path of a folder
+func shQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// cmdQuote quotes s for a batch script, where variables are expanded in quoted strings as well
+func cmdQuote(s string) string {
+	s = strings.ReplaceAll(s, "%", "%%")
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// wrapperOrTool prefers the wrapper script of the folder over the tool on the path
+func wrapperOrTool(folderPath string, name string, wrapper string) (string, string, ToolSource) {
+	wrappers := []string{wrapper}
+	if //goland:noinspection GoBoolExpressions
+	runtime.GOOS == windows {
+		wrappers = []string{wrapper + ".bat", wrapper + ".cmd"}
+	}
+	for _, w := range wrappers {
+		if folderPath != "" && fileExists(filepath.Join(folderPath, w)) {
+			return name, filepath.Join(folderPath, w), ToolSourceWrapper
+		}
+	}
+	binary := name
+	switch name {
+	case "maven":
+		binary = "mvn"
+	case "bundler":
+		binary = "bundle"
+	}
+	return name, lookPath(binary), ToolSourcePath
+}
+
+// envOrPathTool prefers the installation the environment variable points to over the tool on the path
+func envOrPathTool(name string, envVar string, binDir string) (string, string, ToolSource) {
+	if home := os.Getenv(envVar); home != "" {
+		if path := binaryInDir(filepath.Join(home, binDir), name); path != "" {
+			return name, path, ToolSourceEnv
+		}
+	}
+	return name, lookPath(name), ToolSourcePath
+}
+
+// nodeInterpreter returns the node binary for the folder. The version of .nvmrc is looked up in the nvm installation,
+// volta is used if it pins node in package.json.
+func nodeInterpreter(folderPath string) (string, ToolSource) {
+	if folderPath != "" {
+		if version := readTrimmed(filepath.Join(folderPath, ".nvmrc")); version != "" {
+			if path := nvmNode(version); path != "" {
+				return path, ToolSourceNvm
+			}
+		}
+		if voltaHome := voltaHome(); voltaHome != "" && packageJsonField(folderPath, "volta") != nil {
+			if path := binaryInDir(filepath.Join(voltaHome, "bin"), "node"); path != "" {
+				return path, ToolSourceVolta
+			}
+		}
+	}
+	return lookPath("node"), ToolSourcePath
+}
+
+// nvmNode returns the node binary of the installed nvm version that best matches the requested version. Aliases like
+// lts/* or node are resolved the way nvm does.
+func nvmNode(version string) string {
+	nvmDir := os.Getenv("NVM_DIR")
+	if nvmDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		nvmDir = filepath.Join(home, ".nvm")
+	}
+	version = strings.TrimPrefix(resolveNvmAlias(nvmDir, version), "v")
+	entries, err := os.ReadDir(filepath.Join(nvmDir, "versions", "node"))
+	if err != nil {
+		return ""
+	}
+	match := ""
+	for _, entry := range entries {
+		installed := strings.TrimPrefix(entry.Name(), "v")
+		// a partial version like 18 or 18.17 selects the newest installed patch, no version the newest installed one
+		if !entry.IsDir() || (version != "" && installed != version && !strings.HasPrefix(installed, version+".")) {
+			continue
+		}
+		if match == "" || compareVersions(installed, strings.TrimPrefix(match, "v")) > 0 {
+			match = entry.Name()
+		}
+	}
+	if match == "" {
+		return ""
+	}
+	return binaryInDir(filepath.Join(nvmDir, "versions", "node", match, "bin"), "node")
+}
+
+// resolveNvmAlias follows the aliases nvm stores in its alias directory, e.g. lts/* points to lts/iron, which points
+// to the newest installed version of that line. node and stable stand for the newest installed version and resolve to
+// an empty version.
+func resolveNvmAlias(nvmDir string, version string) string {
+	// aliases may point to other aliases, the limit stops cycles
+	for i := 0; i < 10; i++ {
+		if version == "node" || version == "stable" {
+			return ""
+		}
+		if !filepath.IsLocal(version) {
+			return version
+		}
+		target := readTrimmed(filepath.Join(nvmDir, "alias", filepath.FromSlash(version)))
+		if target == "" {
+			return version
+		}
+		version = target
+	}
+	return version
+}
+
+func shimPath(dir string, binary string) string {
+	if //goland:noinspection GoBoolExpressions
+	runtime.GOOS == windows {
+		return filepath.Join(dir, binary+".cmd")
+	}
+	return filepath.Join(dir, binary)
+}
+
+// compareVersions compares two dot separated versions numerically, e.g. 18.9.0 is older than 18.10.0. Parts that are
+// not numbers are compared as strings.
+func compareVersions(a string, b string) int {
+	aParts, bParts := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		aPart, bPart := "0", "0"
+		if i < len(aParts) {
+			aPart = aParts[i]
+		}
+		if i < len(bParts) {
+			bPart = bParts[i]
+		}
+		aNumber, aErr := strconv.Atoi(aPart)
+		bNumber, bErr := strconv.Atoi(bPart)
+		switch {
+		case aErr == nil && bErr == nil && aNumber != bNumber:
+			if aNumber < bNumber {
+				return -1
+			}
+			return 1
+		case (aErr != nil || bErr != nil) && aPart != bPart:
+			return strings.Compare(aPart, bPart)
+		}
+	}
+	return 0
+}
+
+func voltaHome() string {
+	if voltaHome := os.Getenv("VOLTA_HOME"); voltaHome != "" {
+		return voltaHome
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	voltaHome := filepath.Join(home, ".volta")
+	if _, err = os.Stat(voltaHome); err != nil {
+		return ""
+	}
+	return voltaHome
+}
+
+// packageManager returns yarn or pnpm. If it is not installed, but the folder pins it in the packageManager field
+// of package.json, corepack provides it.
+func packageManager(folderPath string, name string, nodePath string) (string, string, ToolSource) {
+	if path := lookPath(name); path != "" {
+		return name, path, ToolSourcePath
+	}
+	pinned, _ := packageJsonField(folderPath, "packageManager").(string)
+	if !strings.HasPrefix(pinned, name+"@") {
+		return name, "", ToolSourcePath
+	}
+	corepack := lookPath("corepack")
+	if nodePath != "" {
+		if path := binaryInDir(filepath.Dir(nodePath), "corepack"); path != "" {
+			corepack = path
+		}
+	}
+	return name, corepack, ToolSourceCorepack
+}
+
+func packageJsonField(folderPath string, field string) any {
+	if folderPath == "" {
+		return nil
+	}
+	content, err := os.ReadFile(filepath.Join(folderPath, "package.json"))
+	if err != nil {
+		return nil
+	}
+	var packageJson map[string]any
+	if err = json.Unmarshal(content, &packageJson); err != nil {
+		return nil
+	}
+	return packageJson[field]
+}
+
+func binaryInDir(dir string, name string) string {
+	candidates := []string{name}
+	if //goland:noinspection GoBoolExpressions
+	runtime.GOOS == windows {
+		candidates = []string{name + ".exe", name + ".cmd"}
+	}
+	for _, candidate := range candidates {
+		if path := filepath.Join(dir, candidate); fileExists(path) {
+			return path
+		}
+	}
+	return ""
+}
+
+func lookPath(name string) string {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return ""
+	}
+	return path
+}
+
+func readTrimmed(path string) string {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(content))
+}
+
+func getBinaryName(name string) string {
+	if //goland:noinspection GoBoolExpressions
+	runtime.GOOS == windows {
+		return name + ".exe"
+	}
+	return name
+}
@@ -0,0 +1,230 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/adrg/xdg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createExecutable(t *testing.T, dir string, name string) string {
+	t.Helper()
+	if //goland:noinspection GoBoolExpressions
+	runtime.GOOS == windows {
+		name += ".exe"
+	}
+	require.NoError(t, os.MkdirAll(dir, 0o700))
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte{}, 0o700))
+	return path
+}
+
+func toolByName(info ToolchainInfo, name string) *Tool {
+	for i := range info.Tools {
+		if info.Tools[i].Name == name {
+			return &info.Tools[i]
+		}
+	}
+	return nil
+}
+
+func Test_Toolchain(t *testing.T) {
+	if //goland:noinspection GoBoolExpressions
+	runtime.GOOS == windows {
+		t.Skipf("wrapper and binary names differ on windows")
+	}
+	pathDir := t.TempDir()
+	t.Setenv("PATH", pathDir)
+	t.Setenv("GRADLE_HOME", "")
+	t.Setenv("DOTNET_ROOT", "")
+	t.Setenv("GOROOT", "")
+	t.Setenv("JAVA_HOME", "")
+	t.Setenv("VOLTA_HOME", "")
+	t.Setenv("HOME", t.TempDir())
+
+	t.Run("wrapper scripts take precedence over the tools on the path", func(t *testing.T) {
+		folder := t.TempDir()
+		createExecutable(t, pathDir, "gradle")
+		mvn := createExecutable(t, pathDir, "mvn")
+		gradlew := createExecutable(t, folder, "gradlew")
+
+		info := New().Toolchain(folder)
+
+		assert.Equal(t, folder, info.Folder)
+		assert.Equal(t, &Tool{Name: "gradle", Path: gradlew, Source: ToolSourceWrapper}, toolByName(info, "gradle"))
+		assert.Equal(t, &Tool{Name: "maven", Path: mvn, Source: ToolSourcePath}, toolByName(info, "maven"))
+	})
+
+	t.Run("tool homes from the environment", func(t *testing.T) {
+		goRoot := t.TempDir()
+		goBinary := createExecutable(t, filepath.Join(goRoot, "bin"), "go")
+		t.Setenv("GOROOT", goRoot)
+
+		info := New().Toolchain(t.TempDir())
+
+		assert.Equal(t, &Tool{Name: "go", Path: goBinary, Source: ToolSourceEnv}, toolByName(info, "go"))
+		assert.Nil(t, toolByName(info, "dotnet"))
+	})
+
+	t.Run("node version of .nvmrc", func(t *testing.T) {
+		nvmDir := t.TempDir()
+		t.Setenv("NVM_DIR", nvmDir)
+		createExecutable(t, filepath.Join(nvmDir, "versions", "node", "v18.17.0", "bin"), "node")
+		// sorts after v18.19.1 lexically
+		createExecutable(t, filepath.Join(nvmDir, "versions", "node", "v18.2.0", "bin"), "node")
+		node := createExecutable(t, filepath.Join(nvmDir, "versions", "node", "v18.19.1", "bin"), "node")
+		npm := createExecutable(t, filepath.Dir(node), "npm")
+		createExecutable(t, filepath.Join(nvmDir, "versions", "node", "v20.11.0", "bin"), "node")
+		folder := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(folder, ".nvmrc"), []byte("v18\n"), 0o600))
+
+		c := New()
+		info := c.Toolchain(folder)
+
+		assert.Equal(t, &Tool{Name: "node", Path: node, Source: ToolSourceNvm}, toolByName(info, "node"))
+		assert.Equal(t, &Tool{Name: "npm", Path: npm, Source: ToolSourceNvm}, toolByName(info, "npm"))
+		assert.Equal(t, []string{filepath.Dir(node)}, c.ToolchainPath(folder))
+	})
+
+	t.Run("node version of an nvm alias", func(t *testing.T) {
+		nvmDir := t.TempDir()
+		t.Setenv("NVM_DIR", nvmDir)
+		createExecutable(t, filepath.Join(nvmDir, "versions", "node", "v18.19.1", "bin"), "node")
+		lts := createExecutable(t, filepath.Join(nvmDir, "versions", "node", "v20.11.0", "bin"), "node")
+		newest := createExecutable(t, filepath.Join(nvmDir, "versions", "node", "v21.6.1", "bin"), "node")
+		require.NoError(t, os.MkdirAll(filepath.Join(nvmDir, "alias", "lts"), 0o700))
+		require.NoError(t, os.WriteFile(filepath.Join(nvmDir, "alias", "lts", "*"), []byte("lts/iron\n"), 0o600))
+		require.NoError(t, os.WriteFile(filepath.Join(nvmDir, "alias", "lts", "iron"), []byte("v20.11.0\n"), 0o600))
+
+		for alias, node := range map[string]string{"lts/*": lts, "lts/iron": lts, "node": newest, "stable": newest} {
+			folder := t.TempDir()
+			require.NoError(t, os.WriteFile(filepath.Join(folder, ".nvmrc"), []byte(alias), 0o600))
+
+			info := New().Toolchain(folder)
+
+			assert.Equal(t, &Tool{Name: "node", Path: node, Source: ToolSourceNvm}, toolByName(info, "node"), alias)
+		}
+	})
+
+	t.Run("wrapper scripts replace the tools of CLI runs", func(t *testing.T) {
+		dataHome := xdg.DataHome
+		xdg.DataHome = t.TempDir()
+		t.Cleanup(func() { xdg.DataHome = dataHome })
+		folder := t.TempDir()
+		gradlew := createExecutable(t, folder, "gradlew")
+
+		c := New()
+		toolchainPath := c.ToolchainPath(folder)
+
+		require.Len(t, toolchainPath, 1)
+		shim, err := os.ReadFile(filepath.Join(toolchainPath[0], "gradle"))
+		require.NoError(t, err)
+		assert.Contains(t, string(shim), gradlew)
+		assert.NoFileExists(t, filepath.Join(toolchainPath[0], "mvn"))
+
+		require.NoError(t, os.Remove(gradlew))
+		assert.Empty(t, c.ToolchainPath(folder))
+		assert.NoFileExists(t, filepath.Join(toolchainPath[0], "gradle"))
+	})
+
+	t.Run("wrapper scripts quote the folder path", func(t *testing.T) {
+		dataHome := xdg.DataHome
+		xdg.DataHome = t.TempDir()
+		t.Cleanup(func() { xdg.DataHome = dataHome })
+		parent := t.TempDir()
+		marker := filepath.Join(parent, "executed")
+		folder := filepath.Join(parent, `it's "$HOME" $(touch executed)`)
+		require.NoError(t, os.MkdirAll(folder, 0o700))
+		gradlew := filepath.Join(folder, "gradlew")
+		require.NoError(t, os.WriteFile(gradlew, []byte("#!/bin/sh\necho \"wrapper $1\"\n"), 0o700))
+
+		toolchainPath := New().ToolchainPath(folder)
+		require.Len(t, toolchainPath, 1)
+		cmd := exec.Command(filepath.Join(toolchainPath[0], "gradle"), "build")
+		cmd.Dir = parent
+		output, err := cmd.CombinedOutput()
+
+		require.NoError(t, err, string(output))
+		assert.Equal(t, "wrapper build\n", string(output))
+		assert.NoFileExists(t, marker)
+	})
+
+	t.Run("wrapper scripts are removed with the folder", func(t *testing.T) {
+		dataHome := xdg.DataHome
+		xdg.DataHome = t.TempDir()
+		t.Cleanup(func() { xdg.DataHome = dataHome })
+		folder := t.TempDir()
+		createExecutable(t, folder, "mvnw")
+		c := New()
+		toolchainPath := c.ToolchainPath(folder)
+		require.Len(t, toolchainPath, 1)
+
+		c.RemoveToolchainShims(folder)
+
+		assert.NoDirExists(t, toolchainPath[0])
+	})
+
+	t.Run("package manager provided by corepack", func(t *testing.T) {
+		createExecutable(t, pathDir, "node")
+		corepack := createExecutable(t, pathDir, "corepack")
+		folder := t.TempDir()
+		packageJson := `{"name": "app", "packageManager": "pnpm@8.15.1"}`
+		require.NoError(t, os.WriteFile(filepath.Join(folder, "package.json"), []byte(packageJson), 0o600))
+
+		info := New().Toolchain(folder)
+
+		assert.Equal(t, &Tool{Name: "pnpm", Path: corepack, Source: ToolSourceCorepack}, toolByName(info, "pnpm"))
+		assert.Nil(t, toolByName(info, "yarn"))
+	})
+}
+
+func Test_determineToolchainHomes_AddsDirectoriesOnce(t *testing.T) {
+	t.Setenv("PATH", os.Getenv("PATH"))
+	goRoot := t.TempDir()
+	t.Setenv("GOROOT", goRoot)
+	c := New()
+
+	c.determineToolchainHomes()
+
+	goBin := filepath.Join(goRoot, "bin")
+	count := 0
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if dir == goBin {
+			count++
+		}
+	}
+	assert.Equal(t, 1, count)
+	assert.Equal(t, 1, strings.Count(c.Path(), goBin))
+}
+
+func Test_cmdQuote(t *testing.T) {
+	assert.Equal(t, `"C:\100%% ""done""\gradlew.bat"`, cmdQuote(`C:\100% "done"\gradlew.bat`))
+}
+
+func Test_compareVersions(t *testing.T) {
+	assert.Equal(t, -1, compareVersions("18.9.0", "18.10.0"))
+	assert.Equal(t, 1, compareVersions("20.0.0", "18.19.1"))
+	assert.Equal(t, 0, compareVersions("18.0", "18.0.0"))
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/cli/cli_constants"
 
 	"github.com/adrg/xdg"
+	"github.com/rs/zerolog/log"
 
 	"github.com/khulnasoft-lab/vulnmap-ls/application/codeaction"
 	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
@@ -34,17 +35,23 @@ import (
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/ide/initialize"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/ide/notification"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/ide/workspace"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/audit"
 	er "github.com/khulnasoft-lab/vulnmap-ls/domain/observability/error_reporting"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/metrics"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/performance"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/ux"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/vulnmap"
 	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/amplitude"
+	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/auditlog"
 	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/cli"
 	cliauth "github.com/khulnasoft-lab/vulnmap-ls/infrastructure/cli/auth"
 	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/cli/install"
 	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/code"
+	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/errorspool"
 	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/iac"
 	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/learn"
+	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/network"
+	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/opentelemetry"
 	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/oss"
 	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/sentry"
 	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/vulnmap_api"
@@ -64,6 +71,7 @@ var instrumentor performance.Instrumentor
 var errorReporter er.ErrorReporter
 var installer install.Installer
 var analytics ux.Analytics
+var auditTrail audit.Trail
 var hoverService hover.Service
 var scanner vulnmap.Scanner
 var cliInitializer *cli.Initializer
@@ -71,6 +79,7 @@ var scanNotifier vulnmap.ScanNotifier
 var codeActionService *codeaction.CodeActionsService
 var fileWatcher *watcher.FileWatcher
 var initMutex = &sync.Mutex{}
+var metricsOnce sync.Once
 var notifier notification.Notifier
 
 func Init() {
@@ -79,6 +88,37 @@ func Init() {
 	initInfrastructure()
 	initDomain()
 	initApplication()
+	initMetrics()
+}
+
+// StartBackgroundJobs starts the periodic work of the services, e.g. sending spooled errors. It isn't part of Init, so
+// tests that use the real services don't run it.
+func StartBackgroundJobs() {
+	initMutex.Lock()
+	defer initMutex.Unlock()
+	if reporter, ok := errorReporter.(er.BackgroundReporter); ok {
+		reporter.Start()
+	}
+}
+
+// Stop ends the background work of the services and waits for it, e.g. when the server exits or a test tears down
+// the services
+func Stop() {
+	initMutex.Lock()
+	defer initMutex.Unlock()
+	if reporter, ok := errorReporter.(er.BackgroundReporter); ok {
+		reporter.Stop()
+	}
+}
+
+// initMetrics registers the metrics that are collected on demand and serves them if an endpoint is configured
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.Default().OnCollect(workspace.CollectIssueCacheMetrics)
+	})
+	if err := metrics.DefaultEndpoint().Configure(config.CurrentConfig().MetricsEndpoint()); err != nil {
+		log.Err(err).Str("method", "initMetrics").Msg("couldn't serve metrics")
+	}
 }
 
 func initDomain() {
@@ -87,6 +127,7 @@ func initDomain() {
 		scanInitializer,
 		instrumentor,
 		analytics,
+		auditTrail,
 		scanNotifier,
 		vulnmapApiClient,
 		authenticationService,
@@ -117,27 +158,31 @@ func initInfrastructure() {
 			})
 	}
 
-	// init NetworkAccess
+	// init NetworkAccess, all http clients are guarded so that offline mode only lets requests to the scan endpoints through
 	networkAccess := c.Engine().GetNetworkAccess()
+	unauthorizedHttpClient := network.GuardClientFactory(networkAccess.GetUnauthorizedHttpClient)
+	httpClient := network.GuardClientFactory(networkAccess.GetHttpClient)
 
 	notifier = domainNotify.NewNotifier()
-	errorReporter = sentry.NewSentryErrorReporter(notifier)
-	installer = install.NewInstaller(errorReporter, networkAccess.GetUnauthorizedHttpClient)
-	learnService = learn.New(c, networkAccess.GetUnauthorizedHttpClient, errorReporter)
-	instrumentor = performance.NewInstrumentor()
-	vulnmapApiClient = vulnmap_api.NewVulnmapApiClient(networkAccess.GetHttpClient)
+	errorSpool := errorspool.New(c.ErrorSpoolPath(), errorspool.DefaultMaxEntries)
+	errorReporter = sentry.NewSpoolingErrorReporter(notifier, errorSpool, unauthorizedHttpClient)
+	auditTrail = auditlog.NewFileTrail()
+	installer = install.NewInstaller(errorReporter, auditTrail, unauthorizedHttpClient)
+	learnService = learn.New(c, unauthorizedHttpClient, errorReporter)
+	instrumentor = metrics.NewInstrumentor(opentelemetry.NewInstrumentor())
+	vulnmapApiClient = vulnmap_api.NewVulnmapApiClient(httpClient)
 	analytics = amplitude.NewAmplitudeClient(vulnmap.AuthenticationCheck, errorReporter)
 	authProvider := cliauth.NewCliAuthenticationProvider(errorReporter)
 	authenticationService = vulnmap.NewAuthenticationService(authProvider, analytics, errorReporter, notifier)
-	vulnmapCli := cli.NewExecutor(authenticationService, errorReporter, analytics, notifier)
+	vulnmapCli := cli.NewExecutor(authenticationService, errorReporter, analytics, notifier, instrumentor)
 
 	if c.Engine().GetConfiguration().GetString(cli_constants.EXECUTION_MODE_KEY) == cli_constants.EXECUTION_MODE_VALUE_EXTENSION {
 		vulnmapCli = cli.NewExtensionExecutor()
 	}
 
-	vulnmapCodeClient = code.NewHTTPRepository(instrumentor, errorReporter, networkAccess.GetHttpClient)
+	vulnmapCodeClient = code.NewHTTPRepository(instrumentor, errorReporter, httpClient)
 	vulnmapCodeBundleUploader = code.NewBundler(vulnmapCodeClient, instrumentor)
-	infrastructureAsCodeScanner = iac.New(instrumentor, errorReporter, analytics, vulnmapCli)
+	infrastructureAsCodeScanner = iac.New(instrumentor, errorReporter, analytics, vulnmapCli, notifier)
 	openSourceScanner = oss.NewCLIScanner(instrumentor, errorReporter, analytics, vulnmapCli, learnService, notifier, c)
 	scanNotifier, _ = appNotification.NewScanNotifier(notifier)
 	vulnmapCodeScanner = code.New(vulnmapCodeBundleUploader, vulnmapApiClient, errorReporter, analytics, learnService, notifier)
@@ -150,11 +195,11 @@ func initInfrastructure() {
 }
 
 func initApplication() {
-	w := workspace.New(instrumentor, scanner, hoverService, scanNotifier, notifier) // don't use getters or it'll deadlock
+	w := workspace.New(instrumentor, scanner, hoverService, scanNotifier, notifier, auditTrail) // don't use getters or it'll deadlock
 	workspace.Set(w)
 	fileWatcher = watcher.NewFileWatcher()
 	codeActionService = codeaction.NewService(config.CurrentConfig(), w, fileWatcher, notifier, vulnmapCodeClient)
-	command.SetService(command.NewService(authenticationService, notifier, learnService, w, vulnmapCodeClient))
+	command.SetService(command.NewService(authenticationService, notifier, learnService, w, vulnmapCodeClient, auditTrail))
 }
 
 /*
@@ -210,12 +255,30 @@ func Analytics() ux.Analytics {
 	return analytics
 }
 
+func Instrumentor() performance.Instrumentor {
+	initMutex.Lock()
+	defer initMutex.Unlock()
+	return instrumentor
+}
+
+func AuditTrail() audit.Trail {
+	initMutex.Lock()
+	defer initMutex.Unlock()
+	return auditTrail
+}
+
 func Installer() install.Installer {
 	initMutex.Lock()
 	defer initMutex.Unlock()
 	return installer
 }
 
+func CliInitializer() *cli.Initializer {
+	initMutex.Lock()
+	defer initMutex.Unlock()
+	return cliInitializer
+}
+
 func CodeActionService() *codeaction.CodeActionsService {
 	initMutex.Lock()
 	defer initMutex.Unlock()
@@ -0,0 +1,75 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package di
+
+import (
+	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
+	appNotification "github.com/khulnasoft-lab/vulnmap-ls/application/server/notification"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/ide/initialize"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/audit"
+	er "github.com/khulnasoft-lab/vulnmap-ls/domain/observability/error_reporting"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/performance"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/ux"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/vulnmap"
+	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/cli"
+	cliauth "github.com/khulnasoft-lab/vulnmap-ls/infrastructure/cli/auth"
+	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/cli/install"
+	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/code"
+	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/iac"
+	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/learn"
+	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/network"
+	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/oss"
+	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/vulnmap_api"
+	domainNotify "github.com/khulnasoft-lab/vulnmap-ls/internal/notification"
+)
+
+// ReplayInit wires the server with fake backends, so that recorded sessions can be replayed without a CLI, Vulnmap
+// account or network access. Offline mode is switched on, so the remaining http clients don't leave the machine.
+func ReplayInit() {
+	initMutex.Lock()
+	defer initMutex.Unlock()
+	c := config.CurrentConfig()
+	c.SetOfflineMode(true)
+	// we don't want to open browsers when replaying
+	vulnmap.DefaultOpenBrowserFunc = func(url string) {}
+
+	notifier = domainNotify.NewNotifier()
+	errorReporter = er.NewTestErrorReporter()
+	installer = install.NewFakeInstaller()
+	learnService = learn.New(c, network.GuardClientFactory(c.Engine().GetNetworkAccess().GetUnauthorizedHttpClient), errorReporter)
+	instrumentor = performance.NewInstrumentor()
+	vulnmapApiClient = &vulnmap_api.FakeApiClient{CodeEnabled: true}
+	analytics = ux.NewTestAnalytics()
+	auditTrail = audit.NewTestTrail()
+	authenticationService = vulnmap.NewAuthenticationService(vulnmap.NewFakeCliAuthenticationProvider(), analytics, errorReporter, notifier)
+	vulnmapCli := cli.NewTestExecutor()
+	vulnmapCodeClient = &code.FakeVulnmapCodeClient{}
+	vulnmapCodeBundleUploader = code.NewBundler(vulnmapCodeClient, instrumentor)
+	infrastructureAsCodeScanner = iac.New(instrumentor, errorReporter, analytics, vulnmapCli, notifier)
+	openSourceScanner = oss.NewCLIScanner(instrumentor, errorReporter, analytics, vulnmapCli, learnService, notifier, c)
+	scanNotifier, _ = appNotification.NewScanNotifier(notifier)
+	vulnmapCodeScanner = code.New(vulnmapCodeBundleUploader, vulnmapApiClient, errorReporter, analytics, learnService, notifier)
+	cliInitializer = cli.NewInitializer(errorReporter, installer, notifier, vulnmapCli)
+	authInitializer := cliauth.NewInitializer(authenticationService, errorReporter, analytics, notifier)
+	scanInitializer = initialize.NewDelegatingInitializer(
+		cliInitializer,
+		authInitializer,
+	)
+
+	initDomain()
+	initApplication()
+}
@@ -29,6 +29,7 @@ import (
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/ide/hover"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/ide/initialize"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/ide/workspace"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/audit"
 	er "github.com/khulnasoft-lab/vulnmap-ls/domain/observability/error_reporting"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/performance"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/ux"
@@ -55,13 +56,14 @@ func TestInit(t *testing.T) {
 	vulnmap.DefaultOpenBrowserFunc = func(url string) {}
 	notifier = domainNotify.NewNotifier()
 	analytics = ux.NewTestAnalytics()
+	auditTrail = audit.NewTestTrail()
 	instrumentor = performance.NewInstrumentor()
 	errorReporter = er.NewTestErrorReporter()
 	installer = install.NewFakeInstaller()
 	authProvider := vulnmap.NewFakeCliAuthenticationProvider()
 	vulnmapApiClient = &vulnmap_api.FakeApiClient{CodeEnabled: true}
 	authenticationService = vulnmap.NewAuthenticationService(authProvider, analytics, errorReporter, notifier)
-	vulnmapCli := cli.NewExecutor(authenticationService, errorReporter, analytics, notifier)
+	vulnmapCli := cli.NewExecutor(authenticationService, errorReporter, analytics, notifier, instrumentor)
 	cliInitializer = cli.NewInitializer(errorReporter, installer, notifier, vulnmapCli)
 	authInitializer := cliauth.NewInitializer(authenticationService, errorReporter, analytics, notifier)
 	scanInitializer = initialize.NewDelegatingInitializer(
@@ -81,11 +83,12 @@ func TestInit(t *testing.T) {
 	learnService = learnMock
 	vulnmapCodeScanner = code.New(vulnmapCodeBundleUploader, vulnmapApiClient, errorReporter, analytics, learnService, notifier)
 	openSourceScanner = oss.NewCLIScanner(instrumentor, errorReporter, analytics, vulnmapCli, learnService, notifier, c)
-	infrastructureAsCodeScanner = iac.New(instrumentor, errorReporter, analytics, vulnmapCli)
+	infrastructureAsCodeScanner = iac.New(instrumentor, errorReporter, analytics, vulnmapCli, notifier)
 	scanner = vulnmap.NewDelegatingScanner(
 		scanInitializer,
 		instrumentor,
 		analytics,
+		auditTrail,
 		scanNotifier,
 		vulnmapApiClient,
 		authenticationService,
@@ -97,13 +100,15 @@ func TestInit(t *testing.T) {
 	hoverService = hover.NewDefaultService(analytics)
 	command.SetService(&vulnmap.CommandServiceMock{})
 	// don't use getters or it'll deadlock
-	w := workspace.New(instrumentor, scanner, hoverService, scanNotifier, notifier)
+	w := workspace.New(instrumentor, scanner, hoverService, scanNotifier, notifier, auditTrail)
 	workspace.Set(w)
 	fileWatcher = watcher.NewFileWatcher()
 	codeActionService = codeaction.NewService(c, w, fileWatcher, notifier, vulnmapCodeClient)
 	t.Cleanup(
 		func() {
 			fakeClient.Clear()
+			// tests may replace the test services with di.Init, their background work must not outlive the test
+			Stop()
 		},
 	)
 }
@@ -0,0 +1,272 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
+	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/cli/install"
+	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/network"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/lsp"
+)
+
+// checkTimeout limits the runtime of checks that run the CLI or call endpoints
+const checkTimeout = 10 * time.Second
+
+// userEndpoint returns the user of the token, it is also used by the whoami workflow
+const userEndpoint = "/v1/user/me"
+
+func checkCli(ctx context.Context, c *config.Config) Check {
+	check := Check{Name: "CLI"}
+	settings := c.CliSettings()
+	check.Details = []string{"path: " + settings.Path()}
+	if !settings.Installed() {
+		if c.ManageCliBinariesAutomatically() {
+			check.Status, check.Message = Warn, "not installed, it is downloaded when the language server starts"
+			return check
+		}
+		check.Status, check.Message = Fail, "not found and automatic binary management is disabled"
+		return check
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, settings.Path(), "--version").Output()
+	if err != nil {
+		check.Status, check.Message = Fail, fmt.Sprintf("could not run the CLI: %v", err)
+		return check
+	}
+	check.Status, check.Message = Pass, "version "+strings.TrimSpace(string(output))
+	return check
+}
+
+// UseToken configures the token the checks run with, unless a token is configured already. The doctor runs without
+// an IDE sending the settings, so the token is taken from the environment or the configuration of the Vulnmap CLI.
+func UseToken(c *config.Config, token string) {
+	if token == "" || c.NonEmptyToken() {
+		return
+	}
+	c.SetToken(token)
+}
+
+// checkAuthentication checks the format of the configured token locally and then asks the API whether it accepts the
+// token, so revoked or expired tokens are found, too.
+func checkAuthentication(ctx context.Context, c *config.Config) Check {
+	check := Check{Name: "Authentication"}
+	method := c.AuthenticationMethod()
+	if method == "" {
+		method = lsp.TokenAuthentication
+	}
+	check.Details = []string{"method: " + string(method)}
+	if !c.NonEmptyToken() {
+		check.Status, check.Message = Fail, "not authenticated, no token is configured"
+		return check
+	}
+
+	if method == lsp.OAuthAuthentication {
+		token, err := c.TokenAsOAuthToken()
+		switch {
+		case err != nil:
+			check.Status, check.Message = Fail, "the configured token is not an OAuth token"
+		case !token.Valid() && token.RefreshToken == "":
+			check.Status, check.Message = Fail, "the OAuth token expired and cannot be refreshed"
+		case !token.Valid():
+			check.Status, check.Message = Warn, "the OAuth access token expired, it is refreshed on the next request"
+		default:
+			check.Status, check.Message = Pass, fmt.Sprintf("OAuth token valid until %s", token.Expiry.Format(time.RFC3339))
+		}
+		if check.Status == Fail {
+			return check
+		}
+		check.Details = append(check.Details, check.Message)
+	} else if _, err := uuid.Parse(c.Token()); err != nil {
+		check.Status, check.Message = Fail, "the configured token is not an API token"
+		return check
+	}
+
+	return verifyToken(ctx, c, check)
+}
+
+// verifyToken calls the user endpoint of the API with the authenticated http client of the language server. A
+// rejected token fails the check, while network problems only lead to a warning, as they are reported by the
+// endpoint check.
+func verifyToken(ctx context.Context, c *config.Config, check Check) Check {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+	url := strings.TrimSuffix(c.VulnmapApi(), "/") + userEndpoint
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		check.Status, check.Message = Warn, "could not verify the token, invalid endpoint "+c.VulnmapApi()
+		return check
+	}
+
+	response, err := network.GuardClient(c.Engine().GetNetworkAccess().GetHttpClient()).Do(request)
+	if err != nil {
+		check.Status, check.Message = Warn, "could not verify the token, the API is not reachable"
+		check.Details = append(check.Details, fmt.Sprintf("%s: %v", url, err))
+		return check
+	}
+	_ = response.Body.Close()
+
+	switch {
+	case response.StatusCode == http.StatusUnauthorized:
+		check.Status, check.Message = Fail, "the token was rejected by the API, please authenticate again"
+	case response.StatusCode == http.StatusForbidden:
+		check.Status, check.Message = Fail, "the token is not allowed to access the API"
+	case response.StatusCode < 200 || response.StatusCode > 299:
+		check.Status, check.Message = Warn, fmt.Sprintf("could not verify the token, the API answered with HTTP %d", response.StatusCode)
+	default:
+		check.Status, check.Message = Pass, "the token is accepted by the API"
+	}
+	check.Details = append(check.Details, fmt.Sprintf("%s: HTTP %d", url, response.StatusCode))
+	return check
+}
+
+// checkEndpoints calls the configured endpoints with the http client of the language server, so proxy and
+// certificate settings are applied. Any http response counts as reachable.
+func checkEndpoints(ctx context.Context, c *config.Config) Check {
+	check := Check{Name: "Endpoints", Status: Pass, Message: "all endpoints are reachable"}
+	client := network.GuardClient(c.Engine().GetNetworkAccess().GetUnauthorizedHttpClient())
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	endpoints := []struct{ name, url string }{{"API", c.VulnmapApi()}, {"Code API", c.VulnmapCodeApi()}}
+	for _, endpoint := range endpoints {
+		request, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint.url, nil)
+		if err != nil {
+			check.Status, check.Message = Fail, "invalid endpoint "+endpoint.url
+			check.Details = append(check.Details, fmt.Sprintf("%s %s: %v", endpoint.name, endpoint.url, err))
+			continue
+		}
+		if proxy, err := http.ProxyFromEnvironment(request); err == nil && proxy != nil {
+			check.Details = append(check.Details, fmt.Sprintf("%s uses proxy %s", endpoint.name, proxy.Redacted()))
+		}
+		response, err := client.Do(request)
+		if err != nil {
+			check.Status, check.Message = Fail, "not all endpoints are reachable"
+			check.Details = append(check.Details, fmt.Sprintf("%s %s: %v", endpoint.name, endpoint.url, err))
+			continue
+		}
+		_ = response.Body.Close()
+		check.Details = append(check.Details, fmt.Sprintf("%s %s: HTTP %d", endpoint.name, endpoint.url, response.StatusCode))
+	}
+
+	for _, caVariable := range []string{"SSL_CERT_FILE", "SSL_CERT_DIR", "NODE_EXTRA_CA_CERTS"} {
+		if value := os.Getenv(caVariable); value != "" {
+			check.Details = append(check.Details, fmt.Sprintf("custom CA certificates from %s: %s", caVariable, value))
+		}
+	}
+	if c.CliSettings().Insecure {
+		check.Details = append(check.Details, "certificate verification is disabled (insecure)")
+		if check.Status == Pass {
+			check.Status = Warn
+		}
+	}
+	return check
+}
+
+func checkToolchain(_ context.Context, c *config.Config) Check {
+	check := Check{Name: "Toolchain"}
+	workingDir, err := os.Getwd()
+	if err != nil {
+		workingDir = ""
+	}
+	info := c.Toolchain(workingDir)
+	for _, tool := range info.Tools {
+		check.Details = append(check.Details, fmt.Sprintf("%s: %s (%s)", tool.Name, tool.Path, tool.Source))
+	}
+	if len(info.Tools) == 0 {
+		check.Status, check.Message = Warn, "no build tools found, Open Source scans need the build tools of the projects"
+		return check
+	}
+	check.Status, check.Message = Pass, fmt.Sprintf("%d tools found", len(info.Tools))
+	return check
+}
+
+// checkTrustedFolders checks the trusted folders sent by the IDE. The language server doesn't persist them, so the
+// check is skipped if no folders are known, e.g. when the doctor runs outside the IDE.
+func checkTrustedFolders(_ context.Context, c *config.Config) Check {
+	check := Check{Name: "Trusted folders", Status: Pass}
+	if !c.IsTrustedFolderFeatureEnabled() {
+		check.Message = "trust is not required"
+		return check
+	}
+	if len(c.TrustedFolders()) == 0 {
+		check.Status, check.Message = Skip, "the trusted folders are managed by the IDE and are unknown outside of it"
+		return check
+	}
+	missing := 0
+	for _, folder := range c.TrustedFolders() {
+		if info, err := os.Stat(folder); err != nil || !info.IsDir() {
+			missing++
+			check.Details = append(check.Details, folder+" (missing)")
+			continue
+		}
+		check.Details = append(check.Details, folder)
+	}
+	switch {
+	case missing > 0:
+		check.Status, check.Message = Warn, fmt.Sprintf("%d of the trusted folders don't exist", missing)
+	default:
+		check.Message = fmt.Sprintf("%d folders trusted", len(c.TrustedFolders()))
+	}
+	return check
+}
+
+// checkCaches checks that the CLI can be downloaded to the install directory and that no download lock blocks it
+func checkCaches(_ context.Context, c *config.Config) Check {
+	check := Check{Name: "Caches", Status: Pass, Message: "the install directory is writable"}
+	installDir := c.CliSettings().DefaultBinaryInstallPath()
+	if installDir == "" {
+		check.Status, check.Message = Fail, "the install directory cannot be created"
+		return check
+	}
+	check.Details = []string{"install directory: " + installDir}
+	probe, err := os.CreateTemp(installDir, "doctor-*")
+	if err != nil {
+		check.Status, check.Message = Fail, "the install directory is not writable"
+		check.Details = append(check.Details, err.Error())
+		return check
+	}
+	_ = probe.Close()
+	_ = os.Remove(probe.Name())
+
+	lockFileName := c.CLIDownloadLockFileName()
+	if lockFile, err := os.Stat(lockFileName); err == nil {
+		check.Status = Warn
+		if install.IsStaleLockFile(lockFileName) {
+			check.Message = "a download lock of a crashed process exists, it is ignored on the next download"
+		} else {
+			check.Message = fmt.Sprintf("a CLI download is in progress since %s", lockFile.ModTime().Format(time.RFC3339))
+		}
+		check.Details = append(check.Details, "lock file: "+lockFileName)
+	}
+	if partialFiles, _ := filepath.Glob(filepath.Join(installDir, "*.partial")); len(partialFiles) > 0 {
+		check.Details = append(check.Details, fmt.Sprintf("%d incomplete downloads, they are resumed", len(partialFiles)))
+	}
+	return check
+}
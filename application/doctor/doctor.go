@@ -0,0 +1,105 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package doctor checks the setup of the language server and prints a report, so users can diagnose problems
+// without collecting trace logs.
+package doctor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
+)
+
+type Status string
+
+const (
+	Pass Status = "pass"
+	Warn Status = "warn"
+	Fail Status = "fail"
+	// Skip is the status of checks that can't be run outside the IDE
+	Skip Status = "skip"
+)
+
+// Check is the result of a single diagnostic check
+type Check struct {
+	Name    string   `json:"name"`
+	Status  Status   `json:"status"`
+	Message string   `json:"message"`
+	Details []string `json:"details,omitempty"`
+}
+
+// Report is the result of all checks
+type Report struct {
+	Version string  `json:"version"`
+	Passed  bool    `json:"passed"`
+	Checks  []Check `json:"checks"`
+}
+
+type checkFunc func(ctx context.Context, c *config.Config) Check
+
+// checks are run in this order, each check is independent of the others
+var checks = []checkFunc{
+	checkCli,
+	checkAuthentication,
+	checkEndpoints,
+	checkToolchain,
+	checkTrustedFolders,
+	checkCaches,
+}
+
+// Run runs all checks with the given configuration. The report passes if no check fails, warnings and skipped checks
+// are allowed.
+func Run(ctx context.Context, c *config.Config) Report {
+	report := Report{Version: config.Version, Passed: true}
+	for _, check := range checks {
+		result := check(ctx, c)
+		if result.Status == Fail {
+			report.Passed = false
+		}
+		report.Checks = append(report.Checks, result)
+	}
+	return report
+}
+
+// Print writes the report as human-readable text or, for scripting, as JSON
+func (r Report) Print(w io.Writer, asJson bool) error {
+	if asJson {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(r)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Vulnmap Language Server %s\n\n", r.Version))
+	for _, check := range r.Checks {
+		sb.WriteString(fmt.Sprintf("[%s] %s: %s\n", strings.ToUpper(string(check.Status)), check.Name, check.Message))
+		for _, detail := range check.Details {
+			sb.WriteString(fmt.Sprintf("       %s\n", detail))
+		}
+	}
+	if r.Passed {
+		sb.WriteString("\nAll checks passed.\n")
+	} else {
+		sb.WriteString("\nSome checks failed.\n")
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
@@ -0,0 +1,241 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package doctor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+
+	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/lsp"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/testutil"
+)
+
+func oauthToken(t *testing.T, token oauth2.Token) string {
+	t.Helper()
+	tokenJson, err := json.Marshal(token)
+	require.NoError(t, err)
+	return string(tokenJson)
+}
+
+func Test_Run_FailsIfACheckFails(t *testing.T) {
+	c := testutil.UnitTest(t)
+	originalChecks := checks
+	t.Cleanup(func() { checks = originalChecks })
+	checks = []checkFunc{
+		func(context.Context, *config.Config) Check { return Check{Name: "a", Status: Warn} },
+		func(context.Context, *config.Config) Check { return Check{Name: "b", Status: Pass} },
+	}
+
+	assert.True(t, Run(context.Background(), c).Passed)
+
+	checks = append(checks, func(context.Context, *config.Config) Check { return Check{Name: "c", Status: Fail} })
+	report := Run(context.Background(), c)
+
+	assert.False(t, report.Passed)
+	assert.Len(t, report.Checks, 3)
+}
+
+func Test_Report_Print(t *testing.T) {
+	report := Report{Version: "1.0.0", Passed: false, Checks: []Check{
+		{Name: "CLI", Status: Pass, Message: "version 1.1000.0", Details: []string{"path: /bin/vulnmap"}},
+		{Name: "Authentication", Status: Fail, Message: "not authenticated"},
+	}}
+
+	t.Run("text", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		require.NoError(t, report.Print(&buf, false))
+
+		assert.Contains(t, buf.String(), "[PASS] CLI: version 1.1000.0\n       path: /bin/vulnmap\n")
+		assert.Contains(t, buf.String(), "[FAIL] Authentication: not authenticated\n")
+		assert.Contains(t, buf.String(), "Some checks failed.")
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		require.NoError(t, report.Print(&buf, true))
+
+		var printed Report
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &printed))
+		assert.Equal(t, report, printed)
+	})
+}
+
+func Test_checkAuthentication(t *testing.T) {
+	c := testutil.UnitTest(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+	c.UpdateApiEndpoints(server.URL)
+
+	tests := []struct {
+		name   string
+		method lsp.AuthenticationMethod
+		token  string
+		status Status
+	}{
+		{"no token", lsp.TokenAuthentication, "", Fail},
+		{"api token", lsp.TokenAuthentication, "00000000-0000-0000-0000-000000000001", Pass},
+		{"invalid api token", lsp.TokenAuthentication, "not-a-token", Fail},
+		{"valid oauth token", lsp.OAuthAuthentication,
+			oauthToken(t, oauth2.Token{AccessToken: "a", Expiry: time.Now().Add(time.Hour)}), Pass},
+		{"refreshable oauth token", lsp.OAuthAuthentication,
+			oauthToken(t, oauth2.Token{AccessToken: "a", RefreshToken: "r", Expiry: time.Now().Add(-time.Hour)}), Warn},
+		{"expired oauth token", lsp.OAuthAuthentication,
+			oauthToken(t, oauth2.Token{AccessToken: "a", Expiry: time.Now().Add(-time.Hour)}), Fail},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c.SetAuthenticationMethod(tt.method)
+			c.SetToken(tt.token)
+
+			check := checkAuthentication(context.Background(), c)
+
+			assert.Equal(t, tt.status, check.Status, check.Message)
+			assert.NotContains(t, check.Message+check.Details[0], "00000000")
+		})
+	}
+}
+
+func Test_checkAuthentication_VerifiesTokenWithApi(t *testing.T) {
+	c := testutil.UnitTest(t)
+	c.SetAuthenticationMethod(lsp.TokenAuthentication)
+	c.SetToken("00000000-0000-0000-0000-000000000001")
+	status := http.StatusOK
+	var authorized bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorized = r.URL.Path == userEndpoint && r.Header.Get("Authorization") != ""
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	c.UpdateApiEndpoints(server.URL)
+
+	tests := []struct {
+		name    string
+		status  int
+		result  Status
+		message string
+	}{
+		{"accepted", http.StatusOK, Pass, "accepted"},
+		{"unauthorized", http.StatusUnauthorized, Fail, "rejected"},
+		{"forbidden", http.StatusForbidden, Fail, "not allowed"},
+		{"server error", http.StatusInternalServerError, Warn, "HTTP 500"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status = tt.status
+
+			check := checkAuthentication(context.Background(), c)
+
+			assert.True(t, authorized)
+			assert.Equal(t, tt.result, check.Status, check.Message)
+			assert.Contains(t, check.Message, tt.message)
+		})
+	}
+
+	t.Run("unreachable", func(t *testing.T) {
+		c.UpdateApiEndpoints("http://localhost:1")
+
+		check := checkAuthentication(context.Background(), c)
+
+		assert.Equal(t, Warn, check.Status)
+		assert.Contains(t, check.Message, "not reachable")
+	})
+}
+
+func Test_checkEndpoints(t *testing.T) {
+	c := testutil.UnitTest(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	t.Cleanup(server.Close)
+	c.UpdateApiEndpoints(server.URL)
+
+	t.Run("reachable", func(t *testing.T) {
+		c.SetVulnmapCodeApi(server.URL)
+
+		check := checkEndpoints(context.Background(), c)
+
+		assert.Equal(t, Pass, check.Status)
+		assert.Contains(t, check.Details, "API "+server.URL+": HTTP 404")
+	})
+
+	t.Run("unreachable", func(t *testing.T) {
+		c.SetVulnmapCodeApi("http://localhost:1")
+
+		check := checkEndpoints(context.Background(), c)
+
+		assert.Equal(t, Fail, check.Status)
+	})
+}
+
+func Test_checkTrustedFolders(t *testing.T) {
+	c := testutil.UnitTest(t)
+	c.SetTrustedFolderFeatureEnabled(true)
+	existing := t.TempDir()
+	c.SetTrustedFolders([]string{existing, filepath.Join(existing, "missing")})
+
+	check := checkTrustedFolders(context.Background(), c)
+
+	assert.Equal(t, Warn, check.Status)
+	assert.Equal(t, []string{existing, filepath.Join(existing, "missing") + " (missing)"}, check.Details)
+}
+
+func Test_checkTrustedFolders_SkippedWithoutFoldersOfTheIDE(t *testing.T) {
+	c := testutil.UnitTest(t)
+	c.SetTrustedFolderFeatureEnabled(true)
+
+	check := checkTrustedFolders(context.Background(), c)
+
+	assert.Equal(t, Skip, check.Status)
+}
+
+func Test_UseToken(t *testing.T) {
+	c := testutil.UnitTest(t)
+	c.SetToken("")
+
+	UseToken(c, "00000000-0000-0000-0000-000000000002")
+	UseToken(c, "00000000-0000-0000-0000-000000000003")
+
+	assert.Equal(t, "00000000-0000-0000-0000-000000000002", c.Token(), "a configured token is kept")
+}
+
+func Test_checkCaches(t *testing.T) {
+	c := testutil.UnitTest(t)
+
+	check := checkCaches(context.Background(), c)
+	assert.Equal(t, Pass, check.Status)
+
+	require.NoError(t, os.WriteFile(c.CLIDownloadLockFileName(), []byte{}, 0o600))
+	check = checkCaches(context.Background(), c)
+	assert.Equal(t, Warn, check.Status)
+	assert.Contains(t, check.Message, "download is in progress")
+}
@@ -6,6 +6,9 @@ import (
 	"runtime/debug"
 	"strings"
 
+	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
+	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/errorspool"
+	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/network"
 	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/sentry"
 )
 
@@ -14,7 +17,10 @@ func OnPanicRecover() {
 		fmt.Println("🚨 Panicking 🚨")
 		fmt.Println(err)
 		debug.PrintStack()
-		er := sentry.NewSentryErrorReporter(nil)
+		c := config.CurrentConfig()
+		spool := errorspool.New(c.ErrorSpoolPath(), errorspool.DefaultMaxEntries)
+		httpClient := network.GuardClientFactory(c.Engine().GetNetworkAccess().GetUnauthorizedHttpClient)
+		er := sentry.NewPanicErrorReporter(spool, httpClient)
 		er.CaptureError(fmt.Errorf("%v", err))
 		er.FlushErrorReporting()
 	}
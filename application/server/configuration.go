@@ -20,26 +20,36 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"os"
 	"reflect"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/creachadair/jrpc2"
 	"github.com/creachadair/jrpc2/handler"
-	"github.com/rs/zerolog/log"
 	"github.com/khulnasoft-lab/go-application-framework/pkg/auth"
 	"github.com/khulnasoft-lab/go-application-framework/pkg/configuration"
+	"github.com/rs/zerolog/log"
+	sglsp "github.com/sourcegraph/go-lsp"
 	"golang.org/x/oauth2"
 
 	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
 	"github.com/khulnasoft-lab/vulnmap-ls/application/di"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/ide/workspace"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/audit"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/metrics"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/ux"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/vulnmap"
+	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/cli"
 	auth2 "github.com/khulnasoft-lab/vulnmap-ls/infrastructure/cli/auth"
+	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/cli/install"
 	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/oauth"
 	"github.com/khulnasoft-lab/vulnmap-ls/internal/lsp"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/product"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/uri"
 )
 
 var cachedOriginalPath = ""
@@ -101,6 +111,11 @@ func UpdateSettings(settings lsp.Settings) {
 
 	writeSettings(settings, false)
 
+	// cached CLI results may depend on the changed settings
+	if clearer, ok := di.Scanner().(vulnmap.ResultCacheClearer); ok {
+		clearer.ClearResultCache()
+	}
+
 	// If a product was removed, clear all issues for this product
 	ws := workspace.Get()
 	if ws != nil {
@@ -124,6 +139,7 @@ func writeSettings(settings lsp.Settings, initialize bool) {
 	}
 	updateSeverityFilter(settings.FilterSeverity)
 	updateProductEnablement(settings)
+	previousFolderCliVersions := config.CurrentConfig().CliSettings().FolderReleaseVersions
 	updateCliConfig(settings)
 
 	// updateApiEndpoints overwrites the authentication method in certain cases (oauth2)
@@ -137,8 +153,14 @@ func writeSettings(settings lsp.Settings, initialize bool) {
 	updateEnvironment(settings)
 	updatePathFromSettings(settings)
 	updateTelemetry(settings)
+	updateTracing(settings)
+	updateMetricsEndpoint(settings)
+	updateAuditLog(settings)
+	updateOfflineMode(settings)
+	updateRpcTraceFile(settings)
 	updateOrganization(settings)
 	manageBinariesAutomatically(settings)
+	installFolderCliVersions(previousFolderCliVersions, initialize)
 	updateTrustedFolders(settings)
 	updateVulnmapCodeSecurity(settings)
 	updateVulnmapCodeQuality(settings)
@@ -224,10 +246,25 @@ func updateTrustedFolders(settings lsp.Settings) {
 	}
 
 	if settings.TrustedFolders != nil {
+		auditTrustChanges(config.CurrentConfig().TrustedFolders(), settings.TrustedFolders)
 		config.CurrentConfig().SetTrustedFolders(settings.TrustedFolders)
 	}
 }
 
+// auditTrustChanges records the folders that were added to or removed from the trusted folders in the audit log
+func auditTrustChanges(previous []string, current []string) {
+	for _, folder := range current {
+		if !slices.Contains(previous, folder) {
+			di.AuditTrail().TrustChanged(audit.TrustProperties{Folder: folder, Decision: audit.TrustGranted})
+		}
+	}
+	for _, folder := range previous {
+		if !slices.Contains(current, folder) {
+			di.AuditTrail().TrustChanged(audit.TrustProperties{Folder: folder, Decision: audit.TrustRevoked})
+		}
+	}
+}
+
 func updateAutoAuthentication(settings lsp.Settings) {
 	// Unless the field is included and set to false, auto-auth should be true by default.
 	autoAuth, err := strconv.ParseBool(settings.AutomaticAuthentication)
@@ -299,6 +336,59 @@ func updateOrganization(settings lsp.Settings) {
 	}
 }
 
+// settingOff switches off settings whose empty value, i.e. a setting that isn't sent, keeps the configuration from
+// the environment
+const settingOff = "off"
+
+// unlessOff returns the value of a setting, or an empty value if the setting is switched off
+func unlessOff(value string) string {
+	if strings.EqualFold(strings.TrimSpace(value), settingOff) {
+		return ""
+	}
+	return value
+}
+
+// updateTracing configures the export of performance traces. Empty settings keep the configuration from the
+// environment, "off" switches the export off.
+func updateTracing(settings lsp.Settings) {
+	if settings.TracingEndpoint == "" && settings.TracingFile == "" {
+		return
+	}
+	config.CurrentConfig().SetTracingSettings(config.TracingSettings{
+		Endpoint: unlessOff(settings.TracingEndpoint),
+		File:     unlessOff(settings.TracingFile),
+	})
+}
+
+// updateMetricsEndpoint serves the Prometheus metrics on the configured loopback address. An empty setting keeps the
+// configuration from the environment, "off" stops serving the metrics.
+func updateMetricsEndpoint(settings lsp.Settings) {
+	if settings.MetricsEndpoint == "" {
+		return
+	}
+	c := config.CurrentConfig()
+	address := unlessOff(settings.MetricsEndpoint)
+	c.SetMetricsEndpoint(address)
+	if err := metrics.DefaultEndpoint().Configure(address); err != nil {
+		log.Err(err).Str("method", "updateMetricsEndpoint").Msg("couldn't serve metrics")
+	}
+}
+
+// updateAuditLog sets the path of the audit log. An empty setting keeps the configuration from the environment.
+func updateAuditLog(settings lsp.Settings) {
+	if settings.AuditLogPath != "" {
+		config.CurrentConfig().SetAuditLogPath(settings.AuditLogPath)
+	}
+}
+
+// updateRpcTraceFile sets the file JSON-RPC messages are recorded to. An empty setting keeps the configuration from the
+// environment.
+func updateRpcTraceFile(settings lsp.Settings) {
+	if settings.RpcTraceFile != "" {
+		config.CurrentConfig().SetRpcTraceFile(settings.RpcTraceFile)
+	}
+}
+
 func updateTelemetry(settings lsp.Settings) {
 	parseBool, err := strconv.ParseBool(settings.SendErrorReports)
 	if err != nil {
@@ -318,6 +408,15 @@ func updateTelemetry(settings lsp.Settings) {
 	}
 }
 
+func updateOfflineMode(settings lsp.Settings) {
+	parseBool, err := strconv.ParseBool(settings.OfflineMode)
+	if err != nil {
+		log.Debug().Msgf("couldn't read offline mode %s", settings.OfflineMode)
+	} else {
+		config.CurrentConfig().SetOfflineMode(parseBool)
+	}
+}
+
 func manageBinariesAutomatically(settings lsp.Settings) {
 	parseBool, err := strconv.ParseBool(settings.ManageBinariesAutomatically)
 	if err != nil {
@@ -367,17 +466,38 @@ func updatePathFromSettings(settings lsp.Settings) {
 
 // TODO store in config, move parsing to CLI
 func updateEnvironment(settings lsp.Settings) {
-	envVars := strings.Split(settings.AdditionalEnv, ";")
-	for _, envVar := range envVars {
-		v := strings.Split(envVar, "=")
-		if len(v) != 2 {
+	for _, envVar := range parseEnvVars(settings.AdditionalEnv) {
+		name, value, _ := strings.Cut(envVar, "=")
+		err := os.Setenv(name, value)
+		if err != nil {
+			log.Err(err).Msgf("couldn't set env variable %s", name)
+		}
+	}
+}
+
+// parseEnvVars parses variables in the form of "key=value;key2=value2", invalid entries are ignored. Values may
+// contain "=".
+func parseEnvVars(envVars string) []string {
+	var parsed []string
+	for _, envVar := range strings.Split(envVars, ";") {
+		name, value, found := strings.Cut(envVar, "=")
+		if !found || strings.TrimSpace(name) == "" {
 			continue
 		}
-		err := os.Setenv(v[0], v[1])
-		if err != nil {
-			log.Err(err).Msgf("couldn't set env variable %s", envVar)
+		parsed = append(parsed, strings.TrimSpace(name)+"="+value)
+	}
+	return parsed
+}
+
+// parseList parses a comma separated list, ignoring empty entries
+func parseList(list string) []string {
+	var parsed []string
+	for _, entry := range strings.Split(list, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			parsed = append(parsed, entry)
 		}
 	}
+	return parsed
 }
 
 func updateCliConfig(settings lsp.Settings) {
@@ -388,13 +508,103 @@ func updateCliConfig(settings lsp.Settings) {
 		log.Debug().Msg("couldn't parse insecure setting")
 	}
 	cliSettings.AdditionalOssParameters = strings.Split(settings.AdditionalParams, " ")
+	updateCliOptions(cliSettings, settings.CliOptions)
 	cliSettings.SetPath(strings.TrimSpace(settings.CliPath))
+	if settings.CliConcurrency != "" {
+		cliSettings.Concurrency, err = strconv.Atoi(settings.CliConcurrency)
+		if err != nil || cliSettings.Concurrency < 0 {
+			log.Debug().Msg("couldn't parse cli concurrency setting")
+			cliSettings.Concurrency = 0
+		}
+	}
+	cliSettings.ScanTimeouts = map[product.Product]time.Duration{}
+	parseScanTimeout(cliSettings, product.ProductOpenSource, settings.OssScanTimeout)
+	parseScanTimeout(cliSettings, product.ProductInfrastructureAsCode, settings.IacScanTimeout)
+	cliSettings.ReleaseSource = strings.TrimSpace(settings.CliReleaseSource)
+	cliSettings.ReleaseVersion = strings.TrimSpace(settings.CliVersion)
+	cliSettings.FolderReleaseVersions = map[string]string{}
+	for folder, version := range settings.FolderCliVersion {
+		if version = strings.TrimSpace(version); version != "" {
+			cliSettings.FolderReleaseVersions[folderPathFromSetting(folder)] = version
+		}
+	}
+	cliSettings.ReleaseChannel = install.ReleaseChannelStable
+	switch channel := strings.ToLower(strings.TrimSpace(settings.CliReleaseChannel)); channel {
+	case "", install.ReleaseChannelStable:
+	case install.ReleaseChannelPreview:
+		cliSettings.ReleaseChannel = channel
+	default:
+		log.Debug().Str("channel", channel).Msg("unknown cli release channel, using stable")
+	}
+	cliSettings.ResultCacheTTL = config.DefaultCliResultCacheTTL
+	if settings.CliResultCacheTtl != "" {
+		ttl, err := time.ParseDuration(settings.CliResultCacheTtl)
+		if err != nil || ttl < 0 {
+			log.Debug().Msg("couldn't parse cli result cache ttl setting")
+		} else {
+			cliSettings.ResultCacheTTL = ttl
+		}
+	}
+	cliSettings.EnvAllowlist = parseList(settings.CliEnvAllowlist)
+	cliSettings.EnvDenylist = parseList(settings.CliEnvDenylist)
+	cliSettings.AdditionalEnv = parseEnvVars(settings.AdditionalEnv)
+	cliSettings.FolderEnv = map[string][]string{}
+	for folder, env := range settings.FolderEnv {
+		cliSettings.FolderEnv[folderPathFromSetting(folder)] = parseEnvVars(env)
+	}
 	currentConfig := config.CurrentConfig()
 	conf := currentConfig.Engine().GetConfiguration()
 	conf.Set(configuration.INSECURE_HTTPS, cliSettings.Insecure)
 	currentConfig.SetCliSettings(cliSettings)
 }
 
+// installFolderCliVersions installs the CLI versions pinned for folders if they changed. On initialization, this is
+// done by the CLI initializer.
+func installFolderCliVersions(previous map[string]string, initialize bool) {
+	current := config.CurrentConfig().CliSettings().FolderReleaseVersions
+	if initialize || maps.Equal(previous, current) || di.CliInitializer() == nil {
+		return
+	}
+	go di.CliInitializer().InstallFolderVersions()
+}
+
+// folderPathFromSetting returns the path of a folder that is given as URI or path in the settings
+func folderPathFromSetting(folder string) string {
+	if strings.Contains(folder, "://") {
+		return uri.PathFromUri(sglsp.DocumentURI(folder))
+	}
+	return folder
+}
+
+// updateCliOptions applies valid CLI options. Invalid options are reported to the user and the previous options stay in
+// effect, so that a typo doesn't change the scope of the scans.
+func updateCliOptions(cliSettings *config.CliSettings, options lsp.CliOptions) {
+	err := cli.ValidateOptions(options, cliSettings.AdditionalOssParameters)
+	if err == nil {
+		cliSettings.Options = options
+		return
+	}
+	log.Warn().Err(err).Str("method", "updateCliOptions").Msg("invalid cli options")
+	cliSettings.Options = config.CurrentConfig().CliSettings().Options
+	if notifier := di.Notifier(); notifier != nil {
+		notifier.SendShowMessage(sglsp.MTError, "The Vulnmap CLI options are invalid, the previous options are used: "+
+			strings.ReplaceAll(err.Error(), "\n", "; "))
+	}
+}
+
+// parseScanTimeout parses durations like "30m", timeouts that can't be parsed fall back to the default
+func parseScanTimeout(cliSettings *config.CliSettings, p product.Product, timeout string) {
+	if timeout == "" {
+		return
+	}
+	duration, err := time.ParseDuration(timeout)
+	if err != nil || duration <= 0 {
+		log.Debug().Str("product", string(p)).Msg("couldn't parse scan timeout setting")
+		return
+	}
+	cliSettings.ScanTimeouts[p] = duration
+}
+
 func updateProductEnablement(settings lsp.Settings) {
 	parseBool, err := strconv.ParseBool(settings.ActivateVulnmapCode)
 	currentConfig := config.CurrentConfig()
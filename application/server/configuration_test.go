@@ -30,6 +30,7 @@ import (
 	"github.com/creachadair/jrpc2"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"golang.org/x/oauth2"
 
 	"github.com/khulnasoft-lab/go-application-framework/pkg/auth"
@@ -37,10 +38,14 @@ import (
 
 	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
 	"github.com/khulnasoft-lab/vulnmap-ls/application/di"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/audit"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/metrics"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/ux"
 
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/vulnmap"
+	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/cli/install"
 	"github.com/khulnasoft-lab/vulnmap-ls/internal/lsp"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/product"
 	"github.com/khulnasoft-lab/vulnmap-ls/internal/testutil"
 )
 
@@ -273,6 +278,18 @@ func Test_UpdateSettings(t *testing.T) {
 			EnableTelemetry:             "false",
 			ManageBinariesAutomatically: "false",
 			CliPath:                     "C:\\Users\\CliPath\\vulnmap-ls.exe",
+			CliConcurrency:              "3",
+			OssScanTimeout:              "30m",
+			IacScanTimeout:              "5m",
+			CliReleaseSource:            "https://mirror.example.com/vulnmap",
+			CliVersion:                  "1.1234.0",
+			CliReleaseChannel:           "Preview",
+			CliEnvAllowlist:             "ARTIFACTORY_*, NPM_TOKEN",
+			CliEnvDenylist:              "AWS_*",
+			FolderEnv:                   map[string]string{"file:///project": "MAVEN_OPTS=-Xmx2g;invalid", "/other": "A=B"},
+			FolderCliVersion:            map[string]string{"file:///pinned": " 1.1200.0 ", "/unpinned": ""},
+			CliResultCacheTtl:           "10m",
+			CliOptions:                  lsp.CliOptions{Dev: true, DetectionDepth: 3, SeverityThreshold: "high"},
 			Token:                       "a fancy token",
 			FilterSeverity:              lsp.DefaultSeverityFilter(),
 			TrustedFolders:              []string{"trustedPath1", "trustedPath2"},
@@ -294,6 +311,7 @@ func Test_UpdateSettings(t *testing.T) {
 		assert.Equal(t, false, c.IsVulnmapIacEnabled())
 		assert.Equal(t, true, c.CliSettings().Insecure)
 		assert.Equal(t, []string{"--all-projects", "-d"}, c.CliSettings().AdditionalOssParameters)
+		assert.Equal(t, lsp.CliOptions{Dev: true, DetectionDepth: 3, SeverityThreshold: "high"}, c.CliSettings().Options)
 		assert.Equal(t, "https://vulnmap.khulnasoft.com/api", c.VulnmapApi())
 		assert.Equal(t, "b", os.Getenv("a"))
 		assert.Equal(t, "d", os.Getenv("c"))
@@ -303,6 +321,18 @@ func Test_UpdateSettings(t *testing.T) {
 		assert.False(t, c.IsTelemetryEnabled())
 		assert.False(t, c.ManageBinariesAutomatically())
 		assert.Equal(t, "C:\\Users\\CliPath\\vulnmap-ls.exe", c.CliSettings().Path())
+		assert.Equal(t, 3, c.CliSettings().MaxConcurrency())
+		assert.Equal(t, 30*time.Minute, c.CliSettings().ScanTimeout(product.ProductOpenSource))
+		assert.Equal(t, 5*time.Minute, c.CliSettings().ScanTimeout(product.ProductInfrastructureAsCode))
+		assert.Equal(t, "https://mirror.example.com/vulnmap", c.CliSettings().ReleaseSource)
+		assert.Equal(t, "1.1234.0", c.CliSettings().ReleaseVersion)
+		assert.Equal(t, install.ReleaseChannelPreview, c.CliSettings().ReleaseChannel)
+		assert.Equal(t, []string{"ARTIFACTORY_*", "NPM_TOKEN"}, c.CliSettings().EnvAllowlist)
+		assert.Equal(t, []string{"AWS_*"}, c.CliSettings().EnvDenylist)
+		assert.Equal(t, []string{"a=b", "c=d"}, c.CliSettings().AdditionalEnv)
+		assert.Equal(t, map[string][]string{"/project": {"MAVEN_OPTS=-Xmx2g"}, "/other": {"A=B"}}, c.CliSettings().FolderEnv)
+		assert.Equal(t, map[string]string{"/pinned": "1.1200.0"}, c.CliSettings().FolderReleaseVersions)
+		assert.Equal(t, 10*time.Minute, c.CliSettings().ResultCacheTTL)
 		assert.Equal(t, "a fancy token", c.Token())
 		assert.Equal(t, lsp.DefaultSeverityFilter(), c.FilterSeverity())
 		assert.Subset(t, []string{"trustedPath1", "trustedPath2"}, c.TrustedFolders())
@@ -316,6 +346,29 @@ func Test_UpdateSettings(t *testing.T) {
 		assert.Equal(t, settings.EnableAnalytics, c.IsAnalyticsEnabled())
 	})
 
+	t.Run("invalid cli concurrency and scan timeouts fall back to defaults", func(t *testing.T) {
+		config.SetCurrentConfig(config.New())
+
+		UpdateSettings(lsp.Settings{CliConcurrency: "many", OssScanTimeout: "forever", IacScanTimeout: "-5m", CliReleaseChannel: "nightly", CliResultCacheTtl: "a while"})
+
+		c := config.CurrentConfig()
+		assert.Equal(t, 0, c.CliSettings().Concurrency)
+		assert.Equal(t, config.DefaultCliScanTimeout, c.CliSettings().ScanTimeout(product.ProductOpenSource))
+		assert.Equal(t, config.DefaultCliScanTimeout, c.CliSettings().ScanTimeout(product.ProductInfrastructureAsCode))
+		assert.Equal(t, install.ReleaseChannelStable, c.CliSettings().ReleaseChannel)
+		assert.Equal(t, config.DefaultCliResultCacheTTL, c.CliSettings().ResultCacheTTL)
+	})
+
+	t.Run("invalid cli options keep the previous options", func(t *testing.T) {
+		config.SetCurrentConfig(config.New())
+		validOptions := lsp.CliOptions{AllProjects: true, Exclude: []string{"dist"}}
+		UpdateSettings(lsp.Settings{CliOptions: validOptions})
+
+		UpdateSettings(lsp.Settings{CliOptions: lsp.CliOptions{Exclude: []string{"src/dist"}}})
+
+		assert.Equal(t, validOptions, config.CurrentConfig().CliSettings().Options)
+	})
+
 	t.Run("empty vulnmap code api is ignored and default is used", func(t *testing.T) {
 		config.SetCurrentConfig(config.New())
 
@@ -352,6 +405,19 @@ func Test_UpdateSettings(t *testing.T) {
 		assert.Equal(t, varCount, len(os.Environ()))
 	})
 
+	t.Run("env vars with = in the value", func(t *testing.T) {
+		config.SetCurrentConfig(config.New())
+		t.Cleanup(func() {
+			_ = os.Unsetenv("JAVA_OPTS_TEST")
+			_ = os.Unsetenv("URL_TEST")
+		})
+
+		UpdateSettings(lsp.Settings{AdditionalEnv: "JAVA_OPTS_TEST=-Dx=y;URL_TEST=https://a?b=c"})
+
+		assert.Equal(t, "-Dx=y", os.Getenv("JAVA_OPTS_TEST"))
+		assert.Equal(t, []string{"JAVA_OPTS_TEST=-Dx=y", "URL_TEST=https://a?b=c"}, config.CurrentConfig().CliSettings().AdditionalEnv)
+	})
+
 	t.Run("broken env variables", func(t *testing.T) {
 		config.SetCurrentConfig(config.New())
 
@@ -370,6 +436,19 @@ func Test_UpdateSettings(t *testing.T) {
 		assert.Contains(t, c.TrustedFolders(), "/a/b")
 		assert.Contains(t, c.TrustedFolders(), "/b/c")
 	})
+	t.Run("trusted folder changes are audited", func(t *testing.T) {
+		config.SetCurrentConfig(config.New())
+		config.CurrentConfig().SetTrustedFolders([]string{"/a/b", "/b/c"})
+		auditTrail := di.AuditTrail().(*audit.TestTrail)
+		eventsBefore := len(auditTrail.Events())
+
+		UpdateSettings(lsp.Settings{TrustedFolders: []string{"/b/c", "/c/d"}})
+
+		assert.Equal(t, []any{
+			audit.TrustProperties{Folder: "/c/d", Decision: audit.TrustGranted},
+			audit.TrustProperties{Folder: "/a/b", Decision: audit.TrustRevoked},
+		}, auditTrail.Events()[eventsBefore:])
+	})
 
 	t.Run("manage binaries automatically", func(t *testing.T) {
 		t.Run("true", func(t *testing.T) {
@@ -400,6 +479,51 @@ func Test_UpdateSettings(t *testing.T) {
 		})
 	})
 
+	t.Run("offline mode", func(t *testing.T) {
+		UpdateSettings(lsp.Settings{OfflineMode: "true"})
+		assert.True(t, config.CurrentConfig().IsOfflineMode())
+
+		UpdateSettings(lsp.Settings{OfflineMode: "dog"})
+		assert.True(t, config.CurrentConfig().IsOfflineMode())
+
+		UpdateSettings(lsp.Settings{OfflineMode: "false"})
+		assert.False(t, config.CurrentConfig().IsOfflineMode())
+	})
+
+	t.Run("tracing can be switched off", func(t *testing.T) {
+		config.CurrentConfig().SetTracingSettings(config.TracingSettings{Endpoint: "http://localhost:4318"})
+
+		UpdateSettings(lsp.Settings{})
+		assert.True(t, config.CurrentConfig().TracingSettings().Enabled())
+
+		UpdateSettings(lsp.Settings{TracingEndpoint: "off"})
+		assert.False(t, config.CurrentConfig().TracingSettings().Enabled())
+	})
+
+	t.Run("metrics endpoint can be switched off", func(t *testing.T) {
+		t.Cleanup(metrics.DefaultEndpoint().Stop)
+
+		UpdateSettings(lsp.Settings{MetricsEndpoint: "127.0.0.1:0"})
+		require.NotNil(t, metrics.DefaultEndpoint().Addr())
+
+		UpdateSettings(lsp.Settings{})
+		require.NotNil(t, metrics.DefaultEndpoint().Addr())
+
+		UpdateSettings(lsp.Settings{MetricsEndpoint: "off"})
+		assert.Nil(t, metrics.DefaultEndpoint().Addr())
+		assert.Empty(t, config.CurrentConfig().MetricsEndpoint())
+	})
+
+	t.Run("rpc trace file", func(t *testing.T) {
+		config.CurrentConfig().SetRpcTraceFile("/from/env.jsonl")
+
+		UpdateSettings(lsp.Settings{RpcTraceFile: ""})
+		assert.Equal(t, "/from/env.jsonl", config.CurrentConfig().RpcTraceFile())
+
+		UpdateSettings(lsp.Settings{RpcTraceFile: "/from/settings.jsonl"})
+		assert.Equal(t, "/from/settings.jsonl", config.CurrentConfig().RpcTraceFile())
+	})
+
 	t.Run("activateVulnmapCodeSecurity is passed", func(t *testing.T) {
 		config.SetCurrentConfig(config.New())
 
@@ -121,6 +121,12 @@ func registerNotifier(srv lsp.Server) {
 				Str("method", "registerNotifier").
 				Interface("trustedPaths", params.TrustedFolders).
 				Msg("sending trusted Folders to client")
+		case lsp.VulnmapOrganizationParams:
+			notifier(srv, "$/vulnmap.organization", params)
+			log.Info().
+				Str("method", "registerNotifier").
+				Str("organization", params.Organization).
+				Msg("sending organization to client")
 		case lsp.VulnmapScanParams:
 			notifier(srv, "$/vulnmap.scan", params)
 			log.Info().
@@ -128,6 +134,12 @@ func registerNotifier(srv lsp.Server) {
 				Interface("product", params.Product).
 				Interface("status", params.Status).
 				Msg("sending scan data to client")
+		case lsp.VulnmapSummaryParams:
+			notifier(srv, "$/vulnmap.summary", params)
+			log.Info().
+				Str("method", "registerNotifier").
+				Str("folderPath", params.FolderPath).
+				Msg("sending summary to client")
 		case vulnmap.ShowMessageRequest:
 			// Function blocks on callback, so we need to run it in a separate goroutine
 			go handleShowMessageRequest(srv, params)
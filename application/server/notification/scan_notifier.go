@@ -44,7 +44,7 @@ func (n *scanNotifier) SendError(pr product.Product, folderPath string) {
 func (n *scanNotifier) SendSuccessForAllProducts(folderPath string, issues []vulnmap.Issue) {
 	for product, enabled := range enabledProducts {
 		if enabled {
-			n.sendSuccess(product, folderPath, issues)
+			n.sendIssues(product, folderPath, issues, lsp.Success)
 		}
 	}
 }
@@ -52,6 +52,15 @@ func (n *scanNotifier) SendSuccessForAllProducts(folderPath string, issues []vul
 // Sends scan success message for a single enabled product
 func (n *scanNotifier) SendSuccess(reportedProduct product.Product, folderPath string, issues []vulnmap.Issue) {
 	// If no issues found, we still should send success message the reported product
+	n.sendIssues(reportedProduct, folderPath, enabledProductIssues(issues), lsp.Success)
+}
+
+// SendPartial sends the issues found so far for a single enabled product, the scan is still in progress
+func (n *scanNotifier) SendPartial(reportedProduct product.Product, folderPath string, issues []vulnmap.Issue) {
+	n.sendIssues(reportedProduct, folderPath, enabledProductIssues(issues), lsp.InProgress)
+}
+
+func enabledProductIssues(issues []vulnmap.Issue) []vulnmap.Issue {
 	productIssues := make([]vulnmap.Issue, 0)
 
 	for _, issue := range issues {
@@ -63,11 +72,10 @@ func (n *scanNotifier) SendSuccess(reportedProduct product.Product, folderPath s
 
 		productIssues = append(productIssues, issue)
 	}
-
-	n.sendSuccess(reportedProduct, folderPath, productIssues)
+	return productIssues
 }
 
-func (n *scanNotifier) sendSuccess(pr product.Product, folderPath string, issues []vulnmap.Issue) {
+func (n *scanNotifier) sendIssues(pr product.Product, folderPath string, issues []vulnmap.Issue, status lsp.ScanStatus) {
 	enabled, ok := enabledProducts[pr]
 	if !enabled || !ok {
 		return
@@ -85,7 +93,7 @@ func (n *scanNotifier) sendSuccess(pr product.Product, folderPath string, issues
 
 	n.notifier.Send(
 		lsp.VulnmapScanParams{
-			Status:     lsp.Success,
+			Status:     status,
 			Product:    product.ToProductCodename(pr),
 			FolderPath: folderPath,
 			Issues:     scanIssues,
@@ -99,37 +107,7 @@ func (n *scanNotifier) appendOssIssues(scanIssues []lsp.ScanIssue, folderPath st
 		if !ok {
 			continue // skip non-oss issues
 		}
-
-		scanIssues = append(scanIssues, lsp.ScanIssue{
-			Id:       additionalData.Key,
-			Title:    additionalData.Title,
-			Severity: issue.Severity.String(),
-			FilePath: issue.AffectedFilePath,
-			AdditionalData: lsp.OssIssueData{
-				License: additionalData.License,
-				Identifiers: lsp.OssIdentifiers{
-					CWE: issue.CWEs,
-					CVE: issue.CVEs,
-				},
-				Description:       additionalData.Description,
-				Language:          additionalData.Language,
-				PackageManager:    additionalData.PackageManager,
-				PackageName:       additionalData.PackageName,
-				Name:              additionalData.Name,
-				Version:           additionalData.Version,
-				Exploit:           additionalData.Exploit,
-				CVSSv3:            additionalData.CVSSv3,
-				CvssScore:         strconv.FormatFloat(additionalData.CvssScore, 'f', 2, 64), // convert float64 to string with 2 decimal places
-				FixedIn:           additionalData.FixedIn,
-				From:              additionalData.From,
-				UpgradePath:       additionalData.UpgradePath,
-				IsPatchable:       additionalData.IsPatchable,
-				IsUpgradable:      additionalData.IsUpgradable,
-				ProjectName:       additionalData.ProjectName,
-				DisplayTargetFile: additionalData.DisplayTargetFile,
-				Details:           additionalData.Details,
-			},
-		})
+		scanIssues = append(scanIssues, toOssScanIssue(issue, additionalData))
 	}
 
 	return scanIssues
@@ -141,23 +119,7 @@ func (n *scanNotifier) appendIacIssues(scanIssues []lsp.ScanIssue, folderPath st
 		if !ok {
 			continue // skip non-iac issues
 		}
-
-		scanIssues = append(scanIssues, lsp.ScanIssue{
-			Id:       additionalData.Key,
-			Title:    additionalData.Title,
-			Severity: issue.Severity.String(),
-			FilePath: issue.AffectedFilePath,
-			AdditionalData: lsp.IacIssueData{
-				PublicId:      additionalData.PublicId,
-				Documentation: additionalData.Documentation,
-				LineNumber:    additionalData.LineNumber,
-				Issue:         additionalData.Issue,
-				Impact:        additionalData.Impact,
-				Resolve:       additionalData.Resolve,
-				Path:          additionalData.Path,
-				References:    additionalData.References,
-			},
-		})
+		scanIssues = append(scanIssues, toIacScanIssue(issue, additionalData))
 	}
 	return scanIssues
 }
@@ -168,66 +130,141 @@ func (n *scanNotifier) appendCodeIssues(scanIssues []lsp.ScanIssue, folderPath s
 		if !ok {
 			continue // skip non-code issues
 		}
+		scanIssues = append(scanIssues, toCodeScanIssue(issue, additionalData))
+	}
+
+	return scanIssues
+}
 
-		exampleCommitFixes := make([]lsp.ExampleCommitFix, 0, len(additionalData.ExampleCommitFixes))
-		for i := range additionalData.ExampleCommitFixes {
-			lines := make([]lsp.CommitChangeLine, 0, len(additionalData.ExampleCommitFixes[i].Lines))
-			for j := range additionalData.ExampleCommitFixes[i].Lines {
-				lines = append(lines, lsp.CommitChangeLine{
-					Line:       additionalData.ExampleCommitFixes[i].Lines[j].Line,
-					LineNumber: additionalData.ExampleCommitFixes[i].Lines[j].LineNumber,
-					LineChange: additionalData.ExampleCommitFixes[i].Lines[j].LineChange,
-				})
-			}
-			exampleCommitFixes = append(exampleCommitFixes, lsp.ExampleCommitFix{
-				CommitURL: additionalData.ExampleCommitFixes[i].CommitURL,
-				Lines:     lines,
+// ToScanIssues converts issues of all products to the model sent with $/vulnmap.scan, keeping their order.
+// Issues without product specific additional data are skipped.
+func ToScanIssues(issues []vulnmap.Issue) []lsp.ScanIssue {
+	scanIssues := make([]lsp.ScanIssue, 0, len(issues))
+	for _, issue := range issues {
+		switch additionalData := issue.AdditionalData.(type) {
+		case vulnmap.OssIssueData:
+			scanIssues = append(scanIssues, toOssScanIssue(issue, additionalData))
+		case vulnmap.IaCIssueData:
+			scanIssues = append(scanIssues, toIacScanIssue(issue, additionalData))
+		case vulnmap.CodeIssueData:
+			scanIssues = append(scanIssues, toCodeScanIssue(issue, additionalData))
+		}
+	}
+	return scanIssues
+}
+
+func toOssScanIssue(issue vulnmap.Issue, additionalData vulnmap.OssIssueData) lsp.ScanIssue {
+	return lsp.ScanIssue{
+		Id:        additionalData.Key,
+		Title:     additionalData.Title,
+		Severity:  issue.Severity.String(),
+		FilePath:  issue.AffectedFilePath,
+		IsIgnored: issue.IsIgnored,
+		AdditionalData: lsp.OssIssueData{
+			License: additionalData.License,
+			Identifiers: lsp.OssIdentifiers{
+				CWE: issue.CWEs,
+				CVE: issue.CVEs,
+			},
+			Description:       additionalData.Description,
+			Language:          additionalData.Language,
+			PackageManager:    additionalData.PackageManager,
+			PackageName:       additionalData.PackageName,
+			Name:              additionalData.Name,
+			Version:           additionalData.Version,
+			Exploit:           additionalData.Exploit,
+			CVSSv3:            additionalData.CVSSv3,
+			CvssScore:         strconv.FormatFloat(additionalData.CvssScore, 'f', 2, 64), // convert float64 to string with 2 decimal places
+			FixedIn:           additionalData.FixedIn,
+			From:              additionalData.From,
+			UpgradePath:       additionalData.UpgradePath,
+			IsPatchable:       additionalData.IsPatchable,
+			IsUpgradable:      additionalData.IsUpgradable,
+			ProjectName:       additionalData.ProjectName,
+			DisplayTargetFile: additionalData.DisplayTargetFile,
+			Details:           additionalData.Details,
+		},
+	}
+}
+
+func toIacScanIssue(issue vulnmap.Issue, additionalData vulnmap.IaCIssueData) lsp.ScanIssue {
+	return lsp.ScanIssue{
+		Id:        additionalData.Key,
+		Title:     additionalData.Title,
+		Severity:  issue.Severity.String(),
+		FilePath:  issue.AffectedFilePath,
+		IsIgnored: issue.IsIgnored,
+		AdditionalData: lsp.IacIssueData{
+			PublicId:      additionalData.PublicId,
+			Documentation: additionalData.Documentation,
+			LineNumber:    additionalData.LineNumber,
+			Issue:         additionalData.Issue,
+			Impact:        additionalData.Impact,
+			Resolve:       additionalData.Resolve,
+			Path:          additionalData.Path,
+			References:    additionalData.References,
+		},
+	}
+}
+
+func toCodeScanIssue(issue vulnmap.Issue, additionalData vulnmap.CodeIssueData) lsp.ScanIssue {
+	exampleCommitFixes := make([]lsp.ExampleCommitFix, 0, len(additionalData.ExampleCommitFixes))
+	for i := range additionalData.ExampleCommitFixes {
+		lines := make([]lsp.CommitChangeLine, 0, len(additionalData.ExampleCommitFixes[i].Lines))
+		for j := range additionalData.ExampleCommitFixes[i].Lines {
+			lines = append(lines, lsp.CommitChangeLine{
+				Line:       additionalData.ExampleCommitFixes[i].Lines[j].Line,
+				LineNumber: additionalData.ExampleCommitFixes[i].Lines[j].LineNumber,
+				LineChange: additionalData.ExampleCommitFixes[i].Lines[j].LineChange,
 			})
 		}
+		exampleCommitFixes = append(exampleCommitFixes, lsp.ExampleCommitFix{
+			CommitURL: additionalData.ExampleCommitFixes[i].CommitURL,
+			Lines:     lines,
+		})
+	}
 
-		markers := make([]lsp.Marker, 0, len(additionalData.Markers))
-		for _, marker := range additionalData.Markers {
-			positions := make([]lsp.MarkerPosition, 0)
-			for _, pos := range marker.Pos {
-				positions = append(positions, lsp.MarkerPosition{
-					Position: lsp.Position{
-						Rows: pos.Rows,
-						Cols: pos.Cols,
-					},
-					File: pos.File,
-				})
-			}
-
-			markers = append(markers, lsp.Marker{
-				Msg: marker.Msg,
-				Pos: positions,
+	markers := make([]lsp.Marker, 0, len(additionalData.Markers))
+	for _, marker := range additionalData.Markers {
+		positions := make([]lsp.MarkerPosition, 0)
+		for _, pos := range marker.Pos {
+			positions = append(positions, lsp.MarkerPosition{
+				Position: lsp.Position{
+					Rows: pos.Rows,
+					Cols: pos.Cols,
+				},
+				File: pos.File,
 			})
 		}
 
-		scanIssues = append(scanIssues, lsp.ScanIssue{
-			Id:       additionalData.Key,
-			Title:    issue.Message,
-			Severity: issue.Severity.String(),
-			FilePath: issue.AffectedFilePath,
-			AdditionalData: lsp.CodeIssueData{
-				Message:            additionalData.Message,
-				Rule:               additionalData.Rule,
-				RuleId:             additionalData.RuleId,
-				RepoDatasetSize:    additionalData.RepoDatasetSize,
-				ExampleCommitFixes: exampleCommitFixes,
-				CWE:                additionalData.CWE,
-				IsSecurityType:     additionalData.IsSecurityType,
-				Text:               additionalData.Text,
-				Cols:               additionalData.Cols,
-				Rows:               additionalData.Rows,
-
-				Markers: markers,
-				LeadURL: "",
-			},
+		markers = append(markers, lsp.Marker{
+			Msg: marker.Msg,
+			Pos: positions,
 		})
 	}
 
-	return scanIssues
+	return lsp.ScanIssue{
+		Id:        additionalData.Key,
+		Title:     issue.Message,
+		Severity:  issue.Severity.String(),
+		FilePath:  issue.AffectedFilePath,
+		IsIgnored: issue.IsIgnored,
+		AdditionalData: lsp.CodeIssueData{
+			Message:            additionalData.Message,
+			Rule:               additionalData.Rule,
+			RuleId:             additionalData.RuleId,
+			RepoDatasetSize:    additionalData.RepoDatasetSize,
+			ExampleCommitFixes: exampleCommitFixes,
+			CWE:                additionalData.CWE,
+			IsSecurityType:     additionalData.IsSecurityType,
+			Text:               additionalData.Text,
+			Cols:               additionalData.Cols,
+			Rows:               additionalData.Rows,
+
+			Markers: markers,
+			LeadURL: "",
+		},
+	}
 }
 
 // Notifies all vulnmap/scan enabled product messages
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	notification2 "github.com/khulnasoft-lab/vulnmap-ls/application/server/notification"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/vulnmap"
@@ -133,7 +134,7 @@ func Test_SendSuccess_SendsForAllEnabledProducts(t *testing.T) {
 				},
 			},
 			Message:             "iacMessage",
-			FormattedMessage:    "iacFormattedMessage",
+			Details:             &vulnmap.IssueDetails{Title: "iacFormattedMessage"},
 			AffectedFilePath:    "iacAffectedFilePath",
 			Product:             product.ProductInfrastructureAsCode,
 			References:          []vulnmap.Reference{},
@@ -166,7 +167,7 @@ func Test_SendSuccess_SendsForAllEnabledProducts(t *testing.T) {
 				},
 			},
 			Message:             "codeMessage",
-			FormattedMessage:    "codeFormattedMessage",
+			Details:             &vulnmap.IssueDetails{Title: "codeFormattedMessage"},
 			AffectedFilePath:    "codeAffectedFilePath",
 			Product:             product.ProductCode,
 			References:          []vulnmap.Reference{},
@@ -268,7 +269,7 @@ func Test_SendSuccess_SendsForOpenSource(t *testing.T) {
 				},
 			},
 			Message:             "Incomplete List of Disallowed Inputs",
-			FormattedMessage:    "Incomplete List of Disallowed Inputs",
+			Details:             &vulnmap.IssueDetails{Title: "Incomplete List of Disallowed Inputs"},
 			AffectedFilePath:    "ossAffectedFilePath",
 			Product:             product.ProductOpenSource,
 			References:          []vulnmap.Reference{},
@@ -368,7 +369,7 @@ func Test_SendSuccess_SendsForVulnmapCode(t *testing.T) {
 				},
 			},
 			Message:             "codeMessage",
-			FormattedMessage:    "codeFormattedMessage",
+			Details:             &vulnmap.IssueDetails{Title: "codeFormattedMessage"},
 			AffectedFilePath:    "codeAffectedFilePath",
 			Product:             product.ProductCode,
 			References:          []vulnmap.Reference{},
@@ -445,7 +446,7 @@ func Test_SendSuccess_SendsForAllVulnmapIac(t *testing.T) {
 				},
 			},
 			Message:             "iacMessage",
-			FormattedMessage:    "iacFormattedMessage",
+			Details:             &vulnmap.IssueDetails{Title: "iacFormattedMessage"},
 			AffectedFilePath:    "iacAffectedFilePath",
 			Product:             product.ProductInfrastructureAsCode,
 			References:          []vulnmap.Reference{},
@@ -497,6 +498,30 @@ func Test_SendInProgress_SendsForAllEnabledProducts(t *testing.T) {
 	assert.Equal(t, 3, len(mockNotifier.SentMessages()))
 }
 
+func Test_SendPartial_SendsInProgressWithIssues(t *testing.T) {
+	testutil.UnitTest(t)
+
+	// Arrange
+	mockNotifier := notification.NewMockNotifier()
+	scanNotifier, _ := notification2.NewScanNotifier(mockNotifier)
+	issues := []vulnmap.Issue{{
+		ID:             "VULNMAP-JS-1",
+		Severity:       vulnmap.High,
+		Product:        product.ProductOpenSource,
+		AdditionalData: vulnmap.OssIssueData{Key: "key"},
+	}}
+
+	// Act
+	scanNotifier.SendPartial(product.ProductOpenSource, "/test/folderPath", issues)
+
+	// Assert
+	require.Len(t, mockNotifier.SentMessages(), 1)
+	scanMessage, ok := mockNotifier.SentMessages()[0].(lsp2.VulnmapScanParams)
+	require.True(t, ok)
+	assert.Equal(t, lsp2.InProgress, scanMessage.Status)
+	assert.Len(t, scanMessage.Issues, 1)
+}
+
 func containsMatchingMessage(t *testing.T,
 	msg any,
 	testCase sendMessageTestCase,
@@ -513,3 +538,21 @@ func containsMatchingMessage(t *testing.T,
 	}
 	return false
 }
+
+func Test_ToScanIssues_KeepsOrderOfAllProducts(t *testing.T) {
+	testutil.UnitTest(t)
+	issues := []vulnmap.Issue{
+		{Product: product.ProductCode, Message: "code", IsIgnored: true, AdditionalData: vulnmap.CodeIssueData{Key: "code-key"}},
+		{Product: product.ProductOpenSource, AdditionalData: vulnmap.OssIssueData{Key: "oss-key"}},
+		{Product: product.ProductInfrastructureAsCode, AdditionalData: vulnmap.IaCIssueData{Key: "iac-key"}},
+		{Product: product.ProductOpenSource}, // no additional data
+	}
+
+	scanIssues := notification2.ToScanIssues(issues)
+
+	assert.Len(t, scanIssues, 3)
+	assert.Equal(t, "code-key", scanIssues[0].Id)
+	assert.True(t, scanIssues[0].IsIgnored)
+	assert.Equal(t, "oss-key", scanIssues[1].Id)
+	assert.Equal(t, "iac-key", scanIssues[2].Id)
+}
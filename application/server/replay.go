@@ -0,0 +1,353 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/adrg/xdg"
+	"github.com/creachadair/jrpc2/channel"
+
+	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
+	"github.com/khulnasoft-lab/vulnmap-ls/application/di"
+	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/cli/install"
+	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/rpctrace"
+)
+
+// machineSettings are settings of the recording that would let the replay reach the network or write to paths of the
+// recorded machine. They are removed from the replayed settings, so the server stays in offline mode.
+var machineSettings = []string{
+	"offlineMode",
+	"endpoint",
+	"vulnmapCodeApi",
+	"cliPath",
+	"cliReleaseSource",
+	"path",
+	"auditLogPath",
+	"metricsEndpoint",
+	"tracingEndpoint",
+	"tracingFile",
+	"rpcTraceFile",
+}
+
+// replayMessage is a single JSON-RPC message of a recording
+type replayMessage struct {
+	JsonRPC string          `json:"jsonrpc,omitempty"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   json.RawMessage `json:"error,omitempty"`
+}
+
+func (m replayMessage) isRequest() bool      { return m.Method != "" && len(m.ID) > 0 }
+func (m replayMessage) isNotification() bool { return m.Method != "" && len(m.ID) == 0 }
+func (m replayMessage) isResponse() bool     { return m.Method == "" && len(m.ID) > 0 }
+
+// Replay feeds the client messages of a recording into a server that runs against fake backends. Requests of the
+// server are answered with the responses the client gave in the recording. Each request waits at most wait for its
+// response, and the server gets wait to settle after the last message. If output is set, the replayed session is
+// recorded to it. Settings of the recording that would reach the network or write to paths of the recorded machine
+// aren't replayed, and the CLI and the data of the replay are kept in a temporary directory that is removed
+// afterwards. Recordings without an initialize request can't be replayed.
+func Replay(c *config.Config, messages []rpctrace.Message, output string, wait time.Duration) error {
+	incoming := replayMessages(messages, rpctrace.Incoming)
+	if !slices.ContainsFunc(incoming, func(message replayMessage) bool { return message.Method == "initialize" }) {
+		return errors.New("the recording has no initialize request, it must be recorded from the start of the session")
+	}
+
+	restoreDataHome, err := useTemporaryDataHome(c)
+	if err != nil {
+		return err
+	}
+	defer restoreDataHome()
+
+	clientCh, serverCh := channel.Direct()
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		serve(c, di.ReplayInit, serverCh, rpctrace.NewFileRecorder(output))
+	}()
+
+	client := newReplayClient(clientCh, clientResponses(messages))
+	go client.receive()
+
+	for _, message := range incoming {
+		if !message.isRequest() && !message.isNotification() {
+			continue
+		}
+		if err = client.send(message, wait); err != nil {
+			break
+		}
+	}
+
+	select {
+	case <-stopped:
+	case <-time.After(wait):
+	}
+	_ = clientCh.Close()
+	<-stopped
+	return err
+}
+
+// useTemporaryDataHome points the data directory, which contains the CLI, the pinned CLI versions, the scan durations
+// and the wrapper shims, and the CLI path to a temporary directory. The fake CLI and the files of the replay would
+// otherwise replace the CLI and the data of the machine. The returned function removes the directory and restores the
+// data directory and the CLI path.
+func useTemporaryDataHome(c *config.Config) (func(), error) {
+	dataHome, err := os.MkdirTemp("", "vulnmap-ls-replay")
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create the data directory of the replay: %w", err)
+	}
+	previousDataHome, previousCliPath := xdg.DataHome, c.CliSettings().Path()
+	xdg.DataHome = dataHome
+	c.CliSettings().SetPath(filepath.Join(c.CliSettings().DefaultBinaryInstallPath(), (&install.Discovery{}).ExecutableName(false)))
+	return func() {
+		xdg.DataHome = previousDataHome
+		c.CliSettings().SetPath(previousCliPath)
+		if err := os.RemoveAll(dataHome); err != nil {
+			c.Logger().Warn().Err(err).Str("method", "useTemporaryDataHome").Msgf("couldn't remove %s", dataHome)
+		}
+	}, nil
+}
+
+// replayMessages parses the messages of the given direction, batches are flattened
+func replayMessages(messages []rpctrace.Message, direction rpctrace.Direction) []replayMessage {
+	var parsed []replayMessage
+	for _, message := range messages {
+		if message.Direction != direction {
+			continue
+		}
+		data := bytes.TrimSpace(message.Message)
+		if len(data) > 0 && data[0] == '[' {
+			var batch []replayMessage
+			if json.Unmarshal(data, &batch) == nil {
+				parsed = append(parsed, batch...)
+			}
+			continue
+		}
+		var single replayMessage
+		if json.Unmarshal(data, &single) == nil {
+			parsed = append(parsed, single)
+		}
+	}
+	return parsed
+}
+
+// clientResponses returns the recorded responses of the client to requests of the server, in order by method
+func clientResponses(messages []rpctrace.Message) map[string][]replayMessage {
+	methods := map[string]string{}
+	for _, message := range replayMessages(messages, rpctrace.Outgoing) {
+		if message.isRequest() {
+			methods[string(message.ID)] = message.Method
+		}
+	}
+	responses := map[string][]replayMessage{}
+	for _, message := range replayMessages(messages, rpctrace.Incoming) {
+		if method, ok := methods[string(message.ID)]; ok && message.isResponse() {
+			responses[method] = append(responses[method], message)
+		}
+	}
+	return responses
+}
+
+// replayClient plays the client side of a recording
+type replayClient struct {
+	ch        channel.Channel
+	sendMutex sync.Mutex
+	mutex     sync.Mutex
+	// responses are the recorded responses that weren't used yet, by method of the server request
+	responses map[string][]replayMessage
+	// pending are the requests of the client that wait for a response, by id
+	pending map[string]chan struct{}
+}
+
+func newReplayClient(ch channel.Channel, responses map[string][]replayMessage) *replayClient {
+	return &replayClient{ch: ch, responses: responses, pending: map[string]chan struct{}{}}
+}
+
+// send sends the message to the server and waits for the response if it is a request
+func (r *replayClient) send(message replayMessage, wait time.Duration) error {
+	switch message.Method {
+	case "initialize":
+		message.Params = withField(withoutProcessId(message.Params), "initializationOptions", withoutMachineSettings)
+	case "workspace/didChangeConfiguration":
+		message.Params = withField(message.Params, "settings", withoutMachineSettings)
+	}
+	message.JsonRPC = "2.0"
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	var done chan struct{}
+	if message.isRequest() {
+		done = make(chan struct{})
+		r.mutex.Lock()
+		r.pending[string(message.ID)] = done
+		r.mutex.Unlock()
+	}
+	if err = r.write(data); err != nil {
+		return fmt.Errorf("couldn't send %s: %w", message.Method, err)
+	}
+	if done == nil {
+		return nil
+	}
+
+	select {
+	case <-done:
+	case <-time.After(wait):
+		c := config.CurrentConfig()
+		c.Logger().Warn().Str("method", "replayClient.send").Msgf("no response to %s (%s)", message.Method, message.ID)
+	}
+	return nil
+}
+
+// receive handles the messages of the server until the channel is closed
+func (r *replayClient) receive() {
+	for {
+		data, err := r.ch.Recv()
+		if err != nil {
+			return
+		}
+		var messages []replayMessage
+		if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+			_ = json.Unmarshal(data, &messages)
+		} else {
+			var message replayMessage
+			if json.Unmarshal(data, &message) == nil {
+				messages = append(messages, message)
+			}
+		}
+		for _, message := range messages {
+			switch {
+			case message.isRequest():
+				r.respond(message)
+			case message.isResponse():
+				r.mutex.Lock()
+				if done, ok := r.pending[string(message.ID)]; ok {
+					close(done)
+					delete(r.pending, string(message.ID))
+				}
+				r.mutex.Unlock()
+			}
+		}
+	}
+}
+
+// respond answers a request of the server with the next recorded response to the same method, or with null
+func (r *replayClient) respond(request replayMessage) {
+	response := replayMessage{Result: json.RawMessage("null")}
+	r.mutex.Lock()
+	if recorded := r.responses[request.Method]; len(recorded) > 0 {
+		response, r.responses[request.Method] = recorded[0], recorded[1:]
+	}
+	r.mutex.Unlock()
+
+	response.JsonRPC, response.ID = "2.0", request.ID
+	if request.Method == "workspace/configuration" {
+		response.Result = withEachElement(response.Result, withoutMachineSettings)
+	}
+	if len(response.Result) == 0 && len(response.Error) == 0 {
+		response.Result = json.RawMessage("null")
+	}
+	data, err := json.Marshal(response)
+	if err == nil {
+		err = r.write(data)
+	}
+	if err != nil {
+		config.CurrentConfig().Logger().Debug().Err(err).Str("method", "replayClient.respond").Msg("couldn't respond")
+	}
+}
+
+func (r *replayClient) write(data []byte) error {
+	r.sendMutex.Lock()
+	defer r.sendMutex.Unlock()
+	return r.ch.Send(data)
+}
+
+// withoutProcessId removes the process id of the recorded client, so the server doesn't stop because it isn't running
+func withoutProcessId(params json.RawMessage) json.RawMessage {
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(params, &fields) != nil {
+		return params
+	}
+	delete(fields, "processId")
+	stripped, err := json.Marshal(fields)
+	if err != nil {
+		return params
+	}
+	return stripped
+}
+
+// withoutMachineSettings removes the machine settings from the settings object
+func withoutMachineSettings(settings json.RawMessage) json.RawMessage {
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(settings, &fields) != nil {
+		return settings
+	}
+	for _, key := range machineSettings {
+		delete(fields, key)
+	}
+	stripped, err := json.Marshal(fields)
+	if err != nil {
+		return settings
+	}
+	return stripped
+}
+
+// withField applies change to the given field of the object, if it exists
+func withField(object json.RawMessage, field string, change func(json.RawMessage) json.RawMessage) json.RawMessage {
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(object, &fields) != nil {
+		return object
+	}
+	value, ok := fields[field]
+	if !ok {
+		return object
+	}
+	fields[field] = change(value)
+	changed, err := json.Marshal(fields)
+	if err != nil {
+		return object
+	}
+	return changed
+}
+
+// withEachElement applies change to each element of the array
+func withEachElement(array json.RawMessage, change func(json.RawMessage) json.RawMessage) json.RawMessage {
+	var elements []json.RawMessage
+	if json.Unmarshal(array, &elements) != nil {
+		return array
+	}
+	for i, element := range elements {
+		elements[i] = change(element)
+	}
+	changed, err := json.Marshal(elements)
+	if err != nil {
+		return array
+	}
+	return changed
+}
@@ -0,0 +1,162 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/adrg/xdg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/rpctrace"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/testutil"
+)
+
+func recorded(direction rpctrace.Direction, message string) rpctrace.Message {
+	return rpctrace.Message{Direction: direction, Message: json.RawMessage(message)}
+}
+
+func Test_Replay_AnswersRequestsOfRecordingAndRecordsSession(t *testing.T) {
+	c := testutil.UnitTest(t)
+	t.Cleanup(cleanupChannels)
+	output := filepath.Join(t.TempDir(), "replay.jsonl")
+	messages := []rpctrace.Message{
+		recorded(rpctrace.Incoming, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"processId":123456789}}`),
+		recorded(rpctrace.Outgoing, `{"jsonrpc":"2.0","id":1,"result":{}}`),
+		recorded(rpctrace.Incoming, `{"jsonrpc":"2.0","method":"initialized","params":{}}`),
+		recorded(rpctrace.Incoming, `{"jsonrpc":"2.0","id":2,"method":"shutdown"}`),
+		recorded(rpctrace.Incoming, `{"jsonrpc":"2.0","method":"exit"}`),
+	}
+
+	err := Replay(c, messages, output, 2*time.Second)
+
+	require.NoError(t, err)
+	assert.True(t, c.IsOfflineMode())
+	replayed, err := rpctrace.Read(output)
+	require.NoError(t, err)
+	var methods []string
+	var initializeResponded bool
+	for _, message := range replayMessages(replayed, rpctrace.Incoming) {
+		methods = append(methods, message.Method)
+		if message.Method == "initialize" {
+			assert.NotContains(t, string(message.Params), "processId")
+		}
+	}
+	for _, message := range replayMessages(replayed, rpctrace.Outgoing) {
+		if message.isResponse() && string(message.ID) == "1" {
+			initializeResponded = true
+		}
+	}
+	assert.Equal(t, []string{"initialize", "initialized", "shutdown", "exit"}, methods)
+	assert.True(t, initializeResponded)
+}
+
+func Test_Replay_KeepsOfflineModeAndLocalSettings(t *testing.T) {
+	c := testutil.UnitTest(t)
+	t.Cleanup(cleanupChannels)
+	endpoint := c.VulnmapApi()
+	messages := []rpctrace.Message{
+		recorded(rpctrace.Incoming, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"initializationOptions":`+
+			`{"offlineMode":"false","endpoint":"https://api.example.com","auditLogPath":"/recorded/audit.jsonl"}}}`),
+		recorded(rpctrace.Incoming, `{"jsonrpc":"2.0","method":"initialized","params":{}}`),
+		recorded(rpctrace.Incoming, `{"jsonrpc":"2.0","method":"workspace/didChangeConfiguration","params":`+
+			`{"settings":{"offlineMode":"false","metricsEndpoint":"localhost:9464","cliPath":"/recorded/cli"}}}`),
+		recorded(rpctrace.Incoming, `{"jsonrpc":"2.0","id":2,"method":"shutdown"}`),
+	}
+
+	err := Replay(c, messages, "", time.Second)
+
+	require.NoError(t, err)
+	assert.True(t, c.IsOfflineMode())
+	assert.Equal(t, endpoint, c.VulnmapApi())
+	assert.Empty(t, c.AuditLogPath())
+	assert.Empty(t, c.MetricsEndpoint())
+	assert.NotEqual(t, "/recorded/cli", c.CliSettings().Path())
+}
+
+func Test_Replay_KeepsCliAndDataOfMachine(t *testing.T) {
+	c := testutil.UnitTest(t)
+	t.Cleanup(cleanupChannels)
+	machineDataHome := t.TempDir()
+	dataHome := xdg.DataHome
+	xdg.DataHome = machineDataHome
+	t.Cleanup(func() { xdg.DataHome = dataHome })
+	c.SetManageBinariesAutomatically(true)
+	c.CliSettings().SetPath("")
+	machineCliPath := c.CliSettings().Path()
+	messages := []rpctrace.Message{
+		recorded(rpctrace.Incoming, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`),
+		recorded(rpctrace.Incoming, `{"jsonrpc":"2.0","method":"initialized","params":{}}`),
+		recorded(rpctrace.Incoming, `{"jsonrpc":"2.0","id":2,"method":"shutdown"}`),
+	}
+
+	err := Replay(c, messages, "", time.Second)
+
+	require.NoError(t, err)
+	assert.Equal(t, machineDataHome, xdg.DataHome)
+	assert.Equal(t, machineCliPath, c.CliSettings().Path())
+	entries, err := os.ReadDir(filepath.Dir(machineCliPath))
+	require.NoError(t, err)
+	assert.Empty(t, entries, "the fake CLI and the data of the replay must not be written to the data directory")
+}
+
+func Test_Replay_WithoutInitialize_Fails(t *testing.T) {
+	c := testutil.UnitTest(t)
+	messages := []rpctrace.Message{
+		recorded(rpctrace.Incoming, `{"jsonrpc":"2.0","id":2,"method":"textDocument/hover","params":{}}`),
+	}
+
+	err := Replay(c, messages, "", time.Second)
+
+	assert.ErrorContains(t, err, "no initialize request")
+}
+
+func Test_withoutMachineSettings(t *testing.T) {
+	params := withField(json.RawMessage(`{"settings":{"offlineMode":"false","endpoint":"https://a","organization":"org"}}`),
+		"settings", withoutMachineSettings)
+
+	assert.JSONEq(t, `{"settings":{"organization":"org"}}`, string(params))
+	assert.JSONEq(t, `[{"organization":"org"}]`,
+		string(withEachElement(json.RawMessage(`[{"organization":"org","cliPath":"/a"}]`), withoutMachineSettings)))
+}
+
+func Test_clientResponses_ReturnsRecordedResponsesByMethod(t *testing.T) {
+	messages := []rpctrace.Message{
+		recorded(rpctrace.Outgoing, `{"jsonrpc":"2.0","id":7,"method":"workspace/configuration","params":{}}`),
+		recorded(rpctrace.Outgoing, `{"jsonrpc":"2.0","id":8,"method":"window/showMessageRequest","params":{}}`),
+		recorded(rpctrace.Incoming, `{"jsonrpc":"2.0","id":8,"result":{"title":"Trust"}}`),
+		recorded(rpctrace.Incoming, `{"jsonrpc":"2.0","id":7,"result":[{}]}`),
+		recorded(rpctrace.Incoming, `{"jsonrpc":"2.0","id":9,"method":"shutdown"}`),
+	}
+
+	responses := clientResponses(messages)
+
+	assert.Len(t, responses, 2)
+	assert.JSONEq(t, `{"title":"Trust"}`, string(responses["window/showMessageRequest"][0].Result))
+	assert.JSONEq(t, `[{}]`, string(responses["workspace/configuration"][0].Result))
+}
+
+func Test_withoutProcessId(t *testing.T) {
+	params := withoutProcessId(json.RawMessage(`{"processId":42,"rootUri":"file:///a"}`))
+
+	assert.JSONEq(t, `{"rootUri":"file:///a"}`, string(params))
+}
@@ -35,14 +35,18 @@ import (
 	"github.com/khulnasoft-lab/vulnmap-ls/application/codeaction"
 	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
 	"github.com/khulnasoft-lab/vulnmap-ls/application/di"
+	"github.com/khulnasoft-lab/vulnmap-ls/application/server/notification"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/ide/codelens"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/ide/command"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/ide/converter"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/ide/hover"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/ide/workspace"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/metrics"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/performance"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/vulnmap"
 	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/cli"
 	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/learn"
+	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/rpctrace"
 	"github.com/khulnasoft-lab/vulnmap-ls/internal/lsp"
 	"github.com/khulnasoft-lab/vulnmap-ls/internal/progress"
 	"github.com/khulnasoft-lab/vulnmap-ls/internal/uri"
@@ -50,6 +54,14 @@ import (
 )
 
 func Start(c *config.Config) {
+	serve(c, func() {
+		di.Init()
+		di.StartBackgroundJobs()
+	}, channel.Header("")(os.Stdin, os.Stdout), rpctrace.NewRecorder())
+}
+
+// serve runs the server on the channel until it is stopped. All messages are passed to the recorder.
+func serve(c *config.Config, initDependencies func(), ch channel.Channel, recorder *rpctrace.Recorder) {
 	var srv *jrpc2.Server
 
 	handlers := handler.Map{}
@@ -60,11 +72,11 @@ func Start(c *config.Config) {
 
 	c.ConfigureLogging(srv)
 	logger := c.Logger().With().Str("method", "server.Start").Logger()
-	di.Init()
+	initDependencies()
 	initHandlers(c, srv, handlers)
 
 	logger.Info().Msg("Starting up...")
-	srv = srv.Start(channel.Header("")(os.Stdin, os.Stdout))
+	srv = srv.Start(rpctrace.Channel(ch, recorder))
 
 	status := srv.WaitStatus()
 	if status.Err != nil {
@@ -95,9 +107,12 @@ func initHandlers(c *config.Config, srv *jrpc2.Server, handlers handler.Map) {
 	handlers["exit"] = exit(srv, c)
 	handlers["workspace/didChangeWorkspaceFolders"] = workspaceDidChangeWorkspaceFoldersHandler(srv)
 	handlers["workspace/willDeleteFiles"] = workspaceWillDeleteFilesHandler()
+	handlers["workspace/didRenameFiles"] = workspaceDidRenameFilesHandler()
 	handlers["workspace/didChangeConfiguration"] = workspaceDidChangeConfiguration(srv)
 	handlers["window/workDoneProgress/cancel"] = windowWorkDoneProgressCancelHandler()
 	handlers["workspace/executeCommand"] = executeCommandHandler(srv)
+	handlers["vulnmap/issues"] = issuesHandler()
+	handlers["$/setTrace"] = setTraceHandler()
 }
 
 func textDocumentDidChangeHandler() jrpc2.Handler {
@@ -136,6 +151,46 @@ func workspaceWillDeleteFilesHandler() jrpc2.Handler {
 	})
 }
 
+// workspaceDidRenameFilesHandler handles the workspace/didRenameFiles message that's raised by the client
+// when files or folders are renamed or moved
+func workspaceDidRenameFilesHandler() jrpc2.Handler {
+	return handler.New(func(ctx context.Context, params lsp.RenameFilesParams) (any, error) {
+		logger := log.With().Str("method", "WorkspaceDidRenameFilesHandler").Logger()
+		logger.Info().Msg("RECEIVING")
+		defer logger.Info().Msg("SENDING")
+
+		ws := workspace.Get()
+		for _, file := range params.Files {
+			oldPath, newPath := uri.PathFromUri(file.OldUri), uri.PathFromUri(file.NewUri)
+			ws.RenameFile(oldPath, newPath)
+			di.CodeActionService().MoveCodeActions(oldPath, newPath)
+		}
+		return nil, nil
+	})
+}
+
+// issuesHandler answers the vulnmap/issues request by querying the cached issues of the workspace
+func issuesHandler() jrpc2.Handler {
+	return handler.New(func(ctx context.Context, params lsp.IssuesParams) (lsp.IssuesResult, error) {
+		logger := log.With().Str("method", "IssuesHandler").Logger()
+		logger.Debug().Interface("params", params).Msg("RECEIVING")
+
+		page, err := workspace.Get().QueryIssues(params)
+		if err != nil {
+			logger.Err(err).Msg("couldn't query issues")
+			return lsp.IssuesResult{}, err
+		}
+
+		result := lsp.IssuesResult{
+			Issues:     notification.ToScanIssues(page.Issues),
+			Total:      page.Total,
+			NextCursor: page.NextCursor,
+		}
+		logger.Debug().Int("issueCount", len(result.Issues)).Msg("SENDING")
+		return result, nil
+	})
+}
+
 func codeLensHandler() jrpc2.Handler {
 	return handler.New(func(ctx context.Context, params sglsp.CodeLensParams) ([]sglsp.CodeLens, error) {
 		log.Info().Str("method", "CodeLensHandler").Msg("RECEIVING")
@@ -244,6 +299,15 @@ func initializeHandler(srv *jrpc2.Server, c *config.Config) handler.Func {
 								},
 							},
 						},
+						DidRename: lsp.FileOperationRegistrationOptions{
+							Filters: []lsp.FileOperationFilter{
+								{
+									Pattern: lsp.FileOperationPattern{
+										Glob: "**",
+									},
+								},
+							},
+						},
 					},
 				},
 				HoverProvider:       true,
@@ -264,6 +328,9 @@ func initializeHandler(srv *jrpc2.Server, c *config.Config) handler.Func {
 						vulnmap.GetLearnLesson,
 						vulnmap.GetSettingsSastEnabled,
 						vulnmap.GetActiveUserCommand,
+						vulnmap.GetToolchainInfoCommand,
+						vulnmap.ExportSupportBundleCommand,
+						vulnmap.SetOrganizationCommand,
 						vulnmap.CodeFixCommand,
 						vulnmap.CodeSubmitFixFeedback,
 					},
@@ -415,6 +482,12 @@ func exit(srv *jrpc2.Server, c *config.Config) jrpc2.Handler {
 		logger.Info().Msg("ENTERING")
 		logger.Info().Msg("Flushing error reporting...")
 		di.ErrorReporter().FlushErrorReporting()
+		di.Stop()
+		if flusher, ok := di.Instrumentor().(performance.Flusher); ok {
+			logger.Info().Msg("Flushing traces...")
+			flusher.Flush()
+		}
+		metrics.DefaultEndpoint().Stop()
 		logger.Info().Msg("Stopping server...")
 		srv.Stop()
 		return nil, nil
@@ -493,7 +566,8 @@ func textDocumentHover() jrpc2.Handler {
 		log.Info().Str("method", "TextDocumentHover").Interface("params", params).Msg("RECEIVING")
 
 		path := uri.PathFromUri(params.TextDocument.URI)
-		hoverResult := di.HoverService().GetHover(path, converter.FromPosition(params.Position))
+		format := hover.FormatFromCapabilities(config.CurrentConfig().ClientCapabilities())
+		hoverResult := di.HoverService().GetHover(path, converter.FromPosition(params.Position), format)
 		return hoverResult, nil
 	})
 }
@@ -522,6 +596,14 @@ func textDocumentCodeActionHandler(c *config.Config) handler.Func {
 	return handler.New(codeaction.GetCodeActionHandler(c, di.CodeActionService()))
 }
 
+// setTraceHandler only logs the new trace level, the recording channel applies it before the message is handled
+func setTraceHandler() jrpc2.Handler {
+	return handler.New(func(_ context.Context, params lsp.SetTraceParams) (any, error) {
+		log.Info().Str("method", "setTraceHandler").Msgf("trace level set to %s", params.Value)
+		return nil, nil
+	})
+}
+
 func noOpHandler() jrpc2.Handler {
 	return handler.New(func(_ context.Context, params sglsp.DidCloseTextDocumentParams) (any, error) {
 		log.Info().Str("method", "NoOpHandler").Interface("params", params).Msg("RECEIVING")
@@ -534,6 +616,9 @@ type RPCLogger struct {
 }
 
 func (r RPCLogger) LogRequest(_ context.Context, req *jrpc2.Request) {
+	if !req.IsNotification() {
+		performance.RpcTimings().Start(req.ID(), req.Method())
+	}
 	r.c.Logger().Debug().Msgf("Incoming JSON-RPC request. Method=%s. ID=%s. Is notification=%v.",
 		req.Method(),
 		req.ID(),
@@ -542,6 +627,12 @@ func (r RPCLogger) LogRequest(_ context.Context, req *jrpc2.Request) {
 
 func (r RPCLogger) LogResponse(_ context.Context, rsp *jrpc2.Response) {
 	logger := r.c.Logger()
+	if call, ok := performance.RpcTimings().Finish(rsp.ID(), rsp.Error() != nil); ok {
+		metrics.RpcDuration.Observe(float64(call.DurationMs)/1000, call.Method)
+		if call.Error {
+			metrics.RpcErrors.Inc(call.Method)
+		}
+	}
 	if rsp.Error() != nil {
 		logger.Err(rsp.Error()).Interface("rsp", *rsp).Msg("Outgoing JSON-RPC response error")
 	}
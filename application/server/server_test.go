@@ -296,6 +296,20 @@ func Test_initialize_shouldSupportDocumentSaving(t *testing.T) {
 	assert.Equal(t, result.Capabilities.TextDocumentSync.Options.WillSaveWaitUntil, true)
 }
 
+func Test_initialize_shouldRegisterForRenamedFiles(t *testing.T) {
+	loc := setupServer(t)
+
+	rsp, err := loc.Client.Call(ctx, "initialize", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result lsp.InitializeResult
+	if err := rsp.UnmarshalResult(&result); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "**", result.Capabilities.Workspace.FileOperations.DidRename.Filters[0].Pattern.Glob)
+}
+
 func Test_initialize_shouldSupportCodeLenses(t *testing.T) {
 	loc := setupServer(t)
 
@@ -1119,7 +1133,7 @@ func Test_IntegrationHoverResults(t *testing.T) {
 
 	assert.Equal(t,
 		hoverResult.Contents.Value,
-		di.HoverService().GetHover(testPath, converter.FromPosition(testPosition)).Contents.Value)
+		di.HoverService().GetHover(testPath, converter.FromPosition(testPosition), hover.Markdown).Contents.Value)
 	assert.Equal(t, hoverResult.Contents.Kind, "markdown")
 }
 func Test_SmokeVulnmapCodeFileScan(t *testing.T) {
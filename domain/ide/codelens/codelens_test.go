@@ -25,6 +25,7 @@ import (
 	"github.com/khulnasoft-lab/vulnmap-ls/application/di"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/ide/converter"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/ide/workspace"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/audit"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/performance"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/vulnmap"
 	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/code"
@@ -56,7 +57,7 @@ func Test_GetCodeLensForPath(t *testing.T) {
 
 	filePath, dir := code.TempWorkdirWithVulnerabilities(t)
 	folder := workspace.NewFolder(dir, "dummy", di.Scanner(), di.HoverService(), di.ScanNotifier(), di.Notifier())
-	workspace.Set(workspace.New(performance.NewInstrumentor(), di.Scanner(), di.HoverService(), di.ScanNotifier(), di.Notifier()))
+	workspace.Set(workspace.New(performance.NewInstrumentor(), di.Scanner(), di.HoverService(), di.ScanNotifier(), di.Notifier(), audit.NewTestTrail()))
 	workspace.Get().AddFolder(folder)
 	folder.ScanFile(context.Background(), filePath)
 
@@ -19,7 +19,6 @@ package command
 import (
 	"context"
 	"errors"
-	"time"
 
 	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
@@ -28,14 +27,17 @@ import (
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/ide"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/ide/converter"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/ide/notification"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/audit"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/vulnmap"
 	"github.com/khulnasoft-lab/vulnmap-ls/internal/lsp"
 )
 
 type fixCodeIssue struct {
 	command       vulnmap.CommandData
+	srv           lsp.Server
 	issueProvider ide.IssueProvider
 	notifier      notification.Notifier
+	auditTrail    audit.Trail
 }
 
 func (cmd *fixCodeIssue) Command() vulnmap.CommandData {
@@ -70,16 +72,22 @@ func (cmd *fixCodeIssue) Execute(ctx context.Context) (any, error) {
 				return nil, nil
 			}
 
-			cmd.notifier.Send(lsp.ApplyWorkspaceEditParams{
+			applied, err := cmd.applyEdit(ctx, lsp.ApplyWorkspaceEditParams{
 				Label: "Vulnmap Code fix",
 				Edit:  converter.ToWorkspaceEdit(edit),
 			})
+			if err != nil || !applied {
+				return nil, err
+			}
+			cmd.auditTrail.FixApplied(audit.FixProperties{
+				Kind:     audit.Autofix,
+				IssueId:  issues[i].ID,
+				FilePath: issuePath,
+				FixId:    codeActionId.String(),
+			})
 
-			// reset codelenses
+			// reset codelenses and refresh them to hide the stale codelens for the fixed issue
 			issues[i].CodelensCommands = nil
-
-			// Give client some time to apply edit, then refresh code lenses to hide stale codelens for the fixed issue
-			time.Sleep(1 * time.Second)
 			cmd.notifier.Send(lsp.CodeLensRefresh{})
 			return nil, nil
 		}
@@ -88,6 +96,27 @@ func (cmd *fixCodeIssue) Execute(ctx context.Context) (any, error) {
 	return nil, errors.New("Failed to find autofix code action.")
 }
 
+// applyEdit asks the client to apply the edit and returns whether it was applied
+func (cmd *fixCodeIssue) applyEdit(ctx context.Context, params lsp.ApplyWorkspaceEditParams) (bool, error) {
+	method := "fixCodeIssue.applyEdit"
+	response, err := cmd.srv.Callback(ctx, "workspace/applyEdit", params)
+	if err != nil {
+		log.Err(err).Str("method", method).Msg("error while sending workspace/applyEdit request")
+		return false, err
+	}
+	if response == nil {
+		return false, nil
+	}
+
+	var result lsp.ApplyWorkspaceEditResult
+	if err = response.UnmarshalResult(&result); err != nil {
+		log.Err(err).Str("method", method).Msg("error while unmarshalling workspace/applyEdit result response")
+		return false, err
+	}
+	log.Info().Str("method", method).Msgf("Workspace edit applied %t. %s", result.Applied, result.FailureReason)
+	return result.Applied, nil
+}
+
 type RangeDto = map[string]interface{}
 type RangePositionDto = map[string]interface{}
 
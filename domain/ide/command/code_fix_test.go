@@ -20,12 +20,16 @@ import (
 	"context"
 	"testing"
 
+	"github.com/creachadair/jrpc2"
+	"github.com/creachadair/jrpc2/channel"
+	"github.com/creachadair/jrpc2/handler"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
 	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/ide/converter"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/audit"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/vulnmap"
 	"github.com/khulnasoft-lab/vulnmap-ls/internal/lsp"
 	"github.com/khulnasoft-lab/vulnmap-ls/internal/notification"
@@ -55,20 +59,55 @@ func (m *issueProviderMock) IssuesFor(path string, r vulnmap.Range) []vulnmap.Is
 	return args.Get(0).([]vulnmap.Issue)
 }
 
+// applyEditClient answers workspace/applyEdit requests like an IDE that applies or rejects the edits
+type applyEditClient struct {
+	client  *jrpc2.Client
+	applied bool
+	edits   []lsp.ApplyWorkspaceEditParams
+}
+
+func newApplyEditClient(t *testing.T, applied bool) *applyEditClient {
+	t.Helper()
+	c := &applyEditClient{applied: applied}
+	clientChannel, serverChannel := channel.Direct()
+	ide := jrpc2.NewServer(handler.Map{
+		"workspace/applyEdit": handler.New(func(_ context.Context, params lsp.ApplyWorkspaceEditParams) (lsp.ApplyWorkspaceEditResult, error) {
+			c.edits = append(c.edits, params)
+			return lsp.ApplyWorkspaceEditResult{Applied: c.applied}, nil
+		}),
+	}, nil).Start(serverChannel)
+	c.client = jrpc2.NewClient(clientChannel, nil)
+	t.Cleanup(func() {
+		_ = c.client.Close()
+		ide.Stop()
+	})
+	return c
+}
+
+func (c *applyEditClient) Notify(_ context.Context, _ string, _ any) error {
+	return nil
+}
+
+func (c *applyEditClient) Callback(ctx context.Context, method string, params any) (*jrpc2.Response, error) {
+	return c.client.Call(ctx, method, params)
+}
+
 func setupClientCapability(config *config.Config) {
 	clientCapabilties := config.ClientCapabilities()
 	clientCapabilties.Workspace.ApplyEdit = true
 	config.SetClientCapabilities(clientCapabilties)
 }
 
-func setupCommand(mockNotifier *notification.MockNotifier) *fixCodeIssue {
+func setupCommand(mockNotifier *notification.MockNotifier, srv lsp.Server) *fixCodeIssue {
 	cmdData := vulnmap.CommandData{
 		CommandId: vulnmap.CodeFixCommand,
 		Arguments: sampleArgs,
 	}
 	cmd := &fixCodeIssue{
-		command:  cmdData,
-		notifier: mockNotifier,
+		command:    cmdData,
+		srv:        srv,
+		notifier:   mockNotifier,
+		auditTrail: audit.NewTestTrail(),
 	}
 	return cmd
 }
@@ -127,7 +166,8 @@ func Test_fixCodeIssue_sendsSuccessfulEdit(t *testing.T) {
 	setupClientCapability(config)
 
 	mockNotifier := notification.NewMockNotifier()
-	cmd := setupCommand(mockNotifier)
+	ide := newApplyEditClient(t, true)
+	cmd := setupCommand(mockNotifier, ide)
 
 	filePath := sampleArgs[1]
 	issueRange := cmd.toRange(sampleArgs[2])
@@ -152,7 +192,44 @@ func Test_fixCodeIssue_sendsSuccessfulEdit(t *testing.T) {
 
 	// Verify workspace edit is sent to the client
 	workspaceEdit := converter.ToWorkspaceEdit(mockEdit)
-	assert.Equal(t, []any{lsp.ApplyWorkspaceEditParams{Label: "Vulnmap Code fix", Edit: workspaceEdit}, lsp.CodeLensRefresh{}}, mockNotifier.SentMessages())
+	assert.Equal(t, []lsp.ApplyWorkspaceEditParams{{Label: "Vulnmap Code fix", Edit: workspaceEdit}}, ide.edits)
+	assert.Equal(t, []any{lsp.CodeLensRefresh{}}, mockNotifier.SentMessages())
+
+	// Verify the applied fix is recorded in the audit trail
+	assert.Equal(t, []any{audit.FixProperties{
+		Kind:     audit.Autofix,
+		IssueId:  issues[0].ID,
+		FilePath: filePath.(string),
+		FixId:    codeActionId.String(),
+	}}, cmd.auditTrail.(*audit.TestTrail).Events())
+}
+
+func Test_fixCodeIssue_rejectedEditIsNotAudited(t *testing.T) {
+	config := testutil.UnitTest(t)
+	setupClientCapability(config)
+	mockNotifier := notification.NewMockNotifier()
+	ide := newApplyEditClient(t, false)
+	cmd := setupCommand(mockNotifier, ide)
+	filePath := sampleArgs[1]
+	issueRange := cmd.toRange(sampleArgs[2])
+	_, deferredMockEdit := setupMockEdit()
+	codeAction := vulnmap.CodeAction{
+		Uuid:         &codeActionId,
+		DeferredEdit: &deferredMockEdit,
+	}
+	issues := setupSampleIssues(issueRange, codeAction, cmd.command)
+	issueProviderMock := new(issueProviderMock)
+	issueProviderMock.On("IssuesFor", filePath, issueRange).Return(issues)
+	cmd.issueProvider = issueProviderMock
+
+	res, err := cmd.Execute(context.Background())
+
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+	assert.Len(t, ide.edits, 1)
+	assert.NotNil(t, issues[0].CodelensCommands)
+	assert.Empty(t, mockNotifier.SentMessages())
+	assert.Empty(t, cmd.auditTrail.(*audit.TestTrail).Events())
 }
 
 func Test_fixCodeIssue_noEdit(t *testing.T) {
@@ -161,7 +238,7 @@ func Test_fixCodeIssue_noEdit(t *testing.T) {
 	setupClientCapability(config)
 
 	mockNotifier := notification.NewMockNotifier()
-	cmd := setupCommand(mockNotifier)
+	cmd := setupCommand(mockNotifier, newApplyEditClient(t, true))
 
 	filePath := sampleArgs[1]
 	issueRange := cmd.toRange(sampleArgs[2])
@@ -189,6 +266,7 @@ func Test_fixCodeIssue_noEdit(t *testing.T) {
 	var sentMessages []any
 	// Verify no workspace edit is sent to the client
 	assert.Equal(t, sentMessages, mockNotifier.SentMessages())
+	assert.Empty(t, cmd.auditTrail.(*audit.TestTrail).Events())
 }
 
 func Test_fixCodeIssue_NoIssueFound(t *testing.T) {
@@ -197,7 +275,7 @@ func Test_fixCodeIssue_NoIssueFound(t *testing.T) {
 	setupClientCapability(config)
 
 	mockNotifier := notification.NewMockNotifier()
-	cmd := setupCommand(mockNotifier)
+	cmd := setupCommand(mockNotifier, newApplyEditClient(t, true))
 
 	filePath := sampleArgs[1]
 	issueRange := cmd.toRange(sampleArgs[2])
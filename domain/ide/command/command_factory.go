@@ -22,8 +22,10 @@ import (
 	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/ide"
 	noti "github.com/khulnasoft-lab/vulnmap-ls/domain/ide/notification"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/audit"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/vulnmap"
 	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/learn"
+	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/network"
 	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/vulnmap_api"
 	"github.com/khulnasoft-lab/vulnmap-ls/internal/lsp"
 )
@@ -36,6 +38,7 @@ func CreateFromCommandData(
 	notifier noti.Notifier,
 	issueProvider ide.IssueProvider,
 	codeApiClient VulnmapCodeHttpClient,
+	auditTrail audit.Trail,
 ) (vulnmap.Command, error) {
 
 	switch commandData.CommandId {
@@ -60,14 +63,20 @@ func CreateFromCommandData(
 	case vulnmap.OpenLearnLesson:
 		return &openLearnLesson{command: commandData, srv: srv, learnService: learnService}, nil
 	case vulnmap.GetSettingsSastEnabled:
-		apiClient := vulnmap_api.NewVulnmapApiClient(config.CurrentConfig().Engine().GetNetworkAccess().GetHttpClient)
+		apiClient := vulnmap_api.NewVulnmapApiClient(network.GuardClientFactory(config.CurrentConfig().Engine().GetNetworkAccess().GetHttpClient))
 		return &sastEnabled{command: commandData, apiClient: apiClient}, nil
 	case vulnmap.GetActiveUserCommand:
 		return &getActiveUser{command: commandData, authService: authService, notifier: notifier}, nil
+	case vulnmap.GetToolchainInfoCommand:
+		return &getToolchainInfo{command: commandData}, nil
+	case vulnmap.ExportSupportBundleCommand:
+		return &exportSupportBundle{command: commandData}, nil
+	case vulnmap.SetOrganizationCommand:
+		return &setOrganization{command: commandData, notifier: notifier}, nil
 	case vulnmap.ReportAnalyticsCommand:
 		return &reportAnalyticsCommand{command: commandData}, nil
 	case vulnmap.CodeFixCommand:
-		return &fixCodeIssue{command: commandData, issueProvider: issueProvider, notifier: notifier}, nil
+		return &fixCodeIssue{command: commandData, srv: srv, issueProvider: issueProvider, notifier: notifier, auditTrail: auditTrail}, nil
 	case vulnmap.CodeSubmitFixFeedback:
 		return &codeFixFeedback{command: commandData, apiClient: codeApiClient}, nil
 	}
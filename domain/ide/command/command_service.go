@@ -25,6 +25,7 @@ import (
 
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/ide"
 	noti "github.com/khulnasoft-lab/vulnmap-ls/domain/ide/notification"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/audit"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/vulnmap"
 	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/learn"
 	"github.com/khulnasoft-lab/vulnmap-ls/internal/lsp"
@@ -38,15 +39,17 @@ type serviceImpl struct {
 	learnService  learn.Service
 	issueProvider ide.IssueProvider
 	codeApiClient VulnmapCodeHttpClient
+	auditTrail    audit.Trail
 }
 
-func NewService(authService vulnmap.AuthenticationService, notifier noti.Notifier, learnService learn.Service, issueProvider ide.IssueProvider, codeApiClient VulnmapCodeHttpClient) vulnmap.CommandService {
+func NewService(authService vulnmap.AuthenticationService, notifier noti.Notifier, learnService learn.Service, issueProvider ide.IssueProvider, codeApiClient VulnmapCodeHttpClient, auditTrail audit.Trail) vulnmap.CommandService {
 	return &serviceImpl{
 		authService:   authService,
 		notifier:      notifier,
 		learnService:  learnService,
 		issueProvider: issueProvider,
 		codeApiClient: codeApiClient,
+		auditTrail:    auditTrail,
 	}
 }
 
@@ -67,7 +70,7 @@ func (service *serviceImpl) ExecuteCommandData(ctx context.Context, commandData
 		"command.serviceImpl.ExecuteCommandData",
 	).Msgf("executing command %s", commandData.CommandId)
 
-	command, err := CreateFromCommandData(commandData, server, service.authService, service.learnService, service.notifier, service.issueProvider, service.codeApiClient)
+	command, err := CreateFromCommandData(commandData, server, service.authService, service.learnService, service.notifier, service.issueProvider, service.codeApiClient, service.auditTrail)
 	if err != nil {
 		log.Error().Err(err).Str("method", "command.serviceImpl.ExecuteCommandData").Msg("failed to create command")
 		return nil, err
@@ -30,7 +30,7 @@ func Test_ExecuteCommand(t *testing.T) {
 		ExpectedAuthURL: "https://auth.url",
 	}
 	authenticationService := vulnmap.NewAuthenticationService(authProvider, nil, nil, nil)
-	service := NewService(authenticationService, nil, nil, nil, nil)
+	service := NewService(authenticationService, nil, nil, nil, nil, nil)
 	cmd := vulnmap.CommandData{
 		CommandId: vulnmap.CopyAuthLinkCommand,
 	}
@@ -0,0 +1,293 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/ide/workspace"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/performance"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/vulnmap"
+	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/cli"
+	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/errorspool"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/logging"
+)
+
+const (
+	// defaultSupportBundleLogFiles is the number of log files added to the bundle if the client doesn't pass one
+	defaultSupportBundleLogFiles = 5
+	// maxSupportBundleLogFileSize limits the part of each log file added to the bundle, newest lines are kept
+	maxSupportBundleLogFileSize = 10 * 1024 * 1024
+	cliVersionTimeout           = 10 * time.Second
+)
+
+// exportSupportBundle writes a zip with logs, the effective configuration, versions, client capabilities, JSON-RPC
+// timings, scan statistics and spooled errors, and returns its path. Everything in the bundle is redacted, so it can be
+// attached to a support ticket. The optional argument is the number of log files to add.
+type exportSupportBundle struct {
+	command vulnmap.CommandData
+}
+
+func (cmd *exportSupportBundle) Command() vulnmap.CommandData {
+	return cmd.command
+}
+
+func (cmd *exportSupportBundle) Execute(ctx context.Context) (any, error) {
+	logFileCount := defaultSupportBundleLogFiles
+	if args := cmd.command.Arguments; len(args) > 0 {
+		if count, ok := args[0].(float64); ok && count > 0 {
+			logFileCount = int(count)
+		}
+	}
+
+	// the random suffix keeps bundles exported within the same second apart
+	bundleFile, err := os.CreateTemp("", fmt.Sprintf("vulnmap-ls-support-%s-*.zip", time.Now().Format("20060102-150405")))
+	if err != nil {
+		return nil, err
+	}
+	bundlePath := bundleFile.Name()
+	_ = bundleFile.Close()
+	err = writeSupportBundle(ctx, config.CurrentConfig(), bundlePath, logFileCount)
+	if err != nil {
+		_ = os.Remove(bundlePath)
+		return nil, err
+	}
+	log.Info().Str("method", "exportSupportBundle.Execute").Msgf("wrote support bundle to %s", bundlePath)
+	return bundlePath, nil
+}
+
+func writeSupportBundle(ctx context.Context, c *config.Config, bundlePath string, logFileCount int) error {
+	file, err := os.OpenFile(bundlePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = file.Close() }()
+
+	zipWriter := zip.NewWriter(file)
+	scrubDict := c.ScrubbingDict()
+	add := func(name string, content []byte) error {
+		entry, err := zipWriter.Create(name)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(entry, logging.Redact(string(content), scrubDict))
+		return err
+	}
+	addJson := func(name string, value any) error {
+		content, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			return err
+		}
+		return add(name, content)
+	}
+
+	timings := performance.RpcTimings()
+	err = addJson("versions.json", supportVersions(ctx, c))
+	if err == nil {
+		err = addJson("configuration.json", effectiveConfiguration(c))
+	}
+	if err == nil {
+		err = addJson("client-capabilities.json", c.ClientCapabilities())
+	}
+	if err == nil {
+		err = addJson("rpc-timings.json", map[string]any{"methods": timings.Methods(), "recent": timings.Recent()})
+	}
+	if err == nil {
+		err = addJson("scan-statistics.json", scanStatistics())
+	}
+	for _, logFile := range recentLogFiles(c.LogPath(), logFileCount) {
+		if err != nil {
+			break
+		}
+		var content []byte
+		content, err = readTail(logFile, maxSupportBundleLogFileSize)
+		if err == nil {
+			err = add("logs/"+filepath.Base(logFile), content)
+		}
+	}
+	for _, errorFile := range errorspool.New(c.ErrorSpoolPath(), errorspool.DefaultMaxEntries).Files() {
+		if err != nil {
+			break
+		}
+		var content []byte
+		content, err = os.ReadFile(errorFile)
+		if err == nil {
+			err = add("errors/"+filepath.Base(errorFile), content)
+		}
+	}
+	if err != nil {
+		_ = zipWriter.Close()
+		return err
+	}
+	return zipWriter.Close()
+}
+
+func supportVersions(ctx context.Context, c *config.Config) map[string]string {
+	versions := map[string]string{
+		"languageServer":     config.Version,
+		"go":                 runtime.Version(),
+		"os":                 runtime.GOOS,
+		"arch":               runtime.GOARCH,
+		"ide":                strings.TrimSpace(c.IdeName() + " " + c.IdeVersion()),
+		"integration":        strings.TrimSpace(c.IntegrationName() + " " + c.IntegrationVersion()),
+		"integrationRuntime": strings.TrimSpace(c.RuntimeName() + " " + c.RuntimeVersion()),
+	}
+	if c.CliSettings().Installed() {
+		ctx, cancel := context.WithTimeout(ctx, cliVersionTimeout)
+		defer cancel()
+		output, err := exec.CommandContext(ctx, c.CliSettings().Path(), "--version").Output()
+		if err == nil {
+			versions["cli"] = strings.TrimSpace(string(output))
+		}
+	}
+	return versions
+}
+
+func effectiveConfiguration(c *config.Config) map[string]any {
+	settings := c.CliSettings()
+	folderEnv := map[string][]string{}
+	for folder, env := range settings.FolderEnv {
+		folderEnv[folder] = cli.RedactEnvironment(env)
+	}
+	return map[string]any{
+		"vulnmapApi":                  c.VulnmapApi(),
+		"vulnmapCodeApi":              c.VulnmapCodeApi(),
+		"authenticationMethod":        c.AuthenticationMethod(),
+		"authenticated":               c.NonEmptyToken(),
+		"activateVulnmapOpenSource":   c.IsVulnmapOssEnabled(),
+		"activateVulnmapCode":         c.IsVulnmapCodeEnabled(),
+		"activateVulnmapCodeSecurity": c.IsVulnmapCodeSecurityEnabled(),
+		"activateVulnmapCodeQuality":  c.IsVulnmapCodeQualityEnabled(),
+		"activateVulnmapIac":          c.IsVulnmapIacEnabled(),
+		"filterSeverity":              c.FilterSeverity(),
+		"automaticScanning":           c.IsAutoScanEnabled(),
+		"enableTrustedFoldersFeature": c.IsTrustedFolderFeatureEnabled(),
+		"trustedFolders":              c.TrustedFolders(),
+		"manageBinariesAutomatically": c.ManageBinariesAutomatically(),
+		"sendErrorReports":            c.IsErrorReportingEnabled(),
+		"enableAnalytics":             c.IsAnalyticsEnabled(),
+		"offlineMode":                 c.IsOfflineMode(),
+		"logLevel":                    c.LogLevel(),
+		"logPath":                     c.LogPath(),
+		"path":                        c.Path(),
+		"cli": map[string]any{
+			"path":                    settings.Path(),
+			"installed":               settings.Installed(),
+			"insecure":                settings.Insecure,
+			"additionalOssParameters": settings.AdditionalOssParameters,
+			"options":                 settings.Options,
+			"concurrency":             settings.MaxConcurrency(),
+			"releaseSource":           settings.ReleaseSource,
+			"releaseVersion":          settings.ReleaseVersion,
+			"releaseChannel":          settings.ReleaseChannel,
+			"envAllowlist":            settings.EnvAllowlist,
+			"envDenylist":             settings.EnvDenylist,
+			"additionalEnv":           cli.RedactEnvironment(settings.AdditionalEnv),
+			"folderEnv":               folderEnv,
+			"resultCacheTtl":          settings.ResultCacheTTL.String(),
+		},
+	}
+}
+
+func scanStatistics() map[string]any {
+	w := workspace.Get()
+	if w == nil {
+		return map[string]any{}
+	}
+	var folders []workspace.ScanStatistics
+	for _, f := range w.Folders() {
+		folders = append(folders, f.ScanStatistics())
+	}
+	durationsMs := map[string]int64{}
+	for key, duration := range w.ScanDurations() {
+		durationsMs[key] = duration.Milliseconds()
+	}
+	return map[string]any{"folders": folders, "averageScanDurationsMs": durationsMs}
+}
+
+// logFilePattern matches the log file and its rotated files, e.g. ls.log, ls.1.log and ls.log.1 for ls.log
+func logFilePattern(logPath string) *regexp.Regexp {
+	ext := filepath.Ext(logPath)
+	stem := strings.TrimSuffix(filepath.Base(logPath), ext)
+	return regexp.MustCompile(`^` + regexp.QuoteMeta(stem) + `(\.\d+)?` + regexp.QuoteMeta(ext) + `(\.\d+)?$`)
+}
+
+// recentLogFiles returns the newest files in the log directory that are the log file or its rotated files, newest
+// first
+func recentLogFiles(logPath string, count int) []string {
+	if logPath == "" {
+		return nil
+	}
+	dir := filepath.Dir(logPath)
+	pattern := logFilePattern(logPath)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	type logFile struct {
+		path    string
+		modTime time.Time
+	}
+	var logFiles []logFile
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || !info.Mode().IsRegular() || !pattern.MatchString(entry.Name()) {
+			continue
+		}
+		logFiles = append(logFiles, logFile{path: filepath.Join(dir, entry.Name()), modTime: info.ModTime()})
+	}
+	sort.Slice(logFiles, func(i, j int) bool { return logFiles[i].modTime.After(logFiles[j].modTime) })
+	paths := make([]string, 0, min(count, len(logFiles)))
+	for i := 0; i < len(logFiles) && i < count; i++ {
+		paths = append(paths, logFiles[i].path)
+	}
+	return paths
+}
+
+// readTail returns the last maxSize bytes of the file
+func readTail(path string, maxSize int64) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > maxSize {
+		if _, err = file.Seek(info.Size()-maxSize, io.SeekStart); err != nil {
+			return nil, err
+		}
+	}
+	return io.ReadAll(file)
+}
@@ -0,0 +1,144 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"archive/zip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/vulnmap"
+	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/errorspool"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/testutil"
+)
+
+func readZip(t *testing.T, path string) map[string]string {
+	t.Helper()
+	reader, err := zip.OpenReader(path)
+	require.NoError(t, err)
+	defer func() { _ = reader.Close() }()
+	files := map[string]string{}
+	for _, file := range reader.File {
+		rc, err := file.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		_ = rc.Close()
+		files[file.Name] = string(content)
+	}
+	return files
+}
+
+func Test_exportSupportBundle_Execute(t *testing.T) {
+	c := testutil.UnitTest(t)
+	token := "4b6a3f1e-2f0a-4c4e-8f5e-0a1b2c3d4e5f"
+	c.SetToken(token)
+	logDir := t.TempDir()
+	c.SetLogPath(filepath.Join(logDir, "vulnmap-ls.log"))
+	c.SetErrorSpoolPath(t.TempDir())
+	require.NoError(t, os.WriteFile(c.LogPath(), []byte("using token "+token+"\nAuthorization: Bearer abc\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(logDir, "vulnmap-ls.1.log"), []byte("rotated"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(logDir, "other.log"), []byte("other"), 0o600))
+
+	cmd := &exportSupportBundle{command: vulnmap.CommandData{
+		CommandId: vulnmap.ExportSupportBundleCommand,
+		Arguments: []any{float64(2)},
+	}}
+
+	result, err := cmd.Execute(context.Background())
+
+	require.NoError(t, err)
+	bundlePath := result.(string)
+	t.Cleanup(func() { _ = os.Remove(bundlePath) })
+	files := readZip(t, bundlePath)
+	assert.Contains(t, files, "versions.json")
+	assert.Contains(t, files, "configuration.json")
+	assert.Contains(t, files, "client-capabilities.json")
+	assert.Contains(t, files, "rpc-timings.json")
+	assert.Contains(t, files, "scan-statistics.json")
+	assert.Contains(t, files, "logs/vulnmap-ls.1.log")
+	assert.NotContains(t, files, "logs/other.log")
+	assert.Equal(t, "using token ***\nAuthorization: Bearer ***\n", files["logs/vulnmap-ls.log"])
+	for name, content := range files {
+		assert.NotContains(t, content, token, name)
+	}
+}
+
+func Test_exportSupportBundle_Execute_BundlesOfTheSameSecondDontOverwriteEachOther(t *testing.T) {
+	c := testutil.UnitTest(t)
+	c.SetLogPath(filepath.Join(t.TempDir(), "vulnmap-ls.log"))
+	c.SetErrorSpoolPath(t.TempDir())
+	cmd := &exportSupportBundle{command: vulnmap.CommandData{CommandId: vulnmap.ExportSupportBundleCommand}}
+
+	first, err := cmd.Execute(context.Background())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.Remove(first.(string)) })
+	second, err := cmd.Execute(context.Background())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.Remove(second.(string)) })
+
+	assert.NotEqual(t, first, second)
+	assert.FileExists(t, first.(string))
+}
+
+func Test_exportSupportBundle_ContainsSpooledErrors(t *testing.T) {
+	c := testutil.UnitTest(t)
+	c.SetLogPath(filepath.Join(t.TempDir(), "vulnmap-ls.log"))
+	c.SetErrorSpoolPath(t.TempDir())
+	spool := errorspool.New(c.ErrorSpoolPath(), errorspool.DefaultMaxEntries)
+	require.NoError(t, spool.Add(errorspool.Entry{Timestamp: time.Now(), Message: "spooled error"}, nil))
+	bundlePath := filepath.Join(t.TempDir(), "bundle.zip")
+
+	err := writeSupportBundle(context.Background(), c, bundlePath, defaultSupportBundleLogFiles)
+
+	require.NoError(t, err)
+	files := readZip(t, bundlePath)
+	spooled := filepath.Base(spool.Files()[0])
+	assert.Contains(t, files["errors/"+spooled], "spooled error")
+}
+
+func Test_recentLogFiles_LimitsCount(t *testing.T) {
+	logDir := t.TempDir()
+	for _, name := range []string{"ls.log", "ls.1.log", "ls.2.log"} {
+		require.NoError(t, os.WriteFile(filepath.Join(logDir, name), []byte(name), 0o600))
+	}
+
+	assert.Len(t, recentLogFiles(filepath.Join(logDir, "ls.log"), 2), 2)
+	assert.Empty(t, recentLogFiles("", 2))
+}
+
+func Test_recentLogFiles_OnlyAddsTheLogFileAndItsRotatedFiles(t *testing.T) {
+	logDir := t.TempDir()
+	for _, name := range []string{"ls.log", "ls.1.log", "ls.log.2", "ls-other.log", "lsp.log", "ls.log.bak"} {
+		require.NoError(t, os.WriteFile(filepath.Join(logDir, name), []byte(name), 0o600))
+	}
+
+	files := recentLogFiles(filepath.Join(logDir, "ls.log"), 10)
+
+	var names []string
+	for _, file := range files {
+		names = append(names, filepath.Base(file))
+	}
+	assert.ElementsMatch(t, []string{"ls.log", "ls.1.log", "ls.log.2"}, names)
+}
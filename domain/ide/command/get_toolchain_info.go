@@ -0,0 +1,69 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/ide/workspace"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/vulnmap"
+)
+
+// getToolchainInfo returns the build tools and runtimes that are used for the workspace folders, so users can
+// check which toolchain the CLI picks up. An optional argument restricts the result to the folder containing
+// the given path, paths outside the workspace folders are rejected. Tools of the folder, e.g. poetry, are only run
+// for trusted folders.
+type getToolchainInfo struct {
+	command vulnmap.CommandData
+}
+
+func (cmd *getToolchainInfo) Command() vulnmap.CommandData {
+	return cmd.command
+}
+
+func (cmd *getToolchainInfo) Execute(_ context.Context) (any, error) {
+	c := config.CurrentConfig()
+	var folders []*workspace.Folder
+	if w := workspace.Get(); w != nil {
+		folders = w.Folders()
+		if args := cmd.command.Arguments; len(args) > 0 {
+			if path, ok := args[0].(string); ok {
+				f := w.GetFolderContaining(path)
+				if f == nil {
+					return nil, fmt.Errorf("%s is not in a workspace folder", path)
+				}
+				folders = []*workspace.Folder{f}
+			}
+		}
+	}
+
+	// without folders, the toolchain of the language server environment is returned
+	if len(folders) == 0 {
+		return []config.ToolchainInfo{c.Toolchain("")}, nil
+	}
+	infos := make([]config.ToolchainInfo, 0, len(folders))
+	for _, f := range folders {
+		if f.IsTrusted() {
+			infos = append(infos, c.Toolchain(f.Path()))
+		} else {
+			infos = append(infos, c.UntrustedToolchain(f.Path()))
+		}
+	}
+	return infos, nil
+}
@@ -0,0 +1,108 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/ide/hover"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/ide/workspace"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/audit"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/performance"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/vulnmap"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/notification"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/testutil"
+)
+
+func Test_getToolchainInfo_Execute(t *testing.T) {
+	testutil.UnitTest(t)
+	notifier := notification.NewNotifier()
+	scanner := vulnmap.NewTestScanner()
+	hoverService := hover.NewFakeHoverService()
+	scanNotifier := vulnmap.NewMockScanNotifier()
+	w := workspace.New(performance.NewInstrumentor(), scanner, hoverService, scanNotifier, notifier, audit.NewTestTrail())
+	workspace.Set(w)
+	folderPaths := []string{t.TempDir(), t.TempDir()}
+	for _, folderPath := range folderPaths {
+		w.AddFolder(workspace.NewFolder(folderPath, filepath.Base(folderPath), scanner, hoverService, scanNotifier, notifier))
+	}
+
+	t.Run("all workspace folders", func(t *testing.T) {
+		cmd := &getToolchainInfo{command: vulnmap.CommandData{CommandId: vulnmap.GetToolchainInfoCommand}}
+
+		result, err := cmd.Execute(context.Background())
+
+		require.NoError(t, err)
+		infos := result.([]config.ToolchainInfo)
+		require.Len(t, infos, 2)
+		assert.ElementsMatch(t, folderPaths, []string{infos[0].Folder, infos[1].Folder})
+	})
+
+	t.Run("folder containing the given path", func(t *testing.T) {
+		cmd := &getToolchainInfo{command: vulnmap.CommandData{
+			CommandId: vulnmap.GetToolchainInfoCommand,
+			Arguments: []any{filepath.Join(folderPaths[1], "package.json")},
+		}}
+
+		result, err := cmd.Execute(context.Background())
+
+		require.NoError(t, err)
+		infos := result.([]config.ToolchainInfo)
+		require.Len(t, infos, 1)
+		assert.Equal(t, folderPaths[1], infos[0].Folder)
+	})
+
+	t.Run("path outside the workspace folders", func(t *testing.T) {
+		cmd := &getToolchainInfo{command: vulnmap.CommandData{
+			CommandId: vulnmap.GetToolchainInfoCommand,
+			Arguments: []any{t.TempDir()},
+		}}
+
+		_, err := cmd.Execute(context.Background())
+
+		assert.Error(t, err)
+	})
+
+	t.Run("tools aren't run in untrusted folders", func(t *testing.T) {
+		testutil.NotOnWindows(t, "uses a shell script as fake poetry")
+		config.CurrentConfig().SetTrustedFolderFeatureEnabled(true)
+		t.Cleanup(func() { config.CurrentConfig().SetTrustedFolderFeatureEnabled(false) })
+		pyproject := []byte("[tool.poetry]\nname = \"app\"")
+		require.NoError(t, os.WriteFile(filepath.Join(folderPaths[0], "pyproject.toml"), pyproject, 0o600))
+		binDir := t.TempDir()
+		marker := filepath.Join(binDir, "executed")
+		poetry := "#!/bin/sh\necho > '" + marker + "'\n"
+		require.NoError(t, os.WriteFile(filepath.Join(binDir, "poetry"), []byte(poetry), 0o700))
+		t.Setenv("PATH", binDir)
+		cmd := &getToolchainInfo{command: vulnmap.CommandData{
+			CommandId: vulnmap.GetToolchainInfoCommand,
+			Arguments: []any{folderPaths[0]},
+		}}
+
+		_, err := cmd.Execute(context.Background())
+
+		require.NoError(t, err)
+		assert.NoFileExists(t, marker)
+	})
+}
@@ -24,6 +24,7 @@ import (
 
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/ide/hover"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/ide/workspace"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/audit"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/error_reporting"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/performance"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/ux"
@@ -53,7 +54,7 @@ func TestLogoutCommand_Execute_ClearsIssues(t *testing.T) {
 	scanner := vulnmap.NewTestScanner()
 	scanner.Issues = []vulnmap.Issue{{ID: "issue-1"}}
 
-	w := workspace.New(performance.NewInstrumentor(), scanner, hoverService, scanNotifier, notifier)
+	w := workspace.New(performance.NewInstrumentor(), scanner, hoverService, scanNotifier, notifier, audit.NewTestTrail())
 	folder := workspace.NewFolder(
 		t.TempDir(),
 		t.Name(),
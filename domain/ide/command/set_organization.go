@@ -0,0 +1,58 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"context"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
+	noti "github.com/khulnasoft-lab/vulnmap-ls/domain/ide/notification"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/ide/workspace"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/vulnmap"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/lsp"
+)
+
+// setOrganization sets the organization the scans run for, tells the client about the new setting and rescans the
+// workspace. Without an argument the default organization of the user is used.
+type setOrganization struct {
+	command  vulnmap.CommandData
+	notifier noti.Notifier
+}
+
+func (cmd *setOrganization) Command() vulnmap.CommandData {
+	return cmd.command
+}
+
+func (cmd *setOrganization) Execute(ctx context.Context) (any, error) {
+	organization := ""
+	if len(cmd.command.Arguments) > 0 {
+		organization, _ = cmd.command.Arguments[0].(string)
+	}
+
+	log.Debug().Str("method", "setOrganization.Execute").Msgf("setting organization to %q", organization)
+	config.CurrentConfig().SetOrganization(organization)
+	cmd.notifier.Send(lsp.VulnmapOrganizationParams{Organization: organization})
+
+	w := workspace.Get()
+	if w != nil {
+		w.ClearIssues(ctx)
+		w.ScanWorkspace(ctx)
+	}
+	return nil, nil
+}
@@ -0,0 +1,64 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/vulnmap"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/lsp"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/notification"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/testutil"
+)
+
+func Test_setOrganization_SetsAndSendsOrganization(t *testing.T) {
+	testutil.UnitTest(t)
+	// an org id, as org slugs are resolved with the API
+	orgId := uuid.NewString()
+	config.CurrentConfig().SetOrganization("unknown-org")
+	notifier := notification.NewMockNotifier()
+	cmd := &setOrganization{
+		command:  vulnmap.CommandData{CommandId: vulnmap.SetOrganizationCommand, Arguments: []any{orgId}},
+		notifier: notifier,
+	}
+
+	_, err := cmd.Execute(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, orgId, config.CurrentConfig().Organization())
+	assert.Equal(t, []any{lsp.VulnmapOrganizationParams{Organization: orgId}}, notifier.SentMessages())
+}
+
+func Test_setOrganization_WithoutArgumentUsesDefaultOrganization(t *testing.T) {
+	testutil.UnitTest(t)
+	config.CurrentConfig().SetOrganization("unknown-org")
+	notifier := notification.NewMockNotifier()
+	cmd := &setOrganization{
+		command:  vulnmap.CommandData{CommandId: vulnmap.SetOrganizationCommand},
+		notifier: notifier,
+	}
+
+	_, err := cmd.Execute(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, []any{lsp.VulnmapOrganizationParams{Organization: ""}}, notifier.SentMessages())
+}
@@ -53,6 +53,8 @@ func (cmd *workspaceFolderScanCommand) Execute(ctx context.Context) (any, error)
 		log.Warn().Interface("folders", w.Folders())
 		return nil, err
 	}
+	// the user expects a new scan, e.g. after ignoring issues on the platform
+	ctx = vulnmap.WithoutCachedResults(ctx)
 	f.ClearScannedStatus()
 	f.ClearDiagnosticsFromPathRecursively(path)
 	f.ScanFolder(ctx)
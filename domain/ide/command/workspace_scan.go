@@ -34,6 +34,8 @@ func (cmd *workspaceScanCommand) Command() vulnmap.CommandData {
 }
 
 func (cmd *workspaceScanCommand) Execute(ctx context.Context) (any, error) {
+	// the user expects a new scan, e.g. after ignoring issues on the platform
+	ctx = vulnmap.WithoutCachedResults(ctx)
 	w := workspace.Get()
 	w.ClearIssues(ctx)
 	w.ScanWorkspace(ctx)
@@ -17,9 +17,6 @@
 package converter
 
 import (
-	"fmt"
-	"regexp"
-
 	sglsp "github.com/sourcegraph/go-lsp"
 
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/ide/hover"
@@ -180,30 +177,13 @@ func ToHoversDocument(path string, issues []vulnmap.Issue) hover.DocumentHovers
 	}
 }
 
+// ToHovers returns the hovers of the issues, their content is rendered from the issues when a hover is requested
 func ToHovers(issues []vulnmap.Issue) (hovers []hover.Hover[hover.Context]) {
-	re := regexp.MustCompile(`<br\s?/?>`)
 	for _, i := range issues {
-		message := ""
-		if len(i.FormattedMessage) > 0 {
-			message = i.FormattedMessage
-		} else {
-			message = i.Message
-		}
-
-		if len(i.References) > 0 {
-			message += "\n\nReferences:\n\n"
-			for _, reference := range i.References {
-				message += fmt.Sprintf("[%s](%s)\n\n", reference.Title, reference.Url)
-			}
-		}
-
-		// sanitize the message, substitute <br> with line break
-		message = re.ReplaceAllString(message, "\n\n")
-
 		hovers = append(hovers, hover.Hover[hover.Context]{
 			Id:      i.ID,
 			Range:   i.Range,
-			Message: message,
+			Message: i.Message,
 			Context: i,
 		})
 	}
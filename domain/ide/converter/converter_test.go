@@ -27,7 +27,9 @@ import (
 
 func TestToHovers(t *testing.T) {
 	testutil.UnitTest(t)
-	testIssue := vulnmap.Issue{FormattedMessage: "<br><br/><br />"}
+	testIssue := vulnmap.Issue{ID: "id", Message: "message"}
 	hovers := ToHovers([]vulnmap.Issue{testIssue})
-	assert.Equal(t, "\n\n\n\n\n\n", hovers[0].Message)
+	assert.Equal(t, "id", hovers[0].Id)
+	assert.Equal(t, "message", hovers[0].Message)
+	assert.Equal(t, testIssue, hovers[0].Context)
 }
@@ -33,10 +33,7 @@ func NewFakeHoverService() *FakeHoverService {
 	}
 }
 
-func (t *FakeHoverService) DeleteHover(_ string) {
-	//TODO implement me
-	panic("implement me")
-}
+func (t *FakeHoverService) DeleteHover(_ string) {}
 
 func (t *FakeHoverService) Channel() chan DocumentHovers {
 	t.calls++
@@ -49,7 +46,7 @@ func (t *FakeHoverService) ClearAllHovers() {
 	}
 }
 
-func (t *FakeHoverService) GetHover(_ string, _ vulnmap.Position) Result {
+func (t *FakeHoverService) GetHover(_ string, _ vulnmap.Position, _ Format) Result {
 	//TODO implement me
 	panic("implement me")
 }
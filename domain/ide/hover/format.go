@@ -0,0 +1,200 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hover
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/gomarkdown/markdown"
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/gomarkdown/markdown/parser"
+
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/vulnmap"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/lsp"
+)
+
+// Format is the LSP markup kind used to render the hover content
+type Format string
+
+const (
+	Markdown  Format = "markdown"
+	PlainText Format = "plaintext"
+)
+
+var multipleNewLines = regexp.MustCompile(`\n{3,}`)
+
+// FormatFromCapabilities returns the first hover content format preferred by the client that we can render.
+// Clients that don't announce a hover capability get markdown, as they always did.
+func FormatFromCapabilities(capabilities lsp.ClientCapabilities) Format {
+	hoverCapabilities := capabilities.TextDocument.Hover
+	if hoverCapabilities == nil || len(hoverCapabilities.ContentFormat) == 0 {
+		return Markdown
+	}
+
+	for _, contentFormat := range hoverCapabilities.ContentFormat {
+		switch Format(contentFormat) {
+		case Markdown:
+			return Markdown
+		case PlainText:
+			return PlainText
+		}
+	}
+	return Markdown
+}
+
+var lineBreakTags = regexp.MustCompile(`<br\s?/?>`)
+
+// renderHover renders the content of a hover in the requested format. Hovers of issues are rendered from the details
+// of the issue, other hovers show their message.
+func renderHover(hover Hover[Context], format Format) string {
+	issue, ok := hover.Context.(vulnmap.Issue)
+	if !ok {
+		return hover.Message
+	}
+	if format == PlainText {
+		return issuePlainText(issue)
+	}
+	return issueMarkdown(issue)
+}
+
+func issueMarkdown(issue vulnmap.Issue) string {
+	var builder strings.Builder
+	if details := issue.Details; details == nil {
+		builder.WriteString(issue.Message + "\n\n")
+	} else {
+		builder.WriteString("### " + details.Title + "\n\n")
+		builder.WriteString("**Severity:** " + severityName(issue.Severity))
+		for _, link := range details.Links {
+			builder.WriteString(fmt.Sprintf(" | [%s](%s)", link.Title, link.Url))
+		}
+		builder.WriteString("\n\n")
+		for _, field := range details.Fields {
+			builder.WriteString(fmt.Sprintf("**%s:** %s\n\n", field.Label, field.Value))
+		}
+		if len(details.UpgradePath) > 0 {
+			builder.WriteString("**Upgrade path:** " + strings.Join(details.UpgradePath, " > ") + "\n\n")
+		}
+		if details.Remediation != "" {
+			builder.WriteString("**Remediation:** " + sanitize(details.Remediation) + "\n\n")
+		}
+		for _, section := range details.Sections {
+			content := strings.TrimSpace(sanitize(section.Content))
+			if content == "" {
+				continue
+			}
+			if section.Title != "" {
+				builder.WriteString("### " + section.Title + "\n\n")
+			}
+			builder.WriteString(content + "\n\n")
+		}
+	}
+	if len(issue.References) > 0 {
+		builder.WriteString("References:\n\n")
+		for _, reference := range issue.References {
+			builder.WriteString(fmt.Sprintf("[%s](%s)\n\n", reference.Title, reference.Url))
+		}
+	}
+	return strings.TrimSpace(builder.String())
+}
+
+func issuePlainText(issue vulnmap.Issue) string {
+	var builder strings.Builder
+	if details := issue.Details; details == nil {
+		builder.WriteString(issue.Message + "\n\n")
+	} else {
+		builder.WriteString(details.Title + "\n")
+		builder.WriteString("Severity: " + severityName(issue.Severity) + "\n")
+		for _, link := range details.Links {
+			builder.WriteString(link.Title + ": " + link.Url + "\n")
+		}
+		for _, field := range details.Fields {
+			builder.WriteString(field.Label + ": " + field.Value + "\n")
+		}
+		if len(details.UpgradePath) > 0 {
+			builder.WriteString("Upgrade path: " + strings.Join(details.UpgradePath, " > ") + "\n")
+		}
+		if details.Remediation != "" {
+			builder.WriteString("Remediation: " + toPlainText(sanitize(details.Remediation)) + "\n")
+		}
+		builder.WriteString("\n")
+		for _, section := range details.Sections {
+			content := toPlainText(sanitize(section.Content))
+			if content == "" {
+				continue
+			}
+			if section.Title != "" {
+				builder.WriteString(section.Title + "\n\n")
+			}
+			builder.WriteString(content + "\n\n")
+		}
+	}
+	if len(issue.References) > 0 {
+		builder.WriteString("References:\n")
+		for _, reference := range issue.References {
+			builder.WriteString(fmt.Sprintf("%s: %s\n", reference.Title, reference.Url))
+		}
+	}
+	return strings.TrimSpace(builder.String())
+}
+
+// sanitize replaces the HTML line breaks of the descriptions with markdown line breaks
+func sanitize(md string) string {
+	return lineBreakTags.ReplaceAllString(md, "\n\n")
+}
+
+func severityName(severity vulnmap.Severity) string {
+	name := severity.String()
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// toPlainText removes the markup of markdown provided by the products, e.g. vulnerability descriptions
+func toPlainText(md string) string {
+	doc := markdown.Parse([]byte(md), parser.NewWithExtensions(parser.CommonExtensions))
+
+	var builder strings.Builder
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		switch n := node.(type) {
+		case *ast.Text, *ast.Code, *ast.CodeBlock:
+			if entering {
+				builder.Write(n.AsLeaf().Literal)
+			}
+		case *ast.Softbreak, *ast.Hardbreak:
+			if entering {
+				builder.WriteString("\n")
+			}
+		case *ast.Link:
+			if !entering && len(n.Destination) > 0 {
+				builder.WriteString(" (" + string(n.Destination) + ")")
+			}
+		case *ast.Paragraph, *ast.Heading, *ast.ListItem, *ast.TableRow:
+			if !entering {
+				builder.WriteString("\n\n")
+			}
+		case *ast.TableCell:
+			if !entering {
+				builder.WriteString(" ")
+			}
+		case *ast.HTMLSpan, *ast.HTMLBlock:
+			return ast.SkipChildren
+		}
+		return ast.GoToNext
+	})
+
+	return strings.TrimSpace(multipleNewLines.ReplaceAllString(builder.String(), "\n\n"))
+}
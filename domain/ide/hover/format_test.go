@@ -0,0 +1,66 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hover
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/vulnmap"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/lsp"
+)
+
+func capabilitiesWithHoverFormats(formats ...string) lsp.ClientCapabilities {
+	capabilities := lsp.ClientCapabilities{}
+	capabilities.TextDocument.Hover = &struct {
+		ContentFormat []string `json:"contentFormat,omitempty"`
+	}{ContentFormat: formats}
+	return capabilities
+}
+
+func Test_FormatFromCapabilities(t *testing.T) {
+	t.Run("defaults to markdown without hover capabilities", func(t *testing.T) {
+		assert.Equal(t, Markdown, FormatFromCapabilities(lsp.ClientCapabilities{}))
+	})
+
+	t.Run("uses first supported format in order of client preference", func(t *testing.T) {
+		assert.Equal(t, PlainText, FormatFromCapabilities(capabilitiesWithHoverFormats("plaintext", "markdown")))
+		assert.Equal(t, Markdown, FormatFromCapabilities(capabilitiesWithHoverFormats("markdown", "plaintext")))
+	})
+
+	t.Run("skips unknown formats", func(t *testing.T) {
+		assert.Equal(t, PlainText, FormatFromCapabilities(capabilitiesWithHoverFormats("html", "plaintext")))
+	})
+}
+
+func Test_toPlainText_StripsMarkup(t *testing.T) {
+	md := "\n### VULNMAP-JS-1: Prototype Pollution\n\n**Issue:** `merge` is unsafe\n\n- first\n- second\n"
+
+	assert.Equal(t, "VULNMAP-JS-1: Prototype Pollution\n\nIssue: merge is unsafe\n\nfirst\n\nsecond", toPlainText(md))
+}
+
+func Test_renderHover_IssueWithoutDetailsShowsMessageAndReferences(t *testing.T) {
+	reference, _ := url.Parse("https://example.com/fix")
+	issue := vulnmap.Issue{Message: "message", References: []vulnmap.Reference{{Title: "fix", Url: reference}}}
+
+	assert.Equal(t, "message\n\nReferences:\n\n[fix](https://example.com/fix)",
+		renderHover(Hover[Context]{Context: issue}, Markdown))
+	assert.Equal(t, "message\n\nReferences:\nfix: https://example.com/fix",
+		renderHover(Hover[Context]{Context: issue}, PlainText))
+}
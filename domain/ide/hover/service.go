@@ -31,7 +31,7 @@ type Service interface {
 	DeleteHover(path string)
 	Channel() chan DocumentHovers
 	ClearAllHovers()
-	GetHover(path string, pos vulnmap.Position) Result
+	GetHover(path string, pos vulnmap.Position, format Format) Result
 	SetAnalytics(analytics ux2.Analytics)
 }
 
@@ -112,22 +112,23 @@ func (s *DefaultHoverService) ClearAllHovers() {
 	s.hoverIndexes = map[string]bool{}
 }
 
-func (s *DefaultHoverService) GetHover(path string, pos vulnmap.Position) Result {
+// GetHover renders the hovers registered for the given position in the requested format
+func (s *DefaultHoverService) GetHover(path string, pos vulnmap.Position, format Format) Result {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
-	var hoverMessage string
+	var contents []string
 	for _, hover := range s.hovers[path] {
 		if s.isHoverForPosition(hover, pos) {
 			s.trackHoverDetails(hover)
-			hoverMessage += hover.Message
+			contents = append(contents, renderHover(hover, format))
 		}
 	}
 
 	return Result{
 		Contents: MarkupContent{
-			Kind:  "markdown",
-			Value: hoverMessage,
+			Kind:  string(format),
+			Value: strings.Join(contents, "\n\n"),
 		},
 	}
 }
@@ -149,13 +149,62 @@ func Test_GetHoverMultiline(t *testing.T) {
 		target.ClearAllHovers()
 		target.hovers[path] = tc.hoverDetails
 
-		result := target.GetHover(path, tc.query)
+		result := target.GetHover(path, tc.query, Markdown)
 		if !reflect.DeepEqual(tc.expected, result) {
 			t.Fatalf("expected: %v, got: %v", tc.expected, result)
 		}
 	}
 }
 
+func Test_GetHover_RendersIssueInRequestedFormat(t *testing.T) {
+	target := NewDefaultService(ux2.NewTestAnalytics()).(*DefaultHoverService)
+	path := "path/to/package.json"
+	issue := vulnmap.Issue{
+		ID:       "VULNMAP-JS-1",
+		Severity: vulnmap.High,
+		Details: &vulnmap.IssueDetails{
+			Title:       "VULNMAP-JS-1: Prototype Pollution",
+			Links:       []vulnmap.Link{{Title: "CWE-79", Url: "https://cwe.mitre.org/data/definitions/79.html"}},
+			Fields:      []vulnmap.DetailField{{Label: "Fixed in", Value: "1.0.1"}},
+			Remediation: "Upgrade to: lodash@1.0.1",
+			UpgradePath: []string{"app@2.0.0", "lodash@1.0.1"},
+			Sections:    []vulnmap.DetailSection{{Title: "Issue", Content: "`merge` is unsafe<br>see below"}},
+		},
+	}
+	target.hovers[path] = []Hover[Context]{{
+		Range: vulnmap.Range{
+			Start: vulnmap.Position{Line: 4, Character: 56},
+			End:   vulnmap.Position{Line: 4, Character: 80},
+		},
+		Message: "Prototype Pollution",
+		Context: issue,
+	}}
+	pos := vulnmap.Position{Line: 4, Character: 66}
+
+	markdown := target.GetHover(path, pos, Markdown)
+	plainText := target.GetHover(path, pos, PlainText)
+
+	assert.Equal(t, Result{Contents: MarkupContent{
+		Kind: "markdown",
+		Value: "### VULNMAP-JS-1: Prototype Pollution\n\n" +
+			"**Severity:** High | [CWE-79](https://cwe.mitre.org/data/definitions/79.html)\n\n" +
+			"**Fixed in:** 1.0.1\n\n" +
+			"**Upgrade path:** app@2.0.0 > lodash@1.0.1\n\n" +
+			"**Remediation:** Upgrade to: lodash@1.0.1\n\n" +
+			"### Issue\n\n`merge` is unsafe\n\nsee below",
+	}}, markdown)
+	assert.Equal(t, Result{Contents: MarkupContent{
+		Kind: "plaintext",
+		Value: "VULNMAP-JS-1: Prototype Pollution\n" +
+			"Severity: High\n" +
+			"CWE-79: https://cwe.mitre.org/data/definitions/79.html\n" +
+			"Fixed in: 1.0.1\n" +
+			"Upgrade path: app@2.0.0 > lodash@1.0.1\n" +
+			"Remediation: Upgrade to: lodash@1.0.1\n\n" +
+			"Issue\n\nmerge is unsafe\n\nsee below",
+	}}, plainText)
+}
+
 func Test_TracksAnalytics(t *testing.T) {
 	analytics := ux2.NewTestAnalytics()
 	target := NewDefaultService(analytics).(*DefaultHoverService)
@@ -178,7 +227,7 @@ func Test_TracksAnalytics(t *testing.T) {
 			Message: "## Vulnerabilities found"},
 	}
 
-	target.GetHover(path, vulnmap.Position{Line: 4, Character: 66})
+	target.GetHover(path, vulnmap.Position{Line: 4, Character: 66}, Markdown)
 	assert.Len(t, analytics.GetAnalytics(), 1)
 	assert.Equal(t, ux2.IssueHoverIsDisplayedProperties{
 		IssueId:   "issue",
@@ -197,7 +246,7 @@ func Test_SendingHovers_AfterClearAll_DoesNotBlock(t *testing.T) {
 		return service.GetHover(hover.Path, vulnmap.Position{
 			Line:      10,
 			Character: 14,
-		}).Contents.Value != ""
+		}, Markdown).Contents.Value != ""
 	}, 1*time.Second, 10*time.Millisecond)
 
 }
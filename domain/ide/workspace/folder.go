@@ -20,9 +20,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/puzpuzpuz/xsync/v3"
 	"github.com/rs/zerolog/log"
@@ -70,11 +72,15 @@ type Folder struct {
 	name                    string
 	status                  FolderStatus
 	documentDiagnosticCache *xsync.MapOf[string, []vulnmap.Issue]
+	lastSuccessfulScans     *xsync.MapOf[product.Product, time.Time]
 	scanner                 vulnmap.Scanner
 	hoverService            hover.Service
 	mutex                   sync.Mutex
 	scanNotifier            vulnmap.ScanNotifier
 	notifier                noti.Notifier
+	// partialIssues contains the issues that partial results of a running scan added to the cache, keyed by product
+	// and unique issue ID. They are new issues of the scan, so they are counted when the complete results arrive.
+	partialIssues *xsync.MapOf[string, bool]
 }
 
 func NewFolder(path string, name string, scanner vulnmap.Scanner, hoverService hover.Service, scanNotifier vulnmap.ScanNotifier, notifier noti.Notifier) *Folder {
@@ -88,6 +94,8 @@ func NewFolder(path string, name string, scanner vulnmap.Scanner, hoverService h
 		notifier:     notifier,
 	}
 	folder.documentDiagnosticCache = xsync.NewMapOf[string, []vulnmap.Issue]()
+	folder.lastSuccessfulScans = xsync.NewMapOf[product.Product, time.Time]()
+	folder.partialIssues = xsync.NewMapOf[string, bool]()
 	return &folder
 }
 
@@ -127,6 +135,11 @@ func (f *Folder) Contains(path string) bool {
 // ClearDiagnosticsFromFile will clear all diagnostics of a file from memory, and send a notification to the client
 // with empty diagnostics results for the specific file
 func (f *Folder) ClearDiagnosticsFromFile(filePath string) {
+	f.clearDiagnosticsFromFile(filePath)
+	f.sendSummary()
+}
+
+func (f *Folder) clearDiagnosticsFromFile(filePath string) {
 	// todo: can we manage the cache internally without leaking it, e.g. by using as a key an MD5 hash rather than a path and defining a TTL?
 	f.documentDiagnosticCache.Delete(filePath)
 	if scanner, ok := f.scanner.(vulnmap.InlineValueProvider); ok {
@@ -143,11 +156,60 @@ func (f *Folder) ClearDiagnosticsFromFile(filePath string) {
 func (f *Folder) ClearDiagnosticsFromPathRecursively(removedPath string) {
 	f.documentDiagnosticCache.Range(func(key string, value []vulnmap.Issue) bool {
 		if strings.Contains(key, removedPath) {
-			f.ClearDiagnosticsFromFile(key)
+			f.clearDiagnosticsFromFile(key)
 		}
 
 		return true // Continue the iteration
 	})
+	f.sendSummary()
+}
+
+// takeRenamedIssues removes the cached issues of a renamed or moved file or directory from this folder, clears their
+// diagnostics, hovers and inline values on the old path and returns them keyed by their new path, with
+// AffectedFilePath rewritten accordingly
+func (f *Folder) takeRenamedIssues(oldPath string, newPath string) map[string][]vulnmap.Issue {
+	renamedIssues := map[string][]vulnmap.Issue{}
+	f.documentDiagnosticCache.Range(func(filePath string, issues []vulnmap.Issue) bool {
+		if !uri.FolderContains(oldPath, filePath) {
+			return true
+		}
+
+		relativePath, err := filepath.Rel(oldPath, filePath)
+		if err != nil {
+			log.Err(err).Str("method", "takeRenamedIssues").Msgf("couldn't determine new path for %s", filePath)
+			return true
+		}
+		newFilePath := filepath.Join(newPath, relativePath)
+
+		movedIssues := make([]vulnmap.Issue, 0, len(issues))
+		for _, issue := range issues {
+			movedIssues = append(movedIssues, issue.Moved(newFilePath))
+		}
+		renamedIssues[newFilePath] = movedIssues
+
+		f.documentDiagnosticCache.Delete(filePath)
+		if scanner, ok := f.scanner.(vulnmap.InlineValueProvider); ok {
+			scanner.MoveInlineValues(filePath, newFilePath)
+		}
+		f.hoverService.DeleteHover(filePath)
+		f.notifier.Send(lsp.PublishDiagnosticsParams{
+			URI:         uri.PathToUri(filePath),
+			Diagnostics: []lsp.Diagnostic{},
+		})
+		return true
+	})
+	return renamedIssues
+}
+
+// addRenamedIssues stores the issues of renamed or moved files in the cache and publishes them for their new path
+func (f *Folder) addRenamedIssues(renamedIssues map[string][]vulnmap.Issue) {
+	supportedIssueTypes := config.CurrentConfig().DisplayableIssueTypes()
+	for filePath, issues := range renamedIssues {
+		f.documentDiagnosticCache.Store(filePath, issues)
+		filteredIssues := FilterIssues(issues, supportedIssueTypes)
+		f.sendDiagnosticsForFile(filePath, filteredIssues)
+		f.sendHoversForFile(filePath, filteredIssues)
+	}
 }
 
 func (f *Folder) scan(ctx context.Context, path string) {
@@ -188,6 +250,14 @@ func (f *Folder) processResults(scanData vulnmap.ScanData) {
 		return
 	}
 
+	if scanData.Product != "" && !scanData.Partial {
+		finished := scanData.TimestampFinished
+		if finished.IsZero() {
+			finished = time.Now().UTC()
+		}
+		f.lastSuccessfulScans.Store(scanData.Product, finished)
+	}
+
 	dedupMap := f.createDedupMap()
 
 	// TODO: perform issue diffing (current <-> newly reported)
@@ -198,19 +268,43 @@ func (f *Folder) processResults(scanData vulnmap.ScanData) {
 			cachedIssues = []vulnmap.Issue{}
 		}
 
-		if !dedupMap[f.getUniqueIssueID(issue)] {
+		uniqueID := f.getUniqueIssueID(issue)
+		partialKey := string(scanData.Product) + "|" + uniqueID
+		isNew := !dedupMap[uniqueID]
+		if isNew {
 			cachedIssues = append(cachedIssues, issue)
+		}
+		if scanData.Partial {
+			if isNew {
+				f.partialIssues.Store(partialKey, true)
+			}
+		} else if _, addedByPartialResult := f.partialIssues.LoadAndDelete(partialKey); isNew || addedByPartialResult {
+			// only new issues are counted, including those that partial results of this scan added to the cache
 			incrementSeverityCount(&scanData, issue)
 		}
 
 		f.documentDiagnosticCache.Store(issue.AffectedFilePath, cachedIssues)
 
 	}
-	log.Debug().Str("method", "processResults").Interface("scanData", scanData).Msg("Finished processing results. Sending analytics.")
-	sendAnalytics(&scanData)
+	if !scanData.Partial {
+		f.clearPartialIssues(scanData.Product)
+		log.Debug().Str("method", "processResults").Interface("scanData", scanData).Msg("Finished processing results. Sending analytics.")
+		sendAnalytics(&scanData)
+	}
 
 	// Filter and publish cached diagnostics
-	f.FilterAndPublishCachedDiagnostics(scanData.Product)
+	f.filterAndPublishCachedDiagnostics(scanData.Product, scanData.Partial)
+}
+
+// clearPartialIssues forgets the issues of partial results of the product once the complete results are processed
+func (f *Folder) clearPartialIssues(p product.Product) {
+	prefix := string(p) + "|"
+	f.partialIssues.Range(func(key string, _ bool) bool {
+		if strings.HasPrefix(key, prefix) {
+			f.partialIssues.Delete(key)
+		}
+		return true
+	})
 }
 
 func incrementSeverityCount(scanData *vulnmap.ScanData, issue vulnmap.Issue) {
@@ -273,6 +367,11 @@ func sendAnalytics(data *vulnmap.ScanData) {
 		return
 	}
 
+	if c.IsOfflineMode() {
+		logger.Debug().Msg("Skipping analytics in offline mode")
+		return
+	}
+
 	scanEvent := json_schemas.ScanDoneEvent{}
 	// Populate the fields with data
 	scanEvent.Data.Type = "analytics"
@@ -309,8 +408,13 @@ func sendAnalytics(data *vulnmap.ScanData) {
 }
 
 func (f *Folder) FilterAndPublishCachedDiagnostics(product product.Product) {
+	f.filterAndPublishCachedDiagnostics(product, false)
+}
+
+// filterAndPublishCachedDiagnostics publishes the cached diagnostics. Partial results are sent as scan in progress.
+func (f *Folder) filterAndPublishCachedDiagnostics(product product.Product, partial bool) {
 	issuesByFile := f.filterCachedDiagnostics()
-	f.publishDiagnostics(product, issuesByFile)
+	f.publishDiagnostics(product, issuesByFile, partial)
 }
 
 func (f *Folder) filterCachedDiagnostics() (fileIssues map[string][]vulnmap.Issue) {
@@ -365,9 +469,10 @@ func isVisibleSeverity(issue vulnmap.Issue) bool {
 	return false
 }
 
-func (f *Folder) publishDiagnostics(product product.Product, issuesByFile map[string][]vulnmap.Issue) {
+func (f *Folder) publishDiagnostics(product product.Product, issuesByFile map[string][]vulnmap.Issue, partial bool) {
 	f.sendDiagnostics(issuesByFile)
-	f.sendScanResults(product, issuesByFile)
+	f.sendScanResults(product, issuesByFile, partial)
+	f.sendSummary()
 	f.sendHovers(issuesByFile) // TODO: this locks up the thread, need to investigate
 }
 
@@ -414,6 +519,38 @@ func (f *Folder) sendHoversForFile(path string, issues []vulnmap.Issue) {
 	f.hoverService.Channel() <- converter.ToHoversDocument(path, issues)
 }
 
+// ScanStatistics summarizes the scan results of a folder
+type ScanStatistics struct {
+	Folder              string                        `json:"folder"`
+	Scanned             bool                          `json:"scanned"`
+	IssuesByProduct     map[product.Product]int       `json:"issuesByProduct"`
+	FilesWithIssues     int                           `json:"filesWithIssues"`
+	LastSuccessfulScans map[product.Product]time.Time `json:"lastSuccessfulScans"`
+}
+
+func (f *Folder) ScanStatistics() ScanStatistics {
+	statistics := ScanStatistics{
+		Folder:              f.path,
+		Scanned:             f.IsScanned(),
+		IssuesByProduct:     map[product.Product]int{},
+		LastSuccessfulScans: map[product.Product]time.Time{},
+	}
+	f.documentDiagnosticCache.Range(func(_ string, issues []vulnmap.Issue) bool {
+		if len(issues) > 0 {
+			statistics.FilesWithIssues++
+		}
+		for _, issue := range issues {
+			statistics.IssuesByProduct[issue.Product]++
+		}
+		return true
+	})
+	f.lastSuccessfulScans.Range(func(p product.Product, scanTime time.Time) bool {
+		statistics.LastSuccessfulScans[p] = scanTime
+		return true
+	})
+	return statistics
+}
+
 func (f *Folder) Path() string         { return f.path }
 func (f *Folder) Name() string         { return f.name }
 func (f *Folder) Status() FolderStatus { return f.status }
@@ -451,6 +588,7 @@ func (f *Folder) ClearDiagnostics() {
 		f.documentDiagnosticCache.Delete(key)
 		return true
 	})
+	f.sendSummary()
 }
 
 func (f *Folder) ClearDiagnosticsByIssueType(removedType product.FilterableIssueType) {
@@ -470,6 +608,7 @@ func (f *Folder) ClearDiagnosticsByIssueType(removedType product.FilterableIssue
 
 		return true
 	})
+	f.sendSummary()
 }
 
 func (f *Folder) IsTrusted() bool {
@@ -485,13 +624,15 @@ func (f *Folder) IsTrusted() bool {
 	return false
 }
 
-func (f *Folder) sendScanResults(processedProduct product.Product, issuesByFile map[string][]vulnmap.Issue) {
+func (f *Folder) sendScanResults(processedProduct product.Product, issuesByFile map[string][]vulnmap.Issue, partial bool) {
 	var productIssues []vulnmap.Issue
 	for _, issues := range issuesByFile {
 		productIssues = append(productIssues, issues...)
 	}
 
-	if processedProduct != "" {
+	if partial && processedProduct != "" {
+		f.scanNotifier.SendPartial(processedProduct, f.Path(), productIssues)
+	} else if processedProduct != "" {
 		f.scanNotifier.SendSuccess(processedProduct, f.Path(), productIssues)
 	} else {
 		f.scanNotifier.SendSuccessForAllProducts(f.Path(), productIssues)
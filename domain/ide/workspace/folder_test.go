@@ -507,6 +507,90 @@ func Test_processResults_ShouldNotSendAnalyticsToAPIIfDisabled(t *testing.T) {
 	f.processResults(data)
 }
 
+func Test_processResults_ShouldNotSendAnalyticsToAPIInOfflineMode(t *testing.T) {
+	c := testutil.UnitTest(t)
+	c.SetAnalyticsEnabled(true)
+	c.SetOfflineMode(true)
+
+	engineMock, gafConfig := setUpEngineMock(t, c)
+
+	f, _ := NewMockFolderWithScanNotifier(notification.NewNotifier())
+	data := vulnmap.ScanData{
+		Product: product.ProductOpenSource,
+		Issues:  []vulnmap.Issue{NewMockIssue("id1", "path1")},
+	}
+
+	engineMock.EXPECT().GetConfiguration().AnyTimes().Return(gafConfig)
+	engineMock.EXPECT().InvokeWithInputAndConfig(localworkflows.WORKFLOWID_REPORT_ANALYTICS, gomock.Any(),
+		gomock.Any()).Times(0)
+
+	// Act
+	f.processResults(data)
+}
+
+func Test_processResults_CountsOnlyNewIssuesForAnalytics(t *testing.T) {
+	c := testutil.UnitTest(t)
+
+	engineMock, gafConfig := setUpEngineMock(t, c)
+	engineMock.EXPECT().GetConfiguration().AnyTimes().Return(gafConfig)
+
+	f, _ := NewMockFolderWithScanNotifier(notification.NewNotifier())
+	cachedIssue := NewMockIssue("id1", "path1")
+	f.processResults(vulnmap.ScanData{Product: product.ProductOpenSource, Issues: []vulnmap.Issue{cachedIssue}})
+	c.SetAnalyticsEnabled(true)
+
+	engineMock.EXPECT().InvokeWithInputAndConfig(localworkflows.WORKFLOWID_REPORT_ANALYTICS, gomock.Any(), gomock.Any()).
+		Do(func(id workflow.Identifier, workflowInputData []workflow.Data, config configuration.Configuration) {
+			payloadBytes, ok := workflowInputData[0].GetPayload().([]byte)
+			require.True(t, ok)
+			var scanDoneEvent json_schemas.ScanDoneEvent
+			require.NoError(t, json.Unmarshal(payloadBytes, &scanDoneEvent))
+			require.Equal(t, 1, scanDoneEvent.Data.Attributes.UniqueIssueCount.Medium)
+		}).Times(1)
+
+	// Act
+	newIssue := NewMockIssue("id2", "path1")
+	f.processResults(vulnmap.ScanData{Product: product.ProductOpenSource, Issues: []vulnmap.Issue{newIssue}, Partial: true})
+	f.processResults(vulnmap.ScanData{Product: product.ProductOpenSource, Issues: []vulnmap.Issue{cachedIssue, newIssue}})
+}
+
+func Test_processResults_PartialResultsArePublishedWithoutAnalytics(t *testing.T) {
+	c := testutil.UnitTest(t)
+	c.SetAnalyticsEnabled(true)
+
+	engineMock, gafConfig := setUpEngineMock(t, c)
+
+	f, scanNotifier := NewMockFolderWithScanNotifier(notification.NewNotifier())
+	const filePath = "path1"
+	mockCodeIssue := NewMockIssue("id1", filePath)
+
+	engineMock.EXPECT().GetConfiguration().AnyTimes().Return(gafConfig)
+	engineMock.EXPECT().InvokeWithInputAndConfig(localworkflows.WORKFLOWID_REPORT_ANALYTICS, gomock.Any(), gomock.Any()).
+		Do(func(id workflow.Identifier, workflowInputData []workflow.Data, config configuration.Configuration) {
+			payloadBytes, ok := workflowInputData[0].GetPayload().([]byte)
+			require.True(t, ok)
+			var scanDoneEvent json_schemas.ScanDoneEvent
+			require.NoError(t, json.Unmarshal(payloadBytes, &scanDoneEvent))
+			// issues already published as partial results are counted once the scan is complete
+			require.Equal(t, 1, scanDoneEvent.Data.Attributes.UniqueIssueCount.Medium)
+		}).Times(1)
+
+	// Act
+	f.processResults(vulnmap.ScanData{Product: product.ProductOpenSource, Issues: []vulnmap.Issue{mockCodeIssue}, Partial: true})
+	_, scanned := f.lastSuccessfulScans.Load(product.ProductOpenSource)
+	cachedIssues := f.DocumentDiagnosticsFromCache(filePath)
+	successCallsAfterPartial := len(scanNotifier.SuccessCalls())
+	f.processResults(vulnmap.ScanData{Product: product.ProductOpenSource, Issues: []vulnmap.Issue{mockCodeIssue}})
+
+	// Assert
+	assert.False(t, scanned, "a partial result is no successful scan")
+	assert.Zero(t, successCallsAfterPartial, "partial results must be sent as in progress")
+	assert.Len(t, scanNotifier.PartialCalls(), 1)
+	assert.Len(t, scanNotifier.SuccessCalls(), 1)
+	assert.Len(t, cachedIssues, 1)
+	assert.Len(t, f.DocumentDiagnosticsFromCache(filePath), 1)
+}
+
 func Test_processResults_ShouldCountSeverityByProduct(t *testing.T) {
 	c := testutil.UnitTest(t)
 	c.SetAnalyticsEnabled(false)
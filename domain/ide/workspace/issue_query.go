@@ -0,0 +1,205 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package workspace
+
+import (
+	"encoding/base64"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	ignore "github.com/sabhiram/go-gitignore"
+
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/vulnmap"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/lsp"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/product"
+)
+
+const defaultIssuesPageSize = 100
+
+// IssuesPage is one page of the issues matching a query
+type IssuesPage struct {
+	Issues []vulnmap.Issue
+	// Total is the number of matching issues across all pages
+	Total int
+	// NextCursor points to the next page, it is empty on the last page
+	NextCursor string
+}
+
+type issueFilter struct {
+	products   map[string]bool
+	severities map[string]bool
+	fileGlob   *ignore.GitIgnore
+	cwes       map[string]bool
+	cves       map[string]bool
+	fixable    *bool
+	ignored    *bool
+}
+
+// QueryIssues returns the cached issues of the workspace folders that match the given filter, sorted and paged
+func (w *Workspace) QueryIssues(params lsp.IssuesParams) (IssuesPage, error) {
+	offset, err := decodeCursor(params.Cursor)
+	if err != nil {
+		return IssuesPage{}, err
+	}
+	if params.SortBy != "" && params.SortBy != lsp.IssuesSortBySeverity && params.SortBy != lsp.IssuesSortByPriority {
+		return IssuesPage{}, fmt.Errorf("unsupported sort order %s", params.SortBy)
+	}
+	limit := params.Limit
+	if limit <= 0 {
+		limit = defaultIssuesPageSize
+	}
+
+	filter := newIssueFilter(params)
+	var matchingIssues []vulnmap.Issue
+	for _, f := range w.Folders() {
+		if params.FolderPath != "" && filepath.Clean(params.FolderPath) != filepath.Clean(f.Path()) {
+			continue
+		}
+		matchingIssues = append(matchingIssues, f.queryIssues(filter)...)
+	}
+	sortIssues(matchingIssues, params.SortBy)
+
+	page := IssuesPage{Issues: []vulnmap.Issue{}, Total: len(matchingIssues)}
+	if offset >= len(matchingIssues) {
+		return page, nil
+	}
+	end := offset + limit
+	if end < len(matchingIssues) {
+		page.NextCursor = encodeCursor(end)
+	} else {
+		end = len(matchingIssues)
+	}
+	page.Issues = matchingIssues[offset:end]
+	return page, nil
+}
+
+func (f *Folder) queryIssues(filter issueFilter) (matchingIssues []vulnmap.Issue) {
+	f.documentDiagnosticCache.Range(func(filePath string, issues []vulnmap.Issue) bool {
+		if filter.fileGlob != nil {
+			relativePath, err := filepath.Rel(f.path, filePath)
+			if err != nil || !filter.fileGlob.MatchesPath(filepath.ToSlash(relativePath)) {
+				return true
+			}
+		}
+		for _, issue := range issues {
+			if filter.matches(issue) {
+				matchingIssues = append(matchingIssues, issue)
+			}
+		}
+		return true
+	})
+	return matchingIssues
+}
+
+func newIssueFilter(params lsp.IssuesParams) issueFilter {
+	filter := issueFilter{
+		products:   toLowerCaseSet(params.Products),
+		severities: toLowerCaseSet(params.Severities),
+		cwes:       toLowerCaseSet(params.CWEs),
+		cves:       toLowerCaseSet(params.CVEs),
+		fixable:    params.Fixable,
+		ignored:    params.Ignored,
+	}
+	if params.FileGlob != "" {
+		filter.fileGlob = ignore.CompileIgnoreLines(params.FileGlob)
+	}
+	return filter
+}
+
+func (filter issueFilter) matches(issue vulnmap.Issue) bool {
+	if len(filter.products) > 0 && !filter.products[product.ToProductCodename(issue.Product)] {
+		return false
+	}
+	if len(filter.severities) > 0 && !filter.severities[issue.Severity.String()] {
+		return false
+	}
+	if len(filter.cwes) > 0 && !containsAny(filter.cwes, issue.CWEs) {
+		return false
+	}
+	if len(filter.cves) > 0 && !containsAny(filter.cves, issue.CVEs) {
+		return false
+	}
+	if filter.fixable != nil && *filter.fixable != issue.IsFixable() {
+		return false
+	}
+	if filter.ignored != nil && *filter.ignored != issue.IsIgnored {
+		return false
+	}
+	return true
+}
+
+// sortIssues sorts by severity or priority, falling back to the location of the issue, so that pages are stable
+func sortIssues(issues []vulnmap.Issue, sortBy string) {
+	sort.SliceStable(issues, func(i, j int) bool {
+		a, b := issues[i], issues[j]
+		if sortBy == lsp.IssuesSortByPriority && a.Priority() != b.Priority() {
+			return a.Priority() > b.Priority()
+		}
+		if a.Severity != b.Severity {
+			return a.Severity < b.Severity
+		}
+		if a.Priority() != b.Priority() {
+			return a.Priority() > b.Priority()
+		}
+		if a.AffectedFilePath != b.AffectedFilePath {
+			return a.AffectedFilePath < b.AffectedFilePath
+		}
+		if a.Range.Start.Line != b.Range.Start.Line {
+			return a.Range.Start.Line < b.Range.Start.Line
+		}
+		return a.ID < b.ID
+	})
+}
+
+func toLowerCaseSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[strings.ToLower(value)] = true
+	}
+	return set
+}
+
+func containsAny(set map[string]bool, values []string) bool {
+	for _, value := range values {
+		if set[strings.ToLower(value)] {
+			return true
+		}
+	}
+	return false
+}
+
+func encodeCursor(offset int) string {
+	return base64.StdEncoding.EncodeToString([]byte(strconv.Itoa(offset)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	decoded, err := base64.StdEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cursor %s: %w", cursor, err)
+	}
+	offset, err := strconv.Atoi(string(decoded))
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("invalid cursor %s", cursor)
+	}
+	return offset, nil
+}
@@ -0,0 +1,146 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package workspace
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/ide/hover"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/audit"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/performance"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/vulnmap"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/lsp"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/notification"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/product"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/testutil"
+)
+
+func setupQueryWorkspace(t *testing.T) (*Workspace, *Folder) {
+	t.Helper()
+	notifier := notification.NewMockNotifier()
+	scanNotifier := vulnmap.NewMockScanNotifier()
+	scanner := vulnmap.NewTestScanner()
+	w := New(performance.NewInstrumentor(), scanner, hover.NewFakeHoverService(), scanNotifier, notifier, audit.NewTestTrail())
+	f := NewFolder(t.TempDir(), "folder", scanner, hover.NewFakeHoverService(), scanNotifier, notifier)
+	w.AddFolder(f)
+
+	pomPath := filepath.Join(f.Path(), "pom.xml")
+	lowOss := NewMockIssueWithSeverity("low-oss", pomPath, vulnmap.Low)
+	lowOss.CVEs = []string{"CVE-2023-1234"}
+	lowOss.AdditionalData = vulnmap.OssIssueData{Key: "low-oss", IsUpgradable: true, CvssScore: 3.1}
+	criticalOss := NewMockIssueWithSeverity("critical-oss", pomPath, vulnmap.Critical)
+	criticalOss.AdditionalData = vulnmap.OssIssueData{Key: "critical-oss", CvssScore: 9.8}
+	f.documentDiagnosticCache.Store(pomPath, []vulnmap.Issue{lowOss, criticalOss})
+
+	codePath := filepath.Join(f.Path(), "src", "main.go")
+	highCode := NewMockIssueWithSeverity("high-code", codePath, vulnmap.High)
+	highCode.Product = product.ProductCode
+	highCode.CWEs = []string{"CWE-79"}
+	highCode.IsIgnored = true
+	highCode.AdditionalData = vulnmap.CodeIssueData{Key: "high-code", PriorityScore: 990}
+	f.documentDiagnosticCache.Store(codePath, []vulnmap.Issue{highCode})
+	return w, f
+}
+
+func issueIDs(issues []vulnmap.Issue) (ids []string) {
+	for _, issue := range issues {
+		ids = append(ids, issue.ID)
+	}
+	return ids
+}
+
+func Test_QueryIssues_SortsBySeverityByDefault(t *testing.T) {
+	testutil.UnitTest(t)
+	w, _ := setupQueryWorkspace(t)
+
+	page, err := w.QueryIssues(lsp.IssuesParams{})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"critical-oss", "high-code", "low-oss"}, issueIDs(page.Issues))
+	assert.Equal(t, 3, page.Total)
+	assert.Empty(t, page.NextCursor)
+}
+
+func Test_QueryIssues_SortsByPriority(t *testing.T) {
+	testutil.UnitTest(t)
+	w, _ := setupQueryWorkspace(t)
+
+	page, err := w.QueryIssues(lsp.IssuesParams{SortBy: lsp.IssuesSortByPriority})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"high-code", "critical-oss", "low-oss"}, issueIDs(page.Issues))
+}
+
+func Test_QueryIssues_Filters(t *testing.T) {
+	testutil.UnitTest(t)
+	w, f := setupQueryWorkspace(t)
+	yes, no := true, false
+
+	tests := []struct {
+		name     string
+		params   lsp.IssuesParams
+		expected []string
+	}{
+		{name: "product", params: lsp.IssuesParams{Products: []string{"code"}}, expected: []string{"high-code"}},
+		{name: "severity", params: lsp.IssuesParams{Severities: []string{"Low", "high"}}, expected: []string{"high-code", "low-oss"}},
+		{name: "file glob", params: lsp.IssuesParams{FileGlob: "src/**/*.go"}, expected: []string{"high-code"}},
+		{name: "cwe", params: lsp.IssuesParams{CWEs: []string{"cwe-79"}}, expected: []string{"high-code"}},
+		{name: "cve", params: lsp.IssuesParams{CVEs: []string{"CVE-2023-1234"}}, expected: []string{"low-oss"}},
+		{name: "fixable", params: lsp.IssuesParams{Fixable: &yes}, expected: []string{"low-oss"}},
+		{name: "not ignored", params: lsp.IssuesParams{Ignored: &no}, expected: []string{"critical-oss", "low-oss"}},
+		{name: "folder", params: lsp.IssuesParams{FolderPath: f.Path() + "-other"}, expected: nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			page, err := w.QueryIssues(test.params)
+
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, issueIDs(page.Issues))
+		})
+	}
+}
+
+func Test_QueryIssues_PagesWithCursor(t *testing.T) {
+	testutil.UnitTest(t)
+	w, _ := setupQueryWorkspace(t)
+
+	firstPage, err := w.QueryIssues(lsp.IssuesParams{Limit: 2})
+	require.NoError(t, err)
+	secondPage, err := w.QueryIssues(lsp.IssuesParams{Limit: 2, Cursor: firstPage.NextCursor})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"critical-oss", "high-code"}, issueIDs(firstPage.Issues))
+	assert.NotEmpty(t, firstPage.NextCursor)
+	assert.Equal(t, []string{"low-oss"}, issueIDs(secondPage.Issues))
+	assert.Empty(t, secondPage.NextCursor)
+	assert.Equal(t, 3, secondPage.Total)
+}
+
+func Test_QueryIssues_InvalidParams(t *testing.T) {
+	testutil.UnitTest(t)
+	w, _ := setupQueryWorkspace(t)
+
+	_, err := w.QueryIssues(lsp.IssuesParams{Cursor: "not a cursor"})
+	assert.Error(t, err)
+
+	_, err = w.QueryIssues(lsp.IssuesParams{SortBy: "name"})
+	assert.Error(t, err)
+}
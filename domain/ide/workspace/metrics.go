@@ -0,0 +1,41 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package workspace
+
+import (
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/metrics"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/product"
+)
+
+// CollectIssueCacheMetrics sets the number of cached issues per product over all folders of the workspace. It is
+// registered as collect function of the metrics registry, so the caches are only counted when metrics are scraped.
+func CollectIssueCacheMetrics() {
+	metrics.CachedIssues.Reset()
+	w := Get()
+	if w == nil {
+		return
+	}
+	issuesByProduct := map[product.Product]int{}
+	for _, folder := range w.Folders() {
+		for p, count := range folder.ScanStatistics().IssuesByProduct {
+			issuesByProduct[p] += count
+		}
+	}
+	for p, count := range issuesByProduct {
+		metrics.CachedIssues.Set(float64(count), string(p))
+	}
+}
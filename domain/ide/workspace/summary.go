@@ -0,0 +1,88 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package workspace
+
+import (
+	"sort"
+	"time"
+
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/vulnmap"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/lsp"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/product"
+)
+
+// Summary aggregates the cached issues of the folder per product, so that clients don't need to count the issues of
+// all files themselves
+func (f *Folder) Summary() lsp.VulnmapSummaryParams {
+	summaries := map[product.Product]*lsp.ProductSummary{}
+	summaryFor := func(p product.Product) *lsp.ProductSummary {
+		summary, ok := summaries[p]
+		if !ok {
+			summary = &lsp.ProductSummary{Product: product.ToProductCodename(p)}
+			summaries[p] = summary
+		}
+		return summary
+	}
+
+	f.documentDiagnosticCache.Range(func(_ string, issues []vulnmap.Issue) bool {
+		for _, issue := range issues {
+			summary := summaryFor(issue.Product)
+			addToSummary(summary, issue)
+		}
+		return true
+	})
+	f.lastSuccessfulScans.Range(func(p product.Product, finished time.Time) bool {
+		summaryFor(p).LastSuccessfulScan = finished.UTC().Format(time.RFC3339)
+		return true
+	})
+
+	params := lsp.VulnmapSummaryParams{FolderPath: f.path, Products: []lsp.ProductSummary{}}
+	for _, summary := range summaries {
+		if summary.Product == "" {
+			continue // issues without a known product can't be attributed
+		}
+		params.Products = append(params.Products, *summary)
+	}
+	sort.Slice(params.Products, func(i, j int) bool {
+		return params.Products[i].Product < params.Products[j].Product
+	})
+	return params
+}
+
+func addToSummary(summary *lsp.ProductSummary, issue vulnmap.Issue) {
+	summary.Total++
+	switch issue.Severity {
+	case vulnmap.Critical:
+		summary.SeverityCount.Critical++
+	case vulnmap.High:
+		summary.SeverityCount.High++
+	case vulnmap.Medium:
+		summary.SeverityCount.Medium++
+	case vulnmap.Low:
+		summary.SeverityCount.Low++
+	}
+	if issue.IsFixable() {
+		summary.Fixable++
+	}
+	if issue.IsIgnored {
+		summary.Ignored++
+	}
+}
+
+func (f *Folder) sendSummary() {
+	f.notifier.Send(f.Summary())
+}
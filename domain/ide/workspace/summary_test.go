@@ -0,0 +1,100 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package workspace
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/ide/hover"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/vulnmap"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/lsp"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/notification"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/product"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/testutil"
+)
+
+func Test_Summary_CountsIssuesPerProduct(t *testing.T) {
+	testutil.UnitTest(t)
+	_, f := setupQueryWorkspace(t)
+
+	summary := f.Summary()
+
+	assert.Equal(t, f.Path(), summary.FolderPath)
+	assert.Equal(t, []lsp.ProductSummary{
+		{
+			Product:       "code",
+			SeverityCount: lsp.SeverityCount{High: 1},
+			Total:         1,
+			Ignored:       1,
+		},
+		{
+			Product:       "oss",
+			SeverityCount: lsp.SeverityCount{Critical: 1, Low: 1},
+			Total:         2,
+			Fixable:       1,
+		},
+	}, summary.Products)
+}
+
+func Test_processResults_SendsSummaryWithLastSuccessfulScan(t *testing.T) {
+	testutil.UnitTest(t)
+	notifier := notification.NewMockNotifier()
+	f := NewFolder(t.TempDir(), "folder", vulnmap.NewTestScanner(), hover.NewFakeHoverService(),
+		vulnmap.NewMockScanNotifier(), notifier)
+	finished := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+	filePath := filepath.Join(f.Path(), "main.tf")
+	issue := NewMockIssue("id1", filePath)
+	issue.Product = product.ProductInfrastructureAsCode
+
+	f.processResults(vulnmap.ScanData{
+		Product:           product.ProductInfrastructureAsCode,
+		Issues:            []vulnmap.Issue{issue},
+		TimestampFinished: finished,
+	})
+
+	assert.Contains(t, notifier.SentMessages(), lsp.VulnmapSummaryParams{
+		FolderPath: f.Path(),
+		Products: []lsp.ProductSummary{
+			{
+				Product:            "iac",
+				SeverityCount:      lsp.SeverityCount{Medium: 1},
+				Total:              1,
+				LastSuccessfulScan: "2023-10-01T12:00:00Z",
+			},
+		},
+	})
+}
+
+func Test_ClearDiagnostics_SendsEmptySummary(t *testing.T) {
+	testutil.UnitTest(t)
+	notifier := notification.NewMockNotifier()
+	f := NewFolder(t.TempDir(), "folder", vulnmap.NewTestScanner(), hover.NewFakeHoverService(),
+		vulnmap.NewMockScanNotifier(), notifier)
+	filePath := filepath.Join(f.Path(), "pom.xml")
+	f.documentDiagnosticCache.Store(filePath, []vulnmap.Issue{NewMockIssue("id1", filePath)})
+
+	f.ClearDiagnostics()
+
+	assert.Contains(t, notifier.SentMessages(), lsp.VulnmapSummaryParams{
+		FolderPath: f.Path(),
+		Products:   []lsp.ProductSummary{},
+	})
+}
@@ -18,20 +18,26 @@ package workspace
 
 import (
 	"context"
+	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/rs/zerolog/log"
 
 	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/ide/hover"
 	noti "github.com/khulnasoft-lab/vulnmap-ls/domain/ide/notification"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/audit"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/performance"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/vulnmap"
 	"github.com/khulnasoft-lab/vulnmap-ls/internal/lsp"
 	"github.com/khulnasoft-lab/vulnmap-ls/internal/product"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/progress"
 	"github.com/khulnasoft-lab/vulnmap-ls/internal/uri"
 )
 
+const scanDurationsFileName = "scan-durations.json"
+
 // todo can we do without a singleton?
 var instance *Workspace
 
@@ -46,6 +52,8 @@ type Workspace struct {
 	trustMutex          sync.Mutex
 	trustRequestOngoing bool // for debouncing
 	notifier            noti.Notifier
+	scanDurations       *progress.DurationHistory
+	auditTrail          audit.Trail
 }
 
 func New(instrumentor performance.Instrumentor,
@@ -53,6 +61,7 @@ func New(instrumentor performance.Instrumentor,
 	hoverService hover.Service,
 	scanNotifier vulnmap.ScanNotifier,
 	notifier noti.Notifier,
+	auditTrail audit.Trail,
 ) *Workspace {
 	return &Workspace{
 		folders:      make(map[string]*Folder, 0),
@@ -61,6 +70,7 @@ func New(instrumentor performance.Instrumentor,
 		hoverService: hoverService,
 		scanNotifier: scanNotifier,
 		notifier:     notifier,
+		auditTrail:   auditTrail,
 	}
 }
 
@@ -82,6 +92,7 @@ func (w *Workspace) RemoveFolder(folderPath string) {
 	}
 	folder.ClearDiagnosticsFromPathRecursively(folderPath)
 	delete(w.folders, folderPath)
+	config.CurrentConfig().RemoveToolchainShims(folderPath)
 }
 
 func (w *Workspace) DeleteFile(filePath string) {
@@ -93,6 +104,30 @@ func (w *Workspace) DeleteFile(filePath string) {
 	}
 }
 
+// RenameFile migrates the cached issues of a renamed or moved file or directory to its new path. If the new path is
+// not part of the workspace anymore, the issues are only cleared.
+func (w *Workspace) RenameFile(oldPath string, newPath string) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	oldFolder := w.GetFolderContaining(oldPath)
+	if oldFolder == nil {
+		return
+	}
+
+	renamedIssues := oldFolder.takeRenamedIssues(oldPath, newPath)
+	newFolder := w.GetFolderContaining(newPath)
+	if newFolder != nil {
+		newFolder.addRenamedIssues(renamedIssues)
+	}
+
+	oldFolder.sendScanResults("", oldFolder.filterCachedDiagnostics(), false)
+	oldFolder.sendSummary()
+	if newFolder != nil && newFolder != oldFolder {
+		newFolder.sendScanResults("", newFolder.filterCachedDiagnostics(), false)
+		newFolder.sendSummary()
+	}
+}
+
 func (w *Workspace) AddFolder(f *Folder) {
 	w.mutex.Lock()
 	defer w.mutex.Unlock()
@@ -129,12 +164,54 @@ func (w *Workspace) Folders() (folder []*Folder) {
 	return folders
 }
 
+// ScanWorkspace scans all trusted folders and reports the progress of all folders and products as one progress
 func (w *Workspace) ScanWorkspace(ctx context.Context) {
 	trusted, _ := w.GetFolderTrust()
+	if len(trusted) == 0 {
+		return
+	}
+
+	scanProgress := progress.NewAggregate("Vulnmap: scanning workspace", w.durationHistory())
+	if productsProvider, ok := w.scanner.(vulnmap.EnabledProductsProvider); ok {
+		for _, folder := range trusted {
+			for _, p := range productsProvider.EnabledProducts() {
+				scanProgress.AddStep(folder.Path(), p)
+			}
+		}
+	}
+	scanProgress.Begin()
+	ctx = progress.WithAggregate(ctx, scanProgress)
 
+	waitGroup := sync.WaitGroup{}
 	for _, folder := range trusted {
-		go folder.ScanFolder(ctx)
+		waitGroup.Add(1)
+		go func(f *Folder) {
+			defer waitGroup.Done()
+			f.ScanFolder(ctx)
+		}(folder)
+	}
+	go func() {
+		waitGroup.Wait()
+		scanProgress.End()
+	}()
+}
+
+func (w *Workspace) durationHistory() *progress.DurationHistory {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.scanDurations == nil {
+		historyFile := ""
+		if dataDir := config.CurrentConfig().CliSettings().DefaultBinaryInstallPath(); dataDir != "" {
+			historyFile = filepath.Join(dataDir, scanDurationsFileName)
+		}
+		w.scanDurations = progress.NewDurationHistory(historyFile)
 	}
+	return w.scanDurations
+}
+
+// ScanDurations returns the average scan durations by folder and product
+func (w *Workspace) ScanDurations() map[string]time.Duration {
+	return w.durationHistory().All()
 }
 
 // ChangeWorkspaceFolders clears the "Removed" folders, adds the "New" folders,
@@ -169,6 +246,7 @@ func (w *Workspace) TrustFoldersAndScan(ctx context.Context, foldersToBeTrusted
 		// we need to append and set the trusted path to the config before the scan, as the scan is checking for trust
 		trustedFolderPaths = append(trustedFolderPaths, f.Path())
 		currentConfig.SetTrustedFolders(trustedFolderPaths)
+		w.auditTrail.TrustChanged(audit.TrustProperties{Folder: f.Path(), Decision: audit.TrustGranted})
 		go f.ScanFolder(ctx)
 	}
 	w.notifier.Send(lsp.VulnmapTrustedFoldersParams{TrustedFolders: trustedFolderPaths})
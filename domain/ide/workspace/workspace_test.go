@@ -18,12 +18,16 @@ package workspace
 
 import (
 	"context"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 
 	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/ide/converter"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/ide/hover"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/audit"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/performance"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/vulnmap"
 	"github.com/khulnasoft-lab/vulnmap-ls/internal/lsp"
@@ -39,7 +43,7 @@ func Test_GetFolderTrust_shouldReturnTrustedAndUntrustedFolders(t *testing.T) {
 	scanner := &vulnmap.TestScanner{}
 	scanNotifier := vulnmap.NewMockScanNotifier()
 	notifier := notification.NewNotifier()
-	w := New(performance.NewInstrumentor(), scanner, nil, nil, notifier)
+	w := New(performance.NewInstrumentor(), scanner, nil, nil, notifier, audit.NewTestTrail())
 	config.CurrentConfig().SetTrustedFolderFeatureEnabled(true)
 	config.CurrentConfig().SetTrustedFolders([]string{trustedDummy})
 	w.AddFolder(NewFolder(trustedDummy, trustedDummy, scanner, nil, scanNotifier, notifier))
@@ -58,7 +62,8 @@ func Test_TrustFoldersAndScan_shouldAddFoldersToTrustedFoldersAndTriggerScan(t *
 	scanner := &vulnmap.TestScanner{}
 	scanNotifier := vulnmap.NewMockScanNotifier()
 	notifier := notification.NewNotifier()
-	w := New(performance.NewInstrumentor(), scanner, nil, nil, notifier)
+	auditTrail := audit.NewTestTrail()
+	w := New(performance.NewInstrumentor(), scanner, nil, nil, notifier, auditTrail)
 	config.CurrentConfig().SetTrustedFolderFeatureEnabled(true)
 	trustedFolder := NewFolder(trustedDummy, trustedDummy, scanner, nil, scanNotifier, notifier)
 	w.AddFolder(trustedFolder)
@@ -69,6 +74,7 @@ func Test_TrustFoldersAndScan_shouldAddFoldersToTrustedFoldersAndTriggerScan(t *
 
 	assert.Contains(t, config.CurrentConfig().TrustedFolders(), trustedFolder.path)
 	assert.NotContains(t, config.CurrentConfig().TrustedFolders(), untrustedFolder.path)
+	assert.Equal(t, []any{audit.TrustProperties{Folder: trustedDummy, Decision: audit.TrustGranted}}, auditTrail.Events())
 	assert.Eventually(t, func() bool {
 		return scanner.Calls() == 1
 	}, time.Second, time.Millisecond, "scanner should be called after trust is granted")
@@ -84,7 +90,7 @@ func Test_AddAndRemoveFoldersAndTriggerScan(t *testing.T) {
 
 	scanner := &vulnmap.TestScanner{}
 	scanNotifier := vulnmap.NewMockScanNotifier()
-	w := New(performance.NewInstrumentor(), scanner, nil, scanNotifier, notification.NewNotifier())
+	w := New(performance.NewInstrumentor(), scanner, nil, scanNotifier, notification.NewNotifier(), audit.NewTestTrail())
 	toBeRemovedFolder := NewFolder(toBeRemovedAbsolutePathAfterConversions, toBeRemoved, scanner, nil, scanNotifier, notification.NewNotifier())
 	w.AddFolder(toBeRemovedFolder)
 
@@ -113,13 +119,70 @@ func Test_AddAndRemoveFoldersAndTriggerScan(t *testing.T) {
 	}, time.Second, time.Millisecond, "scanner should be called after trust is granted")
 }
 
+func Test_RenameFile_MovesCachedIssuesToNewPath(t *testing.T) {
+	testutil.UnitTest(t)
+	notifier := notification.NewMockNotifier()
+	scanNotifier := vulnmap.NewMockScanNotifier()
+	scanner := vulnmap.NewTestScanner()
+	w := New(performance.NewInstrumentor(), scanner, hover.NewFakeHoverService(), scanNotifier, notifier, audit.NewTestTrail())
+	folderPath := filepath.Join(t.TempDir(), "folder")
+	f := NewFolder(folderPath, "folder", scanner, hover.NewFakeHoverService(), scanNotifier, notifier)
+	w.AddFolder(f)
+	oldPath := filepath.Join(folderPath, "old", "package.json")
+	newPath := filepath.Join(folderPath, "new", "package.json")
+	issue := NewMockIssue("id1", oldPath)
+	edit := &vulnmap.WorkspaceEdit{Changes: map[string][]vulnmap.TextEdit{oldPath: {{NewText: "fixed"}}}}
+	issue.CodeActions = []vulnmap.CodeAction{{Title: "Upgrade", Edit: edit}}
+	issue.CodelensCommands = []vulnmap.CommandData{{CommandId: vulnmap.CodeFixCommand, Arguments: []any{"id", oldPath}}}
+	f.documentDiagnosticCache.Store(oldPath, []vulnmap.Issue{issue})
+
+	w.RenameFile(filepath.Join(folderPath, "old"), filepath.Join(folderPath, "new"))
+
+	assert.Nil(t, f.DocumentDiagnosticsFromCache(oldPath))
+	movedIssues := f.DocumentDiagnosticsFromCache(newPath)
+	assert.Len(t, movedIssues, 1)
+	assert.Equal(t, newPath, movedIssues[0].AffectedFilePath)
+	assert.Equal(t, []vulnmap.TextEdit{{NewText: "fixed"}}, movedIssues[0].CodeActions[0].Edit.Changes[newPath])
+	assert.NotContains(t, movedIssues[0].CodeActions[0].Edit.Changes, oldPath)
+	assert.Equal(t, []any{"id", newPath}, movedIssues[0].CodelensCommands[0].Arguments)
+	assert.Contains(t, notifier.SentMessages(), lsp.PublishDiagnosticsParams{
+		URI:         uri.PathToUri(oldPath),
+		Diagnostics: []lsp.Diagnostic{},
+	})
+	assert.Contains(t, notifier.SentMessages(), lsp.PublishDiagnosticsParams{
+		URI:         uri.PathToUri(newPath),
+		Diagnostics: converter.ToDiagnostics(movedIssues),
+	})
+}
+
+func Test_RenameFile_MovesCachedIssuesToOtherFolder(t *testing.T) {
+	testutil.UnitTest(t)
+	notifier := notification.NewMockNotifier()
+	scanNotifier := vulnmap.NewMockScanNotifier()
+	scanner := vulnmap.NewTestScanner()
+	w := New(performance.NewInstrumentor(), scanner, hover.NewFakeHoverService(), scanNotifier, notifier, audit.NewTestTrail())
+	tempDir := t.TempDir()
+	source := NewFolder(filepath.Join(tempDir, "source"), "source", scanner, hover.NewFakeHoverService(), scanNotifier, notifier)
+	target := NewFolder(filepath.Join(tempDir, "target"), "target", scanner, hover.NewFakeHoverService(), scanNotifier, notifier)
+	w.AddFolder(source)
+	w.AddFolder(target)
+	oldPath := filepath.Join(source.Path(), "pom.xml")
+	newPath := filepath.Join(target.Path(), "pom.xml")
+	source.documentDiagnosticCache.Store(oldPath, []vulnmap.Issue{NewMockIssue("id1", oldPath)})
+
+	w.RenameFile(oldPath, newPath)
+
+	assert.Nil(t, source.DocumentDiagnosticsFromCache(oldPath))
+	assert.Len(t, target.DocumentDiagnosticsFromCache(newPath), 1)
+}
+
 func Test_Get(t *testing.T) {
-	New(nil, nil, nil, nil, nil)
+	New(nil, nil, nil, nil, nil, nil)
 	assert.Equal(t, instance, Get())
 }
 
 func Test_Set(t *testing.T) {
-	w := New(nil, nil, nil, nil, nil)
+	w := New(nil, nil, nil, nil, nil, nil)
 	Set(w)
 	assert.Equal(t, w, instance)
 }
@@ -0,0 +1,55 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package audit
+
+import (
+	"sync"
+)
+
+var _ Trail = &TestTrail{} // Explicit interface implementation
+
+func NewTestTrail() *TestTrail {
+	return &TestTrail{}
+}
+
+// TestTrail keeps the recorded events in memory, for tests
+type TestTrail struct {
+	events []any
+	mutex  sync.Mutex
+}
+
+func (t *TestTrail) Events() []any {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return append([]any{}, t.events...)
+}
+
+func (t *TestTrail) ScanCompleted(properties ScanProperties) { t.record(properties) }
+
+func (t *TestTrail) FixApplied(properties FixProperties) { t.record(properties) }
+
+func (t *TestTrail) IgnoreAdded(properties IgnoreProperties) { t.record(properties) }
+
+func (t *TestTrail) TrustChanged(properties TrustProperties) { t.record(properties) }
+
+func (t *TestTrail) CliChanged(properties CliProperties) { t.record(properties) }
+
+func (t *TestTrail) record(event any) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.events = append(t.events, event)
+}
@@ -0,0 +1,88 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package audit
+
+// Trail records security-relevant actions of the language server for compliance, independent of analytics
+type Trail interface {
+	ScanCompleted(properties ScanProperties)
+	FixApplied(properties FixProperties)
+	IgnoreAdded(properties IgnoreProperties)
+	TrustChanged(properties TrustProperties)
+	CliChanged(properties CliProperties)
+}
+
+type FixKind string
+
+// Autofix is a Vulnmap Code fix applied to a file. The language server doesn't apply dependency upgrades, so they
+// aren't recorded.
+const Autofix FixKind = "autofix"
+
+type CliChange string
+
+const (
+	CliInstalled  CliChange = "install"
+	CliUpdated    CliChange = "update"
+	CliRolledBack CliChange = "rollback"
+)
+
+type TrustDecision string
+
+const (
+	TrustGranted TrustDecision = "granted"
+	TrustRevoked TrustDecision = "revoked"
+)
+
+type ScanProperties struct {
+	Folder string `json:"folder"`
+	// Path is the scanned file or folder
+	Path       string `json:"path"`
+	Product    string `json:"product"`
+	CliVersion string `json:"cliVersion,omitempty"`
+	DurationMs int64  `json:"durationMs"`
+	IssueCount int    `json:"issueCount"`
+	// IssuesBySeverity counts the issues per severity, e.g. "high"
+	IssuesBySeverity map[string]int `json:"issuesBySeverity"`
+	Error            string         `json:"error,omitempty"`
+}
+
+type FixProperties struct {
+	Kind FixKind `json:"kind"`
+	// IssueId is the fixed issue
+	IssueId string `json:"issueId"`
+	// FilePath is the changed file
+	FilePath string `json:"filePath"`
+	// FixId is the code action of an autofix
+	FixId string `json:"fixId"`
+}
+
+type IgnoreProperties struct {
+	IssueId  string `json:"issueId"`
+	FilePath string `json:"filePath"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+type TrustProperties struct {
+	Folder   string        `json:"folder"`
+	Decision TrustDecision `json:"decision"`
+}
+
+type CliProperties struct {
+	Change  CliChange `json:"change"`
+	Version string    `json:"version,omitempty"`
+	CliPath string    `json:"cliPath"`
+	Error   string    `json:"error,omitempty"`
+}
@@ -21,3 +21,12 @@ type ErrorReporter interface {
 	CaptureError(err error) bool
 	CaptureErrorAndReportAsIssue(path string, err error) bool
 }
+
+// BackgroundReporter is implemented by error reporters that work in the background, e.g. to send errors that were
+// spooled while the error reporting backend couldn't be reached
+type BackgroundReporter interface {
+	// Start begins the periodic background work
+	Start()
+	// Stop ends the background work and waits for it to finish
+	Stop()
+}
@@ -0,0 +1,146 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	metricsPath       = "/metrics"
+	contentType       = "text/plain; version=0.0.4; charset=utf-8"
+	readHeaderTimeout = 5 * time.Second
+	shutdownTimeout   = 5 * time.Second
+)
+
+// Endpoint serves the metrics of a registry over HTTP on a loopback address, for scraping by Prometheus
+type Endpoint struct {
+	mutex    sync.Mutex
+	registry *Registry
+	address  string
+	server   *http.Server
+	listener net.Listener
+}
+
+var defaultEndpoint = NewEndpoint(defaultRegistry)
+
+// DefaultEndpoint returns the endpoint that serves the default registry
+func DefaultEndpoint() *Endpoint {
+	return defaultEndpoint
+}
+
+func NewEndpoint(registry *Registry) *Endpoint {
+	return &Endpoint{registry: registry}
+}
+
+// Configure serves the metrics on the given address, e.g. localhost:9464, restarting the server if the address
+// changed. An empty address stops the server. Only loopback addresses are accepted.
+func (e *Endpoint) Configure(address string) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if address == e.address {
+		return nil
+	}
+	e.stop()
+	if address == "" {
+		return nil
+	}
+	if err := validateLoopback(address); err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.Handle(metricsPath, e)
+	e.server = &http.Server{Handler: mux, ReadHeaderTimeout: readHeaderTimeout}
+	e.listener = listener
+	e.address = address
+	go func(server *http.Server) {
+		serveErr := server.Serve(listener)
+		if serveErr != nil && !errors.Is(serveErr, http.ErrServerClosed) {
+			log.Err(serveErr).Str("method", "metrics.Endpoint.Configure").Msg("metrics endpoint stopped")
+		}
+	}(e.server)
+	log.Info().Str("method", "metrics.Endpoint.Configure").Msgf("serving metrics on http://%s%s", listener.Addr(), metricsPath)
+	return nil
+}
+
+// Addr returns the address the endpoint listens on, nil if it's not running
+func (e *Endpoint) Addr() net.Addr {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if e.listener == nil {
+		return nil
+	}
+	return e.listener.Addr()
+}
+
+// Stop stops serving the metrics
+func (e *Endpoint) Stop() {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.stop()
+}
+
+// stop shuts the server down. The mutex must be held.
+func (e *Endpoint) stop() {
+	if e.server != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := e.server.Shutdown(ctx); err != nil {
+			log.Warn().Err(err).Str("method", "metrics.Endpoint.stop").Msg("couldn't stop metrics endpoint")
+		}
+	}
+	e.server, e.listener, e.address = nil, nil, ""
+}
+
+func (e *Endpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	if err := e.registry.WriteText(w); err != nil {
+		log.Debug().Err(err).Str("method", "metrics.Endpoint.ServeHTTP").Msg("couldn't write metrics")
+	}
+}
+
+// validateLoopback returns an error if address isn't a loopback address, so metrics aren't exposed to the network
+func validateLoopback(address string) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+	return fmt.Errorf("the metrics endpoint must listen on a loopback address, e.g. localhost:9464, not %s", address)
+}
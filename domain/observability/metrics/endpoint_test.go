@@ -0,0 +1,61 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Endpoint_ServesMetrics(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounter("test_total", "Test counter").Inc()
+	endpoint := NewEndpoint(r)
+	require.NoError(t, endpoint.Configure("127.0.0.1:0"))
+	defer endpoint.Stop()
+
+	response, err := http.Get("http://" + endpoint.Addr().String() + "/metrics")
+	require.NoError(t, err)
+	defer func() { _ = response.Body.Close() }()
+	body, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, contentType, response.Header.Get("Content-Type"))
+	assert.Contains(t, string(body), "test_total 1")
+}
+
+func Test_Endpoint_RejectsNonLoopbackAddresses(t *testing.T) {
+	endpoint := NewEndpoint(NewRegistry())
+
+	assert.Error(t, endpoint.Configure("0.0.0.0:9464"))
+	assert.Error(t, endpoint.Configure("example.com:9464"))
+	assert.Nil(t, endpoint.Addr())
+}
+
+func Test_Endpoint_EmptyAddressStopsServer(t *testing.T) {
+	endpoint := NewEndpoint(NewRegistry())
+	require.NoError(t, endpoint.Configure("localhost:0"))
+
+	require.NoError(t, endpoint.Configure(""))
+
+	assert.Nil(t, endpoint.Addr())
+}
@@ -0,0 +1,51 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metrics
+
+import (
+	"context"
+
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/performance"
+)
+
+// instrumentor records the duration of finished spans in SpanDuration and passes them on to another instrumentor
+type instrumentor struct {
+	delegate performance.Instrumentor
+}
+
+func NewInstrumentor(delegate performance.Instrumentor) performance.Instrumentor {
+	return &instrumentor{delegate: delegate}
+}
+
+func (i *instrumentor) StartSpan(ctx context.Context, operation string) performance.Span {
+	return i.delegate.StartSpan(ctx, operation)
+}
+
+func (i *instrumentor) NewTransaction(ctx context.Context, txName string, operation string) performance.Span {
+	return i.delegate.NewTransaction(ctx, txName, operation)
+}
+
+func (i *instrumentor) Finish(span performance.Span) {
+	i.delegate.Finish(span)
+	SpanDuration.Observe(float64(span.GetDurationMs())/1000, span.GetOperation())
+}
+
+func (i *instrumentor) Flush() {
+	if flusher, ok := i.delegate.(performance.Flusher); ok {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,39 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/performance"
+)
+
+func Test_Instrumentor_Finish_RecordsSpanDuration(t *testing.T) {
+	i := NewInstrumentor(performance.NewInstrumentor())
+
+	span := i.StartSpan(context.Background(), "test.operation")
+	i.Finish(span)
+
+	var buffer bytes.Buffer
+	require.NoError(t, Default().WriteText(&buffer))
+	assert.Contains(t, buffer.String(), `vulnmap_span_duration_seconds_count{operation="test.operation"} 1`)
+}
@@ -0,0 +1,53 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metrics
+
+var defaultRegistry = NewRegistry()
+
+// durationBuckets are the upper bounds in seconds of the buckets of duration histograms, from fast requests to
+// long-running scans
+var durationBuckets = []float64{0.005, 0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60, 300, 900}
+
+// Default returns the registry that the language server exposes on the metrics endpoint
+func Default() *Registry {
+	return defaultRegistry
+}
+
+var (
+	Scans = defaultRegistry.NewCounter("vulnmap_scans_total",
+		"Number of finished scans by product and result", "product", "result")
+	ScanDuration = defaultRegistry.NewHistogram("vulnmap_scan_duration_seconds",
+		"Duration of scans by product", durationBuckets, "product")
+	SpanDuration = defaultRegistry.NewHistogram("vulnmap_span_duration_seconds",
+		"Duration of instrumented operations", durationBuckets, "operation")
+	CliQueueDepth = defaultRegistry.NewGauge("vulnmap_cli_queue_depth",
+		"Number of CLI runs waiting for the concurrency limit")
+	CliRunning = defaultRegistry.NewGauge("vulnmap_cli_running",
+		"Number of CLI runs in progress")
+	CachedIssues = defaultRegistry.NewGauge("vulnmap_cached_issues",
+		"Number of issues in the issue cache by product", "product")
+	CliResultCacheEntries = defaultRegistry.NewGauge("vulnmap_cli_result_cache_entries",
+		"Number of cached CLI results")
+	RpcDuration = defaultRegistry.NewHistogram("vulnmap_jsonrpc_request_duration_seconds",
+		"Duration of JSON-RPC requests by method", durationBuckets, "method")
+	RpcErrors = defaultRegistry.NewCounter("vulnmap_jsonrpc_request_errors_total",
+		"Number of JSON-RPC requests answered with an error by method", "method")
+	BundleUploadBytes = defaultRegistry.NewCounter("vulnmap_bundle_upload_bytes_total",
+		"Number of bytes of file content uploaded to Vulnmap Code")
+	BundleUploadErrors = defaultRegistry.NewCounter("vulnmap_bundle_upload_errors_total",
+		"Number of failed uploads of Vulnmap Code bundles")
+)
@@ -0,0 +1,280 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+type metricType string
+
+const (
+	counterType   metricType = "counter"
+	gaugeType     metricType = "gauge"
+	histogramType metricType = "histogram"
+)
+
+// Registry holds metrics and writes them in the Prometheus text exposition format
+type Registry struct {
+	mutex      sync.Mutex
+	families   map[string]*family
+	collectors []func()
+}
+
+type family struct {
+	mutex      sync.Mutex
+	name       string
+	help       string
+	metricType metricType
+	labelNames []string
+	buckets    []float64
+	series     map[string]*series
+}
+
+type series struct {
+	labelValues  []string
+	value        float64
+	bucketCounts []uint64
+	count        uint64
+}
+
+func NewRegistry() *Registry {
+	return &Registry{families: map[string]*family{}}
+}
+
+// Counter is a metric that only increases, e.g. the number of scans
+type Counter struct{ family *family }
+
+// Gauge is a metric that can go up and down, e.g. the number of queued CLI runs
+type Gauge struct{ family *family }
+
+// Histogram counts observations in buckets, e.g. durations
+type Histogram struct{ family *family }
+
+func (r *Registry) NewCounter(name string, help string, labelNames ...string) *Counter {
+	return &Counter{family: r.register(name, help, counterType, nil, labelNames)}
+}
+
+func (r *Registry) NewGauge(name string, help string, labelNames ...string) *Gauge {
+	return &Gauge{family: r.register(name, help, gaugeType, nil, labelNames)}
+}
+
+// NewHistogram returns a histogram with the given upper bounds of its buckets, in ascending order
+func (r *Registry) NewHistogram(name string, help string, buckets []float64, labelNames ...string) *Histogram {
+	return &Histogram{family: r.register(name, help, histogramType, buckets, labelNames)}
+}
+
+// OnCollect registers a function that is called before the metrics are written, e.g. to set gauges that are
+// expensive to keep up to date
+func (r *Registry) OnCollect(collect func()) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.collectors = append(r.collectors, collect)
+}
+
+// register returns the metric family with the given name, registering it if it doesn't exist yet
+func (r *Registry) register(
+	name string,
+	help string,
+	metricType metricType,
+	buckets []float64,
+	labelNames []string,
+) *family {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if f, ok := r.families[name]; ok {
+		return f
+	}
+	f := &family{
+		name:       name,
+		help:       help,
+		metricType: metricType,
+		labelNames: labelNames,
+		buckets:    buckets,
+		series:     map[string]*series{},
+	}
+	r.families[name] = f
+	return f
+}
+
+// Inc adds one to the counter with the given label values
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds value to the counter with the given label values. Negative values are ignored.
+func (c *Counter) Add(value float64, labelValues ...string) {
+	if value < 0 {
+		return
+	}
+	c.family.update(labelValues, func(s *series) { s.value += value })
+}
+
+// Set sets the gauge with the given label values
+func (g *Gauge) Set(value float64, labelValues ...string) {
+	g.family.update(labelValues, func(s *series) { s.value = value })
+}
+
+// Add adds value to the gauge with the given label values, use negative values to decrease it
+func (g *Gauge) Add(value float64, labelValues ...string) {
+	g.family.update(labelValues, func(s *series) { s.value += value })
+}
+
+// Reset removes all label values of the gauge, e.g. before it is set again in a collect function
+func (g *Gauge) Reset() {
+	g.family.mutex.Lock()
+	defer g.family.mutex.Unlock()
+	g.family.series = map[string]*series{}
+}
+
+// Observe adds value to the histogram with the given label values
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	h.family.update(labelValues, func(s *series) {
+		if s.bucketCounts == nil {
+			s.bucketCounts = make([]uint64, len(h.family.buckets))
+		}
+		for i, upperBound := range h.family.buckets {
+			if value <= upperBound {
+				s.bucketCounts[i]++
+			}
+		}
+		s.count++
+		s.value += value
+	})
+}
+
+func (f *family) update(labelValues []string, update func(s *series)) {
+	if len(labelValues) != len(f.labelNames) {
+		log.Warn().Str("method", "metrics.update").Str("metric", f.name).
+			Msgf("expected %d label values, got %d", len(f.labelNames), len(labelValues))
+		return
+	}
+	key := strings.Join(labelValues, "\xff")
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{labelValues: labelValues}
+		f.series[key] = s
+	}
+	update(s)
+}
+
+// WriteText calls the collect functions and writes all metrics in the Prometheus text exposition format
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mutex.Lock()
+	collectors := append([]func(){}, r.collectors...)
+	families := make([]*family, 0, len(r.families))
+	for _, f := range r.families {
+		families = append(families, f)
+	}
+	r.mutex.Unlock()
+
+	for _, collect := range collectors {
+		collect()
+	}
+	sort.Slice(families, func(i, j int) bool { return families[i].name < families[j].name })
+
+	writer := bufio.NewWriter(w)
+	for _, f := range families {
+		f.write(writer)
+	}
+	return writer.Flush()
+}
+
+func (f *family) write(w *bufio.Writer) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if len(f.series) == 0 {
+		return
+	}
+	_, _ = fmt.Fprintf(w, "# HELP %s %s\n", f.name, escape(f.help, false))
+	_, _ = fmt.Fprintf(w, "# TYPE %s %s\n", f.name, f.metricType)
+
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		s := f.series[key]
+		labels := f.labels(s.labelValues)
+		if f.metricType != histogramType {
+			_, _ = fmt.Fprintf(w, "%s%s %s\n", f.name, labels.format(), formatValue(s.value))
+			continue
+		}
+		for i, upperBound := range f.buckets {
+			bucketLabels := append(labels, label{"le", formatValue(upperBound)})
+			_, _ = fmt.Fprintf(w, "%s_bucket%s %d\n", f.name, bucketLabels.format(), s.bucketCounts[i])
+		}
+		_, _ = fmt.Fprintf(w, "%s_bucket%s %d\n", f.name, append(labels, label{"le", "+Inf"}).format(), s.count)
+		_, _ = fmt.Fprintf(w, "%s_sum%s %s\n", f.name, labels.format(), formatValue(s.value))
+		_, _ = fmt.Fprintf(w, "%s_count%s %d\n", f.name, labels.format(), s.count)
+	}
+}
+
+type label struct {
+	name  string
+	value string
+}
+
+type labels []label
+
+func (f *family) labels(labelValues []string) labels {
+	result := make(labels, 0, len(labelValues)+1)
+	for i, name := range f.labelNames {
+		result = append(result, label{name, labelValues[i]})
+	}
+	return result
+}
+
+func (l labels) format() string {
+	if len(l) == 0 {
+		return ""
+	}
+	formatted := make([]string, 0, len(l))
+	for _, lbl := range l {
+		formatted = append(formatted, fmt.Sprintf("%s=\"%s\"", lbl.name, escape(lbl.value, true)))
+	}
+	return "{" + strings.Join(formatted, ",") + "}"
+}
+
+// escape escapes backslashes and line breaks, and in label values also double quotes
+func escape(s string, quotes bool) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	if quotes {
+		s = strings.ReplaceAll(s, `"`, `\"`)
+	}
+	return s
+}
+
+func formatValue(value float64) string {
+	if math.IsInf(value, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
@@ -0,0 +1,89 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metrics
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeText(t *testing.T, r *Registry) string {
+	t.Helper()
+	var buffer bytes.Buffer
+	require.NoError(t, r.WriteText(&buffer))
+	return buffer.String()
+}
+
+func Test_WriteText_Counter(t *testing.T) {
+	r := NewRegistry()
+	counter := r.NewCounter("test_total", "Test counter", "product", "result")
+
+	counter.Inc("code", "success")
+	counter.Add(2, "code", "success")
+	counter.Add(-1, "code", "success")
+	counter.Inc("oss", "error")
+
+	assert.Equal(t, `# HELP test_total Test counter
+# TYPE test_total counter
+test_total{product="code",result="success"} 3
+test_total{product="oss",result="error"} 1
+`, writeText(t, r))
+}
+
+func Test_WriteText_Histogram(t *testing.T) {
+	r := NewRegistry()
+	histogram := r.NewHistogram("test_seconds", "Test histogram", []float64{0.5, 1}, "method")
+
+	histogram.Observe(0.25, "initialize")
+	histogram.Observe(0.75, "initialize")
+	histogram.Observe(2, "initialize")
+
+	assert.Equal(t, `# HELP test_seconds Test histogram
+# TYPE test_seconds histogram
+test_seconds_bucket{method="initialize",le="0.5"} 1
+test_seconds_bucket{method="initialize",le="1"} 2
+test_seconds_bucket{method="initialize",le="+Inf"} 3
+test_seconds_sum{method="initialize"} 3
+test_seconds_count{method="initialize"} 3
+`, writeText(t, r))
+}
+
+func Test_WriteText_CallsCollectFunctionsAndSkipsEmptyMetrics(t *testing.T) {
+	r := NewRegistry()
+	gauge := r.NewGauge("test_gauge", "Test gauge")
+	r.NewCounter("test_unused_total", "Never incremented")
+	r.OnCollect(func() { gauge.Set(42) })
+
+	assert.Equal(t, "# HELP test_gauge Test gauge\n# TYPE test_gauge gauge\ntest_gauge 42\n", writeText(t, r))
+}
+
+func Test_WriteText_EscapesLabelValues(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounter("test_total", "Test counter", "path").Inc("C:\\path \"quoted\"\n")
+
+	assert.Contains(t, writeText(t, r), `test_total{path="C:\\path \"quoted\"\n"} 1`)
+}
+
+func Test_Update_IgnoresWrongNumberOfLabelValues(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounter("test_total", "Test counter", "product").Inc()
+
+	assert.Empty(t, writeText(t, r))
+}
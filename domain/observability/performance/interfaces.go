@@ -37,3 +37,8 @@ type Span interface {
 
 	GetDurationMs() int64
 }
+
+// Flusher is implemented by instrumentors that buffer spans, so they can be exported before the server exits
+type Flusher interface {
+	Flush()
+}
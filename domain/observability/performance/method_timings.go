@@ -0,0 +1,139 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package performance
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// maxRecentCalls is the number of calls that MethodTimings keeps in addition to the aggregated timings
+	maxRecentCalls = 100
+	// maxPendingCalls bounds the calls waiting for a response, e.g. if responses are never logged for cancelled calls
+	maxPendingCalls = 1000
+)
+
+// MethodTiming aggregates the durations of the calls of a JSON-RPC method
+type MethodTiming struct {
+	Method string `json:"method"`
+	Count  int    `json:"count"`
+	Errors int    `json:"errors"`
+	AvgMs  int64  `json:"avgMs"`
+	MaxMs  int64  `json:"maxMs"`
+	total  time.Duration
+}
+
+// Call is a single JSON-RPC call with its duration
+type Call struct {
+	Method     string    `json:"method"`
+	Start      time.Time `json:"start"`
+	DurationMs int64     `json:"durationMs"`
+	Error      bool      `json:"error"`
+}
+
+type pendingCall struct {
+	method string
+	start  time.Time
+}
+
+// MethodTimings measures how long the language server takes to answer JSON-RPC requests
+type MethodTimings struct {
+	mutex   sync.Mutex
+	pending map[string]pendingCall
+	methods map[string]*MethodTiming
+	recent  []Call
+	now     func() time.Time
+}
+
+var rpcTimings = NewMethodTimings()
+
+// RpcTimings returns the timings of the requests the language server received
+func RpcTimings() *MethodTimings {
+	return rpcTimings
+}
+
+func NewMethodTimings() *MethodTimings {
+	return &MethodTimings{
+		pending: map[string]pendingCall{},
+		methods: map[string]*MethodTiming{},
+		now:     time.Now,
+	}
+}
+
+// Start records the start of the request with the given id
+func (m *MethodTimings) Start(id string, method string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if len(m.pending) >= maxPendingCalls {
+		m.pending = map[string]pendingCall{}
+	}
+	m.pending[id] = pendingCall{method: method, start: m.now()}
+}
+
+// Finish records the response to the request with the given id and returns the finished call. Responses without a
+// started request are ignored and return false.
+func (m *MethodTimings) Finish(id string, failed bool) (Call, bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	call, ok := m.pending[id]
+	if !ok {
+		return Call{}, false
+	}
+	delete(m.pending, id)
+	duration := m.now().Sub(call.start)
+
+	timing, ok := m.methods[call.method]
+	if !ok {
+		timing = &MethodTiming{Method: call.method}
+		m.methods[call.method] = timing
+	}
+	timing.Count++
+	if failed {
+		timing.Errors++
+	}
+	timing.total += duration
+	timing.AvgMs = (timing.total / time.Duration(timing.Count)).Milliseconds()
+	timing.MaxMs = max(timing.MaxMs, duration.Milliseconds())
+
+	finished := Call{Method: call.method, Start: call.start, DurationMs: duration.Milliseconds(), Error: failed}
+	m.recent = append(m.recent, finished)
+	if len(m.recent) > maxRecentCalls {
+		m.recent = m.recent[len(m.recent)-maxRecentCalls:]
+	}
+	return finished, true
+}
+
+// Methods returns the aggregated timings, slowest methods first
+func (m *MethodTimings) Methods() []MethodTiming {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	timings := make([]MethodTiming, 0, len(m.methods))
+	for _, timing := range m.methods {
+		timings = append(timings, *timing)
+	}
+	sort.Slice(timings, func(i, j int) bool { return timings[i].MaxMs > timings[j].MaxMs })
+	return timings
+}
+
+// Recent returns the last calls, oldest first
+func (m *MethodTimings) Recent() []Call {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return append([]Call{}, m.recent...)
+}
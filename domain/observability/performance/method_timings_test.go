@@ -0,0 +1,63 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package performance
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMethodTimings(t *testing.T) {
+	timings := NewMethodTimings()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	timings.now = func() time.Time { return now }
+
+	call := func(id string, method string, duration time.Duration, failed bool) {
+		timings.Start(id, method)
+		now = now.Add(duration)
+		timings.Finish(id, failed)
+	}
+	call("1", "textDocument/hover", 10*time.Millisecond, false)
+	call("2", "textDocument/hover", 30*time.Millisecond, true)
+	call("3", "textDocument/codeAction", 100*time.Millisecond, false)
+	timings.Finish("unknown", false)
+
+	methods := timings.Methods()
+	require.Len(t, methods, 2)
+	assert.Equal(t, "textDocument/codeAction", methods[0].Method)
+	assert.Equal(t, MethodTiming{Method: "textDocument/hover", Count: 2, Errors: 1, AvgMs: 20, MaxMs: 30,
+		total: 40 * time.Millisecond}, methods[1])
+
+	recent := timings.Recent()
+	require.Len(t, recent, 3)
+	assert.Equal(t, Call{Method: "textDocument/hover", Start: recent[1].Start, DurationMs: 30, Error: true}, recent[1])
+}
+
+func TestMethodTimings_KeepsRecentCalls(t *testing.T) {
+	timings := NewMethodTimings()
+
+	for i := 0; i < maxRecentCalls+10; i++ {
+		timings.Start("id", "method")
+		timings.Finish("id", false)
+	}
+
+	assert.Len(t, timings.Recent(), maxRecentCalls)
+	assert.Equal(t, maxRecentCalls+10, timings.Methods()[0].Count)
+}
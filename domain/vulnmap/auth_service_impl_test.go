@@ -30,6 +30,7 @@ import (
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/ide/converter"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/ide/hover"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/ide/workspace"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/audit"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/error_reporting"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/performance"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/ux"
@@ -123,7 +124,7 @@ func Test_Logout(t *testing.T) {
 	hoverService := hover.NewFakeHoverService()
 	scanner := vulnmap.NewTestScanner()
 	scanNotifier, _ := appNotification.NewScanNotifier(notifier)
-	w := workspace.New(performance.NewInstrumentor(), scanner, hoverService, scanNotifier, notifier)
+	w := workspace.New(performance.NewInstrumentor(), scanner, hoverService, scanNotifier, notifier, audit.NewTestTrail())
 	workspace.Set(w)
 	f := workspace.NewFolder("", "", scanner, hoverService, scanNotifier, notifier)
 	w.AddFolder(f)
@@ -134,7 +135,7 @@ func Test_Logout(t *testing.T) {
 	scanner.AddTestIssue(issue)
 	f.ScanFile(context.Background(), issueFile)
 
-	testIssue := vulnmap.Issue{FormattedMessage: "<br><br/><br />"}
+	testIssue := vulnmap.Issue{ID: "id", Message: "message"}
 	hovers := converter.ToHovers([]vulnmap.Issue{testIssue})
 
 	_, _ = service.Provider().Authenticate(context.Background())
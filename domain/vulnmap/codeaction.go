@@ -96,3 +96,15 @@ func NewPreferredCodeAction(title string, edit *WorkspaceEdit, command *CommandD
 	*action.IsPreferred = true
 	return action, nil
 }
+
+// Moved returns a copy of the action for a file that was renamed or moved from oldPath to newPath. Deferred edits are
+// moved once they are resolved.
+func (c CodeAction) Moved(oldPath string, newPath string) CodeAction {
+	c.Edit = c.Edit.moved(oldPath, newPath)
+	if c.DeferredEdit != nil {
+		deferredEdit := *c.DeferredEdit
+		movedEdit := func() *WorkspaceEdit { return deferredEdit().moved(oldPath, newPath) }
+		c.DeferredEdit = &movedEdit
+	}
+	return c
+}
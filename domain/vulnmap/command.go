@@ -39,6 +39,9 @@ const (
 	GetSettingsSastEnabled       = "vulnmap.getSettingsSastEnabled"
 	GetActiveUserCommand         = "vulnmap.getActiveUser"
 	ReportAnalyticsCommand       = "vulnmap.reportAnalytics"
+	GetToolchainInfoCommand      = "vulnmap.getToolchainInfo"
+	ExportSupportBundleCommand   = "vulnmap.exportSupportBundle"
+	SetOrganizationCommand       = "vulnmap.setOrganization"
 
 	// Vulnmap Code specific commands
 	CodeFixCommand        = "vulnmap.code.fix"
@@ -36,3 +36,18 @@ type WorkspaceEdit struct {
 	 */
 	Changes map[string][]TextEdit
 }
+
+// moved returns a copy of the edit that changes newPath instead of oldPath
+func (e *WorkspaceEdit) moved(oldPath string, newPath string) *WorkspaceEdit {
+	if e == nil {
+		return nil
+	}
+	changes := make(map[string][]TextEdit, len(e.Changes))
+	for path, edits := range e.Changes {
+		if path == oldPath {
+			path = newPath
+		}
+		changes[path] = edits
+	}
+	return &WorkspaceEdit{Changes: changes}
+}
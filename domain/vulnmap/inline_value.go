@@ -33,4 +33,7 @@ type InlineValueProvider interface {
 
 	// ClearInlineValues clears inline values for a given path.
 	ClearInlineValues(path string)
+
+	// MoveInlineValues moves the inline values of a renamed or moved file to its new path.
+	MoveInlineValues(oldPath string, newPath string)
 }
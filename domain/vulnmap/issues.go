@@ -30,6 +30,40 @@ type Reference struct {
 	Url   *url.URL
 }
 
+// Link is a titled link in the details of an issue, e.g. to a CVE
+type Link struct {
+	Title string
+	Url   string
+}
+
+// DetailField is a labelled value in the details of an issue, e.g. the versions that fix a vulnerability
+type DetailField struct {
+	Label string
+	Value string
+}
+
+// DetailSection is a part of the details of an issue with an optional title. The content is markdown, as the
+// products receive their descriptions as markdown.
+type DetailSection struct {
+	Title   string
+	Content string
+}
+
+// IssueDetails are the structured contents of the hover of an issue. They are rendered in the format the client
+// supports when the hover is requested.
+type IssueDetails struct {
+	Title string
+	// Links identify the issue, e.g. its CVEs, CWEs and its page on the Vulnmap website
+	Links []Link
+	// Fields contain product specific facts, e.g. the affected package or the priority score
+	Fields []DetailField
+	// Remediation describes how to fix the issue, e.g. the upgrade that fixes a vulnerability
+	Remediation string
+	// UpgradePath lists the packages to upgrade, from the direct dependency to the vulnerable package
+	UpgradePath []string
+	Sections    []DetailSection
+}
+
 // Issue models a problem, vulnerability, or situation within your code that requires your attention
 type Issue struct {
 	// ID uniquely identifies the issue, it is intended to be human-readable
@@ -40,8 +74,9 @@ type Issue struct {
 	Range Range
 	// Message is a human-readable description of the issue
 	Message string
-	// todo [jc] this contains a formatted longest message for hovers, this needs to be pushed up and rendered in presentation. [bd] shouldn't the content and formatting be decided by the product?
-	FormattedMessage string
+	// Details contain the content of the hover. The product decides on the content, rendering into the format
+	// supported by the client happens in presentation.
+	Details *IssueDetails
 	// AffectedFilePath is the file path to the file where the issue was found
 	AffectedFilePath string
 	// Product is the Vulnmap product, e.g. Vulnmap Open Source
@@ -62,6 +97,8 @@ type Issue struct {
 	CVEs []string
 	// AdditionalData contains data that can be passed by the product (e.g. for presentation)
 	AdditionalData any
+	// IsIgnored is true if the issue has been ignored (suppressed) on the Vulnmap platform
+	IsIgnored bool
 }
 
 type CodeIssueData struct {
@@ -80,6 +117,7 @@ type CodeIssueData struct {
 	Rows               CodePoint          `json:"rows"`
 	IsSecurityType     bool               `json:"isSecurityType"`
 	IsAutofixable      bool               `json:"isAutofixable"`
+	PriorityScore      int                `json:"priorityScore"`
 }
 
 type ExampleCommitFix struct {
@@ -177,6 +215,61 @@ func (i Issue) GetFilterableIssueType() product.FilterableIssueType {
 	}
 }
 
+// IsFixable returns true if the product offers a fix for the issue, e.g. an upgrade, a patch or an autofix
+func (i Issue) IsFixable() bool {
+	switch additionalData := i.AdditionalData.(type) {
+	case OssIssueData:
+		return additionalData.IsUpgradable || additionalData.IsPatchable
+	case CodeIssueData:
+		return additionalData.IsAutofixable
+	default:
+		return false
+	}
+}
+
+// Priority returns a score between 0 and 1000 used to rank issues, higher is more important.
+// Vulnmap Code delivers a priority score, Open Source issues are ranked by their CVSS score.
+func (i Issue) Priority() int {
+	switch additionalData := i.AdditionalData.(type) {
+	case OssIssueData:
+		return int(additionalData.CvssScore * 100)
+	case CodeIssueData:
+		return additionalData.PriorityScore
+	default:
+		return 0
+	}
+}
+
+// Moved returns a copy of the issue for a file that was renamed or moved to newPath. The edits of its code actions and
+// the path arguments of its codelens commands refer to newPath, too.
+func (i Issue) Moved(newPath string) Issue {
+	oldPath := i.AffectedFilePath
+	i.AffectedFilePath = newPath
+	if i.CodeActions != nil {
+		codeActions := make([]CodeAction, 0, len(i.CodeActions))
+		for _, action := range i.CodeActions {
+			codeActions = append(codeActions, action.Moved(oldPath, newPath))
+		}
+		i.CodeActions = codeActions
+	}
+	if i.CodelensCommands != nil {
+		commands := make([]CommandData, 0, len(i.CodelensCommands))
+		for _, command := range i.CodelensCommands {
+			arguments := make([]any, 0, len(command.Arguments))
+			for _, argument := range command.Arguments {
+				if path, ok := argument.(string); ok && path == oldPath {
+					argument = newPath
+				}
+				arguments = append(arguments, argument)
+			}
+			command.Arguments = arguments
+			commands = append(commands, command)
+		}
+		i.CodelensCommands = commands
+	}
+	return i
+}
+
 func (i Issue) String() string {
 	return fmt.Sprintf("%s, ID: %s, Range: %s", i.AffectedFilePath, i.ID, i.Range)
 }
@@ -0,0 +1,118 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vulnmap
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/product"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/uri"
+)
+
+// knownIssue is the last known ignore state of an issue
+type knownIssue struct {
+	FilePath string `json:"filePath"`
+	Ignored  bool   `json:"ignored"`
+}
+
+// knownIgnores persists the ignore state of the issues found by the last scans of each folder and product, so that
+// ignores added between sessions, e.g. in a .vulnmap policy or on the Vulnmap platform, are found after a restart.
+// Issues that a scan no longer finds are removed.
+type knownIgnores struct {
+	mutex sync.Mutex
+	// path is the state file, empty for the default in the data directory
+	path   string
+	issues map[string]map[string]knownIssue
+}
+
+// update stores the issues of the scan of path in folderPath and returns the issues that were known and not ignored
+// before, but are ignored now
+func (k *knownIgnores) update(folderPath string, path string, p product.Product, issues []Issue) []Issue {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	k.load()
+
+	scope := folderPath + "|" + string(p)
+	previous := k.issues[scope]
+	current := map[string]knownIssue{}
+	// a scan of a file or subdirectory only replaces the issues in it
+	for key, issue := range previous {
+		if !uri.FolderContains(path, issue.FilePath) {
+			current[key] = issue
+		}
+	}
+
+	var newlyIgnored []Issue
+	for _, issue := range issues {
+		key := auditIssueKey(issue)
+		if known, ok := previous[key]; ok && !known.Ignored && issue.IsIgnored {
+			newlyIgnored = append(newlyIgnored, issue)
+		}
+		current[key] = knownIssue{FilePath: issue.AffectedFilePath, Ignored: issue.IsIgnored}
+	}
+	if len(current) == 0 {
+		delete(k.issues, scope)
+	} else {
+		k.issues[scope] = current
+	}
+	k.save()
+	return newlyIgnored
+}
+
+func (k *knownIgnores) statePath() string {
+	if k.path != "" {
+		return k.path
+	}
+	return filepath.Join(config.CurrentConfig().CliSettings().DefaultBinaryInstallPath(), "audit-ignores.json")
+}
+
+func (k *knownIgnores) load() {
+	if k.issues != nil {
+		return
+	}
+	k.issues = map[string]map[string]knownIssue{}
+	content, err := os.ReadFile(k.statePath())
+	if err != nil {
+		return
+	}
+	if err = json.Unmarshal(content, &k.issues); err != nil {
+		log.Debug().Err(err).Str("method", "knownIgnores.load").Msg("ignoring unreadable ignore state")
+		k.issues = map[string]map[string]knownIssue{}
+	}
+}
+
+func (k *knownIgnores) save() {
+	method := "knownIgnores.save"
+	content, err := json.Marshal(k.issues)
+	if err != nil {
+		log.Err(err).Str("method", method).Msg("couldn't marshal ignore state")
+		return
+	}
+	path := k.statePath()
+	if err = os.MkdirAll(filepath.Dir(path), 0700); err == nil {
+		err = os.WriteFile(path, content, 0600)
+	}
+	if err != nil {
+		log.Err(err).Str("method", method).Msg("couldn't save ignore state")
+	}
+}
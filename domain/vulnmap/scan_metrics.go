@@ -0,0 +1,36 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vulnmap
+
+import (
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/metrics"
+)
+
+const (
+	scanResultSuccess = "success"
+	scanResultError   = "error"
+)
+
+// recordScanMetrics counts the finished scan and records its duration per product
+func recordScanMetrics(data ScanData) {
+	result := scanResultSuccess
+	if data.Err != nil {
+		result = scanResultError
+	}
+	metrics.Scans.Inc(string(data.Product), result)
+	metrics.ScanDuration.Observe(float64(data.DurationMs)/1000, string(data.Product))
+}
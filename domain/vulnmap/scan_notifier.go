@@ -4,6 +4,8 @@ import "github.com/khulnasoft-lab/vulnmap-ls/internal/product"
 
 type ScanNotifier interface {
 	SendInProgress(folderPath string)
+	// SendPartial sends the issues found so far by a product that is still scanning
+	SendPartial(product product.Product, folderPath string, issues []Issue)
 	SendSuccess(product product.Product, folderPath string, issues []Issue)
 	SendSuccessForAllProducts(folderPath string, issues []Issue)
 	SendError(product product.Product, folderPath string)
@@ -8,6 +8,7 @@ var _ ScanNotifier = &MockScanNotifier{}
 
 type MockScanNotifier struct {
 	inProgressCalls []string
+	partialCalls    []string
 	successCalls    []string
 	errorCalls      []string
 }
@@ -18,6 +19,10 @@ func (m *MockScanNotifier) SendInProgress(folderPath string) {
 	m.inProgressCalls = append(m.inProgressCalls, folderPath)
 }
 
+func (m *MockScanNotifier) SendPartial(product product.Product, folderPath string, issues []Issue) {
+	m.partialCalls = append(m.partialCalls, folderPath)
+}
+
 func (m *MockScanNotifier) SendSuccessForAllProducts(folderPath string, issues []Issue) {
 	m.successCalls = append(m.successCalls, folderPath)
 }
@@ -34,6 +39,10 @@ func (m *MockScanNotifier) InProgressCalls() []string {
 	return m.inProgressCalls
 }
 
+func (m *MockScanNotifier) PartialCalls() []string {
+	return m.partialCalls
+}
+
 func (m *MockScanNotifier) SuccessCalls() []string {
 	return m.successCalls
 }
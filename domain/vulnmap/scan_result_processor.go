@@ -17,6 +17,7 @@
 package vulnmap
 
 import (
+	"context"
 	"time"
 
 	"github.com/khulnasoft-lab/vulnmap-ls/internal/product"
@@ -33,6 +34,9 @@ type ScanData struct {
 	Medium            int
 	Low               int
 	SeverityCount     map[product.Product]SeverityCount
+	// Partial is true for results published while the scan is still running, e.g. for one project of a monorepo.
+	// The complete results are processed when the scan has finished.
+	Partial bool
 }
 
 type SeverityCount struct {
@@ -47,3 +51,24 @@ type ScanResultProcessor = func(scanData ScanData)
 //type ScanResultProcessor = func(product product.Product, issues []Issue, err error)
 
 func NoopResultProcessor(_ ScanData) {}
+
+type partialResultsContextKey struct{}
+
+// WithPartialResultProcessor returns a context that allows product scanners to publish results while still scanning
+func WithPartialResultProcessor(ctx context.Context, processResults ScanResultProcessor) context.Context {
+	return context.WithValue(ctx, partialResultsContextKey{}, processResults)
+}
+
+// PublishPartialResults passes the issues found so far to the result processor of the context, if there is one
+func PublishPartialResults(ctx context.Context, p product.Product, issues []Issue) {
+	processResults, ok := ctx.Value(partialResultsContextKey{}).(ScanResultProcessor)
+	if !ok || ctx.Err() != nil || len(issues) == 0 {
+		return
+	}
+	processResults(ScanData{
+		Product:           p,
+		Issues:            issues,
+		TimestampFinished: time.Now().UTC(),
+		Partial:           true,
+	})
+}
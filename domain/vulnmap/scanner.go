@@ -26,17 +26,20 @@ import (
 	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/ide/initialize"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/ide/notification"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/audit"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/performance"
 	ux2 "github.com/khulnasoft-lab/vulnmap-ls/domain/observability/ux"
 	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/vulnmap_api"
 	"github.com/khulnasoft-lab/vulnmap-ls/internal/lsp"
 	"github.com/khulnasoft-lab/vulnmap-ls/internal/product"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/progress"
 )
 
 var (
-	_ Scanner             = (*DelegatingConcurrentScanner)(nil)
-	_ InlineValueProvider = (*DelegatingConcurrentScanner)(nil)
-	_ PackageScanner      = (*DelegatingConcurrentScanner)(nil)
+	_ Scanner                 = (*DelegatingConcurrentScanner)(nil)
+	_ InlineValueProvider     = (*DelegatingConcurrentScanner)(nil)
+	_ PackageScanner          = (*DelegatingConcurrentScanner)(nil)
+	_ EnabledProductsProvider = (*DelegatingConcurrentScanner)(nil)
 )
 
 type Scanner interface {
@@ -50,6 +53,30 @@ type Scanner interface {
 	Init() error
 }
 
+// EnabledProductsProvider is implemented by scanners that know in advance which products a scan runs
+type EnabledProductsProvider interface {
+	EnabledProducts() []product.Product
+}
+
+// ResultCacheClearer is implemented by scanners that cache scan results that can get stale when settings change
+type ResultCacheClearer interface {
+	ClearResultCache()
+}
+
+type skipResultCacheContextKey struct{}
+
+// WithoutCachedResults returns a context for scans the user triggered, which rerun the CLI instead of returning
+// cached results, so that e.g. ignores made on the platform are picked up
+func WithoutCachedResults(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipResultCacheContextKey{}, true)
+}
+
+// CachedResultsAllowed returns false if the scan of the context must not return cached results
+func CachedResultsAllowed(ctx context.Context) bool {
+	skip, _ := ctx.Value(skipResultCacheContextKey{}).(bool)
+	return !skip
+}
+
 type PackageScanner interface {
 	ScanPackages(ctx context.Context, config *config.Config, path string, content string)
 }
@@ -60,10 +87,12 @@ type DelegatingConcurrentScanner struct {
 	initializer   initialize.Initializer
 	instrumentor  performance.Instrumentor
 	analytics     ux2.Analytics
+	auditTrail    audit.Trail
 	scanNotifier  ScanNotifier
 	vulnmapApiClient vulnmap_api.VulnmapApiClient
 	authService   AuthenticationService
 	notifier      notification.Notifier
+	knownIgnores  knownIgnores
 }
 
 func (sc *DelegatingConcurrentScanner) ScanPackages(ctx context.Context, config *config.Config, path string, content string) {
@@ -78,6 +107,7 @@ func NewDelegatingScanner(
 	initializer initialize.Initializer,
 	instrumentor performance.Instrumentor,
 	analytics ux2.Analytics,
+	auditTrail audit.Trail,
 	scanNotifier ScanNotifier,
 	vulnmapApiClient vulnmap_api.VulnmapApiClient,
 	authService AuthenticationService,
@@ -87,6 +117,7 @@ func NewDelegatingScanner(
 	return &DelegatingConcurrentScanner{
 		instrumentor:  instrumentor,
 		analytics:     analytics,
+		auditTrail:    auditTrail,
 		initializer:   initializer,
 		scanNotifier:  scanNotifier,
 		vulnmapApiClient: vulnmapApiClient,
@@ -96,6 +127,15 @@ func NewDelegatingScanner(
 	}
 }
 
+// ClearResultCache clears the cached results of all product scanners, e.g. after the settings changed
+func (sc *DelegatingConcurrentScanner) ClearResultCache() {
+	for _, scanner := range sc.scanners {
+		if s, ok := scanner.(ResultCacheClearer); ok {
+			s.ClearResultCache()
+		}
+	}
+}
+
 func (sc *DelegatingConcurrentScanner) ClearInlineValues(path string) {
 	for _, scanner := range sc.scanners {
 		if s, ok := scanner.(InlineValueProvider); ok {
@@ -104,6 +144,14 @@ func (sc *DelegatingConcurrentScanner) ClearInlineValues(path string) {
 	}
 }
 
+func (sc *DelegatingConcurrentScanner) MoveInlineValues(oldPath string, newPath string) {
+	for _, scanner := range sc.scanners {
+		if s, ok := scanner.(InlineValueProvider); ok {
+			s.MoveInlineValues(oldPath, newPath)
+		}
+	}
+}
+
 func (sc *DelegatingConcurrentScanner) GetInlineValues(path string, myRange Range) (values []InlineValue, err error) {
 	for _, scanner := range sc.scanners {
 		if s, ok := scanner.(InlineValueProvider); ok {
@@ -119,6 +167,16 @@ func (sc *DelegatingConcurrentScanner) GetInlineValues(path string, myRange Rang
 	return values, err
 }
 
+// EnabledProducts returns the products whose scanners are enabled
+func (sc *DelegatingConcurrentScanner) EnabledProducts() (products []product.Product) {
+	for _, scanner := range sc.scanners {
+		if scanner.IsEnabled() {
+			products = append(products, scanner.Product())
+		}
+	}
+	return products
+}
+
 func (sc *DelegatingConcurrentScanner) Init() error {
 	err := sc.initializer.Init()
 	if err != nil {
@@ -186,11 +244,15 @@ func (sc *DelegatingConcurrentScanner) Scan(
 			waitGroup.Add(1)
 			go func(s ProductScanner) {
 				defer waitGroup.Done()
-				span := sc.instrumentor.NewTransaction(context.WithValue(ctx, s.Product(), s), string(s.Product()), method)
+				if scanProgress := progress.AggregateFromContext(ctx); scanProgress != nil {
+					scanProgress.StartStep(folderPath, s.Product())
+					defer scanProgress.FinishStep(folderPath, s.Product())
+				}
+				productCtx := progress.WithStep(context.WithValue(ctx, s.Product(), s), folderPath, s.Product())
+				productCtx = WithPartialResultProcessor(productCtx, processResults)
+				span := sc.instrumentor.NewTransaction(productCtx, string(s.Product()), method)
 				defer sc.instrumentor.Finish(span)
 				log.Info().Msgf("Scanning %s with %T: STARTED", path, s)
-				// TODO change interface of scan to pass a func (processResults), which would enable products to stream
-
 				scanSpan := sc.instrumentor.StartSpan(span.Context(), "scan")
 				foundIssues, err := s.Scan(scanSpan.Context(), path, folderPath)
 				sc.instrumentor.Finish(scanSpan)
@@ -204,6 +266,8 @@ func (sc *DelegatingConcurrentScanner) Scan(
 					TimestampFinished: time.Now().UTC(),
 				}
 				processResults(data)
+				recordScanMetrics(data)
+				sc.auditScan(folderPath, path, data)
 				log.Info().Msgf("Scanning %s with %T: COMPLETE found %v issues", path, s, len(foundIssues))
 			}(scanner)
 		} else {
@@ -218,6 +282,52 @@ func (sc *DelegatingConcurrentScanner) Scan(
 	// TODO: handle learn actions centrally instead of in each scanner
 }
 
+// auditScan records the finished scan in the audit trail
+func (sc *DelegatingConcurrentScanner) auditScan(folderPath string, path string, data ScanData) {
+	properties := audit.ScanProperties{
+		Folder:           folderPath,
+		Path:             path,
+		Product:          string(data.Product),
+		DurationMs:       data.DurationMs,
+		IssueCount:       len(data.Issues),
+		IssuesBySeverity: map[string]int{},
+	}
+	// Vulnmap Code doesn't use the CLI
+	if data.Product != product.ProductCode {
+		properties.CliVersion = config.CurrentConfig().CliSettings().Version()
+	}
+	for _, issue := range data.Issues {
+		properties.IssuesBySeverity[issue.Severity.String()]++
+	}
+	if data.Err != nil {
+		properties.Error = data.Err.Error()
+	}
+	sc.auditTrail.ScanCompleted(properties)
+	if data.Err == nil {
+		sc.auditIgnores(folderPath, path, data.Product, data.Issues)
+	}
+}
+
+// auditIgnores records the issues that an earlier scan, possibly of an earlier session, found not ignored and that
+// are ignored now, e.g. because they were ignored in a .vulnmap policy or on the Vulnmap platform. Without an audit log,
+// the ignore state isn't tracked.
+func (sc *DelegatingConcurrentScanner) auditIgnores(folderPath string, path string, p product.Product, issues []Issue) {
+	if config.CurrentConfig().AuditLogPath() == "" {
+		return
+	}
+	for _, issue := range sc.knownIgnores.update(folderPath, path, p, issues) {
+		sc.auditTrail.IgnoreAdded(audit.IgnoreProperties{IssueId: issue.ID, FilePath: issue.AffectedFilePath})
+	}
+}
+
+// auditIssueKey identifies an issue across scans
+func auditIssueKey(issue Issue) string {
+	if data, ok := issue.AdditionalData.(CodeIssueData); ok && data.Key != "" {
+		return data.Key
+	}
+	return string(issue.Product) + "|" + issue.AffectedFilePath + "|" + issue.ID
+}
+
 func getEnabledAnalysisTypes(productScanners []ProductScanner) (analysisTypes []ux2.AnalysisType) {
 	for _, ps := range productScanners {
 		if !ps.IsEnabled() {
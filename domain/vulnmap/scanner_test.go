@@ -18,6 +18,7 @@ package vulnmap
 
 import (
 	"context"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -26,6 +27,7 @@ import (
 
 	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/ide/initialize"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/audit"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/error_reporting"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/performance"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/ux"
@@ -57,8 +59,19 @@ func setupScanner(testProductScanners ...ProductScanner) (
 	scanner Scanner,
 	analytics *ux.TestAnalytics,
 	scanNotifier ScanNotifier,
+) {
+	scanner, analytics, scanNotifier, _ = setupScannerWithAuditTrail(testProductScanners...)
+	return scanner, analytics, scanNotifier
+}
+
+func setupScannerWithAuditTrail(testProductScanners ...ProductScanner) (
+	scanner Scanner,
+	analytics *ux.TestAnalytics,
+	scanNotifier ScanNotifier,
+	auditTrail *audit.TestTrail,
 ) {
 	analytics = ux.NewTestAnalytics()
+	auditTrail = audit.NewTestTrail()
 	scanNotifier = NewMockScanNotifier()
 	notifier := notification.NewNotifier()
 	apiClient := &vulnmap_api.FakeApiClient{CodeEnabled: false}
@@ -70,13 +83,112 @@ func setupScanner(testProductScanners ...ProductScanner) (
 		initialize.NewDelegatingInitializer(),
 		performance.NewInstrumentor(),
 		analytics,
+		auditTrail,
 		scanNotifier,
 		apiClient,
 		authenticationService,
 		notifier,
 		testProductScanners...,
 	)
-	return scanner, analytics, scanNotifier
+	return scanner, analytics, scanNotifier, auditTrail
+}
+
+func TestScan_RecordsScanInAuditTrail(t *testing.T) {
+	testutil.UnitTest(t)
+	config.CurrentConfig().CliSettings().SetVersion("1.1234.0")
+	ossScanner := NewTestProductScanner(product.ProductOpenSource, true)
+	scanner, _, _, auditTrail := setupScannerWithAuditTrail(ossScanner)
+
+	scanner.Scan(context.Background(), "/folder/package.json", NoopResultProcessor, "/folder")
+
+	assert.Eventually(t, func() bool { return len(auditTrail.Events()) == 1 }, 1*time.Second, 10*time.Millisecond)
+	properties := auditTrail.Events()[0].(audit.ScanProperties)
+	assert.Equal(t, "/folder", properties.Folder)
+	assert.Equal(t, "/folder/package.json", properties.Path)
+	assert.Equal(t, string(product.ProductOpenSource), properties.Product)
+	assert.Equal(t, "1.1234.0", properties.CliVersion)
+	assert.Empty(t, properties.Error)
+}
+
+func TestScan_RecordsNewIgnoresInAuditTrail(t *testing.T) {
+	c := testutil.UnitTest(t)
+	c.SetAuditLogPath(filepath.Join(t.TempDir(), "audit.jsonl"))
+	scanner, _, _, auditTrail := setupScannerWithAuditTrail()
+	sc := scanner.(*DelegatingConcurrentScanner)
+	sc.knownIgnores.path = filepath.Join(t.TempDir(), "audit-ignores.json")
+	issue := Issue{ID: "rule", AffectedFilePath: "/folder/main.go", AdditionalData: CodeIssueData{Key: "key"}}
+	alreadyIgnored := Issue{ID: "rule", AffectedFilePath: "/folder/other.go", IsIgnored: true}
+
+	sc.auditIgnores("/folder", "/folder", product.ProductCode, []Issue{issue, alreadyIgnored})
+	assert.Empty(t, auditTrail.Events())
+
+	issue.IsIgnored = true
+	sc.auditIgnores("/folder", "/folder", product.ProductCode, []Issue{issue, alreadyIgnored})
+	sc.auditIgnores("/folder", "/folder", product.ProductCode, []Issue{issue, alreadyIgnored})
+
+	assert.Equal(t, []any{audit.IgnoreProperties{IssueId: "rule", FilePath: "/folder/main.go"}}, auditTrail.Events())
+}
+
+func TestScan_RecordsIgnoresAddedBetweenSessions(t *testing.T) {
+	c := testutil.UnitTest(t)
+	c.SetAuditLogPath(filepath.Join(t.TempDir(), "audit.jsonl"))
+	statePath := filepath.Join(t.TempDir(), "audit-ignores.json")
+	issue := Issue{ID: "rule", AffectedFilePath: "/folder/main.go", AdditionalData: CodeIssueData{Key: "key"}}
+	scanner, _, _, _ := setupScannerWithAuditTrail()
+	sc := scanner.(*DelegatingConcurrentScanner)
+	sc.knownIgnores.path = statePath
+	sc.auditIgnores("/folder", "/folder", product.ProductCode, []Issue{issue})
+
+	restarted, _, _, auditTrail := setupScannerWithAuditTrail()
+	sc = restarted.(*DelegatingConcurrentScanner)
+	sc.knownIgnores.path = statePath
+	issue.IsIgnored = true
+	sc.auditIgnores("/folder", "/folder", product.ProductCode, []Issue{issue})
+
+	assert.Equal(t, []any{audit.IgnoreProperties{IssueId: "rule", FilePath: "/folder/main.go"}}, auditTrail.Events())
+}
+
+func TestScan_ForgetsIssuesThatAreNoLongerFound(t *testing.T) {
+	c := testutil.UnitTest(t)
+	c.SetAuditLogPath(filepath.Join(t.TempDir(), "audit.jsonl"))
+	scanner, _, _, auditTrail := setupScannerWithAuditTrail()
+	sc := scanner.(*DelegatingConcurrentScanner)
+	sc.knownIgnores.path = filepath.Join(t.TempDir(), "audit-ignores.json")
+	fixed := Issue{ID: "fixed", AffectedFilePath: "/folder/main.go"}
+	other := Issue{ID: "other", AffectedFilePath: "/folder/other.go"}
+	sc.auditIgnores("/folder", "/folder", product.ProductOpenSource, []Issue{fixed, other})
+
+	// a file scan only replaces the issues of the file
+	sc.auditIgnores("/folder", "/folder/main.go", product.ProductOpenSource, nil)
+
+	assert.Equal(t, map[string]knownIssue{auditIssueKey(other): {FilePath: "/folder/other.go"}},
+		sc.knownIgnores.issues["/folder|"+string(product.ProductOpenSource)])
+	fixed.IsIgnored = true
+	sc.auditIgnores("/folder", "/folder/main.go", product.ProductOpenSource, []Issue{fixed})
+	assert.Empty(t, auditTrail.Events())
+}
+
+func TestScan_WithoutAuditLog_DoesNotTrackIgnores(t *testing.T) {
+	testutil.UnitTest(t)
+	scanner, _, _, _ := setupScannerWithAuditTrail()
+	sc := scanner.(*DelegatingConcurrentScanner)
+	sc.knownIgnores.path = filepath.Join(t.TempDir(), "audit-ignores.json")
+
+	sc.auditIgnores("/folder", "/folder", product.ProductCode, []Issue{{ID: "rule", AffectedFilePath: "/folder/main.go"}})
+
+	assert.NoFileExists(t, sc.knownIgnores.path)
+}
+
+func TestScanner_ClearResultCache_ClearsProductScanners(t *testing.T) {
+	testutil.UnitTest(t)
+	ossScanner := NewTestProductScanner(product.ProductOpenSource, true)
+	iacScanner := NewTestProductScanner(product.ProductInfrastructureAsCode, true)
+	scanner, _, _ := setupScanner(ossScanner, iacScanner)
+
+	scanner.(ResultCacheClearer).ClearResultCache()
+
+	assert.Equal(t, 1, ossScanner.ResultCacheClears())
+	assert.Equal(t, 1, iacScanner.ResultCacheClears())
 }
 
 func TestScan_whenProductScannerEnabled_SendsAnalysisTriggered(t *testing.T) {
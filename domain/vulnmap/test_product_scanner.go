@@ -39,6 +39,7 @@ type TestProductScanner struct {
 	product      product.Product
 	enabled      bool
 	scans        int
+	cacheClears  int
 	mutex        sync.Mutex
 	scanDuration time.Duration
 }
@@ -79,6 +80,18 @@ func (t *TestProductScanner) Scans() int {
 	return t.scans
 }
 
+func (t *TestProductScanner) ClearResultCache() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.cacheClears++
+}
+
+func (t *TestProductScanner) ResultCacheClears() int {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.cacheClears
+}
+
 func (t *TestProductScanner) IsEnabled() bool {
 	return t.enabled
 }
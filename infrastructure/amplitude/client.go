@@ -47,6 +47,8 @@ func NewAmplitudeClient(authFunc func() (string, error), errorReporter error_rep
 		},
 	})
 
+	// The Amplitude destination builds its own http client, which can't be given a guarded transport, so it is removed
+	// before any event is sent. Events are delivered by the Segment plugin, whose transport is guarded.
 	segmentPlugin := NewSegmentPlugin()
 	ampli.Instance.Client.Remove(destination.NewAmplitudePlugin().Name()) // remove Amplitude as events destination, as we use Segment for it
 	ampli.Instance.Client.Add(segmentPlugin)
@@ -180,7 +182,7 @@ func (c *Client) ScanModeIsSelected(properties ux2.ScanModeIsSelectedProperties)
 
 func (c *Client) enqueueEvent(eventFn captureEvent) {
 	conf := config.CurrentConfig()
-	if conf.IsTelemetryEnabled() && !conf.IsFedramp() {
+	if conf.IsTelemetryEnabled() && !conf.IsFedramp() && !conf.IsOfflineMode() {
 		eventFn(
 			c.authenticatedUserId,
 			ampli.EventOptions{
@@ -210,7 +212,7 @@ func (c *Client) Identify() {
 	}
 	c.authenticatedUserId = userId
 
-	if !conf.IsTelemetryEnabled() || conf.IsFedramp() {
+	if !conf.IsTelemetryEnabled() || conf.IsFedramp() || conf.IsOfflineMode() {
 		return
 	}
 	identifyEvent := ampli.Identify.Builder().UserId(userId).Build()
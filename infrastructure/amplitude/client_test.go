@@ -17,13 +17,17 @@
 package amplitude
 
 import (
+	"errors"
+	"net/http"
 	"sync"
+	"sync/atomic"
 	"testing"
 
 	"github.com/segmentio/analytics-go"
 	segment "github.com/segmentio/analytics-go"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/khulnasoft-lab/vulnmap-ls/ampli"
 	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/error_reporting"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/ux"
@@ -72,6 +76,17 @@ func TestClient_NoIdentifyForFedramp(t *testing.T) {
 	assert.Equal(t, 0, len(fakeSegmentClient.trackedEvents))
 }
 
+func TestClient_NoEventsInOfflineMode(t *testing.T) {
+	s, fakeSegmentClient, c := setupUnitTest(t)
+	c.SetTelemetryEnabled(true)
+	c.SetOfflineMode(true)
+
+	s.Identify()
+	s.PluginIsInstalled(ux.PluginIsInstalledProperties{})
+
+	assert.Equal(t, 0, len(fakeSegmentClient.trackedEvents))
+}
+
 func Test_AnalyticEvents(t *testing.T) {
 	s, fakeSegmentClient, conf := setupUnitTest(t)
 	conf.SetRuntimeVersion("1.2.3")
@@ -181,6 +196,30 @@ func Test_AnalyticEventsNotSentForFedramp(t *testing.T) {
 	assert.Equal(t, 0, len(fakeSegmentClient.trackedEvents))
 }
 
+func Test_AnalyticEventsAreNotSentThroughTheUnguardedAmplitudeClient(t *testing.T) {
+	s, fakeSegmentClient, c := setupUnitTest(t)
+	c.SetTelemetryEnabled(true)
+	transport := &recordingTransport{}
+	original := http.DefaultTransport
+	http.DefaultTransport = transport
+	t.Cleanup(func() { http.DefaultTransport = original })
+
+	s.PluginIsInstalled(ux.PluginIsInstalledProperties{})
+	ampli.Instance.Flush()
+
+	assert.Equal(t, 1, len(fakeSegmentClient.trackedEvents))
+	assert.Zero(t, transport.requests.Load())
+}
+
+type recordingTransport struct {
+	requests atomic.Int32
+}
+
+func (r *recordingTransport) RoundTrip(_ *http.Request) (*http.Response, error) {
+	r.requests.Add(1)
+	return nil, errors.New("unexpected request")
+}
+
 func setupUnitTest(t *testing.T) (*Client, *FakeSegmentClient, *config.Config) {
 	c := testutil.UnitTest(t)
 	authFunc := func() (string, error) { return "fakeUser", nil }
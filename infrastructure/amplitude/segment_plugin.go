@@ -5,6 +5,7 @@ import (
 	segment "github.com/segmentio/analytics-go"
 
 	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
+	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/network"
 
 	"github.com/amplitude/analytics-go/amplitude"
 	"github.com/amplitude/analytics-go/amplitude/types"
@@ -21,7 +22,7 @@ func NewSegmentPlugin() *SegmentPlugin {
 
 // Setup is called on plugin installation
 func (plugin *SegmentPlugin) Setup(config amplitude.Config) {
-	client, err := segment.NewWithConfig(getSegmentPublicKey(), segment.Config{Logger: &segmentLogger{}})
+	client, err := segment.NewWithConfig(getSegmentPublicKey(), segment.Config{Logger: &segmentLogger{}, Transport: network.GuardTransport(nil)})
 	if err != nil {
 		log.Error().Str("method", "NewSegmentClient").Err(err).Msg("Error creating Segment client")
 	}
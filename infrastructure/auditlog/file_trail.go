@@ -0,0 +1,176 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package auditlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/audit"
+)
+
+const (
+	// maxFileSize is the size in bytes after which the audit log is rotated
+	maxFileSize = 10 * 1024 * 1024
+	// maxRotatedFiles is the number of rotated files that are kept next to the audit log, e.g. audit.jsonl.1
+	maxRotatedFiles = 5
+)
+
+type eventType string
+
+const (
+	scanEvent   eventType = "scan"
+	fixEvent    eventType = "fix"
+	ignoreEvent eventType = "ignore"
+	trustEvent  eventType = "trust"
+	cliEvent    eventType = "cli"
+)
+
+// entry is a line of the audit log
+type entry struct {
+	Timestamp  time.Time `json:"timestamp"`
+	Event      eventType `json:"event"`
+	Properties any       `json:"properties"`
+}
+
+// fileTrail appends the events as JSON lines to the configured audit log and rotates it when it gets too big. The
+// path is read for every event, so it can change at runtime. Without a path, events are not recorded.
+type fileTrail struct {
+	mutex sync.Mutex
+	path  string
+	file  *os.File
+	size  int64
+	now   func() time.Time
+}
+
+func NewFileTrail() audit.Trail {
+	return &fileTrail{now: time.Now}
+}
+
+func (t *fileTrail) ScanCompleted(properties audit.ScanProperties) {
+	t.append(scanEvent, properties)
+}
+
+func (t *fileTrail) FixApplied(properties audit.FixProperties) {
+	t.append(fixEvent, properties)
+}
+
+func (t *fileTrail) IgnoreAdded(properties audit.IgnoreProperties) {
+	t.append(ignoreEvent, properties)
+}
+
+func (t *fileTrail) TrustChanged(properties audit.TrustProperties) {
+	t.append(trustEvent, properties)
+}
+
+func (t *fileTrail) CliChanged(properties audit.CliProperties) {
+	t.append(cliEvent, properties)
+}
+
+func (t *fileTrail) append(event eventType, properties any) {
+	method := "auditlog.fileTrail.append"
+	path := config.CurrentConfig().AuditLogPath()
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if path == "" {
+		t.close()
+		return
+	}
+	line, err := json.Marshal(entry{Timestamp: t.now().UTC(), Event: event, Properties: properties})
+	if err != nil {
+		log.Err(err).Str("method", method).Msg("couldn't marshal audit event")
+		return
+	}
+	line = append(line, '\n')
+
+	if err = t.open(path); err != nil {
+		log.Err(err).Str("method", method).Msg("couldn't open audit log")
+		return
+	}
+	if t.size > 0 && t.size+int64(len(line)) > maxFileSize {
+		if err = t.rotate(); err != nil {
+			log.Err(err).Str("method", method).Msg("couldn't rotate audit log")
+			return
+		}
+	}
+	n, err := t.file.Write(line)
+	t.size += int64(n)
+	if err != nil {
+		log.Err(err).Str("method", method).Msg("couldn't write audit event")
+	}
+}
+
+// open opens the audit log at path for appending, if it isn't open yet. The mutex must be held.
+func (t *fileTrail) open(path string) error {
+	if t.file != nil && t.path == path {
+		return nil
+	}
+	t.close()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	t.file, t.path, t.size = file, path, info.Size()
+	return nil
+}
+
+// rotate renames the audit log to path.1, shifting older files and deleting the oldest one, and opens a new log.
+// The mutex must be held.
+func (t *fileTrail) rotate() error {
+	path := t.path
+	t.close()
+	_ = os.Remove(rotatedPath(path, maxRotatedFiles))
+	for i := maxRotatedFiles - 1; i >= 1; i-- {
+		if err := os.Rename(rotatedPath(path, i), rotatedPath(path, i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := os.Rename(path, rotatedPath(path, 1)); err != nil {
+		return err
+	}
+	return t.open(path)
+}
+
+// close closes the audit log. The mutex must be held.
+func (t *fileTrail) close() {
+	if t.file != nil {
+		if err := t.file.Close(); err != nil {
+			log.Warn().Err(err).Str("method", "auditlog.fileTrail.close").Msg("couldn't close audit log")
+		}
+	}
+	t.file, t.path, t.size = nil, "", 0
+}
+
+func rotatedPath(path string, i int) string {
+	return fmt.Sprintf("%s.%d", path, i)
+}
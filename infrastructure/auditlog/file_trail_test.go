@@ -0,0 +1,87 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package auditlog
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/audit"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/testutil"
+)
+
+func Test_FileTrail_AppendsJsonLines(t *testing.T) {
+	testutil.UnitTest(t)
+	path := filepath.Join(t.TempDir(), "audit", "audit.jsonl")
+	config.CurrentConfig().SetAuditLogPath(path)
+	trail := NewFileTrail()
+
+	trail.ScanCompleted(audit.ScanProperties{Folder: "/folder", Product: "Vulnmap Open Source", IssueCount: 2})
+	trail.TrustChanged(audit.TrustProperties{Folder: "/folder", Decision: audit.TrustRevoked})
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	require.Len(t, lines, 2)
+	var scan struct {
+		Event      string               `json:"event"`
+		Properties audit.ScanProperties `json:"properties"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &scan))
+	assert.Equal(t, "scan", scan.Event)
+	assert.Equal(t, 2, scan.Properties.IssueCount)
+	assert.Contains(t, lines[1], `"event":"trust"`)
+	assert.Contains(t, lines[1], `"decision":"revoked"`)
+}
+
+func Test_FileTrail_NoPath_RecordsNothing(t *testing.T) {
+	testutil.UnitTest(t)
+	config.CurrentConfig().SetAuditLogPath("")
+	trail := &fileTrail{}
+
+	trail.IgnoreAdded(audit.IgnoreProperties{IssueId: "VULNMAP-1"})
+
+	assert.Nil(t, trail.file)
+}
+
+func Test_FileTrail_RotatesWhenFull(t *testing.T) {
+	testutil.UnitTest(t)
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte(strings.Repeat("x", maxFileSize-10)+"\n"), 0600))
+	require.NoError(t, os.WriteFile(rotatedPath(path, 1), []byte("oldest\n"), 0600))
+	config.CurrentConfig().SetAuditLogPath(path)
+	trail := NewFileTrail()
+
+	trail.FixApplied(audit.FixProperties{Kind: audit.Autofix, IssueId: "VULNMAP-1", FixId: "fix"})
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `"fixId":"fix"`)
+	rotated, err := os.ReadFile(rotatedPath(path, 1))
+	require.NoError(t, err)
+	assert.Len(t, rotated, maxFileSize-9)
+	previous, err := os.ReadFile(rotatedPath(path, 2))
+	require.NoError(t, err)
+	assert.Equal(t, "oldest\n", string(previous))
+}
@@ -21,7 +21,6 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"os"
 	"os/exec"
 	"strings"
 
@@ -194,9 +193,9 @@ func (a *CliAuthenticationProvider) buildCLICmd(ctx context.Context, args ...str
 		args = append(args, "--insecure")
 	}
 	cmd := exec.CommandContext(ctx, config.CurrentConfig().CliSettings().Path(), args...)
-	cmd.Env = cli.AppendCliEnvironmentVariables(os.Environ(), false)
+	cmd.Env = cli.CliEnvironment("", false)
 
-	log.Info().Str("command", cmd.String()).Interface("env", cmd.Env).Msg("running Vulnmap CLI command")
+	log.Info().Str("command", cmd.String()).Interface("env", cli.RedactEnvironment(cmd.Env)).Msg("running Vulnmap CLI command")
 	return cmd
 }
 
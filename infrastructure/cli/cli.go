@@ -17,29 +17,34 @@
 package cli
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"io"
 	"os"
 	"os/exec"
 	"strings"
 	"sync"
-	"time"
 
 	"github.com/rs/zerolog/log"
 
 	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
 	noti "github.com/khulnasoft-lab/vulnmap-ls/domain/ide/notification"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/error_reporting"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/performance"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/ux"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/vulnmap"
+	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/cli/install"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/concurrency"
 )
 
 type VulnmapCli struct {
 	authenticationService vulnmap.AuthenticationService
 	errorReporter         error_reporting.ErrorReporter
 	analytics             ux.Analytics
-	semaphore             chan int
-	cliTimeout            time.Duration
+	limiter               *concurrency.Limiter
 	notifier              noti.Notifier
+	instrumentor          performance.Instrumentor
 }
 
 var Mutex = &sync.Mutex{}
@@ -49,16 +54,15 @@ func NewExecutor(
 	errorReporter error_reporting.ErrorReporter,
 	analytics ux.Analytics,
 	notifier noti.Notifier,
+	instrumentor performance.Instrumentor,
 ) Executor {
-	concurrencyLimit := 2
-
 	return &VulnmapCli{
 		authenticationService,
 		errorReporter,
 		analytics,
-		make(chan int, concurrencyLimit),
-		90 * time.Minute, // TODO: add preference to make this configurable [ROAD-1184]
+		newLimiter(),
 		notifier,
+		instrumentor,
 	}
 }
 
@@ -67,44 +71,114 @@ type Executor interface {
 	ExpandParametersFromConfig(base []string) []string
 }
 
+// StreamingExecutor is implemented by executors that can pass on the output of the CLI while it is still running.
+// consume reads stdout as it is written, the complete stdout is returned as well, e.g. for error handling.
+type StreamingExecutor interface {
+	ExecuteStreaming(
+		ctx context.Context,
+		cmd []string,
+		workingDir string,
+		consume func(stdout io.Reader) error,
+	) (resp []byte, err error)
+}
+
 func (c VulnmapCli) Execute(ctx context.Context, cmd []string, workingDir string) (resp []byte, err error) {
 	method := "VulnmapCli.Execute"
 	log.Debug().Str("method", method).Interface("cmd", cmd).Str("workingDir", workingDir).Msg("calling Vulnmap CLI")
 
-	// set deadline to handle CLI hanging when obtaining semaphore
-	ctx, cancel := context.WithDeadline(ctx, time.Now().Add(c.cliTimeout))
-	defer cancel()
-
-	// handle concurrency limit, and when context is cancelled
-	select {
-	case c.semaphore <- 1:
-		defer func() { <-c.semaphore }()
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	}
-
-	output, err := c.doExecute(ctx, cmd, workingDir)
+	output, err := executeLimited(ctx, c.limiter, func(ctx context.Context) ([]byte, error) {
+		return c.doExecute(ctx, cmd, workingDir)
+	})
 	log.Trace().Str("method", method).Str("response", string(output))
 	return output, err
 }
 
 func (c VulnmapCli) doExecute(ctx context.Context, cmd []string, workingDir string) ([]byte, error) {
+	span := c.instrumentor.StartSpan(ctx, "cli.execute")
+	defer c.instrumentor.Finish(span)
 	command := c.getCommand(cmd, workingDir, ctx)
 	output, err := command.Output()
 	return output, err
 }
 
+func (c VulnmapCli) ExecuteStreaming(
+	ctx context.Context,
+	cmd []string,
+	workingDir string,
+	consume func(stdout io.Reader) error,
+) (resp []byte, err error) {
+	method := "VulnmapCli.ExecuteStreaming"
+	log.Debug().Str("method", method).Interface("cmd", cmd).Str("workingDir", workingDir).Msg("calling Vulnmap CLI")
+
+	output, err := executeLimited(ctx, c.limiter, func(ctx context.Context) ([]byte, error) {
+		return c.doExecuteStreaming(ctx, cmd, workingDir, consume)
+	})
+	log.Trace().Str("method", method).Str("response", string(output))
+	return output, err
+}
+
+func (c VulnmapCli) doExecuteStreaming(
+	ctx context.Context,
+	cmd []string,
+	workingDir string,
+	consume func(stdout io.Reader) error,
+) ([]byte, error) {
+	span := c.instrumentor.StartSpan(ctx, "cli.executeStreaming")
+	defer c.instrumentor.Finish(span)
+	command := c.getCommand(cmd, workingDir, ctx)
+	var stderr bytes.Buffer
+	command.Stderr = &stderr
+	stdoutPipe, err := command.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err = command.Start(); err != nil {
+		return nil, err
+	}
+
+	var stdout bytes.Buffer
+	stdoutReader := io.TeeReader(stdoutPipe, &stdout)
+	if consumeErr := consume(stdoutReader); consumeErr != nil {
+		log.Debug().Err(consumeErr).Str("method", "doExecuteStreaming").Msg("couldn't consume CLI output while running")
+	}
+	// the pipe must be read completely before waiting, and the caller gets the complete output
+	_, _ = io.Copy(io.Discard, stdoutReader)
+
+	err = command.Wait()
+	var exitError *exec.ExitError
+	if errors.As(err, &exitError) {
+		// like command.Output(), make stderr available to the error handling of the caller
+		exitError.Stderr = stderr.Bytes()
+	}
+	return stdout.Bytes(), err
+}
+
 func (c VulnmapCli) getCommand(cmd []string, workingDir string, ctx context.Context) *exec.Cmd {
-	command := exec.CommandContext(ctx, cmd[0], cmd[1:]...)
+	command := exec.CommandContext(ctx, cliPathFor(cmd[0], workingDir), cmd[1:]...)
 	command.Dir = workingDir
-	cliEnv := AppendCliEnvironmentVariables(os.Environ(), true)
-	command.Env = cliEnv
+	command.Env = CliEnvironment(workingDir, true)
 	log.Trace().Str("method", "getCommand").Interface("command.Args", command.Args).Send()
-	log.Trace().Str("method", "getCommand").Interface("command.Env", command.Env).Send()
+	log.Trace().Str("method", "getCommand").Interface("command.Env", RedactEnvironment(command.Env)).Send()
 	log.Trace().Str("method", "getCommand").Interface("command.Dir", command.Dir).Send()
 	return command
 }
 
+// cliPathFor returns the installed CLI version pinned for workingDir instead of the configured CLI. If the pinned
+// version isn't installed (yet), the configured CLI is used.
+func cliPathFor(cliPath string, workingDir string) string {
+	settings := config.CurrentConfig().CliSettings()
+	version := settings.FolderReleaseVersion(workingDir)
+	if version == "" || cliPath != settings.Path() {
+		return cliPath
+	}
+	pinnedPath := install.PinnedCliPath(version)
+	if _, err := os.Stat(pinnedPath); err != nil {
+		log.Warn().Str("method", "cliPathFor").Str("workingDir", workingDir).Msgf("CLI %s is not installed", version)
+		return cliPath
+	}
+	return pinnedPath
+}
+
 func expandParametersFromConfig(base []string) []string {
 	var expandedParams = base
 	conf := config.CurrentConfig()
@@ -19,7 +19,7 @@ package cli
 import (
 	"context"
 	"strings"
-	"time"
+	"sync"
 
 	"github.com/khulnasoft-lab/go-application-framework/pkg/configuration"
 	"github.com/khulnasoft-lab/go-application-framework/pkg/workflow"
@@ -27,44 +27,39 @@ import (
 	"github.com/rs/zerolog/log"
 
 	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/concurrency"
 )
 
+// ExtensionExecutor runs the CLI through the legacycli workflow when the Language Server runs within the Vulnmap CLI.
+// It doesn't implement StreamingExecutor, as the workflow returns the output only once the CLI is finished.
+// The workflow runs the CLI with the environment of the Vulnmap CLI process, so CliEnvironment isn't applied: the
+// environment filter, the folder specific variables, the detected toolchain and the Python interpreter are ignored.
 type ExtensionExecutor struct {
-	semaphore  chan int
-	cliTimeout time.Duration
+	limiter *concurrency.Limiter
+	mutex   sync.Mutex
+	// ignoredEnvSettings are the settings of the last warning about ignored environment settings
+	ignoredEnvSettings string
 }
 
 func NewExtensionExecutor() Executor {
-	concurrencyLimit := 2
-
 	return &ExtensionExecutor{
-		make(chan int, concurrencyLimit),
-		90 * time.Minute, // TODO: add preference to make this configurable [ROAD-1184]
+		limiter: newLimiter(),
 	}
 }
 
-func (c ExtensionExecutor) Execute(ctx context.Context, cmd []string, workingDir string) (resp []byte, err error) {
+func (c *ExtensionExecutor) Execute(ctx context.Context, cmd []string, workingDir string) (resp []byte, err error) {
 	method := "ExtensionExecutor.Execute"
 	log.Debug().Str("method", method).Interface("cmd", cmd[1:]).Str("workingDir", workingDir).Msg("calling legacycli extension")
+	c.warnAboutIgnoredEnvSettings(workingDir)
 
-	// set deadline to handle CLI hanging when obtaining semaphore
-	ctx, cancel := context.WithDeadline(ctx, time.Now().Add(c.cliTimeout))
-	defer cancel()
-
-	// handle concurrency limit, and when context is cancelled
-	select {
-	case c.semaphore <- 1:
-		defer func() { <-c.semaphore }()
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	}
-
-	output, err := c.doExecute(ctx, cmd, workingDir)
+	output, err := executeLimited(ctx, c.limiter, func(ctx context.Context) ([]byte, error) {
+		return c.doExecute(ctx, cmd, workingDir)
+	})
 	log.Trace().Str("method", method).Str("response", string(output))
 	return output, err
 }
 
-func (c ExtensionExecutor) doExecute(ctx context.Context, cmd []string, workingDir string) ([]byte, error) {
+func (c *ExtensionExecutor) doExecute(ctx context.Context, cmd []string, workingDir string) ([]byte, error) {
 	output := []byte{}
 
 	engine := config.CurrentConfig().Engine()
@@ -82,11 +77,41 @@ func (c ExtensionExecutor) doExecute(ctx context.Context, cmd []string, workingD
 	return output, err
 }
 
-func (c ExtensionExecutor) ExpandParametersFromConfig(base []string) []string {
+// warnAboutIgnoredEnvSettings logs a warning if environment settings are configured that the legacycli workflow
+// can't apply. It only warns again when the ignored settings change.
+func (c *ExtensionExecutor) warnAboutIgnoredEnvSettings(workingDir string) {
+	ignored := strings.Join(ignoredEnvSettings(workingDir), ", ")
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if ignored == "" || ignored == c.ignoredEnvSettings {
+		return
+	}
+	c.ignoredEnvSettings = ignored
+	log.Warn().Str("method", "warnAboutIgnoredEnvSettings").Msgf("the settings %s are ignored, as the environment "+
+		"of CLI runs can't be changed when the language server runs within the Vulnmap CLI", ignored)
+}
+
+// ignoredEnvSettings returns the names of the configured settings that CliEnvironment applies to CLI runs in
+// workingDir
+func ignoredEnvSettings(workingDir string) (ignored []string) {
+	settings := config.CurrentConfig().CliSettings()
+	if len(settings.EnvAllowlist) > 0 {
+		ignored = append(ignored, "cliEnvAllowlist")
+	}
+	if len(settings.EnvDenylist) > 0 {
+		ignored = append(ignored, "cliEnvDenylist")
+	}
+	if len(folderEnvironment(settings.FolderEnv, workingDir)) > 0 {
+		ignored = append(ignored, "folderEnv")
+	}
+	return ignored
+}
+
+func (c *ExtensionExecutor) ExpandParametersFromConfig(base []string) []string {
 	return expandParametersFromConfig(base)
 }
 
-func (c ExtensionExecutor) CliVersion() string {
+func (c *ExtensionExecutor) CliVersion() string {
 	cmd := []string{"version"}
 	output, err := c.Execute(context.Background(), cmd, "")
 	if err != nil {
@@ -90,3 +90,36 @@ func Test_ExecuteLegacyCLI_FAILED(t *testing.T) {
 	assert.Equal(t, expectedPayload, actualData)
 
 }
+
+func Test_ExtensionExecutor_DoesNotStream(t *testing.T) {
+	_, ok := NewExtensionExecutor().(StreamingExecutor)
+
+	assert.False(t, ok, "the legacycli workflow returns the output only when the CLI is finished")
+}
+
+func Test_ExtensionExecutor_WarnsAboutIgnoredEnvSettings(t *testing.T) {
+	testutil.UnitTest(t)
+	folder := t.TempDir()
+	settings := config.NewCliSettings()
+	settings.EnvDenylist = []string{"AWS_*"}
+	settings.FolderEnv = map[string][]string{folder: {"MAVEN_OPTS=-Xmx2g"}}
+	config.CurrentConfig().SetCliSettings(settings)
+	config.CurrentConfig().SetEngine(app.CreateAppEngine())
+	executorUnderTest := NewExtensionExecutor().(*ExtensionExecutor)
+
+	t.Run("folder outside of folderEnv", func(t *testing.T) {
+		assert.Equal(t, []string{"cliEnvDenylist"}, ignoredEnvSettings(t.TempDir()))
+	})
+
+	t.Run("folder with folderEnv", func(t *testing.T) {
+		_, _ = executorUnderTest.Execute(context.Background(), []string{"vulnmap", "test"}, folder)
+
+		assert.Equal(t, "cliEnvDenylist, folderEnv", executorUnderTest.ignoredEnvSettings)
+	})
+
+	t.Run("no environment settings", func(t *testing.T) {
+		config.CurrentConfig().SetCliSettings(config.NewCliSettings())
+
+		assert.Empty(t, ignoredEnvSettings(folder))
+	})
+}
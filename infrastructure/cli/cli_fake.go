@@ -17,7 +17,9 @@
 package cli
 
 import (
+	"bytes"
 	"context"
+	"io"
 	"os"
 	"sync"
 	"time"
@@ -88,6 +90,19 @@ func (t *TestExecutor) Execute(ctx context.Context, _ []string, _ string) (resp
 	}
 }
 
+func (t *TestExecutor) ExecuteStreaming(
+	ctx context.Context,
+	cmd []string,
+	workingDir string,
+	consume func(stdout io.Reader) error,
+) (resp []byte, err error) {
+	resp, err = t.Execute(ctx, cmd, workingDir)
+	if resp != nil {
+		_ = consume(bytes.NewReader(resp))
+	}
+	return resp, err
+}
+
 func (t *TestExecutor) ExpandParametersFromConfig(_ []string) []string {
 	return nil
 }
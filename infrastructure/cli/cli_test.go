@@ -18,13 +18,20 @@ package cli
 
 import (
 	"context"
+	"errors"
+	"io"
+	"os/exec"
+	"path/filepath"
 	"testing"
 
 	"github.com/adrg/xdg"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/performance"
+	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/cli/install"
 	"github.com/khulnasoft-lab/vulnmap-ls/internal/testutil"
 )
 
@@ -56,3 +63,44 @@ func TestGetCommand_AddsToEnvironmentAndSetsDir(t *testing.T) {
 	assert.Equal(t, xdg.DataHome, cmd.Dir)
 	assert.Contains(t, cmd.Env, DisableAnalyticsEnvVar+"=1")
 }
+
+func TestGetCommand_UsesCliVersionPinnedForFolder(t *testing.T) {
+	c := testutil.UnitTest(t)
+	xdgDataHome := xdg.DataHome
+	xdg.DataHome = t.TempDir()
+	t.Cleanup(func() { xdg.DataHome = xdgDataHome })
+	folder := t.TempDir()
+	c.CliSettings().SetPath(filepath.Join(t.TempDir(), "vulnmap"))
+	c.CliSettings().FolderReleaseVersions = map[string]string{folder: "1.1200.0"}
+	pinnedPath, err := install.NewFakeInstaller().InstallVersion(context.Background(), "1.1200.0")
+	require.NoError(t, err)
+
+	pinnedCmd := VulnmapCli{}.getCommand([]string{c.CliSettings().Path(), "test"}, filepath.Join(folder, "sub"), context.Background())
+	otherCmd := VulnmapCli{}.getCommand([]string{c.CliSettings().Path(), "test"}, t.TempDir(), context.Background())
+
+	assert.Equal(t, pinnedPath, pinnedCmd.Path)
+	assert.Equal(t, c.CliSettings().Path(), otherCmd.Path)
+}
+
+func Test_ExecuteStreaming_PassesOutputWhileRunningAndReturnsIt(t *testing.T) {
+	testutil.UnitTest(t)
+	testutil.NotOnWindows(t, "uses sh")
+	cli := VulnmapCli{limiter: newLimiter(), instrumentor: performance.NewInstrumentor()}
+	script := "echo first; echo oops >&2; sleep 0.1; echo second; exit 3"
+
+	var consumed []byte
+	output, err := cli.ExecuteStreaming(context.Background(), []string{"sh", "-c", script}, t.TempDir(),
+		func(stdout io.Reader) error {
+			// read only the first line, the rest must still be returned
+			consumed = make([]byte, len("first\n"))
+			_, readErr := io.ReadFull(stdout, consumed)
+			return readErr
+		})
+
+	assert.Equal(t, "first\n", string(consumed))
+	assert.Equal(t, "first\nsecond\n", string(output))
+	var exitError *exec.ExitError
+	require.True(t, errors.As(err, &exitError))
+	assert.Equal(t, 3, exitError.ExitCode())
+	assert.Equal(t, "oops\n", string(exitError.Stderr))
+}
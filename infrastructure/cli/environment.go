@@ -17,6 +17,10 @@
 package cli
 
 import (
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/khulnasoft-lab/go-application-framework/pkg/auth"
@@ -24,6 +28,7 @@ import (
 
 	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
 	"github.com/khulnasoft-lab/vulnmap-ls/internal/lsp"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/uri"
 )
 
 const (
@@ -36,8 +41,148 @@ const (
 	IntegrationEnvironmentVersionEnvVar = "VULNMAP_INTEGRATION_ENVIRONMENT_VERSION"
 	IntegrationEnvironmentEnvVarValue   = "language-server"
 	VulnmapOauthTokenEnvVar                = "VULNMAP_OAUTH_TOKEN"
+	// OfflineEnvVar passes offline mode to the language server and extensions the CLI runs
+	OfflineEnvVar = "VULNMAP_OFFLINE"
 )
 
+// defaultEnvAllowlist contains the patterns of the environment variables that the CLI and the build tools it invokes
+// need. Patterns are matched case-insensitively and support the wildcards of path.Match.
+var defaultEnvAllowlist = []string{
+	// system
+	"PATH", "PATHEXT", "HOME", "USER", "USERNAME", "LOGNAME", "SHELL", "LANG", "LANGUAGE", "LC_*", "TZ", "TERM",
+	"TMP", "TEMP", "TMPDIR", "XDG_*", "USERPROFILE", "HOMEDRIVE", "HOMEPATH", "APPDATA", "LOCALAPPDATA",
+	"PROGRAMDATA", "PROGRAMFILES*", "PROGRAMW6432", "COMMONPROGRAMFILES*", "SYSTEMROOT", "SYSTEMDRIVE", "WINDIR",
+	"COMSPEC", "PROCESSOR_ARCHITECTURE", "NUMBER_OF_PROCESSORS", "OS",
+	// git and ssh, e.g. for private dependencies
+	"SSH_AUTH_SOCK", "GIT_*",
+	// proxies and certificates
+	"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY", "ALL_PROXY", "SSL_CERT_FILE", "SSL_CERT_DIR", "NODE_EXTRA_CA_CERTS",
+	"REQUESTS_CA_BUNDLE",
+	// build tools
+	"JAVA_HOME", "JAVA_OPTS", "JDK_*", "M2_HOME", "MAVEN_*", "GRADLE_*", "SBT_*", "NODE_*", "NVM_*", "NPM_CONFIG_*",
+	"YARN_*", "PNPM_HOME", "VOLTA_*", "COREPACK_*", "PYTHON*", "VIRTUAL_ENV", "CONDA_*", "PIP_*", "PIPENV_*",
+	"POETRY_*", "GOPATH", "GOROOT", "GOPROXY", "GOPRIVATE", "GONOPROXY", "GONOSUMDB", "GOINSECURE", "GOFLAGS",
+	"GO111MODULE", "DOTNET_*", "NUGET_*", "MSBUILD*", "GEM_*", "BUNDLE_*", "RUBY*", "COMPOSER_*", "CARGO_*",
+	"RUSTUP_*", "SWIFT*",
+	// toolchain managers
+	"PYENV_*", "ASDF_*", "SDKMAN_*",
+	// cli configuration
+	"VULNMAP_*",
+}
+
+// defaultEnvDenylist contains the patterns of environment variables that are likely to contain secrets.
+// Variables matching them are only passed to the CLI if they are explicitly named in the configured allowlist.
+var defaultEnvDenylist = []string{
+	"*TOKEN*", "*SECRET*", "*PASSWORD*", "*PASSWD*", "*API_KEY*", "*APIKEY*", "*CREDENTIAL*", "*PRIVATE_KEY*",
+	"*_AUTH",
+}
+
+// allowAllEnv is the allowlist entry that passes the whole environment to the CLI
+const allowAllEnv = "*"
+
+// CliEnvironment returns the environment of a CLI process running in workingDir. It consists of the filtered
+// environment of the language server, the additional and folder specific variables configured by the user and
+// the variables the language server sets for the CLI. It isn't applied when the language server runs within the
+// Vulnmap CLI, see ExtensionExecutor.
+func CliEnvironment(workingDir string, appendToken bool) []string {
+	c := config.CurrentConfig()
+	settings := c.CliSettings()
+	env := FilterEnvironment(os.Environ(), settings.EnvAllowlist, settings.EnvDenylist)
+	for _, dir := range c.ToolchainPath(workingDir) {
+		env = append(env, prependToPath(env, dir))
+	}
+	if interpreter := c.PythonInterpreter(workingDir); interpreter != "" {
+		// pip and other tools of the folder's python environment must be found before the global ones
+		env = append(env, prependToPath(env, filepath.Dir(interpreter)))
+	}
+	env = append(env, settings.AdditionalEnv...)
+	env = append(env, folderEnvironment(settings.FolderEnv, workingDir)...)
+	return AppendCliEnvironmentVariables(env, appendToken)
+}
+
+// FilterEnvironment returns the variables of env that match the default or the given allowlist and don't match
+// the default or the given denylist. Variables that are explicitly named in the allowlist are not subject to the
+// default denylist, and an allowlist entry of "*" passes all variables not matching the denylists.
+func FilterEnvironment(env []string, allowlist []string, denylist []string) (filtered []string) {
+	allowAll := false
+	explicitlyAllowed := map[string]bool{}
+	for _, pattern := range allowlist {
+		if pattern == allowAllEnv {
+			allowAll = true
+		}
+		explicitlyAllowed[strings.ToUpper(pattern)] = true
+	}
+
+	for _, s := range env {
+		name, _, _ := strings.Cut(s, "=")
+		upperName := strings.ToUpper(name)
+		if matchesEnvPattern(upperName, denylist) {
+			continue
+		}
+		if !explicitlyAllowed[upperName] && matchesEnvPattern(upperName, defaultEnvDenylist) {
+			continue
+		}
+		if allowAll || matchesEnvPattern(upperName, allowlist) || matchesEnvPattern(upperName, defaultEnvAllowlist) {
+			filtered = append(filtered, s)
+		}
+	}
+	return filtered
+}
+
+func matchesEnvPattern(upperName string, patterns []string) bool {
+	for _, pattern := range patterns {
+		matched, err := path.Match(strings.ToUpper(pattern), upperName)
+		if err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// folderEnvironment returns the variables of all configured folders containing workingDir. The variables of
+// nested folders come last, so they take precedence.
+func folderEnvironment(folderEnv map[string][]string, workingDir string) (env []string) {
+	if workingDir == "" {
+		return nil
+	}
+	var folders []string
+	for folder := range folderEnv {
+		if uri.FolderContains(folder, workingDir) {
+			folders = append(folders, folder)
+		}
+	}
+	sort.Slice(folders, func(i, j int) bool { return len(folders[i]) < len(folders[j]) })
+	for _, folder := range folders {
+		env = append(env, folderEnv[folder]...)
+	}
+	return env
+}
+
+// prependToPath returns the PATH variable of env with dir as first entry
+func prependToPath(env []string, dir string) string {
+	pathVar, path := "PATH", ""
+	for _, s := range env {
+		name, value, _ := strings.Cut(s, "=")
+		if strings.EqualFold(name, "PATH") {
+			pathVar, path = name, value
+		}
+	}
+	if path == "" {
+		return pathVar + "=" + dir
+	}
+	return pathVar + "=" + dir + string(os.PathListSeparator) + path
+}
+
+// RedactEnvironment returns the names of the variables with redacted values, to be used in logs
+func RedactEnvironment(env []string) []string {
+	redacted := make([]string, 0, len(env))
+	for _, s := range env {
+		name, _, _ := strings.Cut(s, "=")
+		redacted = append(redacted, name+"=***")
+	}
+	return redacted
+}
+
 // AppendCliEnvironmentVariables Returns the input array with additional variables used in the CLI run in the form of "key=value".
 // Since we append, our values are overwriting existing env variables (because exec.Cmd.Env chooses the last value
 // in case of key duplications).
@@ -51,6 +196,7 @@ func AppendCliEnvironmentVariables(currentEnv []string, appendToken bool) (updat
 		TokenEnvVar:                              true,
 		VulnmapOauthTokenEnvVar:                     true,
 		DisableAnalyticsEnvVar:                   true,
+		OfflineEnvVar:                            true,
 		auth.CONFIG_KEY_OAUTH_TOKEN:              true,
 		configuration.FF_OAUTH_AUTH_FLOW_ENABLED: true,
 	}
@@ -77,9 +223,13 @@ func AppendCliEnvironmentVariables(currentEnv []string, appendToken bool) (updat
 	if currentConfig.VulnmapApi() != "" {
 		updatedEnv = append(updatedEnv, ApiEnvVar+"="+currentConfig.VulnmapApi())
 	}
-	if !currentConfig.IsTelemetryEnabled() {
+	// offline mode doesn't allow the analytics of the CLI either
+	if !currentConfig.IsTelemetryEnabled() || currentConfig.IsOfflineMode() {
 		updatedEnv = append(updatedEnv, DisableAnalyticsEnvVar+"=1")
 	}
+	if currentConfig.IsOfflineMode() {
+		updatedEnv = append(updatedEnv, OfflineEnvVar+"=1")
+	}
 
 	if currentConfig.IntegrationName() != "" {
 		updatedEnv = append(updatedEnv, IntegrationNameEnvVarKey+"="+currentConfig.IntegrationName())
@@ -17,9 +17,13 @@
 package cli
 
 import (
+	"os"
+	"path/filepath"
+	"slices"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
 	"github.com/khulnasoft-lab/vulnmap-ls/internal/lsp"
@@ -112,4 +116,131 @@ func TestAddConfigValuesToEnv(t *testing.T) {
 
 		assert.Contains(t, updatedEnv, "VULNMAP_CFG_DISABLE_ANALYTICS=1")
 	})
+
+	t.Run("Disables analytics and passes offline mode, if offline", func(t *testing.T) {
+		testutil.UnitTest(t)
+		c := config.CurrentConfig()
+		c.SetTelemetryEnabled(true)
+		c.SetOfflineMode(true)
+
+		updatedEnv := AppendCliEnvironmentVariables([]string{OfflineEnvVar + "=0"}, true)
+
+		assert.Contains(t, updatedEnv, DisableAnalyticsEnvVar+"=1")
+		assert.Contains(t, updatedEnv, OfflineEnvVar+"=1")
+		assert.NotContains(t, updatedEnv, OfflineEnvVar+"=0")
+	})
+}
+
+func TestFilterEnvironment(t *testing.T) {
+	env := []string{
+		"PATH=/usr/bin",
+		"Path=C:\\Windows",
+		"JAVA_HOME=/opt/java",
+		"MAVEN_OPTS=-Xmx2g",
+		"HTTPS_PROXY=http://proxy:3128",
+		"SSH_AUTH_SOCK=/tmp/ssh-agent.sock",
+		"GIT_SSH_COMMAND=ssh -i key",
+		"PYENV_ROOT=/home/user/.pyenv",
+		"ASDF_DIR=/home/user/.asdf",
+		"SDKMAN_DIR=/home/user/.sdkman",
+		"GITHUB_TOKEN=secret",
+		"NPM_TOKEN=npm-secret",
+		"AWS_SECRET_ACCESS_KEY=aws-secret",
+		"ARTIFACTORY_URL=https://artifactory",
+		"RANDOM_VAR=value",
+	}
+
+	t.Run("passes only default allowlisted variables", func(t *testing.T) {
+		filtered := FilterEnvironment(env, nil, nil)
+
+		assert.Equal(t, []string{
+			"PATH=/usr/bin",
+			"Path=C:\\Windows",
+			"JAVA_HOME=/opt/java",
+			"MAVEN_OPTS=-Xmx2g",
+			"HTTPS_PROXY=http://proxy:3128",
+			"SSH_AUTH_SOCK=/tmp/ssh-agent.sock",
+			"GIT_SSH_COMMAND=ssh -i key",
+			"PYENV_ROOT=/home/user/.pyenv",
+			"ASDF_DIR=/home/user/.asdf",
+			"SDKMAN_DIR=/home/user/.sdkman",
+		}, filtered)
+	})
+
+	t.Run("passes configured allowlist patterns and explicitly named secrets", func(t *testing.T) {
+		filtered := FilterEnvironment(env, []string{"artifactory_*", "NPM_TOKEN", "*_SECRET_*"}, nil)
+
+		assert.Contains(t, filtered, "ARTIFACTORY_URL=https://artifactory")
+		assert.Contains(t, filtered, "NPM_TOKEN=npm-secret")
+		assert.NotContains(t, filtered, "GITHUB_TOKEN=secret")
+		assert.NotContains(t, filtered, "AWS_SECRET_ACCESS_KEY=aws-secret")
+	})
+
+	t.Run("configured denylist takes precedence", func(t *testing.T) {
+		filtered := FilterEnvironment(env, []string{"NPM_TOKEN"}, []string{"*_PROXY", "NPM_TOKEN"})
+
+		assert.NotContains(t, filtered, "HTTPS_PROXY=http://proxy:3128")
+		assert.NotContains(t, filtered, "NPM_TOKEN=npm-secret")
+		assert.Contains(t, filtered, "JAVA_HOME=/opt/java")
+	})
+
+	t.Run("wildcard allowlist passes everything but secrets", func(t *testing.T) {
+		filtered := FilterEnvironment(env, []string{"*"}, nil)
+
+		assert.Contains(t, filtered, "RANDOM_VAR=value")
+		assert.NotContains(t, filtered, "GITHUB_TOKEN=secret")
+		assert.NotContains(t, filtered, "AWS_SECRET_ACCESS_KEY=aws-secret")
+	})
+}
+
+func TestCliEnvironment(t *testing.T) {
+	testutil.UnitTest(t)
+	c := config.CurrentConfig()
+	c.SetAuthenticationMethod(lsp.TokenAuthentication)
+	c.SetToken("testToken")
+	t.Setenv("GITHUB_TOKEN", "secret")
+	t.Setenv("JAVA_HOME", "/opt/java")
+	projectDir := filepath.Join(t.TempDir(), "project")
+	moduleDir := filepath.Join(projectDir, "module")
+	settings := config.NewCliSettings()
+	settings.AdditionalEnv = []string{"GLOBAL=1"}
+	settings.FolderEnv = map[string][]string{
+		projectDir:                      {"MAVEN_OPTS=-Xmx1g", "PROJECT=1"},
+		moduleDir:                       {"MAVEN_OPTS=-Xmx2g"},
+		filepath.Join(projectDir + "2"): {"OTHER=1"},
+	}
+	c.SetCliSettings(settings)
+
+	env := CliEnvironment(moduleDir, true)
+
+	assert.Contains(t, env, "JAVA_HOME=/opt/java")
+	assert.Contains(t, env, "GLOBAL=1")
+	assert.Contains(t, env, "PROJECT=1")
+	assert.NotContains(t, env, "OTHER=1")
+	assert.NotContains(t, env, "GITHUB_TOKEN=secret")
+	assert.Contains(t, env, TokenEnvVar+"=testToken")
+	// exec.Cmd uses the last value of duplicated keys, so the most specific folder wins
+	assert.Less(t, slices.Index(env, "MAVEN_OPTS=-Xmx1g"), slices.Index(env, "MAVEN_OPTS=-Xmx2g"))
+}
+
+func TestRedactEnvironment(t *testing.T) {
+	redacted := RedactEnvironment([]string{"VULNMAP_TOKEN=secret", "PATH=/usr/bin=x", "EMPTY"})
+
+	assert.Equal(t, []string{"VULNMAP_TOKEN=***", "PATH=***", "EMPTY=***"}, redacted)
+}
+
+func TestCliEnvironment_PrependsPythonEnvironmentToPath(t *testing.T) {
+	testutil.UnitTest(t)
+	testutil.NotOnWindows(t, "virtual environments use Scripts\\python.exe on windows")
+	t.Setenv("PATH", "/usr/bin")
+	folder := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(folder, "requirements.txt"), []byte("flask"), 0o600))
+	binDir := filepath.Join(folder, ".venv", "bin")
+	require.NoError(t, os.MkdirAll(binDir, 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "python"), []byte{}, 0o700))
+
+	env := CliEnvironment(folder, false)
+
+	assert.Contains(t, env, "PATH="+binDir+string(os.PathListSeparator)+"/usr/bin")
+	assert.Less(t, slices.Index(env, "PATH=/usr/bin"), slices.Index(env, "PATH="+binDir+string(os.PathListSeparator)+"/usr/bin"))
 }
@@ -0,0 +1,308 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrorType classifies CLI failures that the user can fix. It is used as diagnostic code of the error diagnostic.
+type ErrorType string
+
+const (
+	ErrorTypeNoSupportedFiles ErrorType = "vulnmap-cli/no-supported-files"
+	ErrorTypeMissingBuildTool ErrorType = "vulnmap-cli/missing-build-tool"
+	ErrorTypeAuthFailed       ErrorType = "vulnmap-cli/auth-failed"
+	ErrorTypeOrgNotFound      ErrorType = "vulnmap-cli/org-not-found"
+)
+
+// noSupportedFilesExitCode is returned by the CLI if no supported projects were detected
+const noSupportedFilesExitCode = 3
+
+// ScanError is a CLI failure of a known type, parsed from the JSON error payload and the error output of the CLI
+type ScanError struct {
+	Type ErrorType
+	// Code is the error code reported by the CLI, if any
+	Code string
+	// Message is the error message reported by the CLI
+	Message string
+	// Path is the file (or directory) the CLI reported the error for, if any
+	Path string
+	// Tool is the name of the missing build tool for errors of type ErrorTypeMissingBuildTool
+	Tool string
+}
+
+func (e *ScanError) Error() string {
+	return e.Message + "\n" + e.Remedy()
+}
+
+// DiagnosticCode returns the code of the diagnostic that shows the error
+func (e *ScanError) DiagnosticCode() string {
+	return string(e.Type)
+}
+
+// DiagnosticData returns the missing build tool, so that code actions can be specific to it
+func (e *ScanError) DiagnosticData() any {
+	if e.Tool == "" {
+		return nil
+	}
+	return map[string]string{"tool": e.Tool}
+}
+
+// Remedy returns a suggestion how the user can fix the error
+func (e *ScanError) Remedy() string {
+	switch e.Type {
+	case ErrorTypeNoSupportedFiles:
+		return "Open a folder with a supported manifest or lock file, or check the additional parameters of the scan."
+	case ErrorTypeMissingBuildTool:
+		if e.Tool == "java" {
+			return "Set JAVA_HOME to a JDK installation, e.g. with the additionalEnv or folderEnv setting, " +
+				"or add java to the path setting."
+		}
+		tool := e.Tool
+		if tool == "" {
+			tool = "the build tool of the project"
+		}
+		return fmt.Sprintf("Install %s and make sure it can be found, e.g. by adding it to the path setting.", tool)
+	case ErrorTypeAuthFailed:
+		return "Re-authenticate with Vulnmap."
+	case ErrorTypeOrgNotFound:
+		return "Check the organization setting, it must be an organization your account is a member of."
+	default:
+		return ""
+	}
+}
+
+// DiagnosticPath returns the file the error should be shown on: the file reported by the CLI if it exists,
+// otherwise the scanned path
+func (e *ScanError) DiagnosticPath(scanPath string) string {
+	if e.Path == "" {
+		return scanPath
+	}
+	path := e.Path
+	if !filepath.IsAbs(path) {
+		dir := scanPath
+		if info, err := os.Stat(scanPath); err == nil && !info.IsDir() {
+			dir = filepath.Dir(scanPath)
+		}
+		path = filepath.Join(dir, path)
+	}
+	if _, err := os.Stat(path); err != nil {
+		return scanPath
+	}
+	return path
+}
+
+type errorPattern struct {
+	errorType ErrorType
+	pattern   *regexp.Regexp
+}
+
+// errorCodes map the error codes of the CLI to the error types they stand for
+var errorCodes = map[string]ErrorType{
+	"401":          ErrorTypeAuthFailed,
+	"VULNMAP-0005": ErrorTypeAuthFailed,
+}
+
+// errorPatterns are matched in order against the error message and the error output of the CLI, before missing build
+// tools and missing projects. They match messages of the CLI, not words that also occur in regular output.
+var errorPatterns = []errorPattern{
+	{ErrorTypeAuthFailed, regexp.MustCompile(
+		`(?i)authentication failed\. please check the api token|requires an authenticated account|` +
+			`missing api token|please run .?vulnmap auth`)},
+	{ErrorTypeOrgNotFound, regexp.MustCompile(
+		`(?i)\borg(?:ani[sz]ation)? (?:"[^"\n]*"|'[^'\n]*'|\S+) (?:was not found|does not exist)`)},
+}
+
+// noSupportedFilesPattern matches the messages of the CLI if it found no project to scan
+var noSupportedFilesPattern = regexp.MustCompile(
+	`(?i)could not detect supported target files|no supported (target )?(files|projects)|could not detect package manager|could not find any valid iac files`)
+
+// buildToolNames are the executables the CLI runs to resolve dependencies
+const buildToolNames = `java|javac|mvnw?|gradlew?|sbt|python3?|pip3?|pipenv|poetry|node|npm|yarn|pnpm|dotnet|nuget|go`
+
+// missingBuildToolPatterns match the messages of shells, the operating system and the CLI for build tools that
+// can't be found. The tool group is the name of the missing tool.
+var missingBuildToolPatterns = []*regexp.Regexp{
+	// sh, bash and zsh
+	regexp.MustCompile(`(?im)(?:^|[\s:/])(?P<tool>` + buildToolNames + `): (?:command )?not found`),
+	regexp.MustCompile(`(?i)'(?P<tool>` + buildToolNames + `)' is not recognized as an internal or external command`),
+	regexp.MustCompile(`(?i)exec: "(?P<tool>` + buildToolNames + `)": executable file not found`),
+	regexp.MustCompile(`(?i)(?P<tool>java)_home is (?:not set|set to an invalid directory)`),
+	regexp.MustCompile(`(?i)please (?:ensure|make sure) (?P<tool>` + buildToolNames + `) is installed`),
+	regexp.MustCompile(`(?im)could not find (?:the )?(?P<tool>` + buildToolNames + `)(?:\s+(?:executable|binary|command)|[\s'"]*$)`),
+}
+
+// buildTools maps the names of missing executables to the tool names shown to the user
+var buildTools = map[string]string{
+	"javac":   "java",
+	"mvn":     "maven",
+	"mvnw":    "maven",
+	"gradlew": "gradle",
+	"python3": "python",
+	"pip":     "python",
+	"pip3":    "python",
+	"pipenv":  "python",
+	"poetry":  "python",
+	"node":    "npm",
+	"nuget":   "dotnet",
+}
+
+// missingBuildTool returns the name of the build tool the text reports as missing, ok is false if it doesn't
+func missingBuildTool(text string) (tool string, ok bool) {
+	for _, pattern := range missingBuildToolPatterns {
+		match := pattern.FindStringSubmatch(text)
+		if match == nil {
+			continue
+		}
+		tool = strings.ToLower(match[pattern.SubexpIndex("tool")])
+		if name, found := buildTools[tool]; found {
+			tool = name
+		}
+		return tool, true
+	}
+	return "", false
+}
+
+type jsonApiError struct {
+	Status string `json:"status"`
+	Code   string `json:"code"`
+	Title  string `json:"title"`
+	Detail string `json:"detail"`
+}
+
+// errorPayload is the JSON the CLI prints instead of results when it fails
+type errorPayload struct {
+	Error             string          `json:"error"`
+	Message           string          `json:"message"`
+	Path              string          `json:"path"`
+	TargetFile        string          `json:"targetFile"`
+	DisplayTargetFile string          `json:"displayTargetFile"`
+	Code              json.RawMessage `json:"code"`
+	Errors            []jsonApiError  `json:"errors"`
+}
+
+func (p errorPayload) message() string {
+	if p.Error != "" {
+		return p.Error
+	}
+	if p.Message != "" {
+		return p.Message
+	}
+	for _, e := range p.Errors {
+		if e.Detail != "" {
+			return e.Detail
+		}
+		if e.Title != "" {
+			return e.Title
+		}
+	}
+	return ""
+}
+
+func (p errorPayload) code() string {
+	for _, e := range p.Errors {
+		if e.Code != "" {
+			return e.Code
+		}
+		if e.Status != "" {
+			return e.Status
+		}
+	}
+	if len(p.Code) == 0 {
+		return ""
+	}
+	if code, err := strconv.Unquote(string(p.Code)); err == nil {
+		return code
+	}
+	return string(p.Code)
+}
+
+func (p errorPayload) path() string {
+	if p.TargetFile != "" {
+		return p.TargetFile
+	}
+	if p.DisplayTargetFile != "" {
+		return p.DisplayTargetFile
+	}
+	return p.Path
+}
+
+// ParseScanError classifies a failed CLI run by its JSON output, its error output and its exit code.
+// It returns nil if the failure is not of a known type.
+func ParseScanError(output []byte, stderr []byte, exitCode int) *ScanError {
+	payload := parseErrorPayload(output)
+	message := payload.message()
+	text := message + "\n" + string(stderr)
+	if message == "" {
+		message = strings.TrimSpace(string(stderr))
+	}
+
+	scanError := &ScanError{Code: payload.code(), Message: message, Path: payload.path()}
+	scanError.Type = errorCodes[scanError.Code]
+	if scanError.Type == "" {
+		for _, p := range errorPatterns {
+			if p.pattern.MatchString(text) {
+				scanError.Type = p.errorType
+				break
+			}
+		}
+	}
+	if scanError.Type == "" {
+		if tool, ok := missingBuildTool(text); ok {
+			scanError.Type, scanError.Tool = ErrorTypeMissingBuildTool, tool
+		}
+	}
+	if scanError.Type == "" {
+		if exitCode != noSupportedFilesExitCode && !noSupportedFilesPattern.MatchString(text) {
+			return nil
+		}
+		scanError.Type = ErrorTypeNoSupportedFiles
+	}
+	if scanError.Message == "" {
+		scanError.Message = "The Vulnmap CLI failed."
+	}
+	return scanError
+}
+
+// parseErrorPayload returns the first error of the CLI output, which can be a single JSON object or an array of
+// results, e.g. when scanning all projects
+func parseErrorPayload(output []byte) errorPayload {
+	output = bytes.TrimSpace(output)
+	var payloads []errorPayload
+	if bytes.HasPrefix(output, []byte("[")) {
+		_ = json.Unmarshal(output, &payloads)
+	} else {
+		var payload errorPayload
+		if json.Unmarshal(output, &payload) == nil {
+			payloads = append(payloads, payload)
+		}
+	}
+	for _, payload := range payloads {
+		if payload.message() != "" {
+			return payload
+		}
+	}
+	return errorPayload{}
+}
@@ -0,0 +1,150 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseScanError(t *testing.T) {
+	tests := []struct {
+		name         string
+		output       string
+		stderr       string
+		exitCode     int
+		expectedType ErrorType
+		expectedTool string
+		expectedCode string
+		expectedPath string
+	}{
+		{
+			name:         "missing java",
+			output:       `{"ok": false, "error": "ERROR: JAVA_HOME is not set and no 'java' command could be found", "path": "pom.xml"}`,
+			exitCode:     2,
+			expectedType: ErrorTypeMissingBuildTool,
+			expectedTool: "java",
+			expectedPath: "pom.xml",
+		},
+		{
+			name:         "missing build tool on stderr",
+			output:       "not json",
+			stderr:       "/bin/sh: mvn: command not found",
+			exitCode:     2,
+			expectedType: ErrorTypeMissingBuildTool,
+			expectedTool: "maven",
+		},
+		{
+			name:         "authentication failed as json api error",
+			output:       `{"jsonapi": {"version": "1.0"}, "errors": [{"status": "401", "code": "VULNMAP-0005", "detail": "Authentication failed. Please check the API token"}]}`,
+			exitCode:     2,
+			expectedType: ErrorTypeAuthFailed,
+			expectedCode: "VULNMAP-0005",
+		},
+		{
+			name:         "unauthorized status code",
+			output:       `{"ok": false, "error": "request failed", "code": 401}`,
+			exitCode:     2,
+			expectedType: ErrorTypeAuthFailed,
+			expectedCode: "401",
+		},
+		{
+			name:         "org not found",
+			output:       `{"ok": false, "error": "Org my-org was not found or you may not have the correct permissions"}`,
+			exitCode:     2,
+			expectedType: ErrorTypeOrgNotFound,
+		},
+		{
+			name:         "no supported files in an array of results",
+			output:       `[{"ok": true}, {"ok": false, "error": "Could not detect supported target files in /project", "code": 1010}]`,
+			exitCode:     2,
+			expectedType: ErrorTypeNoSupportedFiles,
+			expectedCode: "1010",
+		},
+		{
+			name:         "missing build tool on windows",
+			stderr:       "'gradle' is not recognized as an internal or external command,\r\noperable program or batch file.",
+			exitCode:     2,
+			expectedType: ErrorTypeMissingBuildTool,
+			expectedTool: "gradle",
+		},
+		{
+			name:         "missing go executable",
+			output:       `{"ok": false, "error": "exec: \"go\": executable file not found in $PATH"}`,
+			exitCode:     2,
+			expectedType: ErrorTypeMissingBuildTool,
+			expectedTool: "go",
+		},
+		{
+			name:         "no supported projects exit code",
+			exitCode:     3,
+			expectedType: ErrorTypeNoSupportedFiles,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scanError := ParseScanError([]byte(test.output), []byte(test.stderr), test.exitCode)
+
+			require.NotNil(t, scanError)
+			assert.Equal(t, test.expectedType, scanError.Type)
+			assert.Equal(t, test.expectedTool, scanError.Tool)
+			assert.Equal(t, test.expectedCode, scanError.Code)
+			assert.Equal(t, test.expectedPath, scanError.Path)
+			assert.NotEmpty(t, scanError.Message)
+			assert.Contains(t, scanError.Error(), scanError.Remedy())
+		})
+	}
+
+	t.Run("regular output is not typed", func(t *testing.T) {
+		for _, stderr := range []string{
+			"go: downloading golang.org/x/text v0.14.0\ngo: module not found",
+			"npm ERR! 401 Unauthorized - GET https://registry.example.com/left-pad",
+			"npm WARN deprecated node-uuid@1.4.8: use uuid",
+			"warning: the organization of the imports was not found in .editorconfig",
+			"hook: lint-staged: command not found, skipping",
+		} {
+			assert.Nil(t, ParseScanError(nil, []byte(stderr), 2), stderr)
+		}
+	})
+
+	t.Run("unknown errors are not typed", func(t *testing.T) {
+		scanError := ParseScanError([]byte(`{"ok": false, "error": "something unexpected happened"}`), nil, 2)
+
+		assert.Nil(t, scanError)
+	})
+}
+
+func TestScanError_Remedy(t *testing.T) {
+	assert.Contains(t, (&ScanError{Type: ErrorTypeMissingBuildTool, Tool: "java"}).Remedy(), "JAVA_HOME")
+	assert.Contains(t, (&ScanError{Type: ErrorTypeMissingBuildTool, Tool: "gradle"}).Remedy(), "Install gradle")
+	assert.Contains(t, (&ScanError{Type: ErrorTypeAuthFailed}).Remedy(), "Re-authenticate")
+}
+
+func TestScanError_DiagnosticPath(t *testing.T) {
+	dir := t.TempDir()
+	manifest := filepath.Join(dir, "pom.xml")
+	require.NoError(t, os.WriteFile(manifest, []byte("<project/>"), 0o600))
+
+	assert.Equal(t, manifest, (&ScanError{Path: "pom.xml"}).DiagnosticPath(dir))
+	assert.Equal(t, manifest, (&ScanError{Path: manifest}).DiagnosticPath(dir))
+	assert.Equal(t, dir, (&ScanError{Path: "build.gradle"}).DiagnosticPath(dir))
+	assert.Equal(t, dir, (&ScanError{}).DiagnosticPath(dir))
+}
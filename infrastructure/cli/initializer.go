@@ -31,6 +31,7 @@ import (
 	noti "github.com/khulnasoft-lab/vulnmap-ls/domain/ide/notification"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/error_reporting"
 	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/cli/install"
+	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/network"
 	"github.com/khulnasoft-lab/vulnmap-ls/internal/lsp"
 )
 
@@ -77,7 +78,12 @@ func (i *Initializer) Init() error {
 
 	if cliInstalled {
 		if i.isOutdatedCli() {
-			go i.updateCli()
+			go func() {
+				i.updateCli()
+				i.InstallFolderVersions()
+			}()
+		} else {
+			go i.InstallFolderVersions()
 		}
 		i.notifier.Send(lsp.VulnmapIsAvailableCli{CliPath: cliPathInConfig()})
 		return nil
@@ -98,6 +104,8 @@ func (i *Initializer) Init() error {
 			time.Sleep(2 * time.Second)
 		}
 	}
+	// waits for Init to release the mutex, so that the pinned versions are installed after the CLI
+	go i.InstallFolderVersions()
 	return nil
 }
 
@@ -148,8 +156,13 @@ func (i *Initializer) installCli() {
 }
 
 func (i *Initializer) handleInstallerError(err error) {
+	// in offline mode, only CLI release sources on the local file system can be used, failing downloads are expected
+	if errors.Is(err, network.ErrOffline) {
+		log.Info().Err(err).Str("method", "handleInstallerError").Msg("CLI can't be downloaded in offline mode")
+		return
+	}
 	// we don't want to report errors caused by concurrent downloads, they will resolve themselves after 1h
-	if !strings.Contains(err.Error(), "installer lockfile from ") {
+	if !isLockFileError(err) {
 		i.errorReporter.CaptureError(err)
 	}
 }
@@ -172,7 +185,63 @@ func (i *Initializer) updateCli() {
 	}
 }
 
+// isLockFileError returns true if a download failed because another download holds the installer lockfile
+func isLockFileError(err error) bool {
+	return strings.Contains(err.Error(), "installer lockfile from ")
+}
+
+// folderVersionInstallAttempts and folderVersionRetryDelay limit how long a pinned version waits for a download of
+// another process, which holds the installer lockfile for up to 10 minutes
+var (
+	folderVersionInstallAttempts = 20
+	folderVersionRetryDelay      = 30 * time.Second
+)
+
+// InstallFolderVersions installs the CLI versions pinned for folders that aren't installed yet
+func (i *Initializer) InstallFolderVersions() {
+	c := config.CurrentConfig()
+	if !c.ManageCliBinariesAutomatically() {
+		return
+	}
+	installed := map[string]bool{}
+	for folder, version := range c.CliSettings().FolderReleaseVersions {
+		if installed[version] {
+			continue
+		}
+		installed[version] = true
+		i.installFolderVersion(folder, version)
+	}
+}
+
+// installFolderVersion installs a pinned version under the CLI mutex, so that it doesn't compete with an install or
+// update of the CLI for the installer lockfile, and retries while another process holds the lockfile
+func (i *Initializer) installFolderVersion(folder string, version string) {
+	logger := log.With().Str("method", "installFolderVersion").Str("folder", folder).Logger()
+	for attempt := 1; ; attempt++ {
+		Mutex.Lock()
+		cliPath, err := i.installer.InstallVersion(context.Background(), version)
+		Mutex.Unlock()
+		if err == nil {
+			logger.Info().Msgf("CLI %s installed at %s", version, cliPath)
+			return
+		}
+		if isLockFileError(err) && attempt < folderVersionInstallAttempts {
+			logger.Debug().Msgf("another download is in progress, retrying to install CLI %s in %s", version,
+				folderVersionRetryDelay)
+			time.Sleep(folderVersionRetryDelay)
+			continue
+		}
+		logger.Err(err).Msgf("couldn't install CLI %s", version)
+		i.handleInstallerError(err)
+		return
+	}
+}
+
 func (i *Initializer) isOutdatedCli() bool {
+	if pinned := config.CurrentConfig().CliSettings().ReleaseVersion; pinned != "" {
+		return !sameVersion(config.CurrentConfig().CliSettings().Version(), pinned)
+	}
+
 	cliPath := cliPathInConfig()
 
 	fileInfo, err := os.Stat(cliPath) // todo: we can save stat calls by caching mod time
@@ -186,6 +255,12 @@ func (i *Initializer) isOutdatedCli() bool {
 	return fileInfo.ModTime().Before(fourDaysAgo)
 }
 
+// sameVersion returns true if the output of `vulnmap --version`, e.g. "1.1234.0 (standalone)", is the given version
+func sameVersion(cliVersion string, version string) bool {
+	fields := strings.Fields(cliVersion)
+	return len(fields) > 0 && strings.TrimPrefix(fields[0], "v") == strings.TrimPrefix(strings.TrimSpace(version), "v")
+}
+
 // logCliVersion runs the cli with `--version` and returns the version
 func (i *Initializer) logCliVersion(cliPath string) {
 	output, err := i.cli.Execute(context.Background(), []string{cliPath, "--version"}, "")
@@ -194,6 +269,7 @@ func (i *Initializer) logCliVersion(cliPath string) {
 		version = string(output)
 		version = strings.Trim(version, "\n")
 	}
+	config.CurrentConfig().CliSettings().SetVersion(version)
 	log.Info().Msg("vulnmap-cli: " + version + " (" + cliPath + ")")
 }
 
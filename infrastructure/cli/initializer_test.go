@@ -17,15 +17,19 @@
 package cli
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"os"
 	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/adrg/xdg"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/audit"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/error_reporting"
 	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/cli/filename"
 	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/cli/install"
@@ -97,7 +101,7 @@ func TestInitializer_whenNoCli_InstallsToDefaultCliPath(t *testing.T) {
 	config.CurrentConfig().SetManageBinariesAutomatically(true)
 
 	clientFunc := func() *http.Client { return http.DefaultClient }
-	installer := install.NewInstaller(error_reporting.NewTestErrorReporter(), clientFunc)
+	installer := install.NewInstaller(error_reporting.NewTestErrorReporter(), audit.NewTestTrail(), clientFunc)
 	initializer := SetupInitializerWithInstaller(t, installer)
 
 	// ensure CLI is not installed on the system
@@ -231,6 +235,72 @@ func TestInitializer_whenBinaryUpdatesAllowed_Updates(t *testing.T) {
 	}, time.Second, time.Millisecond)
 }
 
+func TestInitializer_whenPinnedVersionInstalled_DoesNotUpdate(t *testing.T) {
+	testutil.UnitTest(t)
+	config.CurrentConfig().SetManageBinariesAutomatically(true)
+	createDummyCliBinaryWithCreatedDate(t, fiveDaysAgo)
+	initializer := SetupInitializer(t)
+	config.CurrentConfig().CliSettings().ReleaseVersion = "v1.1234.0"
+
+	config.CurrentConfig().CliSettings().SetVersion("1.1234.0 (standalone)")
+	upToDate := !initializer.isOutdatedCli()
+	config.CurrentConfig().CliSettings().SetVersion("1.1200.0 (standalone)")
+	outdated := initializer.isOutdatedCli()
+
+	assert.True(t, upToDate, "the pinned version is installed, even though the CLI is older than 4 days")
+	assert.True(t, outdated)
+}
+
+func TestInitializer_InstallFolderVersions_InstallsEachPinnedVersionOnce(t *testing.T) {
+	testutil.UnitTest(t)
+	xdgDataHome := xdg.DataHome
+	xdg.DataHome = t.TempDir()
+	t.Cleanup(func() { xdg.DataHome = xdgDataHome })
+	config.CurrentConfig().SetManageBinariesAutomatically(true)
+	config.CurrentConfig().CliSettings().FolderReleaseVersions = map[string]string{"/a": "1.1200.0", "/b": "1.1200.0"}
+	installer := install.NewFakeInstaller()
+	initializer := SetupInitializerWithInstaller(t, installer)
+
+	initializer.InstallFolderVersions()
+
+	assert.Equal(t, 1, installer.Installs())
+	assert.FileExists(t, install.PinnedCliPath("1.1200.0"))
+}
+
+// lockedInstaller fails to install pinned versions while another download holds the installer lockfile
+type lockedInstaller struct {
+	*install.FakeInstaller
+	lockedAttempts int
+	attempts       int
+}
+
+func (l *lockedInstaller) InstallVersion(ctx context.Context, version string) (string, error) {
+	l.attempts++
+	if l.attempts <= l.lockedAttempts {
+		return "", errors.New("installer lockfile from 2023-11-14 12:00:00 found")
+	}
+	return l.FakeInstaller.InstallVersion(ctx, version)
+}
+
+func TestInitializer_InstallFolderVersions_RetriesWhileLockfileIsHeld(t *testing.T) {
+	testutil.UnitTest(t)
+	xdgDataHome := xdg.DataHome
+	xdg.DataHome = t.TempDir()
+	t.Cleanup(func() { xdg.DataHome = xdgDataHome })
+	retryDelay := folderVersionRetryDelay
+	folderVersionRetryDelay = time.Millisecond
+	t.Cleanup(func() { folderVersionRetryDelay = retryDelay })
+	config.CurrentConfig().SetManageBinariesAutomatically(true)
+	config.CurrentConfig().CliSettings().FolderReleaseVersions = map[string]string{"/a": "1.1200.0"}
+	installer := &lockedInstaller{FakeInstaller: install.NewFakeInstaller(), lockedAttempts: 2}
+	initializer := SetupInitializerWithInstaller(t, installer)
+
+	initializer.InstallFolderVersions()
+
+	assert.Equal(t, 3, installer.attempts)
+	assert.FileExists(t, install.PinnedCliPath("1.1200.0"))
+}
+
 func createDummyCliBinaryWithCreatedDate(t *testing.T, binaryCreationDate time.Time) {
 	// prepare user directory with OS specific dummy CLI binary
 	temp := t.TempDir()
@@ -17,13 +17,16 @@
 package install
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
+	"time"
 
-	"github.com/adrg/xdg"
 	"github.com/rs/zerolog/log"
 
 	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
@@ -31,10 +34,22 @@ import (
 	"github.com/khulnasoft-lab/vulnmap-ls/internal/progress"
 )
 
+const (
+	maxDownloadAttempts = 5
+	initialRetryDelay   = time.Second
+	maxRetryDelay       = 30 * time.Second
+)
+
+var errDownloadCancelled = errors.New("download of the Vulnmap CLI was cancelled")
+
 type Downloader struct {
 	progressTracker *progress.Tracker
 	errorReporter   error_reporting.ErrorReporter
 	httpClient      func() *http.Client
+	maxAttempts     int
+	retryDelay      func(attempt int) time.Duration
+	// dir is the directory the CLI is downloaded to, the directory of the configured CLI if empty
+	dir string
 }
 
 func NewDownloader(errorReporter error_reporting.ErrorReporter, httpClientFunc func() *http.Client) *Downloader {
@@ -42,14 +57,27 @@ func NewDownloader(errorReporter error_reporting.ErrorReporter, httpClientFunc f
 		progressTracker: progress.NewTracker(true),
 		errorReporter:   errorReporter,
 		httpClient:      httpClientFunc,
+		maxAttempts:     maxDownloadAttempts,
+		retryDelay:      exponentialBackoff,
+	}
+}
+
+// exponentialBackoff doubles the delay with every failed attempt, up to maxRetryDelay
+func exponentialBackoff(attempt int) time.Duration {
+	delay := initialRetryDelay << (attempt - 1)
+	if delay <= 0 || delay > maxRetryDelay {
+		return maxRetryDelay
 	}
+	return delay
 }
 
 // writeCounter counts the number of bytes written to it.
 type writeCounter struct {
 	total           int64 // total size
-	downloaded      int64 // downloaded # of bytes transferred
-	onProgress      func(downloaded int64, total int64, progressTracker *progress.Tracker)
+	downloaded      int64 // downloaded # of bytes transferred, including the bytes of a resumed download
+	resumedFrom     int64 // # of bytes that were downloaded before, they don't count for the throughput
+	started         time.Time
+	onProgress      func(downloaded int64, total int64, bytesPerSecond float64, progressTracker *progress.Tracker)
 	progressTracker *progress.Tracker
 }
 
@@ -59,17 +87,53 @@ type writeCounter struct {
 func (wc *writeCounter) Write(p []byte) (n int, e error) {
 	n = len(p)
 	wc.downloaded += int64(n)
-	wc.onProgress(wc.downloaded, wc.total, wc.progressTracker)
+	var bytesPerSecond float64
+	if elapsed := time.Since(wc.started).Seconds(); elapsed > 0 {
+		bytesPerSecond = float64(wc.downloaded-wc.resumedFrom) / elapsed
+	}
+	wc.onProgress(wc.downloaded, wc.total, bytesPerSecond, wc.progressTracker)
 	return
 }
 
-func newWriter(size int64, progressTracker *progress.Tracker, onProgress func(downloaded, total int64, progressTracker *progress.Tracker)) io.Writer {
-	return &writeCounter{total: size, progressTracker: progressTracker, onProgress: onProgress}
+func newWriter(
+	resumedFrom int64,
+	size int64,
+	progressTracker *progress.Tracker,
+	onProgress func(downloaded, total int64, bytesPerSecond float64, progressTracker *progress.Tracker),
+) io.Writer {
+	return &writeCounter{
+		total:           size,
+		downloaded:      resumedFrom,
+		resumedFrom:     resumedFrom,
+		started:         time.Now(),
+		progressTracker: progressTracker,
+		onProgress:      onProgress,
+	}
 }
 
-func onProgress(downloaded, total int64, progressTracker *progress.Tracker) {
+func onProgress(downloaded, total int64, bytesPerSecond float64, progressTracker *progress.Tracker) {
+	if total <= 0 {
+		progressTracker.ReportWithMessage(1, fmt.Sprintf("%s (%s/s)", formatBytes(downloaded), formatBytes(int64(bytesPerSecond))))
+		return
+	}
 	percentage := float64(downloaded) / float64(total) * 100
-	progressTracker.Report(int(percentage))
+	message := fmt.Sprintf("%s of %s (%s/s)", formatBytes(downloaded), formatBytes(total), formatBytes(int64(bytesPerSecond)))
+	progressTracker.ReportWithMessage(int(percentage), message)
+}
+
+func formatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	value := float64(bytes)
+	for _, suffix := range []string{"KB", "MB", "GB"} {
+		value /= unit
+		if value < unit || suffix == "GB" {
+			return fmt.Sprintf("%.1f %s", value, suffix)
+		}
+	}
+	return ""
 }
 
 func (d *Downloader) lockFileName() string {
@@ -96,6 +160,10 @@ func (d *Downloader) Download(r *Release, isUpdate bool) error {
 	if downloadURL == "" {
 		return fmt.Errorf("no builds found for current OS")
 	}
+	expectedChecksum, err := expectedChecksum(r, &cliDiscovery)
+	if err != nil {
+		return err
+	}
 
 	log.Info().Str("download_url", downloadURL).Msgf("Vulnmap CLI %s in progress...", kindStr)
 
@@ -105,81 +173,177 @@ func (d *Downloader) Download(r *Release, isUpdate bool) error {
 		d.progressTracker.BeginWithMessage("Downloading Vulnmap CLI...", "We download Vulnmap CLI to run security scans.")
 	}
 
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 	doneCh := make(chan bool)
+	go d.progressTracker.CancelOrDone(func() {
+		cancel()
+		log.Info().Str("method", "Download").Msgf("Cancellation received. Aborting %s.", kindStr)
+	}, doneCh)
+	defer func() {
+		doneCh <- true
+		log.Info().Str("method", "Download").Msgf("finished Vulnmap CLI %s", kindStr)
+	}()
 
-	var resp *http.Response
+	executableFileName := cliDiscovery.ExecutableName(isUpdate)
+	// the partial file is kept if the download fails, so that the next attempt or the next start resumes it
+	partialPath := d.partialFilePath(executableFileName, expectedChecksum)
+	err = d.downloadWithRetries(ctx, downloadURL, partialPath)
+	if err != nil {
+		if !errors.Is(err, errDownloadCancelled) {
+			d.errorReporter.CaptureError(err)
+		}
+		d.progressTracker.EndWithMessage(fmt.Sprintf("Vulnmap CLI %s failed.", kindStr))
+		return err
+	}
 
-	resp, err = d.httpClient().Get(downloadURL)
-	log.Debug().Any("response-headers", resp.Header).Msg("headers")
+	err = compareChecksum(expectedChecksum, partialPath)
+	if err == nil {
+		err = d.verifyDownloadSignature(r, partialPath)
+	}
 	if err != nil {
+		// a corrupt partial download must not be resumed. A failed verification can mean a tampered release, so it is
+		// logged and reported independently of the audit trail.
+		log.Error().Err(err).Str("method", "Download").Msg("verification of the downloaded Vulnmap CLI failed")
+		d.errorReporter.CaptureError(err)
+		_ = os.Remove(partialPath)
+		d.progressTracker.EndWithMessage(fmt.Sprintf("Vulnmap CLI %s failed.", kindStr))
 		return err
 	}
 
-	go func(body io.ReadCloser) {
-		d.progressTracker.CancelOrDone(func() {
-			_ = body.Close()
+	err = d.moveToDestination(executableFileName, partialPath)
+	d.removeStalePartialFiles(executableFileName)
 
-			log.Info().Str("method", "Download").Msgf("Cancellation received. Aborting %s.", kindStr)
-		}, doneCh)
-	}(resp.Body)
+	if isUpdate {
+		d.progressTracker.EndWithMessage("Vulnmap CLI has been updated.")
+	} else {
+		d.progressTracker.EndWithMessage("Vulnmap CLI has been downloaded.")
+	}
 
-	if resp.StatusCode != http.StatusOK {
-		d.errorReporter.CaptureError(err)
-		return fmt.Errorf("failed to %s Vulnmap CLI from %q: %s", kindStr, downloadURL, resp.Status)
+	return err
+}
+
+// partialFilePath returns the path of the incomplete download. It contains the checksum, so that only downloads of
+// the same binary are resumed.
+func (d *Downloader) partialFilePath(executableFileName string, checksum HashSum) string {
+	cliDirectory := d.cliDirectory()
+	return filepath.Join(cliDirectory, fmt.Sprintf("%s.%.12s.partial", executableFileName, checksum.String()))
+}
+
+// removeStalePartialFiles removes incomplete downloads of other releases
+func (d *Downloader) removeStalePartialFiles(executableFileName string) {
+	cliDirectory := d.cliDirectory()
+	stalePartialFiles, _ := filepath.Glob(filepath.Join(cliDirectory, executableFileName+".*.partial"))
+	for _, stalePartialFile := range stalePartialFiles {
+		_ = os.Remove(stalePartialFile)
 	}
-	executableFileName := cliDiscovery.ExecutableName(isUpdate)
-	defer func(Body io.ReadCloser) {
-		_ = Body.Close()
-		doneCh <- true
-		log.Info().Str("method", "Download").Msgf("finished Vulnmap CLI %s", kindStr)
-	}(resp.Body)
+}
 
-	// pipe stream
-	cliReader := io.TeeReader(resp.Body, newWriter(resp.ContentLength, d.progressTracker, onProgress))
+// downloadWithRetries retries failed downloads with exponential backoff, each attempt resumes the partial file
+func (d *Downloader) downloadWithRetries(ctx context.Context, downloadURL string, partialPath string) error {
+	logger := log.With().Str("method", "downloadWithRetries").Str("download_url", downloadURL).Logger()
+	maxAttempts := max(d.maxAttempts, 1)
+	retryDelay := d.retryDelay
+	if retryDelay == nil {
+		retryDelay = exponentialBackoff
+	}
 
-	_ = os.MkdirAll(xdg.DataHome, 0755)
-	tmpDirPath, err := os.MkdirTemp(xdg.DataHome, "downloads")
-	if err != nil {
-		log.Err(err).Str("method", "Download").Msg("couldn't create tmpdir")
-		return err
+	var err error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		var retryable bool
+		retryable, err = d.downloadAttempt(ctx, downloadURL, partialPath)
+		if ctx.Err() != nil {
+			return errDownloadCancelled
+		}
+		if err == nil || !retryable || attempt == maxAttempts {
+			break
+		}
+
+		delay := retryDelay(attempt)
+		logger.Warn().Err(err).Int("attempt", attempt).Dur("retry_in", delay).Msg("download failed, retrying")
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return errDownloadCancelled
+		}
 	}
+	return err
+}
 
-	cliTmpPath := filepath.Join(tmpDirPath, executableFileName)
-	cliTmpFile, err := os.Create(cliTmpPath)
-	if err != nil {
-		return err
+// downloadAttempt downloads the rest of the binary into the partial file. If the partial file already contains a part
+// of the binary, only the missing bytes are requested with an HTTP range request.
+func (d *Downloader) downloadAttempt(ctx context.Context, downloadURL string, partialPath string) (retryable bool, err error) {
+	var offset int64
+	if fileInfo, statErr := os.Stat(partialPath); statErr == nil {
+		offset = fileInfo.Size()
 	}
-	defer func() {
-		_ = cliTmpFile.Close()
-		_ = os.RemoveAll(tmpDirPath)
-	}()
 
-	bytesCopied, err := io.Copy(cliTmpFile, cliReader)
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, downloadURL, nil)
 	if err != nil {
-		return err
+		return false, err
+	}
+	if offset > 0 {
+		request.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
 	}
-	log.Info().Int64("bytes_copied", bytesCopied).Msgf("copied to %s", cliTmpFile.Name())
 
-	expectedChecksum, err := expectedChecksum(r, &cliDiscovery)
+	resp, err := d.httpClient().Do(request)
 	if err != nil {
-		return err
+		return true, err
 	}
+	defer func(Body io.ReadCloser) { _ = Body.Close() }(resp.Body)
+	log.Debug().Any("response-headers", resp.Header).Msg("headers")
 
-	err = compareChecksum(expectedChecksum, cliTmpFile.Name())
-	if err != nil {
-		return err
+	flags := os.O_CREATE | os.O_WRONLY
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		flags |= os.O_APPEND
+		log.Info().Str("method", "downloadAttempt").Int64("offset", offset).Msg("resuming download")
+	case resp.StatusCode == http.StatusOK:
+		// the server doesn't support ranges or there's nothing to resume
+		flags |= os.O_TRUNC
+		offset = 0
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+		// the partial file doesn't match the binary on the server, start over
+		_ = os.Remove(partialPath)
+		return true, fmt.Errorf("failed to resume Vulnmap CLI download from %q: %s", downloadURL, resp.Status)
+	default:
+		retryable = resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests
+		return retryable, fmt.Errorf("failed to download Vulnmap CLI from %q: %s", downloadURL, resp.Status)
 	}
 
-	_ = cliTmpFile.Close() // close file to allow moving it on Windows
-	err = d.moveToDestination(executableFileName, cliTmpFile.Name())
+	total := int64(-1)
+	if resp.ContentLength >= 0 {
+		total = offset + resp.ContentLength
+	}
 
-	if isUpdate {
-		d.progressTracker.EndWithMessage("Vulnmap CLI has been updated.")
-	} else {
-		d.progressTracker.EndWithMessage("Vulnmap CLI has been downloaded.")
+	err = os.MkdirAll(filepath.Dir(partialPath), 0755)
+	if err != nil {
+		return false, err
+	}
+	partialFile, err := os.OpenFile(partialPath, flags, 0644)
+	if err != nil {
+		return false, err
 	}
+	defer func() { _ = partialFile.Close() }()
 
-	return err
+	// pipe stream
+	cliReader := io.TeeReader(resp.Body, newWriter(offset, total, d.progressTracker, onProgress))
+	bytesCopied, err := io.Copy(partialFile, cliReader)
+	log.Info().Int64("bytes_copied", bytesCopied).Msgf("copied to %s", partialPath)
+	if err != nil {
+		return true, err
+	}
+	if total >= 0 && offset+bytesCopied < total {
+		return true, fmt.Errorf("download of Vulnmap CLI ended after %d of %d bytes", offset+bytesCopied, total)
+	}
+	return false, nil
+}
+
+func (d *Downloader) cliDirectory() string {
+	if d.dir != "" {
+		return d.dir
+	}
+	return filepath.Dir(config.CurrentConfig().CliSettings().Path())
 }
 
 func (d *Downloader) createLockFile() error {
@@ -191,11 +355,13 @@ func (d *Downloader) createLockFile() error {
 		return err
 	}
 	defer func(file *os.File) { _ = file.Close() }(file)
-	return nil
+	// the process id allows other processes to detect a lockfile that was left behind by a crash
+	_, err = file.WriteString(strconv.Itoa(os.Getpid()))
+	return err
 }
 
 func (d *Downloader) moveToDestination(destinationFileName string, sourceFilePath string) (err error) {
-	cliDirectory := filepath.Dir(config.CurrentConfig().CliSettings().Path())
+	cliDirectory := d.cliDirectory()
 	destinationFilePath := filepath.Join(cliDirectory, destinationFileName) // vulnmap-win.exe.latest
 	log.Info().Str("method", "moveToDestination").Str("path", destinationFilePath).Msg("copying Vulnmap CLI to user directory")
 
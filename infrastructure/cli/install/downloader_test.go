@@ -17,14 +17,24 @@
 package install
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"net/http"
+	"net/http/httptest"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strconv"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/error_reporting"
 	"github.com/khulnasoft-lab/vulnmap-ls/internal/lsp"
 	"github.com/khulnasoft-lab/vulnmap-ls/internal/progress"
 	"github.com/khulnasoft-lab/vulnmap-ls/internal/testutil"
@@ -111,3 +121,199 @@ func getTestAsset() *Release {
 	}
 	return r
 }
+
+// releaseForAllPlatforms returns a release that has the same binary for all platforms
+func releaseForAllPlatforms(url string, content []byte) *Release {
+	checksum := sha256.Sum256(content)
+	asset := &ReleaseAsset{URL: url, ChecksumInfo: hex.EncodeToString(checksum[:]) + "  vulnmap"}
+	return &Release{Assets: &ReleaseAssets{
+		AlpineLinux: asset, Linux: asset, LinuxARM64: asset, MacOS: asset, MacOSARM64: asset, Windows: asset,
+	}}
+}
+
+type testDownloadServer struct {
+	*httptest.Server
+	mutex        sync.Mutex
+	rangeHeaders []string
+}
+
+func (s *testDownloadServer) requests() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]string{}, s.rangeHeaders...)
+}
+
+// newTestDownloadServer serves the content with support for range requests, fail is called for every request and
+// can answer the request instead
+func newTestDownloadServer(t *testing.T, content []byte, fail func(w http.ResponseWriter, attempt int) bool) *testDownloadServer {
+	t.Helper()
+	server := &testDownloadServer{}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.mutex.Lock()
+		server.rangeHeaders = append(server.rangeHeaders, r.Header.Get("Range"))
+		attempt := len(server.rangeHeaders)
+		server.mutex.Unlock()
+		if fail != nil && fail(w, attempt) {
+			return
+		}
+		http.ServeContent(w, r, "vulnmap", time.Time{}, bytes.NewReader(content))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestDownloader(t *testing.T) (*Downloader, string) {
+	t.Helper()
+	d := &Downloader{
+		progressTracker: progress.NewTestTracker(make(chan lsp.ProgressParams, 100000), make(chan lsp.ProgressToken, 1)),
+		errorReporter:   error_reporting.NewTestErrorReporter(),
+		httpClient:      func() *http.Client { return http.DefaultClient },
+		maxAttempts:     maxDownloadAttempts,
+		retryDelay:      func(int) time.Duration { return 0 },
+	}
+	cliPath := filepath.Join(t.TempDir(), (&Discovery{}).ExecutableName(false))
+	config.CurrentConfig().CliSettings().SetPath(cliPath)
+	return d, cliPath
+}
+
+func Test_Download_ResumesPartialDownload(t *testing.T) {
+	testutil.UnitTest(t)
+	content := bytes.Repeat([]byte("vulnmap"), 1000)
+	server := newTestDownloadServer(t, content, nil)
+	d, cliPath := newTestDownloader(t)
+	release := releaseForAllPlatforms(server.URL+"/vulnmap", content)
+	checksum, _ := expectedChecksum(release, &Discovery{})
+	partialPath := d.partialFilePath((&Discovery{}).ExecutableName(false), checksum)
+	require.NoError(t, os.WriteFile(partialPath, content[:3000], 0644))
+
+	err := d.Download(release, false)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bytes=3000-"}, server.requests())
+	downloaded, _ := os.ReadFile(cliPath)
+	assert.Equal(t, content, downloaded)
+	assert.NoFileExists(t, partialPath)
+}
+
+func Test_Download_RetriesAndResumesAfterConnectionLoss(t *testing.T) {
+	testutil.UnitTest(t)
+	content := bytes.Repeat([]byte("vulnmap"), 1000)
+	server := newTestDownloadServer(t, content, func(w http.ResponseWriter, attempt int) bool {
+		switch attempt {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return true
+		case 2:
+			// send half of the binary and drop the connection
+			w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write(content[:len(content)/2])
+			w.(http.Flusher).Flush()
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			_ = conn.Close()
+			return true
+		}
+		return false
+	})
+	d, cliPath := newTestDownloader(t)
+
+	err := d.Download(releaseForAllPlatforms(server.URL+"/vulnmap", content), false)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"", "", "bytes=3500-"}, server.requests())
+	downloaded, _ := os.ReadFile(cliPath)
+	assert.Equal(t, content, downloaded)
+}
+
+func Test_Download_DoesNotRetryClientErrors(t *testing.T) {
+	testutil.UnitTest(t)
+	server := newTestDownloadServer(t, nil, func(w http.ResponseWriter, _ int) bool {
+		w.WriteHeader(http.StatusNotFound)
+		return true
+	})
+	d, cliPath := newTestDownloader(t)
+
+	err := d.Download(releaseForAllPlatforms(server.URL+"/vulnmap", []byte("vulnmap")), false)
+
+	assert.ErrorContains(t, err, "404")
+	assert.Len(t, server.requests(), 1)
+	assert.NoFileExists(t, cliPath)
+}
+
+func Test_Download_GivesUpAfterMaxAttempts(t *testing.T) {
+	testutil.UnitTest(t)
+	server := newTestDownloadServer(t, nil, func(w http.ResponseWriter, _ int) bool {
+		w.WriteHeader(http.StatusBadGateway)
+		return true
+	})
+	d, _ := newTestDownloader(t)
+
+	err := d.Download(releaseForAllPlatforms(server.URL+"/vulnmap", []byte("vulnmap")), false)
+
+	assert.Error(t, err)
+	assert.Len(t, server.requests(), maxDownloadAttempts)
+}
+
+type capturingErrorReporter struct {
+	error_reporting.ErrorReporter
+	captured []error
+}
+
+func (r *capturingErrorReporter) CaptureError(err error) bool {
+	r.captured = append(r.captured, err)
+	return true
+}
+
+func Test_Download_ReportsFailedVerification(t *testing.T) {
+	testutil.UnitTest(t)
+	content := bytes.Repeat([]byte("vulnmap"), 1000)
+	server := newTestDownloadServer(t, content, nil)
+	d, cliPath := newTestDownloader(t)
+	errorReporter := &capturingErrorReporter{ErrorReporter: error_reporting.NewTestErrorReporter()}
+	d.errorReporter = errorReporter
+
+	err := d.Download(releaseForAllPlatforms(server.URL+"/vulnmap", []byte("another binary")), false)
+
+	assert.Error(t, err)
+	assert.Equal(t, []error{err}, errorReporter.captured)
+	assert.NoFileExists(t, cliPath)
+}
+
+func Test_exponentialBackoff(t *testing.T) {
+	assert.Equal(t, time.Second, exponentialBackoff(1))
+	assert.Equal(t, 4*time.Second, exponentialBackoff(3))
+	assert.Equal(t, maxRetryDelay, exponentialBackoff(10))
+	assert.Equal(t, maxRetryDelay, exponentialBackoff(100))
+}
+
+func Test_onProgress_ReportsThroughput(t *testing.T) {
+	progressCh := make(chan lsp.ProgressParams, 10)
+	tracker := progress.NewTestTracker(progressCh, make(chan lsp.ProgressToken, 1))
+
+	onProgress(3*1024*1024, 12*1024*1024, 1.5*1024*1024, tracker)
+
+	report := (<-progressCh).Value.(lsp.WorkDoneProgressReport)
+	assert.Equal(t, 25, report.Percentage)
+	assert.Equal(t, "3.0 MB of 12.0 MB (1.5 MB/s)", report.Message)
+}
+
+func Test_createLockFile_IgnoresLockFileOfCrashedProcess(t *testing.T) {
+	testutil.UnitTest(t)
+	d, _ := newTestDownloader(t)
+	lockFileName := d.lockFileName()
+	require.NoError(t, os.MkdirAll(filepath.Dir(lockFileName), 0755))
+	t.Cleanup(func() { _ = os.Remove(lockFileName) })
+
+	// a running process holds the lock
+	require.NoError(t, d.createLockFile())
+	_, err := createLockFile(d)
+	assert.Error(t, err)
+
+	// a process that doesn't run anymore left the lock behind
+	crashed := exec.Command("go", "version")
+	require.NoError(t, crashed.Run())
+	require.NoError(t, os.WriteFile(lockFileName, []byte(strconv.Itoa(crashed.Process.Pid)), 0644))
+	name, err := createLockFile(d)
+	assert.NoError(t, err)
+	assert.Equal(t, lockFileName, name)
+}
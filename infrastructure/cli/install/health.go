@@ -0,0 +1,82 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package install
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const healthCheckTimeout = 30 * time.Second
+
+// healthCheckCommands are run after an update, they must not need network access or authentication
+var healthCheckCommands = [][]string{{"version"}, {"--help"}}
+
+// checkCliHealth returns an error if the CLI can't run the health check commands
+func checkCliHealth(cliPath string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+	defer cancel()
+	for _, args := range healthCheckCommands {
+		output, err := exec.CommandContext(ctx, cliPath, args...).CombinedOutput()
+		if err == nil && strings.TrimSpace(string(output)) == "" {
+			err = fmt.Errorf("no output")
+		}
+		if err != nil {
+			return fmt.Errorf("health check `vulnmap %s` of the Vulnmap CLI failed: %w", strings.Join(args, " "), err)
+		}
+	}
+	return nil
+}
+
+func backupPath(cliPath string) string {
+	return cliPath + ".previous"
+}
+
+// backupCli copies the CLI, so that it can be restored if the update is broken
+func backupCli(cliPath string) (string, error) {
+	backup := backupPath(cliPath)
+	source, err := os.Open(cliPath)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = source.Close() }()
+	destination, err := os.OpenFile(backup, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0755)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = destination.Close() }()
+	if _, err = io.Copy(destination, source); err != nil {
+		return "", err
+	}
+	return backup, nil
+}
+
+// restoreCli replaces a broken CLI with its backup
+func restoreCli(backup string, cliPath string) error {
+	log.Warn().Str("method", "restoreCli").Str("cliPath", cliPath).Msg("restoring previous Vulnmap CLI")
+	if err := os.Remove(cliPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return os.Rename(backup, cliPath)
+}
@@ -0,0 +1,45 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package install
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/testutil"
+)
+
+func Test_checkCliHealth(t *testing.T) {
+	testutil.UnitTest(t)
+	testutil.NotOnWindows(t, "uses shell scripts as CLI")
+	dir := t.TempDir()
+	healthyCli := filepath.Join(dir, "healthy")
+	require.NoError(t, os.WriteFile(healthyCli, []byte("#!/bin/sh\necho 1.1234.0\n"), 0755))
+	brokenCli := filepath.Join(dir, "broken")
+	require.NoError(t, os.WriteFile(brokenCli, []byte("#!/bin/sh\necho crash >&2\nexit 2\n"), 0755))
+	silentCli := filepath.Join(dir, "silent")
+	require.NoError(t, os.WriteFile(silentCli, []byte("#!/bin/sh\n"), 0755))
+
+	assert.NoError(t, checkCliHealth(healthyCli))
+	assert.ErrorContains(t, checkCliHealth(brokenCli), "vulnmap version")
+	assert.Error(t, checkCliHealth(silentCli))
+	assert.Error(t, checkCliHealth(filepath.Join(dir, "missing")))
+}
@@ -31,6 +31,7 @@ import (
 	"github.com/rs/zerolog/log"
 
 	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/audit"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/error_reporting"
 )
 
@@ -38,17 +39,23 @@ type Installer interface {
 	Find() (string, error)
 	Install(ctx context.Context) (string, error)
 	Update(ctx context.Context) (bool, error)
+	// InstallVersion installs the given CLI version next to the configured CLI and returns its path
+	InstallVersion(ctx context.Context, version string) (string, error)
 }
 
 type Install struct {
 	errorReporter error_reporting.ErrorReporter
+	auditTrail    audit.Trail
 	httpClient    func() *http.Client
+	healthCheck   func(cliPath string) error
 }
 
-func NewInstaller(errorReporter error_reporting.ErrorReporter, client func() *http.Client) *Install {
+func NewInstaller(errorReporter error_reporting.ErrorReporter, auditTrail audit.Trail, client func() *http.Client) *Install {
 	return &Install{
 		errorReporter: errorReporter,
-		httpClient:    client,
+		auditTrail:    auditTrail,
+		httpClient:    withFileTransport(client),
+		healthCheck:   checkCliHealth,
 	}
 }
 
@@ -71,12 +78,12 @@ func (i *Install) Find() (string, error) {
 
 func (i *Install) Install(ctx context.Context) (string, error) {
 	r := NewCLIRelease(i.httpClient)
-	latestRelease, err := r.GetLatestRelease(ctx)
+	release, err := r.GetRelease(ctx)
 	if err != nil {
 		return "", err
 	}
 
-	return i.installRelease(latestRelease)
+	return i.installRelease(release)
 }
 
 func (i *Install) installRelease(release *Release) (string, error) {
@@ -91,18 +98,62 @@ func (i *Install) installRelease(release *Release) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	i.recordCliChange(audit.CliInstalled, release, config.CurrentConfig().CliSettings().Path(), nil)
 
 	return i.Find()
 }
 
+// InstallVersion installs a pinned CLI version to its own directory, so that folders can use different versions. An
+// installed version is not downloaded again.
+func (i *Install) InstallVersion(ctx context.Context, version string) (string, error) {
+	cliPath := PinnedCliPath(version)
+	if _, err := os.Stat(cliPath); err == nil {
+		return cliPath, nil
+	}
+	release, err := NewCLIRelease(i.httpClient).getRelease(ctx, releasePath(version, ""))
+	if err != nil {
+		return "", err
+	}
+
+	d := NewDownloader(i.errorReporter, i.httpClient)
+	d.dir = filepath.Dir(cliPath)
+	lockFileName, err := createLockFile(d)
+	if err != nil {
+		return "", err
+	}
+	defer func(name string) { cleanupLockFile(name) }(lockFileName)
+
+	if err = d.Download(release, false); err != nil {
+		return "", err
+	}
+	// a broken pinned CLI must not stay installed, as it would not be downloaded again
+	if err = i.healthCheck(cliPath); err != nil {
+		if removeErr := os.Remove(cliPath); removeErr != nil {
+			err = errors.Join(err, removeErr)
+		}
+		i.recordCliChange(audit.CliInstalled, release, cliPath, err)
+		return "", err
+	}
+	i.recordCliChange(audit.CliInstalled, release, cliPath, nil)
+	return cliPath, nil
+}
+
+// PinnedCliPath returns the path of a CLI version installed with InstallVersion
+func PinnedCliPath(version string) string {
+	return filepath.Join(config.CurrentConfig().CliSettings().DefaultBinaryInstallPath(),
+		"versions",
+		releasePath(version, ""),
+		(&Discovery{}).ExecutableName(false))
+}
+
 func (i *Install) Update(ctx context.Context) (bool, error) {
 	r := NewCLIRelease(i.httpClient)
-	latestRelease, err := r.GetLatestRelease(ctx)
+	release, err := r.GetRelease(ctx)
 	if err != nil {
 		return false, err
 	}
 
-	return i.updateFromRelease(latestRelease)
+	return i.updateFromRelease(release)
 }
 
 func (i *Install) updateFromRelease(r *Release) (bool, error) {
@@ -132,14 +183,41 @@ func (i *Install) updateFromRelease(r *Release) (bool, error) {
 		return false, err
 	}
 
+	cliPath := config.CurrentConfig().CliSettings().Path()
+	backup, err := backupCli(cliPath)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = os.Remove(backup) }()
+
 	err = replaceOutdatedCli(cliDiscovery)
+	if err == nil {
+		err = i.healthCheck(cliPath)
+	}
 	if err != nil {
+		if restoreErr := restoreCli(backup, cliPath); restoreErr != nil {
+			err = errors.Join(err, restoreErr)
+		}
+		i.recordCliChange(audit.CliRolledBack, r, cliPath, err)
 		return false, err
 	}
 
+	i.recordCliChange(audit.CliUpdated, r, cliPath, nil)
 	return true, nil
 }
 
+// recordCliChange records installs, updates and rollbacks of the CLI in the audit trail
+func (i *Install) recordCliChange(change audit.CliChange, release *Release, cliPath string, err error) {
+	properties := audit.CliProperties{Change: change, CliPath: cliPath}
+	if release != nil {
+		properties.Version = release.Version
+	}
+	if err != nil {
+		properties.Error = err.Error()
+	}
+	i.auditTrail.CliChanged(properties)
+}
+
 func replaceOutdatedCli(cliDiscovery Discovery) error {
 	log.Info().Str("method", "replaceOutdatedCli").Msg("replacing outdated CLI with latest")
 
@@ -206,7 +284,7 @@ func expectedChecksum(r *Release, cliDiscovery *Discovery) (HashSum, error) {
 func createLockFile(d *Downloader) (lockfileName string, err error) {
 	lockFileName := config.CurrentConfig().CLIDownloadLockFileName()
 	fileInfo, err := os.Stat(lockFileName)
-	if err == nil && (time.Since(fileInfo.ModTime()) < 10*time.Minute) {
+	if err == nil && (time.Since(fileInfo.ModTime()) < 10*time.Minute) && !IsStaleLockFile(lockFileName) {
 		msg := fmt.Sprintf("installer lockfile from %v found", fileInfo.ModTime())
 		log.Error().Str("method", "Download").Str("lockfile", lockFileName).Msg(msg)
 		return "", errors.New(msg)
@@ -265,6 +343,21 @@ func (t *FakeInstaller) Install(_ context.Context) (string, error) {
 	return "", nil
 }
 
+func (t *FakeInstaller) InstallVersion(_ context.Context, version string) (string, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	path := PinnedCliPath(version)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, []byte("fake"), 0755); err != nil {
+		return "", err
+	}
+
+	t.installs++
+	return path, nil
+}
+
 func (t *FakeInstaller) Update(_ context.Context) (bool, error) {
 	t.mutex.Lock()
 	defer t.mutex.Unlock()
@@ -28,6 +28,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/audit"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/error_reporting"
 	"github.com/khulnasoft-lab/vulnmap-ls/internal/testutil"
 )
@@ -46,7 +47,7 @@ func TestInstaller_Find(t *testing.T) {
 
 	t.Setenv("PATH", cliDir)
 
-	i := NewInstaller(error_reporting.NewTestErrorReporter(), nil)
+	i := NewInstaller(error_reporting.NewTestErrorReporter(), audit.NewTestTrail(), nil)
 
 	execPath, err := i.Find()
 
@@ -73,7 +74,7 @@ func Test_Find_CliPathInSettings_CliPathFound(t *testing.T) {
 	t.Setenv("VULNMAP_TOKEN", "")
 	t.Setenv("VULNMAP_CLI_PATH", "")
 	config.CurrentConfig().CliSettings().SetPath(cliPath)
-	installer := NewInstaller(error_reporting.NewTestErrorReporter(), nil)
+	installer := NewInstaller(error_reporting.NewTestErrorReporter(), audit.NewTestTrail(), nil)
 
 	// Act
 	foundPath, err := installer.Find()
@@ -90,7 +91,7 @@ func TestInstaller_Find_emptyPath(t *testing.T) {
 	t.Skipf("removes real binaries from user directory")
 
 	t.Setenv("PATH", "")
-	i := NewInstaller(error_reporting.NewTestErrorReporter(), nil)
+	i := NewInstaller(error_reporting.NewTestErrorReporter(), audit.NewTestTrail(), nil)
 
 	execPath, err := i.Find()
 
@@ -108,7 +109,7 @@ func TestInstaller_Install_DoNotDownloadIfLockfileFound(t *testing.T) {
 	}
 	_ = file.Close()
 
-	i := NewInstaller(error_reporting.NewTestErrorReporter(), nil)
+	i := NewInstaller(error_reporting.NewTestErrorReporter(), audit.NewTestTrail(), nil)
 	_, err = i.installRelease(r)
 
 	assert.Error(t, err)
@@ -117,7 +118,7 @@ func TestInstaller_Install_DoNotDownloadIfLockfileFound(t *testing.T) {
 func TestInstaller_Update_DoesntUpdateIfNoLatestRelease(t *testing.T) {
 	testutil.UnitTest(t)
 	// prepare
-	i := NewInstaller(error_reporting.NewTestErrorReporter(), nil)
+	i := NewInstaller(error_reporting.NewTestErrorReporter(), audit.NewTestTrail(), nil)
 
 	temp := t.TempDir()
 	fakeCliFile := testutil.CreateTempFile(temp, t)
@@ -162,7 +163,7 @@ func TestInstaller_Update_DownloadsLatestCli(t *testing.T) {
 
 	// prepare
 	ctx := context.Background()
-	i := NewInstaller(error_reporting.NewTestErrorReporter(), func() *http.Client { return http.DefaultClient })
+	i := NewInstaller(error_reporting.NewTestErrorReporter(), audit.NewTestTrail(), func() *http.Client { return http.DefaultClient })
 	cliDir, err := os.MkdirTemp("", "")
 	if err != nil {
 		t.Fatal(t, err, "Failed to create temp dir")
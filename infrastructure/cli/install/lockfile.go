@@ -0,0 +1,62 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package install
+
+import (
+	"errors"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/rs/zerolog/log"
+)
+
+// IsStaleLockFile returns true if the process that created the lockfile doesn't run anymore, e.g. because it crashed
+// during the download. Lockfiles without a process id are never considered stale.
+func IsStaleLockFile(lockFileName string) bool {
+	content, err := os.ReadFile(lockFileName)
+	if err != nil {
+		return false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(content)))
+	if err != nil || pid <= 0 {
+		return false
+	}
+	if processExists(pid) {
+		return false
+	}
+	log.Info().Str("method", "IsStaleLockFile").Str("lockfile", lockFileName).Int("pid", pid).
+		Msg("ignoring lockfile of a process that doesn't run anymore")
+	return true
+}
+
+func processExists(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	defer func() { _ = process.Release() }()
+	//goland:noinspection GoBoolExpressions
+	if runtime.GOOS == "windows" {
+		// on Windows, finding the process fails if it doesn't exist
+		return true
+	}
+	err = process.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -24,6 +24,8 @@ import (
 	"net/http"
 
 	"github.com/rs/zerolog/log"
+
+	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
 )
 
 const defaultBaseURL = "https://static.vulnmap.khulnasoft.com"
@@ -49,22 +51,45 @@ type ReleaseAsset struct {
 	URL          string `json:"url,omitempty"`
 	ChecksumInfo string `json:"sha256,omitempty"`
 	ChecksumURL  string `json:"sha256Url,omitempty"`
+	// SignatureURL is the URL of the detached Ed25519 signature, the URL of the binary with ".sig" appended if empty
+	SignatureURL string `json:"signatureUrl,omitempty"`
 }
 
 type CLIRelease struct {
 	baseURL    string
 	httpClient func() *http.Client
+	// sourceErr is returned for all requests if the configured release source is invalid
+	sourceErr error
 }
 
+// NewCLIRelease returns a CLIRelease that fetches releases from the configured release source
 func NewCLIRelease(httpClient func() *http.Client) *CLIRelease {
+	baseURL, err := releaseBaseURL(config.CurrentConfig().CliSettings().ReleaseSource)
+	if err != nil {
+		log.Error().Err(err).Str("method", "NewCLIRelease").Msg("the CLI is not downloaded")
+	}
 	return &CLIRelease{
-		baseURL:    defaultBaseURL,
-		httpClient: httpClient,
+		baseURL:    baseURL,
+		httpClient: withFileTransport(httpClient),
+		sourceErr:  err,
 	}
 }
 
 func (r *CLIRelease) GetLatestRelease(ctx context.Context) (*Release, error) {
-	releaseURL := fmt.Sprintf("%s/cli/latest/release.json", r.baseURL)
+	return r.getRelease(ctx, "latest")
+}
+
+// GetRelease returns the configured release, i.e. the pinned version or the latest release of the configured channel
+func (r *CLIRelease) GetRelease(ctx context.Context) (*Release, error) {
+	settings := config.CurrentConfig().CliSettings()
+	return r.getRelease(ctx, releasePath(settings.ReleaseVersion, settings.ReleaseChannel))
+}
+
+func (r *CLIRelease) getRelease(ctx context.Context, path string) (*Release, error) {
+	if r.sourceErr != nil {
+		return nil, r.sourceErr
+	}
+	releaseURL := fmt.Sprintf("%s/cli/%s/release.json", r.baseURL, path)
 	log.Ctx(ctx).Trace().Str("url", releaseURL).Msg("requesting version for Vulnmap CLI")
 
 	resp, err := r.httpClient().Get(releaseURL)
@@ -91,5 +116,41 @@ func (r *CLIRelease) GetLatestRelease(ctx context.Context) (*Release, error) {
 		return nil, fmt.Errorf("%w: unable to unmarshal: %q", err, string(body))
 	}
 
+	err = p.resolveAssetURLs(releaseURL, r.baseURL)
+	if err != nil {
+		return nil, err
+	}
+	if p.Assets != nil && p.checksumInfo() == "" && p.checksumURL() != "" {
+		err = r.fetchChecksumInfo(&p)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return &p, nil
 }
+
+// fetchChecksumInfo reads the checksum of the current platform from its checksum file, for release sources that
+// don't contain the checksums in the release metadata
+func (r *CLIRelease) fetchChecksumInfo(release *Release) error {
+	checksumURL := release.checksumURL()
+	resp, err := r.httpClient().Get(checksumURL)
+	if err != nil {
+		return err
+	}
+	defer func(Body io.ReadCloser) { _ = Body.Close() }(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to obtain Vulnmap CLI checksum from %q: %s", checksumURL, resp.Status)
+	}
+	checksumInfo, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	for _, asset := range release.Assets.all() {
+		if asset.ChecksumURL == checksumURL {
+			asset.ChecksumInfo = string(checksumInfo)
+		}
+	}
+	return nil
+}
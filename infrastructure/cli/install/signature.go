@@ -0,0 +1,113 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package install
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
+)
+
+// signingPublicKey returns the embedded public key, nil in builds without a key
+func signingPublicKey() (ed25519.PublicKey, error) {
+	encodedKey := strings.TrimSpace(config.CliSigningPublicKey)
+	if encodedKey == "" {
+		return nil, nil
+	}
+	key, err := base64.StdEncoding.DecodeString(encodedKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("the embedded public key to verify the Vulnmap CLI is invalid")
+	}
+	return key, nil
+}
+
+// parseSignature accepts raw Ed25519 signatures and base64 encoded ones
+func parseSignature(content []byte) ([]byte, error) {
+	if len(content) == ed25519.SignatureSize {
+		return content, nil
+	}
+	signature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(content)))
+	if err != nil || len(signature) != ed25519.SignatureSize {
+		return nil, errors.New("the signature of the Vulnmap CLI has an invalid format")
+	}
+	return signature, nil
+}
+
+func verifySignature(publicKey ed25519.PublicKey, filePath string, signature []byte) error {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(publicKey, content, signature) {
+		return errors.New("the signature of the downloaded Vulnmap CLI is invalid")
+	}
+	return nil
+}
+
+// verifyDownloadSignature verifies the binary against the detached signature of the release asset. As the signature
+// is checked with the embedded public key, a compromised release source can't provide a valid binary.
+func (d *Downloader) verifyDownloadSignature(r *Release, filePath string) error {
+	publicKey, err := signingPublicKey()
+	if err != nil {
+		return err
+	}
+	if publicKey == nil {
+		log.Warn().Str("method", "verifyDownloadSignature").Msg("no public key embedded, the download is only verified by its checksum")
+		return nil
+	}
+
+	signatureURL := r.signatureURL()
+	resp, err := d.httpClient().Get(signatureURL)
+	if err != nil {
+		return err
+	}
+	defer func(Body io.ReadCloser) { _ = Body.Close() }(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to obtain Vulnmap CLI signature from %q: %s", signatureURL, resp.Status)
+	}
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	signature, err := parseSignature(content)
+	if err != nil {
+		return err
+	}
+	return verifySignature(publicKey, filePath, signature)
+}
+
+// signatureURL returns the URL of the detached signature of the binary for the current platform
+func (r *Release) signatureURL() string {
+	downloadURL := r.downloadURL()
+	if r.Assets != nil {
+		for _, asset := range r.Assets.all() {
+			if asset.URL == downloadURL && asset.SignatureURL != "" {
+				return asset.SignatureURL
+			}
+		}
+	}
+	return downloadURL + ".sig"
+}
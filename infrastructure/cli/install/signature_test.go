@@ -0,0 +1,102 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package install
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/error_reporting"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/testutil"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/uri"
+)
+
+func setSigningKey(t *testing.T, key ed25519.PublicKey, development string) {
+	t.Helper()
+	previousKey, previousDevelopment := config.CliSigningPublicKey, config.Development
+	t.Cleanup(func() { config.CliSigningPublicKey, config.Development = previousKey, previousDevelopment })
+	config.CliSigningPublicKey = base64.StdEncoding.EncodeToString(key)
+	config.Development = development
+}
+
+func Test_verifyDownloadSignature(t *testing.T) {
+	testutil.UnitTest(t)
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	setSigningKey(t, publicKey, "false")
+
+	dir := t.TempDir()
+	binaryPath := filepath.Join(dir, "vulnmap")
+	binary := []byte("the real cli")
+	require.NoError(t, os.WriteFile(binaryPath, binary, 0644))
+	asset := &ReleaseAsset{URL: string(uri.PathToUri(binaryPath))}
+	release := &Release{Assets: &ReleaseAssets{
+		AlpineLinux: asset, Linux: asset, LinuxARM64: asset, MacOS: asset, MacOSARM64: asset, Windows: asset,
+	}}
+	downloader := NewDownloader(error_reporting.NewTestErrorReporter(), withFileTransport(func() *http.Client {
+		return http.DefaultClient
+	}))
+
+	t.Run("valid base64 signature", func(t *testing.T) {
+		signature := base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, binary))
+		require.NoError(t, os.WriteFile(binaryPath+".sig", []byte(signature+"\n"), 0644))
+
+		assert.NoError(t, downloader.verifyDownloadSignature(release, binaryPath))
+	})
+
+	t.Run("valid raw signature", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(binaryPath+".sig", ed25519.Sign(privateKey, binary), 0644))
+
+		assert.NoError(t, downloader.verifyDownloadSignature(release, binaryPath))
+	})
+
+	t.Run("signature of another binary", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(binaryPath+".sig", ed25519.Sign(privateKey, []byte("malicious cli")), 0644))
+
+		assert.Error(t, downloader.verifyDownloadSignature(release, binaryPath))
+	})
+
+	t.Run("missing signature", func(t *testing.T) {
+		require.NoError(t, os.Remove(binaryPath+".sig"))
+
+		assert.Error(t, downloader.verifyDownloadSignature(release, binaryPath))
+	})
+}
+
+func Test_verifyDownloadSignature_WithoutKeyOnlyChecksTheChecksum(t *testing.T) {
+	testutil.UnitTest(t)
+	setSigningKey(t, nil, "false")
+	downloader := NewDownloader(error_reporting.NewTestErrorReporter(), withFileTransport(func() *http.Client {
+		return http.DefaultClient
+	}))
+	release := &Release{Assets: &ReleaseAssets{}}
+
+	key, err := signingPublicKey()
+
+	assert.NoError(t, err)
+	assert.Nil(t, key)
+	assert.NoError(t, downloader.verifyDownloadSignature(release, filepath.Join(t.TempDir(), "vulnmap")))
+}
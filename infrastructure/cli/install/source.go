@@ -0,0 +1,174 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package install
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	sglsp "github.com/sourcegraph/go-lsp"
+
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/uri"
+)
+
+const (
+	// ReleaseChannelStable installs the latest stable release of the CLI
+	ReleaseChannelStable = "stable"
+	// ReleaseChannelPreview installs the latest preview release of the CLI
+	ReleaseChannelPreview = "preview"
+)
+
+// releaseBaseURL returns the base URL of the configured release source, which can be an HTTPS mirror of the public
+// release endpoint, a file:// URL or a local directory. Without a source, the public release endpoint is used. Other
+// schemes, e.g. http://, are rejected, as the binary and its checksum would come over the same unauthenticated channel.
+func releaseBaseURL(source string) (string, error) {
+	source = strings.TrimSpace(source)
+	if source == "" {
+		return defaultBaseURL, nil
+	}
+	if !strings.Contains(source, "://") {
+		source = string(uri.PathToUri(source))
+	}
+	sourceURL, err := url.Parse(source)
+	if err != nil {
+		return "", fmt.Errorf("invalid CLI release source %q: %w", source, err)
+	}
+	if scheme := strings.ToLower(sourceURL.Scheme); scheme != "https" && scheme != "file" {
+		return "", fmt.Errorf("CLI release source %q must be an https:// or file:// URL or a local directory", source)
+	}
+	return strings.TrimSuffix(source, "/"), nil
+}
+
+// releasePath returns the path of the release metadata of a pinned version, or of the latest release of a channel.
+func releasePath(version string, channel string) string {
+	version = strings.TrimSpace(version)
+	if version != "" {
+		return "v" + strings.TrimPrefix(version, "v")
+	}
+	if channel == ReleaseChannelPreview {
+		return ReleaseChannelPreview
+	}
+	return "latest"
+}
+
+// resolveAssetURLs makes the asset URLs of a release absolute and points them to the release source. Relative URLs
+// are resolved against the location of the release metadata, so that a mirror can contain relative paths, and URLs
+// of the public release endpoint are replaced by the base URL of the mirror.
+func (r *Release) resolveAssetURLs(releaseURL string, baseURL string) error {
+	if r.Assets == nil {
+		return nil
+	}
+	metadataURL, err := url.Parse(releaseURL)
+	if err != nil {
+		return err
+	}
+	resolve := func(assetURL string) (string, error) {
+		if assetURL == "" {
+			return "", nil
+		}
+		if baseURL != defaultBaseURL && strings.HasPrefix(assetURL, defaultBaseURL+"/") {
+			return baseURL + strings.TrimPrefix(assetURL, defaultBaseURL), nil
+		}
+		reference, parseErr := url.Parse(assetURL)
+		if parseErr != nil {
+			return "", parseErr
+		}
+		return metadataURL.ResolveReference(reference).String(), nil
+	}
+
+	for _, asset := range r.Assets.all() {
+		if asset.URL, err = resolve(asset.URL); err != nil {
+			return err
+		}
+		if asset.ChecksumURL, err = resolve(asset.ChecksumURL); err != nil {
+			return err
+		}
+		if asset.SignatureURL, err = resolve(asset.SignatureURL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *ReleaseAssets) all() []*ReleaseAsset {
+	var assets []*ReleaseAsset
+	for _, asset := range []*ReleaseAsset{a.AlpineLinux, a.Linux, a.LinuxARM64, a.MacOS, a.MacOSARM64, a.Windows} {
+		if asset != nil {
+			assets = append(assets, asset)
+		}
+	}
+	return assets
+}
+
+// withFileTransport returns http clients that can also read file:// URLs, to install the CLI from a local directory
+func withFileTransport(httpClient func() *http.Client) func() *http.Client {
+	return func() *http.Client {
+		client := http.DefaultClient
+		if httpClient != nil {
+			client = httpClient()
+		}
+		transport := client.Transport
+		if transport == nil {
+			transport = http.DefaultTransport
+		}
+		clientCopy := *client
+		clientCopy.Transport = &fileTransport{next: transport}
+		return &clientCopy
+	}
+}
+
+// fileTransport serves file:// URLs from the local file system and passes on all other requests
+type fileTransport struct {
+	next http.RoundTripper
+}
+
+func (t *fileTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	if request.URL.Scheme != "file" {
+		return t.next.RoundTrip(request)
+	}
+
+	path := uri.PathFromUri(sglsp.DocumentURI(request.URL.String()))
+	response := &http.Response{
+		Proto:      "HTTP/1.0",
+		ProtoMajor: 1,
+		Header:     http.Header{},
+		Request:    request,
+	}
+	file, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		response.StatusCode = http.StatusNotFound
+		response.Status = fmt.Sprintf("%d %s", http.StatusNotFound, http.StatusText(http.StatusNotFound))
+		response.Body = io.NopCloser(bytes.NewReader(nil))
+		return response, nil
+	}
+	stat, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	response.StatusCode = http.StatusOK
+	response.Status = fmt.Sprintf("%d %s", http.StatusOK, http.StatusText(http.StatusOK))
+	response.ContentLength = stat.Size()
+	response.Body = file
+	return response, nil
+}
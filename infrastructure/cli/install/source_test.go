@@ -0,0 +1,276 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package install
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/adrg/xdg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/audit"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/error_reporting"
+	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/network"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/testutil"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/uri"
+)
+
+func Test_releaseBaseURL(t *testing.T) {
+	dir := t.TempDir()
+
+	for source, expected := range map[string]string{
+		"":                                    defaultBaseURL,
+		"https://mirror.example.com/vulnmap/": "https://mirror.example.com/vulnmap",
+		dir:                                   string(uri.PathToUri(dir)),
+	} {
+		baseURL, err := releaseBaseURL(source)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, baseURL)
+	}
+
+	t.Run("unauthenticated sources are rejected", func(t *testing.T) {
+		for _, source := range []string{"http://mirror.example.com/vulnmap", "ftp://mirror.example.com/vulnmap"} {
+			_, err := releaseBaseURL(source)
+			assert.Error(t, err, source)
+		}
+	})
+}
+
+func Test_releasePath(t *testing.T) {
+	assert.Equal(t, "latest", releasePath("", ""))
+	assert.Equal(t, "latest", releasePath("", ReleaseChannelStable))
+	assert.Equal(t, "preview", releasePath("", ReleaseChannelPreview))
+	assert.Equal(t, "v1.1234.0", releasePath("1.1234.0", ReleaseChannelPreview))
+	assert.Equal(t, "v1.1234.0", releasePath("v1.1234.0", ""))
+}
+
+func Test_resolveAssetURLs(t *testing.T) {
+	release := Release{Assets: &ReleaseAssets{
+		Linux:   &ReleaseAsset{URL: defaultBaseURL + "/cli/v1.0.0/vulnmap-linux"},
+		Windows: &ReleaseAsset{URL: "vulnmap-win.exe", ChecksumURL: "vulnmap-win.exe.sha256"},
+	}}
+
+	err := release.resolveAssetURLs("https://mirror.example.com/cli/v1.0.0/release.json", "https://mirror.example.com")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "https://mirror.example.com/cli/v1.0.0/vulnmap-linux", release.Assets.Linux.URL)
+	assert.Equal(t, "https://mirror.example.com/cli/v1.0.0/vulnmap-win.exe", release.Assets.Windows.URL)
+	assert.Equal(t, "https://mirror.example.com/cli/v1.0.0/vulnmap-win.exe.sha256", release.Assets.Windows.ChecksumURL)
+}
+
+// createLocalMirror creates a release directory with relative asset URLs and checksum files, as used in air-gapped
+// networks, and returns the checksum of the CLI
+func createLocalMirror(t *testing.T, mirrorDir string, version string) string {
+	t.Helper()
+	releaseDir := filepath.Join(mirrorDir, "cli", version)
+	require.NoError(t, os.MkdirAll(releaseDir, 0755))
+	cliContent := []byte("dummy-cli-" + version)
+	checksum := sha256.Sum256(cliContent)
+	checksumHex := hex.EncodeToString(checksum[:])
+	require.NoError(t, os.WriteFile(filepath.Join(releaseDir, "vulnmap"), cliContent, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(releaseDir, "vulnmap.sha256"), []byte(checksumHex+"  vulnmap\n"), 0644))
+
+	asset := `{"url": "vulnmap", "sha256Url": "vulnmap.sha256"}`
+	releaseJson := fmt.Sprintf(`{"version": %q, "assets": {"vulnmap-alpine": %s, "vulnmap-linux": %s,
+		"vulnmap-linux-arm64": %s, "vulnmap-macos": %s, "vulnmap-macos-arm64": %s, "vulnmap-win.exe": %s}}`,
+		version, asset, asset, asset, asset, asset, asset)
+	require.NoError(t, os.WriteFile(filepath.Join(releaseDir, "release.json"), []byte(releaseJson), 0644))
+	return checksumHex
+}
+
+func TestCLIRelease_GetRelease_FromLocalDirectoryWithPinnedVersion(t *testing.T) {
+	testutil.UnitTest(t)
+	mirrorDir := t.TempDir()
+	checksum := createLocalMirror(t, mirrorDir, "v1.2.3")
+	createLocalMirror(t, mirrorDir, "latest")
+	settings := config.CurrentConfig().CliSettings()
+	settings.ReleaseSource = mirrorDir
+	settings.ReleaseVersion = "1.2.3"
+
+	release, err := NewCLIRelease(func() *http.Client { return http.DefaultClient }).GetRelease(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, "v1.2.3", release.Version)
+	assert.Equal(t, string(uri.PathToUri(filepath.Join(mirrorDir, "cli", "v1.2.3", "vulnmap"))), release.downloadURL())
+	assert.Equal(t, checksum+"  vulnmap\n", release.checksumInfo())
+}
+
+func TestCLIRelease_GetRelease_RejectsHttpSource(t *testing.T) {
+	testutil.UnitTest(t)
+	requested := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { requested = true }))
+	t.Cleanup(server.Close)
+	config.CurrentConfig().CliSettings().ReleaseSource = server.URL
+
+	_, err := NewCLIRelease(server.Client).GetRelease(context.Background())
+
+	assert.Error(t, err)
+	assert.False(t, requested)
+}
+
+func TestInstaller_Install_FromLocalMirror(t *testing.T) {
+	testutil.UnitTest(t)
+	testutil.CreateDummyProgressListener(t)
+	mirrorDir := t.TempDir()
+	checksum := createLocalMirror(t, mirrorDir, "preview")
+	settings := config.CurrentConfig().CliSettings()
+	settings.ReleaseSource = string(uri.PathToUri(mirrorDir))
+	settings.ReleaseChannel = ReleaseChannelPreview
+	cliPath := filepath.Join(t.TempDir(), (&Discovery{}).ExecutableName(false))
+	settings.SetPath(cliPath)
+	installer := NewInstaller(error_reporting.NewTestErrorReporter(), audit.NewTestTrail(), func() *http.Client { return http.DefaultClient })
+
+	installedPath, err := installer.Install(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, cliPath, installedPath)
+	expectedChecksum, _ := HashSumFromHexDigest(checksum)
+	assert.NoError(t, compareChecksum(expectedChecksum, cliPath))
+}
+
+func TestInstaller_Install_OfflineMode_FromLocalMirror(t *testing.T) {
+	c := testutil.UnitTest(t)
+	c.SetOfflineMode(true)
+	testutil.CreateDummyProgressListener(t)
+	mirrorDir := t.TempDir()
+	createLocalMirror(t, mirrorDir, "latest")
+	settings := c.CliSettings()
+	settings.ReleaseSource = string(uri.PathToUri(mirrorDir))
+	cliPath := filepath.Join(t.TempDir(), (&Discovery{}).ExecutableName(false))
+	settings.SetPath(cliPath)
+	httpClient := network.GuardClientFactory(func() *http.Client { return testutil.NoDialHttpClient(t) })
+	installer := NewInstaller(error_reporting.NewTestErrorReporter(), audit.NewTestTrail(), httpClient)
+
+	installedPath, err := installer.Install(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, cliPath, installedPath)
+}
+
+func TestInstaller_Install_OfflineMode_DoesNotDownload(t *testing.T) {
+	c := testutil.UnitTest(t)
+	c.SetOfflineMode(true)
+	testutil.CreateDummyProgressListener(t)
+	c.CliSettings().SetPath(filepath.Join(t.TempDir(), (&Discovery{}).ExecutableName(false)))
+	httpClient := network.GuardClientFactory(func() *http.Client { return testutil.NoDialHttpClient(t) })
+	installer := NewInstaller(error_reporting.NewTestErrorReporter(), audit.NewTestTrail(), httpClient)
+
+	_, err := installer.Install(context.Background())
+
+	assert.True(t, errors.Is(err, network.ErrOffline))
+}
+
+func prepareUpdateFromLocalMirror(t *testing.T, healthCheck func(cliPath string) error) (*Install, string, *audit.TestTrail) {
+	t.Helper()
+	testutil.CreateDummyProgressListener(t)
+	mirrorDir := t.TempDir()
+	createLocalMirror(t, mirrorDir, "latest")
+	settings := config.CurrentConfig().CliSettings()
+	settings.ReleaseSource = mirrorDir
+	cliPath := filepath.Join(t.TempDir(), (&Discovery{}).ExecutableName(false))
+	require.NoError(t, os.WriteFile(cliPath, []byte("previous cli"), 0755))
+	settings.SetPath(cliPath)
+	auditTrail := audit.NewTestTrail()
+	installer := NewInstaller(error_reporting.NewTestErrorReporter(), auditTrail, func() *http.Client { return http.DefaultClient })
+	installer.healthCheck = healthCheck
+	return installer, cliPath, auditTrail
+}
+
+func cliChanges(t *testing.T, auditTrail *audit.TestTrail) []audit.CliProperties {
+	t.Helper()
+	var changes []audit.CliProperties
+	for _, event := range auditTrail.Events() {
+		if change, ok := event.(audit.CliProperties); ok {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+func TestInstaller_Update_RollsBackIfHealthCheckFails(t *testing.T) {
+	testutil.UnitTest(t)
+	installer, cliPath, auditTrail := prepareUpdateFromLocalMirror(t, func(cliPath string) error {
+		return errors.New("the cli crashed")
+	})
+
+	updated, err := installer.Update(context.Background())
+
+	assert.False(t, updated)
+	assert.ErrorContains(t, err, "the cli crashed")
+	content, _ := os.ReadFile(cliPath)
+	assert.Equal(t, "previous cli", string(content))
+	assert.NoFileExists(t, backupPath(cliPath))
+	changes := cliChanges(t, auditTrail)
+	require.Len(t, changes, 1)
+	assert.Equal(t, audit.CliRolledBack, changes[0].Change)
+	assert.Equal(t, "latest", changes[0].Version)
+	assert.Equal(t, cliPath, changes[0].CliPath)
+	assert.Contains(t, changes[0].Error, "the cli crashed")
+}
+
+func TestInstaller_Update_KeepsHealthyUpdate(t *testing.T) {
+	testutil.UnitTest(t)
+	var checkedPath string
+	installer, cliPath, auditTrail := prepareUpdateFromLocalMirror(t, func(cliPath string) error {
+		checkedPath = cliPath
+		return nil
+	})
+
+	updated, err := installer.Update(context.Background())
+
+	assert.True(t, updated)
+	assert.NoError(t, err)
+	assert.Equal(t, cliPath, checkedPath)
+	content, _ := os.ReadFile(cliPath)
+	assert.Equal(t, "dummy-cli-latest", string(content))
+	assert.NoFileExists(t, backupPath(cliPath))
+	changes := cliChanges(t, auditTrail)
+	require.Len(t, changes, 1)
+	assert.Equal(t, audit.CliUpdated, changes[0].Change)
+	assert.Len(t, auditTrail.Events(), 1)
+}
+
+func TestInstaller_InstallVersion_RemovesCliIfHealthCheckFails(t *testing.T) {
+	testutil.UnitTest(t)
+	xdgDataHome := xdg.DataHome
+	xdg.DataHome = t.TempDir()
+	t.Cleanup(func() { xdg.DataHome = xdgDataHome })
+	installer, _, auditTrail := prepareUpdateFromLocalMirror(t, func(cliPath string) error {
+		return errors.New("the cli crashed")
+	})
+	createLocalMirror(t, config.CurrentConfig().CliSettings().ReleaseSource, "v1.2.3")
+
+	_, err := installer.InstallVersion(context.Background(), "1.2.3")
+
+	assert.ErrorContains(t, err, "the cli crashed")
+	assert.NoFileExists(t, PinnedCliPath("1.2.3"))
+	changes := cliChanges(t, auditTrail)
+	require.Len(t, changes, 1)
+	assert.Equal(t, audit.CliInstalled, changes[0].Change)
+	assert.Contains(t, changes[0].Error, "the cli crashed")
+}
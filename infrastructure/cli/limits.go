@@ -0,0 +1,128 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/metrics"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/concurrency"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/progress"
+)
+
+// TimeoutError is returned when the CLI didn't finish within the configured timeout
+type TimeoutError struct {
+	Timeout time.Duration
+	// Queued is true if the timeout was reached while waiting for other CLI runs to finish
+	Queued bool
+}
+
+func (e *TimeoutError) Error() string {
+	if e.Queued {
+		return fmt.Sprintf("The Vulnmap CLI did not start within the scan timeout of %s, "+
+			"as other scans were still running. Consider increasing the CLI concurrency or the scan timeout.", e.Timeout)
+	}
+	return fmt.Sprintf("The Vulnmap CLI did not finish within the scan timeout of %s. "+
+		"Consider increasing the scan timeout.", e.Timeout)
+}
+
+type timeoutContextKey struct{}
+
+// WithTimeout returns a context that makes the executor stop the CLI after the given timeout instead of the default
+func WithTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, timeoutContextKey{}, timeout)
+}
+
+func timeoutFromContext(ctx context.Context) time.Duration {
+	if timeout, ok := ctx.Value(timeoutContextKey{}).(time.Duration); ok && timeout > 0 {
+		return timeout
+	}
+	return config.DefaultCliScanTimeout
+}
+
+func newLimiter() *concurrency.Limiter {
+	return concurrency.NewLimiter(func() int {
+		return config.CurrentConfig().CliSettings().MaxConcurrency()
+	})
+}
+
+// executeLimited waits until the limiter allows another CLI run, reporting the time spent in the queue as progress,
+// and runs execute with the timeout of the context. Timeouts are returned as TimeoutError.
+func executeLimited(
+	ctx context.Context,
+	limiter *concurrency.Limiter,
+	execute func(ctx context.Context) ([]byte, error),
+) ([]byte, error) {
+	timeout := timeoutFromContext(ctx)
+	// the deadline also covers the time in the queue, to handle a CLI hanging while we wait for it
+	executeCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var queueProgress *progress.Tracker
+	metrics.CliQueueDepth.Add(1)
+	release, err := limiter.Acquire(executeCtx, func(waited time.Duration) {
+		message := fmt.Sprintf("queued behind %d Vulnmap CLI runs for %s", limiter.Running(), waited.Round(time.Second))
+		if queueProgress == nil {
+			queueProgress = progress.NewTrackerFromContext(ctx, false)
+			queueProgress.BeginWithMessage("Waiting for the Vulnmap CLI", message)
+			return
+		}
+		queueProgress.ReportWithMessage(queuePercentage(waited), message)
+	})
+	metrics.CliQueueDepth.Add(-1)
+	if queueProgress != nil {
+		queueProgress.End()
+	}
+	if err != nil {
+		if isTimeout(ctx, executeCtx) {
+			return nil, &TimeoutError{Timeout: timeout, Queued: true}
+		}
+		return nil, err
+	}
+	defer release()
+	metrics.CliRunning.Add(1)
+	defer metrics.CliRunning.Add(-1)
+
+	output, err := execute(executeCtx)
+	if isTimeout(ctx, executeCtx) {
+		timeoutErr := &TimeoutError{Timeout: timeout}
+		log.Warn().Err(err).Str("method", "executeLimited").Msg(timeoutErr.Error())
+		return output, timeoutErr
+	}
+	return output, err
+}
+
+// queueWaitHorizon is the time in the queue that is shown as half of the progress
+const queueWaitHorizon = 30 * time.Second
+
+// queuePercentage returns the progress of waiting in the queue. The scan timeout is too long to be used as 100%, the
+// progress would barely move. So the percentage approaches 99% the longer the wait takes: 50% after queueWaitHorizon,
+// 90% after nine times that. The message with the time waited is updated after that, too.
+func queuePercentage(waited time.Duration) int {
+	return min(max(int(99*waited/(waited+queueWaitHorizon)), 1), 99)
+}
+
+// isTimeout returns true if the execution context reached its deadline, not the one of the caller
+func isTimeout(ctx context.Context, executeCtx context.Context) bool {
+	return ctx.Err() == nil && errors.Is(executeCtx.Err(), context.DeadlineExceeded)
+}
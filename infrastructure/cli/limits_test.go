@@ -0,0 +1,133 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/concurrency"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/lsp"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/progress"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/testutil"
+)
+
+func Test_executeLimited_ReturnsTimeoutErrorWhenCliTakesTooLong(t *testing.T) {
+	testutil.UnitTest(t)
+	limiter := concurrency.NewLimiter(func() int { return 1 })
+	ctx := WithTimeout(context.Background(), 50*time.Millisecond)
+
+	_, err := executeLimited(ctx, limiter, func(ctx context.Context) ([]byte, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	var timeoutErr *TimeoutError
+	require.ErrorAs(t, err, &timeoutErr)
+	assert.False(t, timeoutErr.Queued)
+	assert.Equal(t, 50*time.Millisecond, timeoutErr.Timeout)
+	assert.Equal(t, 0, limiter.Running())
+}
+
+func Test_executeLimited_ReturnsQueuedTimeoutErrorWhenCliDoesNotStart(t *testing.T) {
+	testutil.UnitTest(t)
+	limiter := concurrency.NewLimiter(func() int { return 1 })
+	release, err := limiter.Acquire(context.Background(), nil)
+	require.NoError(t, err)
+	defer release()
+	ctx := WithTimeout(context.Background(), 50*time.Millisecond)
+
+	_, err = executeLimited(ctx, limiter, func(ctx context.Context) ([]byte, error) {
+		t.Fatal("the CLI must not run while the limit is reached")
+		return nil, nil
+	})
+
+	var timeoutErr *TimeoutError
+	require.ErrorAs(t, err, &timeoutErr)
+	assert.True(t, timeoutErr.Queued)
+}
+
+func Test_executeLimited_CancellationIsNoTimeout(t *testing.T) {
+	testutil.UnitTest(t)
+	limiter := concurrency.NewLimiter(func() int { return 1 })
+	ctx, cancel := context.WithCancel(context.Background())
+
+	_, err := executeLimited(ctx, limiter, func(ctx context.Context) ([]byte, error) {
+		cancel()
+		return nil, context.Canceled
+	})
+
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func Test_executeLimited_ReportsQueuedStateAsProgress(t *testing.T) {
+	testutil.UnitTest(t)
+	limiter := concurrency.NewLimiter(func() int { return 1 })
+	release, err := limiter.Acquire(context.Background(), nil)
+	require.NoError(t, err)
+	ctx := WithTimeout(context.Background(), 10*time.Second)
+	done := make(chan error)
+
+	go func() {
+		_, err := executeLimited(ctx, limiter, func(ctx context.Context) ([]byte, error) { return nil, nil })
+		done <- err
+	}()
+
+	var begin lsp.WorkDoneProgressBegin
+	assert.Eventually(t, func() bool {
+		for len(progress.Channel) > 0 {
+			if value, ok := (<-progress.Channel).Value.(lsp.WorkDoneProgressBegin); ok {
+				begin = value
+				return true
+			}
+		}
+		return false
+	}, 5*time.Second, 10*time.Millisecond)
+	release()
+	require.NoError(t, <-done)
+
+	assert.Equal(t, "Waiting for the Vulnmap CLI", begin.Title)
+	assert.Contains(t, begin.Message, "queued behind 1 Vulnmap CLI runs")
+	var ended bool
+	for len(progress.Channel) > 0 {
+		if _, ok := (<-progress.Channel).Value.(lsp.WorkDoneProgressEnd); ok {
+			ended = true
+		}
+	}
+	assert.True(t, ended)
+}
+
+func Test_queuePercentage_Increases(t *testing.T) {
+	assert.Equal(t, 1, queuePercentage(0))
+	assert.Equal(t, 49, queuePercentage(queueWaitHorizon))
+	previous := queuePercentage(0)
+	for waited := time.Second; waited <= 10*time.Minute; waited += time.Second {
+		percentage := queuePercentage(waited)
+		if waited <= 20*time.Second {
+			assert.Greater(t, percentage, previous, waited.String())
+		} else {
+			assert.GreaterOrEqual(t, percentage, previous, waited.String())
+		}
+		previous = percentage
+	}
+	assert.Equal(t, 89, queuePercentage(9*queueWaitHorizon))
+	assert.Less(t, queuePercentage(24*time.Hour), 100)
+}
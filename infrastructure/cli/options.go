@@ -0,0 +1,120 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/lsp"
+)
+
+const (
+	allProjectsFlag       = "--all-projects"
+	devFlag               = "--dev"
+	detectionDepthFlag    = "--detection-depth"
+	excludeFlag           = "--exclude"
+	severityThresholdFlag = "--severity-threshold"
+	commandFlag           = "--command"
+	fileFlag              = "--file"
+)
+
+var severityThresholds = map[string]bool{
+	"low":      true,
+	"medium":   true,
+	"high":     true,
+	"critical": true,
+}
+
+// ValidateOptions returns an error describing all invalid options and invalid combinations of the options with each
+// other or with the additional parameters
+func ValidateOptions(options lsp.CliOptions, additionalParams []string) error {
+	var errs []error
+	allProjects := options.AllProjects || hasParam(additionalParams, allProjectsFlag)
+
+	if options.DetectionDepth < 0 {
+		errs = append(errs, fmt.Errorf("detectionDepth must be positive, but is %d", options.DetectionDepth))
+	}
+	if options.DetectionDepth > 0 && !allProjects {
+		errs = append(errs, errors.New("detectionDepth can only be used with allProjects"))
+	}
+	if len(options.Exclude) > 0 && !allProjects {
+		errs = append(errs, errors.New("exclude can only be used with allProjects"))
+	}
+	for _, exclude := range options.Exclude {
+		if exclude == "" || strings.ContainsAny(exclude, `/\,`) {
+			errs = append(errs, fmt.Errorf("exclude must contain directory or file names, not paths, but contains %q", exclude))
+		}
+	}
+	if options.SeverityThreshold != "" && !severityThresholds[options.SeverityThreshold] {
+		errs = append(errs, fmt.Errorf("severityThreshold must be one of low, medium, high or critical, but is %q",
+			options.SeverityThreshold))
+	}
+	if allProjects && hasParam(additionalParams, fileFlag) {
+		errs = append(errs, errors.New("allProjects can't be combined with --file in the additional parameters"))
+	}
+
+	for _, flag := range OssFlags(options) {
+		name, _, _ := strings.Cut(flag, "=")
+		if hasParam(additionalParams, name) {
+			errs = append(errs, fmt.Errorf("%s is set in the CLI options and in the additional parameters", name))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// OssFlags returns the CLI flags of the options for Open Source scans
+func OssFlags(options lsp.CliOptions) (flags []string) {
+	if options.AllProjects {
+		flags = append(flags, allProjectsFlag)
+	}
+	if options.Dev {
+		flags = append(flags, devFlag)
+	}
+	if options.DetectionDepth > 0 {
+		flags = append(flags, detectionDepthFlag+"="+strconv.Itoa(options.DetectionDepth))
+	}
+	if len(options.Exclude) > 0 {
+		flags = append(flags, excludeFlag+"="+strings.Join(options.Exclude, ","))
+	}
+	if options.SeverityThreshold != "" {
+		flags = append(flags, severityThresholdFlag+"="+options.SeverityThreshold)
+	}
+	if options.Command != "" {
+		flags = append(flags, commandFlag+"="+options.Command)
+	}
+	return flags
+}
+
+// IacFlags returns the CLI flags of the options for IaC scans, the other options only apply to Open Source scans
+func IacFlags(options lsp.CliOptions) (flags []string) {
+	if options.SeverityThreshold != "" {
+		flags = append(flags, severityThresholdFlag+"="+options.SeverityThreshold)
+	}
+	return flags
+}
+
+func hasParam(params []string, flag string) bool {
+	for _, param := range params {
+		if param == flag || strings.HasPrefix(param, flag+"=") {
+			return true
+		}
+	}
+	return false
+}
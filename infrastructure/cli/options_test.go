@@ -0,0 +1,90 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/lsp"
+)
+
+func TestValidateOptions(t *testing.T) {
+	t.Run("valid options", func(t *testing.T) {
+		options := lsp.CliOptions{
+			AllProjects:       true,
+			Dev:               true,
+			DetectionDepth:    4,
+			Exclude:           []string{"dist", "fixtures"},
+			SeverityThreshold: "high",
+			Command:           "python3",
+		}
+
+		assert.NoError(t, ValidateOptions(options, []string{"-d", ""}))
+	})
+
+	t.Run("all projects can be set in the additional parameters", func(t *testing.T) {
+		options := lsp.CliOptions{DetectionDepth: 2, Exclude: []string{"dist"}}
+
+		assert.NoError(t, ValidateOptions(options, []string{"--all-projects"}))
+	})
+
+	tests := []struct {
+		name             string
+		options          lsp.CliOptions
+		additionalParams []string
+		expectedError    string
+	}{
+		{"negative detection depth", lsp.CliOptions{AllProjects: true, DetectionDepth: -1}, nil, "detectionDepth must be positive"},
+		{"detection depth without all projects", lsp.CliOptions{DetectionDepth: 2}, nil, "detectionDepth can only be used with allProjects"},
+		{"exclude without all projects", lsp.CliOptions{Exclude: []string{"dist"}}, nil, "exclude can only be used with allProjects"},
+		{"exclude with paths", lsp.CliOptions{AllProjects: true, Exclude: []string{"src/dist"}}, nil, "not paths"},
+		{"unknown severity", lsp.CliOptions{SeverityThreshold: "severe"}, nil, "severityThreshold must be one of"},
+		{"all projects with file", lsp.CliOptions{AllProjects: true}, []string{"--file=pom.xml"}, "can't be combined with --file"},
+		{"duplicated flag", lsp.CliOptions{Dev: true}, []string{"--dev"}, "--dev is set in the CLI options and in the additional parameters"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := ValidateOptions(test.options, test.additionalParams)
+
+			assert.ErrorContains(t, err, test.expectedError)
+		})
+	}
+}
+
+func TestOssFlags(t *testing.T) {
+	options := lsp.CliOptions{
+		AllProjects:       true,
+		Dev:               true,
+		DetectionDepth:    4,
+		Exclude:           []string{"dist", "fixtures"},
+		SeverityThreshold: "high",
+		Command:           "python3",
+	}
+
+	assert.Equal(t, []string{
+		"--all-projects",
+		"--dev",
+		"--detection-depth=4",
+		"--exclude=dist,fixtures",
+		"--severity-threshold=high",
+		"--command=python3",
+	}, OssFlags(options))
+	assert.Equal(t, []string{"--severity-threshold=high"}, IacFlags(options))
+	assert.Empty(t, OssFlags(lsp.CliOptions{}))
+}
@@ -0,0 +1,185 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/metrics"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/vulnmap"
+)
+
+// skippedDirs are not searched for files that affect scan results, they contain dependencies or build output
+var skippedDirs = map[string]bool{
+	"node_modules": true,
+	"venv":         true,
+	"__pycache__":  true,
+	"vendor":       true,
+	"target":       true,
+	"build":        true,
+}
+
+type resultCacheEntry struct {
+	key     string
+	output  []byte
+	created time.Time
+}
+
+// ResultCache caches the output of successful CLI scans per scanned path, so that rescans that can't change the
+// results (e.g. after saving a README) don't run the CLI again. Entries expire after the configured TTL,
+// so that new advisories are picked up.
+type ResultCache struct {
+	mutex   sync.Mutex
+	entries map[string]resultCacheEntry
+	now     func() time.Time
+}
+
+func NewResultCache() *ResultCache {
+	return &ResultCache{entries: map[string]resultCacheEntry{}, now: time.Now}
+}
+
+// Get returns the cached output of the scan of path if it was cached with the same key and hasn't expired. Scans the
+// user triggered don't get cached output.
+func (c *ResultCache) Get(ctx context.Context, path string, key string) ([]byte, bool) {
+	if !vulnmap.CachedResultsAllowed(ctx) {
+		return nil, false
+	}
+	ttl := config.CurrentConfig().CliSettings().ResultCacheTTL
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	entry, ok := c.entries[path]
+	if !ok || entry.key != key {
+		return nil, false
+	}
+	if ttl <= 0 || c.now().Sub(entry.created) > ttl {
+		delete(c.entries, path)
+		metrics.CliResultCacheEntries.Add(-1)
+		return nil, false
+	}
+	return entry.output, true
+}
+
+// Put caches the output of the scan of path, replacing the previous output
+func (c *ResultCache) Put(path string, key string, output []byte) {
+	if key == "" || config.CurrentConfig().CliSettings().ResultCacheTTL <= 0 {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if _, ok := c.entries[path]; !ok {
+		metrics.CliResultCacheEntries.Add(1)
+	}
+	c.entries[path] = resultCacheEntry{key: key, output: output, created: c.now()}
+}
+
+// Clear removes all cached results
+func (c *ResultCache) Clear() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	metrics.CliResultCacheEntries.Add(-float64(len(c.entries)))
+	c.entries = map[string]resultCacheEntry{}
+}
+
+// ResultCacheKey returns the cache key of a scan with cmd of path in workingDir. It hashes the command (which contains
+// the additional parameters and the organization), the API endpoint, the token (as the results depend on the account,
+// and the environment doesn't contain it), the environment of the CLI (which contains the additional and folder
+// variables, the path setting and the toolchain), the CLI binary (which changes with the CLI version) and all files
+// below path for which isRelevant returns true, e.g. manifests and lock files. It returns an empty key if the cache is
+// disabled or the files can't be read, such scans are not cached.
+func ResultCacheKey(cmd []string, workingDir string, path string, isRelevant func(relativePath string) bool) string {
+	c := config.CurrentConfig()
+	if c.CliSettings().ResultCacheTTL <= 0 {
+		return ""
+	}
+	hash := sha256.New()
+	writeField := func(values ...string) {
+		for _, v := range values {
+			_, _ = io.WriteString(hash, v)
+			_, _ = hash.Write([]byte{0})
+		}
+	}
+	writeField(cmd...)
+	writeField(c.VulnmapApi())
+	tokenHash := sha256.Sum256([]byte(c.Token()))
+	writeField(hex.EncodeToString(tokenHash[:]))
+	writeField(CliEnvironment(workingDir, false)...)
+
+	cliPath := c.CliSettings().Path()
+	if info, err := os.Stat(cliPath); err == nil {
+		writeField(cliPath, strconv.FormatInt(info.Size(), 10), info.ModTime().UTC().String())
+	}
+
+	files, err := relevantFiles(path, isRelevant)
+	if err != nil {
+		return ""
+	}
+	for _, file := range files {
+		content, err := os.ReadFile(filepath.Join(path, file))
+		if err != nil {
+			return ""
+		}
+		contentHash := sha256.Sum256(content)
+		writeField(filepath.ToSlash(file), hex.EncodeToString(contentHash[:]))
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// relevantFiles returns the sorted paths relative to root of the relevant files, root itself can be a file
+func relevantFiles(root string, isRelevant func(relativePath string) bool) ([]string, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{"."}, nil
+	}
+
+	var files []string
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != root && (skippedDirs[d.Name()] || strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		relativePath, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if isRelevant(relativePath) {
+			files = append(files, relativePath)
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
@@ -0,0 +1,131 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/vulnmap"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/testutil"
+)
+
+func isManifest(relativePath string) bool {
+	return filepath.Base(relativePath) == "package.json"
+}
+
+func TestResultCacheKey(t *testing.T) {
+	testutil.UnitTest(t)
+	dir := t.TempDir()
+	manifest := filepath.Join(dir, "sub", "package.json")
+	require.NoError(t, os.MkdirAll(filepath.Dir(manifest), 0o700))
+	require.NoError(t, os.WriteFile(manifest, []byte(`{"dependencies": {}}`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("readme"), 0o600))
+	cmd := []string{"vulnmap", "test", dir, "--json"}
+
+	key := ResultCacheKey(cmd, dir, dir, isManifest)
+	require.NotEmpty(t, key)
+
+	t.Run("unrelated files don't change the key", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("changed"), 0o600))
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "node_modules", "dep"), 0o700))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "node_modules", "dep", "package.json"), []byte("{}"), 0o600))
+		for _, skipped := range []string{"venv", "vendor", "target", "build"} {
+			require.NoError(t, os.MkdirAll(filepath.Join(dir, skipped), 0o700))
+			require.NoError(t, os.WriteFile(filepath.Join(dir, skipped, "package.json"), []byte("{}"), 0o600))
+		}
+
+		assert.Equal(t, key, ResultCacheKey(cmd, dir, dir, isManifest))
+	})
+
+	t.Run("parameters and organization change the key", func(t *testing.T) {
+		assert.NotEqual(t, key, ResultCacheKey(append(cmd, "--all-projects"), dir, dir, isManifest))
+		assert.NotEqual(t, key, ResultCacheKey(append(cmd, "--org=other-org"), dir, dir, isManifest))
+	})
+
+	t.Run("the CLI environment changes the key", func(t *testing.T) {
+		settings := config.CurrentConfig().CliSettings()
+		settings.AdditionalEnv = []string{"JAVA_HOME=/other/jdk"}
+		t.Cleanup(func() { settings.AdditionalEnv = nil })
+
+		assert.NotEqual(t, key, ResultCacheKey(cmd, dir, dir, isManifest))
+	})
+
+	t.Run("the account changes the key", func(t *testing.T) {
+		token := config.CurrentConfig().Token()
+		config.CurrentConfig().SetToken("token-of-another-account")
+		t.Cleanup(func() { config.CurrentConfig().SetToken(token) })
+
+		assert.NotEqual(t, key, ResultCacheKey(cmd, dir, dir, isManifest))
+	})
+
+	t.Run("manifest changes change the key", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(manifest, []byte(`{"dependencies": {"lodash": "4.17.20"}}`), 0o600))
+
+		assert.NotEqual(t, key, ResultCacheKey(cmd, dir, dir, isManifest))
+	})
+}
+
+func TestResultCacheKey_Disabled(t *testing.T) {
+	testutil.UnitTest(t)
+	config.CurrentConfig().CliSettings().ResultCacheTTL = 0
+	dir := t.TempDir()
+
+	assert.Empty(t, ResultCacheKey([]string{"vulnmap", "test"}, dir, dir, isManifest))
+}
+
+func TestResultCache(t *testing.T) {
+	testutil.UnitTest(t)
+	now := time.Now()
+	cache := NewResultCache()
+	cache.now = func() time.Time { return now }
+	output := []byte("[]")
+
+	cache.Put("/project", "key", output)
+
+	cached, ok := cache.Get(context.Background(), "/project", "key")
+	assert.True(t, ok)
+	assert.Equal(t, output, cached)
+
+	_, ok = cache.Get(context.Background(), "/project", "other key")
+	assert.False(t, ok)
+
+	_, ok = cache.Get(vulnmap.WithoutCachedResults(context.Background()), "/project", "key")
+	assert.False(t, ok, "scans triggered by the user must not return cached results")
+
+	now = now.Add(config.DefaultCliResultCacheTTL + time.Second)
+	_, ok = cache.Get(context.Background(), "/project", "key")
+	assert.False(t, ok, "expired entries must not be returned")
+}
+
+func TestResultCache_Disabled(t *testing.T) {
+	testutil.UnitTest(t)
+	config.CurrentConfig().CliSettings().ResultCacheTTL = 0
+	cache := NewResultCache()
+
+	cache.Put("/project", "key", []byte("[]"))
+
+	_, ok := cache.Get(context.Background(), "/project", "key")
+	assert.False(t, ok)
+}
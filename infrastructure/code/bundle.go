@@ -108,7 +108,7 @@ func (b *Bundle) retrieveAnalysis(ctx context.Context) ([]vulnmap.Issue, error)
 		return []vulnmap.Issue{}, nil
 	}
 
-	p := progress.NewTracker(false)
+	p := progress.NewTrackerFromContext(ctx, false)
 	p.BeginWithMessage("Vulnmap Code analysis for "+b.rootPath, "Retrieving results...")
 
 	method := "code.retrieveAnalysis"
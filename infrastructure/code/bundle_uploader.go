@@ -24,6 +24,7 @@ import (
 	"github.com/puzpuzpuz/xsync"
 	"github.com/rs/zerolog/log"
 
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/metrics"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/performance"
 	"github.com/khulnasoft-lab/vulnmap-ls/internal/progress"
 	"github.com/khulnasoft-lab/vulnmap-ls/internal/util"
@@ -51,7 +52,7 @@ func (b *BundleUploader) Upload(ctx context.Context, bundle Bundle, files map[st
 	defer b.instrumentor.Finish(s)
 
 	// make uploads in batches until no missing files reported anymore
-	t := progress.NewTracker(false)
+	t := progress.NewTrackerFromContext(ctx, false)
 	t.BeginWithMessage("Vulnmap Code analysis for "+bundle.rootPath, "Uploading batches...")
 	defer t.EndWithMessage("Upload done.")
 
@@ -68,8 +69,10 @@ func (b *BundleUploader) Upload(ctx context.Context, bundle Bundle, files map[st
 			}
 			err := bundle.Upload(s.Context(), uploadBatch)
 			if err != nil {
+				metrics.BundleUploadErrors.Inc()
 				return Bundle{}, err
 			}
+			metrics.BundleUploadBytes.Add(float64(uploadBatch.contentSize()))
 			uploadedFiles += len(uploadBatch.documents)
 			percentage := float64(i) / float64(len(uploadBatches)) * 100
 			t.Report(int(math.RoundToEven(percentage)))
@@ -84,7 +87,7 @@ func (b *BundleUploader) groupInBatches(
 	bundle Bundle,
 	files map[string]BundleFile,
 ) []*UploadBatch {
-	t := progress.NewTracker(false)
+	t := progress.NewTrackerFromContext(ctx, false)
 	t.BeginWithMessage("Vulnmap Code analysis for "+bundle.rootPath, "Creating batches...")
 	defer t.EndWithMessage("Batches created.")
 
@@ -177,7 +177,7 @@ func (sc *Scanner) Scan(ctx context.Context, path string, folderPath string) (is
 	defer sc.BundleUploader.instrumentor.Finish(span)
 
 	// Start the scan
-	t := progress.NewTracker(false)
+	t := progress.NewTrackerFromContext(ctx, false)
 	t.BeginWithMessage("Vulnmap Code: Collecting files in \""+folderPath+"\"", "Evaluating ignores and counting files...")
 	fileFilter, _ := sc.fileFilters.Load(folderPath)
 	if fileFilter == nil {
@@ -313,7 +313,7 @@ func (sc *Scanner) createBundle(ctx context.Context,
 	span := sc.BundleUploader.instrumentor.StartSpan(ctx, "code.createBundle")
 	defer sc.BundleUploader.instrumentor.Finish(span)
 
-	t := progress.NewTracker(false)
+	t := progress.NewTrackerFromContext(ctx, false)
 	t.BeginUnquantifiableLength("Creating file bundle", "Checking and adding files for analysis")
 	defer t.End()
 
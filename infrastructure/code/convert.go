@@ -63,29 +63,16 @@ func (r *rule) getCodeIssueType() vulnmap.Type {
 	return vulnmap.CodeQualityIssue
 }
 
-func (r *rule) cwe() string {
-	count := len(r.Properties.Cwe)
-	if count == 0 {
-		return ""
-	}
-	builder := strings.Builder{}
-	builder.Grow(100)
-	ending := "y"
-	if count > 1 {
-		ending = "ies"
-	}
-	builder.WriteString(fmt.Sprintf("Vulnerabilit%s: ", ending))
-	for i, cwe := range r.Properties.Cwe {
-		if i > 0 {
-			builder.WriteString(" | ")
-		}
-		builder.WriteString(fmt.Sprintf(
-			"[%s](%s)",
-			cwe,
-			fmt.Sprintf("https://cwe.mitre.org/data/definitions/%s.html", strings.Split(cwe, "-")[1])))
+// cweLinks returns the links of the CWEs of the rule
+func (r *rule) cweLinks() []vulnmap.Link {
+	var links []vulnmap.Link
+	for _, cwe := range r.Properties.Cwe {
+		links = append(links, vulnmap.Link{
+			Title: cwe,
+			Url:   fmt.Sprintf("https://cwe.mitre.org/data/definitions/%s.html", strings.TrimPrefix(cwe, "CWE-")),
+		})
 	}
-	builder.WriteString("\n\n\n")
-	return builder.String()
+	return links
 }
 
 func (c *exampleCommit) toReference() (reference vulnmap.Reference) {
@@ -139,22 +126,14 @@ func (r *result) getCodeFlow(baseDir string) (dataflow []dataflowElement) {
 	return dataflow
 }
 
-func (r *result) priorityScore() string {
-	priorityScore := r.Properties.PriorityScore
-	if priorityScore == 0 {
-		return ""
-	}
-	var builder strings.Builder
-	builder.Grow(20)
-	builder.WriteString(fmt.Sprintf(" | Priority Score %d", priorityScore))
-	return builder.String()
-}
-
-func (r *rule) titleWithLeadingPipeOrEmpty() string {
-	if r.ShortDescription.Text != "" {
-		return fmt.Sprintf(" | %s", r.ShortDescription.Text)
+// isSuppressed returns true if the result has an accepted (or not yet reviewed) suppression, e.g. an ignore
+func (r *result) isSuppressed() bool {
+	for _, s := range r.Suppressions {
+		if s.Status != "rejected" {
+			return true
+		}
 	}
-	return ""
+	return false
 }
 
 func (r *rule) detailsOrEmpty() string {
@@ -165,48 +144,32 @@ func (r *rule) detailsOrEmpty() string {
 	return ""
 }
 
-func (r *result) formattedMessage(rule rule, baseDir string) string {
-	const separator = "\n\n\n\n"
-	var builder strings.Builder
-	builder.Grow(500)
-	builder.WriteString(fmt.Sprintf("### %s", issueSeverityToMarkdown(issueSeverity(r.Level))))
-	builder.WriteString(rule.titleWithLeadingPipeOrEmpty())
-	builder.WriteString(r.priorityScore())
-	cwe := rule.cwe()
-	if cwe != "" {
-		builder.WriteString(" | ")
+// details returns the structured content of the hover, it is rendered for the client at presentation time
+func (r *result) details(rule rule, title string, baseDir string) *vulnmap.IssueDetails {
+	var fields []vulnmap.DetailField
+	if r.Properties.PriorityScore != 0 {
+		fields = append(fields, vulnmap.DetailField{Label: "Priority score", Value: strconv.Itoa(r.Properties.PriorityScore)})
 	}
-	builder.WriteString(cwe)
-	builder.WriteString(separator)
-	builder.WriteString(r.Message.Text)
-	builder.WriteString(separator)
-	builder.WriteString(rule.detailsOrEmpty())
-	builder.WriteString(separator)
-	builder.WriteString("### Data Flow\n\n")
+
+	var dataFlow strings.Builder
 	for _, elem := range r.getCodeFlow(baseDir) {
-		builder.WriteString(elem.toMarkDown())
+		dataFlow.WriteString(elem.toMarkDown())
 	}
-	builder.WriteString(separator)
-	builder.WriteString("### Example Commit Fixes\n\n")
+	var exampleFixes strings.Builder
 	for _, fix := range rule.getExampleCommits() {
-		builder.WriteString(fix.toMarkdown())
+		exampleFixes.WriteString(fix.toMarkdown())
 	}
-	builder.WriteString(separator)
-	return builder.String()
-}
 
-func issueSeverityToMarkdown(severity vulnmap.Severity) string {
-	switch severity {
-	case vulnmap.Critical:
-		return "🔥 Critical Severity"
-	case vulnmap.High:
-		return "🚨 High Severity"
-	case vulnmap.Medium:
-		return "⚠️ Medium Severity"
-	case vulnmap.Low:
-		return "⬇️ Low Severity"
-	default:
-		return "❔️ Unknown Severity"
+	return &vulnmap.IssueDetails{
+		Title:  title,
+		Links:  rule.cweLinks(),
+		Fields: fields,
+		Sections: []vulnmap.DetailSection{
+			{Content: r.Message.Text},
+			{Content: rule.detailsOrEmpty()},
+			{Title: "Data Flow", Content: dataFlow.String()},
+			{Title: "Example Commit Fixes", Content: exampleFixes.String()},
+		},
 	}
 }
 
@@ -300,7 +263,6 @@ func (s *SarifResponse) toIssues(baseDir string) (issues []vulnmap.Issue, err er
 
 			rule := r.getRule(result.RuleID)
 			message := result.getMessage(rule)
-			formattedMessage := result.formattedMessage(rule, baseDir)
 
 			exampleCommits := rule.getExampleCommits()
 			exampleFixes := make([]vulnmap.ExampleCommitFix, 0, len(exampleCommits))
@@ -350,6 +312,7 @@ func (s *SarifResponse) toIssues(baseDir string) (issues []vulnmap.Issue, err er
 				Rows:               [2]int{startLine, endLine},
 				IsSecurityType:     isSecurityType,
 				IsAutofixable:      result.Properties.IsAutofixable,
+				PriorityScore:      result.Properties.PriorityScore,
 			}
 
 			d := vulnmap.Issue{
@@ -357,7 +320,7 @@ func (s *SarifResponse) toIssues(baseDir string) (issues []vulnmap.Issue, err er
 				Range:               myRange,
 				Severity:            issueSeverity(result.Level),
 				Message:             message,
-				FormattedMessage:    formattedMessage,
+				Details:             result.details(rule, title, baseDir),
 				IssueType:           issueType,
 				AffectedFilePath:    absPath,
 				Product:             product.ProductCode,
@@ -365,6 +328,7 @@ func (s *SarifResponse) toIssues(baseDir string) (issues []vulnmap.Issue, err er
 				References:          rule.getReferences(),
 				AdditionalData:      additionalData,
 				CWEs:                rule.Properties.Cwe,
+				IsIgnored:           result.isSuppressed(),
 			}
 
 			issues = append(issues, d)
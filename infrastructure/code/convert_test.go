@@ -26,6 +26,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/vulnmap"
@@ -638,7 +639,7 @@ func TestVulnmapCodeBackendService_convert_shouldConvertIssues(t *testing.T) {
 	assert.Equal(t, product.ProductCode, issue.Product)
 	assert.Equal(t, issueDescriptionURL, issue.IssueDescriptionURL)
 	assert.Equal(t, references, issue.References)
-	assert.Contains(t, issue.FormattedMessage, "Example Commit Fixes")
+	assert.Equal(t, "Example Commit Fixes", issue.Details.Sections[3].Title)
 	assert.Equal(t, markersForSampleSarifResponse(path), issue.AdditionalData.(vulnmap.CodeIssueData).Markers)
 	assert.Equal(t, resp.Sarif.Runs[0].Tool.Driver.Rules[0].Properties.Cwe, issue.CWEs)
 }
@@ -699,16 +700,21 @@ func markersForSampleSarifResponse(path string) []vulnmap.Marker {
 	return references
 }
 
-func Test_getFormattedMessage(t *testing.T) {
+func Test_details(t *testing.T) {
 	testutil.UnitTest(t)
 	p, _, sarifResponse := setupConversionTests(t, true, true)
 	run := sarifResponse.Sarif.Runs[0]
 	result := run.Results[0]
 
-	msg := result.formattedMessage(run.getRule("1"), filepath.Dir(p))
+	details := result.details(run.getRule("1"), "title", filepath.Dir(p))
 
-	assert.Contains(t, msg, "Example Commit Fixes")
-	assert.Contains(t, msg, "Data Flow")
+	assert.Equal(t, "title", details.Title)
+	require.Len(t, details.Sections, 4)
+	assert.Equal(t, result.Message.Text, details.Sections[0].Content)
+	assert.Equal(t, "Data Flow", details.Sections[2].Title)
+	assert.NotEmpty(t, details.Sections[2].Content)
+	assert.Equal(t, "Example Commit Fixes", details.Sections[3].Title)
+	assert.NotEmpty(t, details.Sections[3].Content)
 }
 
 func setupConversionTests(t *testing.T,
@@ -794,19 +800,35 @@ func Test_LineChangeChar(t *testing.T) {
 	assert.Equal(t, "-", e.lineChangeChar("removed"))
 }
 
-func Test_rule_cwe(t *testing.T) {
-	t.Run("display CWEs if reported", func(t *testing.T) {
+func Test_rule_cweLinks(t *testing.T) {
+	t.Run("links CWEs if reported", func(t *testing.T) {
 		cut := rule{Properties: ruleProperties{
 			Cwe: []string{"CWE-23", "CWE-24"},
 		}}
-		assert.Contains(t, cut.cwe(), "https://cwe.mitre.org/data/definitions/23.html")
-		assert.Contains(t, cut.cwe(), "https://cwe.mitre.org/data/definitions/24.html")
+		assert.Equal(t, []vulnmap.Link{
+			{Title: "CWE-23", Url: "https://cwe.mitre.org/data/definitions/23.html"},
+			{Title: "CWE-24", Url: "https://cwe.mitre.org/data/definitions/24.html"},
+		}, cut.cweLinks())
 	})
-	t.Run("dont display CWEs if not reported", func(t *testing.T) {
+	t.Run("no links if no CWEs are reported", func(t *testing.T) {
 		cut := rule{Properties: ruleProperties{
 			Cwe: []string{},
 		}}
-		assert.NotContains(t, cut.cwe(), "CWE:")
+		assert.Empty(t, cut.cweLinks())
+	})
+}
+
+func Test_result_isSuppressed(t *testing.T) {
+	t.Run("not suppressed without suppressions", func(t *testing.T) {
+		assert.False(t, (&result{}).isSuppressed())
+	})
+	t.Run("suppressed with an accepted suppression", func(t *testing.T) {
+		cut := result{Suppressions: []suppression{{Kind: "external", Status: "accepted"}}}
+		assert.True(t, cut.isSuppressed())
+	})
+	t.Run("not suppressed with a rejected suppression", func(t *testing.T) {
+		cut := result{Suppressions: []suppression{{Kind: "external", Status: "rejected"}}}
+		assert.False(t, cut.isSuppressed())
 	})
 }
 
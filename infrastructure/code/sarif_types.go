@@ -91,6 +91,12 @@ type resultProperties struct {
 	IsAutofixable bool `json:"isAutofixable"`
 }
 
+type suppression struct {
+	Kind          string `json:"kind"`
+	Status        string `json:"status"`
+	Justification string `json:"justification"`
+}
+
 type result struct {
 	RuleID       string           `json:"ruleId"`
 	RuleIndex    int              `json:"ruleIndex"`
@@ -100,6 +106,7 @@ type result struct {
 	Fingerprints fingerprints     `json:"fingerprints"`
 	CodeFlows    []codeFlow       `json:"codeFlows"`
 	Properties   resultProperties `json:"properties"`
+	Suppressions []suppression    `json:"suppressions,omitempty"`
 }
 
 type exampleCommitFix struct {
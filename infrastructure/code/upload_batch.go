@@ -61,6 +61,15 @@ func (b *UploadBatch) getSize() int {
 	return size + b.size
 }
 
+// contentSize returns the number of bytes of the contents of the files in the batch
+func (b *UploadBatch) contentSize() int {
+	size := 0
+	for _, document := range b.documents {
+		size += len(document.Content)
+	}
+	return size
+}
+
 func (b *UploadBatch) hasContent() bool {
 	return len(b.documents) > 0
 }
@@ -0,0 +1,191 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package errorspool keeps errors that couldn't be reported in a bounded local directory, so that they can be sent
+// later or exported with the support bundle.
+package errorspool
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/logging"
+)
+
+const (
+	// DefaultMaxEntries is the number of spooled errors that are kept, the oldest are removed first
+	DefaultMaxEntries = 100
+	// maxMessageLength truncates long error messages, e.g. with embedded CLI output
+	maxMessageLength = 4096
+	fileSuffix       = ".json"
+)
+
+// Frame is a stack frame without absolute paths
+type Frame struct {
+	Function string `json:"function,omitempty"`
+	Module   string `json:"module,omitempty"`
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+}
+
+// Entry is a spooled error
+type Entry struct {
+	// EventId stays the same for all attempts to send the entry, so that Sentry drops duplicates
+	EventId    string            `json:"eventId,omitempty"`
+	Timestamp  time.Time         `json:"timestamp"`
+	Type       string            `json:"type"`
+	Message    string            `json:"message"`
+	Stacktrace []Frame           `json:"stacktrace,omitempty"`
+	Context    map[string]string `json:"context,omitempty"`
+}
+
+// Spool stores scrubbed entries as one JSON file per error in a directory
+type Spool struct {
+	mutex      sync.Mutex
+	dir        string
+	maxEntries int
+}
+
+func New(dir string, maxEntries int) *Spool {
+	return &Spool{dir: dir, maxEntries: maxEntries}
+}
+
+func (s *Spool) Dir() string {
+	return s.dir
+}
+
+// Add scrubs the entry with the given dictionary, writes it to the spool and removes the oldest entries above the
+// limit
+func (s *Spool) Add(entry Entry, scrubDict map[string]bool) error {
+	content, err := json.Marshal(entry.Scrubbed(scrubDict))
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if err = os.MkdirAll(s.dir, 0700); err != nil {
+		return err
+	}
+	// the timestamp prefix keeps the files in order, the random part avoids collisions
+	file, err := os.CreateTemp(s.dir, fmt.Sprintf("%020d-*%s", entry.Timestamp.UnixNano(), fileSuffix))
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(content)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(file.Name())
+		return err
+	}
+	files := s.files()
+	for len(files) > s.maxEntries {
+		_ = os.Remove(files[0])
+		files = files[1:]
+	}
+	return nil
+}
+
+// Files returns the paths of the spooled entries, oldest first
+func (s *Spool) Files() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.files()
+}
+
+func (s *Spool) files() []string {
+	dirEntries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil
+	}
+	var files []string
+	for _, dirEntry := range dirEntries {
+		if !dirEntry.IsDir() && strings.HasSuffix(dirEntry.Name(), fileSuffix) {
+			files = append(files, filepath.Join(s.dir, dirEntry.Name()))
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+// Flush passes the spooled entries to send, oldest first, and stops at the first entry that couldn't be sent. The sent
+// entries are only removed if delivered confirms that they arrived, a nil delivered removes them right away. It returns
+// the number of removed entries.
+func (s *Spool) Flush(send func(entry Entry) bool, delivered func() bool) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	var sent []string
+	for _, file := range s.files() {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		var entry Entry
+		if err = json.Unmarshal(content, &entry); err != nil {
+			log.Debug().Err(err).Str("method", "errorspool.Flush").Str("file", file).Msg("removing unreadable entry")
+			_ = os.Remove(file)
+			continue
+		}
+		if !send(entry) {
+			break
+		}
+		sent = append(sent, file)
+	}
+	if len(sent) == 0 || (delivered != nil && !delivered()) {
+		return 0
+	}
+	for _, file := range sent {
+		_ = os.Remove(file)
+	}
+	return len(sent)
+}
+
+// Scrubbed returns a copy of the entry without secrets and with the home directory replaced by ~
+func (e Entry) Scrubbed(scrubDict map[string]bool) Entry {
+	home, _ := os.UserHomeDir()
+	scrub := func(s string) string {
+		if home != "" && home != string(filepath.Separator) {
+			s = strings.ReplaceAll(s, home, "~")
+		}
+		return logging.Redact(s, scrubDict)
+	}
+
+	scrubbed := e
+	scrubbed.Message = scrub(e.Message)
+	if len(scrubbed.Message) > maxMessageLength {
+		scrubbed.Message = scrubbed.Message[:maxMessageLength] + "..."
+	}
+	scrubbed.Stacktrace = make([]Frame, len(e.Stacktrace))
+	for i, frame := range e.Stacktrace {
+		frame.File = scrub(frame.File)
+		scrubbed.Stacktrace[i] = frame
+	}
+	scrubbed.Context = make(map[string]string, len(e.Context))
+	for key, value := range e.Context {
+		scrubbed.Context[key] = scrub(value)
+	}
+	return scrubbed
+}
@@ -0,0 +1,123 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package errorspool
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Add_WritesScrubbedEntry(t *testing.T) {
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+	spool := New(t.TempDir(), DefaultMaxEntries)
+	entry := Entry{
+		Timestamp:  time.Now(),
+		Type:       "*errors.errorString",
+		Message:    "failed with token super-secret-token in " + filepath.Join(home, "project"),
+		Stacktrace: []Frame{{Function: "scan", File: filepath.Join(home, "go", "scan.go"), Line: 42}},
+		Context:    map[string]string{"path": filepath.Join(home, "project", "main.go")},
+	}
+
+	require.NoError(t, spool.Add(entry, map[string]bool{"super-secret-token": true}))
+
+	files := spool.Files()
+	require.Len(t, files, 1)
+	content, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), "super-secret-token")
+	assert.NotContains(t, string(content), home)
+	var spooled Entry
+	require.NoError(t, json.Unmarshal(content, &spooled))
+	assert.Equal(t, filepath.Join("~", "go", "scan.go"), spooled.Stacktrace[0].File)
+	assert.Equal(t, filepath.Join("~", "project", "main.go"), spooled.Context["path"])
+}
+
+func Test_Add_RemovesOldestEntriesAboveLimit(t *testing.T) {
+	spool := New(t.TempDir(), 2)
+	start := time.Now()
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, spool.Add(Entry{Timestamp: start.Add(time.Duration(i) * time.Second), Message: "error"}, nil))
+	}
+
+	var timestamps []time.Time
+	spool.Flush(func(entry Entry) bool {
+		timestamps = append(timestamps, entry.Timestamp)
+		return true
+	}, nil)
+	require.Len(t, timestamps, 2)
+	assert.True(t, timestamps[0].Equal(start.Add(time.Second)))
+	assert.True(t, timestamps[1].Equal(start.Add(2*time.Second)))
+}
+
+func Test_Add_TruncatesLongMessages(t *testing.T) {
+	spool := New(t.TempDir(), DefaultMaxEntries)
+	long := make([]byte, 2*maxMessageLength)
+	for i := range long {
+		long[i] = 'a' + byte(i%2)
+	}
+
+	require.NoError(t, spool.Add(Entry{Timestamp: time.Now(), Message: string(long)}, nil))
+
+	spool.Flush(func(entry Entry) bool {
+		assert.Len(t, entry.Message, maxMessageLength+len("..."))
+		return true
+	}, nil)
+}
+
+func Test_Flush_KeepsEntriesThatCouldNotBeSent(t *testing.T) {
+	spool := New(t.TempDir(), DefaultMaxEntries)
+	require.NoError(t, spool.Add(Entry{Timestamp: time.Now(), Message: "first"}, nil))
+	require.NoError(t, spool.Add(Entry{Timestamp: time.Now().Add(time.Second), Message: "second"}, nil))
+
+	sent := spool.Flush(func(entry Entry) bool { return entry.Message == "first" }, nil)
+
+	assert.Equal(t, 1, sent)
+	assert.Len(t, spool.Files(), 1)
+}
+
+func Test_Flush_KeepsEntriesIfDeliveryIsNotConfirmed(t *testing.T) {
+	spool := New(t.TempDir(), DefaultMaxEntries)
+	require.NoError(t, spool.Add(Entry{EventId: "event-id", Timestamp: time.Now(), Message: "error"}, nil))
+
+	sent := spool.Flush(func(entry Entry) bool { return true }, func() bool { return false })
+
+	assert.Equal(t, 0, sent)
+	assert.Len(t, spool.Files(), 1)
+	spool.Flush(func(entry Entry) bool {
+		assert.Equal(t, "event-id", entry.EventId)
+		return false
+	}, nil)
+}
+
+func Test_Flush_RemovesUnreadableEntries(t *testing.T) {
+	dir := t.TempDir()
+	spool := New(dir, DefaultMaxEntries)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.json"), []byte("{"), 0600))
+
+	sent := spool.Flush(func(entry Entry) bool { return true }, nil)
+
+	assert.Equal(t, 0, sent)
+	assert.Empty(t, spool.Files())
+}
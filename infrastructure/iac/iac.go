@@ -30,12 +30,12 @@ import (
 	"strings"
 	"sync"
 
-	"github.com/gomarkdown/markdown"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 	sglsp "github.com/sourcegraph/go-lsp"
 
 	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/ide/notification"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/error_reporting"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/performance"
 	ux2 "github.com/khulnasoft-lab/vulnmap-ls/domain/observability/ux"
@@ -64,11 +64,19 @@ var extensions = map[string]bool{
 	".tf":   true,
 }
 
+// affectsResults returns true for the files the IaC results depend on, a change of any other file doesn't require
+// a new scan
+func affectsResults(relativePath string) bool {
+	return extensions[filepath.Ext(relativePath)] || filepath.Base(relativePath) == ".vulnmap"
+}
+
 type Scanner struct {
 	instrumentor  performance.Instrumentor
 	errorReporter error_reporting.ErrorReporter
 	analytics     ux2.Analytics
 	cli           cli.Executor
+	notifier      notification.Notifier
+	resultCache   *cli.ResultCache
 	mutex         sync.Mutex
 	runningScans  map[sglsp.DocumentURI]*scans.ScanProgress
 }
@@ -76,13 +84,16 @@ type Scanner struct {
 func New(instrumentor performance.Instrumentor,
 	errorReporter error_reporting.ErrorReporter,
 	analytics ux2.Analytics,
-	cli cli.Executor,
+	executor cli.Executor,
+	notifier notification.Notifier,
 ) *Scanner {
 	return &Scanner{
 		instrumentor:  instrumentor,
 		errorReporter: errorReporter,
 		analytics:     analytics,
-		cli:           cli,
+		cli:           executor,
+		notifier:      notifier,
+		resultCache:   cli.NewResultCache(),
 		mutex:         sync.Mutex{},
 		runningScans:  map[sglsp.DocumentURI]*scans.ScanProgress{},
 	}
@@ -96,6 +107,10 @@ func (iac *Scanner) Product() product.Product {
 	return product.ProductInfrastructureAsCode
 }
 
+func (iac *Scanner) ClearResultCache() {
+	iac.resultCache.Clear()
+}
+
 func (iac *Scanner) SupportedCommands() []vulnmap.CommandName {
 	return []vulnmap.CommandName{}
 }
@@ -113,7 +128,7 @@ func (iac *Scanner) Scan(ctx context.Context, path string, _ string) (issues []v
 	if !iac.isSupported(documentURI) {
 		return issues, nil
 	}
-	p := progress.NewTracker(false) // todo - get progress trackers via DI
+	p := progress.NewTrackerFromContext(ctx, false) // todo - get progress trackers via DI
 	p.BeginUnquantifiableLength("Scanning for Vulnmap IaC issues", path)
 	defer p.EndWithMessage("Vulnmap Iac Scan completed.")
 
@@ -145,7 +160,14 @@ func (iac *Scanner) Scan(ctx context.Context, path string, _ string) (issues []v
 	p.Report(80)
 	if err != nil {
 		noCancellation := ctx.Err() == nil
-		if noCancellation { // Only reports errors that are not intentional cancellations
+		var scanError *cli.ScanError
+		var timeoutError *cli.TimeoutError
+		if noCancellation && errors.As(err, &scanError) {
+			iac.handleScanError(path, scanError)
+		} else if noCancellation && errors.As(err, &timeoutError) {
+			// the user can fix timeouts in the settings, so we notify them but don't send it to sentry
+			iac.notifier.SendErrorDiagnostic(path, timeoutError)
+		} else if noCancellation { // Only reports errors that are not intentional cancellations
 			iac.errorReporter.CaptureErrorAndReportAsIssue(path, err)
 		} else { // If the scan was cancelled, return empty results
 			return issues, nil
@@ -203,11 +225,23 @@ func (iac *Scanner) doScan(ctx context.Context,
 	defer iac.mutex.Unlock()
 
 	cmd := iac.cliCmd(documentURI)
-	res, err := iac.cli.Execute(ctx, cmd, workspacePath)
+	scanPath := uri.PathFromUri(documentURI)
+	cacheKey := cli.ResultCacheKey(cmd, workspacePath, scanPath, affectsResults)
+	if res, cached := iac.resultCache.Get(ctx, scanPath, cacheKey); cached {
+		log.Debug().Str("method", method).Str("path", scanPath).Msg("IaC files unchanged, using cached CLI results")
+		return iac.unmarshal(res)
+	}
+	timeout := config.CurrentConfig().CliSettings().ScanTimeout(product.ProductInfrastructureAsCode)
+	res, err := iac.cli.Execute(cli.WithTimeout(ctx, timeout), cmd, workspacePath)
 
 	if ctx.Err() != nil {
 		return nil, ctx.Err()
 	}
+	defer func() {
+		if err == nil {
+			iac.resultCache.Put(scanPath, cacheKey, res)
+		}
+	}()
 
 	if err != nil {
 		switch errorType := err.(type) {
@@ -226,6 +260,9 @@ func (iac *Scanner) doScan(ctx context.Context,
 				}
 
 			ERR:
+				if scanError := cli.ParseScanError(res, errorType.Stderr, errorType.ExitCode()); scanError != nil {
+					return nil, scanError
+				}
 				errorOutput := string(res) + "\n\n\nSTDERR output:\n" + string(err.(*exec.ExitError).Stderr)
 				log.Err(err).Str("method", method).Str("output", errorOutput).Msg("Error while calling Vulnmap CLI")
 				err = errors.Wrap(err, fmt.Sprintf("Error executing %v.\n%s", cmd, errorOutput))
@@ -240,6 +277,16 @@ func (iac *Scanner) doScan(ctx context.Context,
 	return iac.unmarshal(res)
 }
 
+// handleScanError notifies the user about CLI errors they can fix, these are not sent to sentry
+func (iac *Scanner) handleScanError(path string, scanError *cli.ScanError) {
+	if scanError.Type == cli.ErrorTypeNoSupportedFiles {
+		log.Debug().Str("method", "iac.Scan").Msg("no supported files detected.")
+		return
+	}
+	log.Warn().Str("method", "iac.Scan").Str("errorType", string(scanError.Type)).Msg(scanError.Message)
+	iac.notifier.SendErrorDiagnostic(scanError.DiagnosticPath(path), scanError)
+}
+
 func (iac *Scanner) unmarshal(res []byte) (scanResults []iacScanResult, err error) {
 	method := "iac.unmarshal"
 	output := string(res)
@@ -270,6 +317,7 @@ func (iac *Scanner) cliCmd(u sglsp.DocumentURI) []string {
 			Msg("Error while extracting file absolutePath")
 	}
 	cmd := iac.cli.ExpandParametersFromConfig([]string{config.CurrentConfig().CliSettings().Path(), "iac", "test", path, "--json"})
+	cmd = append(cmd, cli.IacFlags(config.CurrentConfig().CliSettings().Options)...)
 	log.Debug().Msg(fmt.Sprintf("IAC: command: %s", cmd))
 	return cmd
 }
@@ -321,34 +369,26 @@ func (iac *Scanner) trackResult(success bool) {
 	)
 }
 
-// todo this needs to be pushed up to presentation
-func (iac *Scanner) getExtendedMessage(issue iacIssue) string {
-	title := issue.Title
-	description := issue.IacDescription.Issue
-	impact := issue.IacDescription.Impact
-	resolve := issue.IacDescription.Resolve
-
-	if config.CurrentConfig().Format() == config.FormatHtml {
-		title = string(markdown.ToHTML([]byte(title), nil, nil))
-		description = string(markdown.ToHTML([]byte(description), nil, nil))
-		impact = string(markdown.ToHTML([]byte(impact), nil, nil))
-		resolve = string(markdown.ToHTML([]byte(resolve), nil, nil))
+// details returns the structured content of the hover, it is rendered for the client at presentation time
+func (iac *Scanner) details(issue iacIssue, issueURL *url.URL) *vulnmap.IssueDetails {
+	details := &vulnmap.IssueDetails{
+		Title:       fmt.Sprintf("%s: %s", issue.PublicID, issue.Title),
+		Remediation: issue.IacDescription.Resolve,
+		Sections: []vulnmap.DetailSection{
+			{Title: "Issue", Content: issue.IacDescription.Issue},
+			{Title: "Impact", Content: issue.IacDescription.Impact},
+		},
 	}
-
-	return fmt.Sprintf(
-		"\n### %s: %s\n\n**Issue:** %s\n\n**Impact:** %s\n\n**Resolve:** %s\n",
-		issue.PublicID, title, description, impact, resolve,
-	)
-
+	if issueURL != nil {
+		details.Links = []vulnmap.Link{{Title: issue.PublicID, Url: issueURL.String()}}
+	}
+	return details
 }
 
 func (iac *Scanner) toIssue(affectedFilePath string, issue iacIssue, fileContent string) (vulnmap.Issue, error) {
 	const defaultRangeStart = 0
 	const defaultRangeEnd = 80
 	title := issue.IacDescription.Issue
-	if config.CurrentConfig().Format() == config.FormatHtml {
-		title = string(markdown.ToHTML([]byte(title), nil, nil))
-	}
 	codeActionTitle := fmt.Sprintf("Open description of '%s' in browser (Vulnmap)", issue.Title)
 
 	// Try to gather the length of the line for the range
@@ -385,7 +425,7 @@ func (iac *Scanner) toIssue(affectedFilePath string, issue iacIssue, fileContent
 			End:   vulnmap.Position{Line: issue.LineNumber, Character: rangeEnd},
 		},
 		Message:             fmt.Sprintf("%s (Vulnmap)", title),
-		FormattedMessage:    iac.getExtendedMessage(issue),
+		Details:             iac.details(issue, issueURL),
 		Severity:            iac.toIssueSeverity(issue.Severity),
 		AffectedFilePath:    affectedFilePath,
 		Product:             product.ProductInfrastructureAsCode,
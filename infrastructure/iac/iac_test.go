@@ -20,15 +20,16 @@ import (
 	"context"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
-	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/error_reporting"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/performance"
 	ux2 "github.com/khulnasoft-lab/vulnmap-ls/domain/observability/ux"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/vulnmap"
 	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/cli"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/notification"
 	"github.com/khulnasoft-lab/vulnmap-ls/internal/testutil"
 )
 
@@ -38,7 +39,7 @@ import (
 func Test_Scan_IsInstrumented(t *testing.T) {
 	testutil.UnitTest(t)
 	instrumentor := performance.NewInstrumentor()
-	scanner := New(instrumentor, error_reporting.NewTestErrorReporter(), ux2.NewTestAnalytics(), cli.NewTestExecutor())
+	scanner := New(instrumentor, error_reporting.NewTestErrorReporter(), ux2.NewTestAnalytics(), cli.NewTestExecutor(), notification.NewNotifier())
 
 	_, _ = scanner.Scan(context.Background(), "fake.yml", "")
 
@@ -55,7 +56,7 @@ func Test_Scan_IsInstrumented(t *testing.T) {
 func Test_SuccessfulScanFile_TracksAnalytics(t *testing.T) {
 	testutil.UnitTest(t)
 	analytics := ux2.NewTestAnalytics()
-	scanner := New(performance.NewInstrumentor(), error_reporting.NewTestErrorReporter(), analytics, cli.NewTestExecutor())
+	scanner := New(performance.NewInstrumentor(), error_reporting.NewTestErrorReporter(), analytics, cli.NewTestExecutor(), notification.NewNotifier())
 
 	issues, err := scanner.Scan(context.Background(), "fake.yml", "")
 
@@ -72,7 +73,7 @@ func Test_ErroredWorkspaceScan_TracksAnalytics(t *testing.T) {
 	testutil.UnitTest(t)
 	analytics := ux2.NewTestAnalytics()
 	executor := cli.NewTestExecutor()
-	scanner := New(performance.NewInstrumentor(), error_reporting.NewTestErrorReporter(), analytics, executor)
+	scanner := New(performance.NewInstrumentor(), error_reporting.NewTestErrorReporter(), analytics, executor, notification.NewNotifier())
 
 	executor.ExecuteResponse = []byte("invalid JSON")
 	issues, err := scanner.Scan(context.Background(), "fake.yml", "")
@@ -86,39 +87,29 @@ func Test_ErroredWorkspaceScan_TracksAnalytics(t *testing.T) {
 	}, analytics.GetAnalytics()[0])
 }
 
-func Test_toHover_asHTML(t *testing.T) {
+func Test_details_isStructured(t *testing.T) {
 	testutil.UnitTest(t)
-	scanner := New(performance.NewInstrumentor(), error_reporting.NewTestErrorReporter(), ux2.NewTestAnalytics(), cli.NewTestExecutor())
-	config.CurrentConfig().SetFormat(config.FormatHtml)
-
-	h := scanner.getExtendedMessage(sampleIssue())
-
-	assert.Equal(
-		t,
-		"\n### PublicID: <p>Title</p>\n\n\n**Issue:** <p>Issue</p>\n\n\n**Impact:** <p>Impact</p>\n\n\n**Resolve:** <p>Resolve</p>\n\n",
-		h,
-	)
-}
-
-func Test_toHover_asMD(t *testing.T) {
-	testutil.UnitTest(t)
-	scanner := New(performance.NewInstrumentor(), error_reporting.NewTestErrorReporter(), ux2.NewTestAnalytics(), cli.NewTestExecutor())
-	config.CurrentConfig().SetFormat(config.FormatMd)
-
-	h := scanner.getExtendedMessage(sampleIssue())
-
-	assert.Equal(
-		t,
-		"\n### PublicID: Title\n\n**Issue:** Issue\n\n**Impact:** Impact\n\n**Resolve:** Resolve\n",
-		h,
-	)
+	scanner := New(performance.NewInstrumentor(), error_reporting.NewTestErrorReporter(), ux2.NewTestAnalytics(), cli.NewTestExecutor(), notification.NewNotifier())
+	issueURL := scanner.createIssueURL("PublicID")
+
+	details := scanner.details(sampleIssue(), issueURL)
+
+	assert.Equal(t, &vulnmap.IssueDetails{
+		Title:       "PublicID: Title",
+		Links:       []vulnmap.Link{{Title: "PublicID", Url: "https://security.vulnmap.khulnasoft.com/rules/cloud/PublicID"}},
+		Remediation: "Resolve",
+		Sections: []vulnmap.DetailSection{
+			{Title: "Issue", Content: "Issue"},
+			{Title: "Impact", Content: "Impact"},
+		},
+	}, details)
 }
 
 func Test_Scan_CancelledContext_DoesNotScan(t *testing.T) {
 	// Arrange
 	testutil.UnitTest(t)
 	cliMock := cli.NewTestExecutor()
-	scanner := New(performance.NewInstrumentor(), error_reporting.NewTestErrorReporter(), ux2.NewTestAnalytics(), cliMock)
+	scanner := New(performance.NewInstrumentor(), error_reporting.NewTestErrorReporter(), ux2.NewTestAnalytics(), cliMock, notification.NewNotifier())
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel()
 
@@ -132,7 +123,7 @@ func Test_Scan_CancelledContext_DoesNotScan(t *testing.T) {
 func Test_retrieveIssues_IgnoresParsingErrors(t *testing.T) {
 	testutil.UnitTest(t)
 
-	scanner := New(performance.NewInstrumentor(), error_reporting.NewTestErrorReporter(), ux2.NewTestAnalytics(), cli.NewTestExecutor())
+	scanner := New(performance.NewInstrumentor(), error_reporting.NewTestErrorReporter(), ux2.NewTestAnalytics(), cli.NewTestExecutor(), notification.NewNotifier())
 
 	results := []iacScanResult{
 		{
@@ -159,7 +150,7 @@ func Test_retrieveIssues_IgnoresParsingErrors(t *testing.T) {
 func Test_createIssueDataForCustomUI_SuccessfullyParses(t *testing.T) {
 
 	sampleIssue := sampleIssue()
-	scanner := New(performance.NewInstrumentor(), error_reporting.NewTestErrorReporter(), ux2.NewTestAnalytics(), cli.NewTestExecutor())
+	scanner := New(performance.NewInstrumentor(), error_reporting.NewTestErrorReporter(), ux2.NewTestAnalytics(), cli.NewTestExecutor(), notification.NewNotifier())
 	issue, err := scanner.toIssue("test.yml", sampleIssue, "")
 
 	expectedAdditionalData := vulnmap.IaCIssueData{
@@ -266,3 +257,34 @@ func sampleIssue() iacIssue {
 		},
 	}
 }
+
+type timeoutExecutor struct {
+	*cli.TestExecutor
+}
+
+func (e timeoutExecutor) Execute(context.Context, []string, string) ([]byte, error) {
+	return nil, &cli.TimeoutError{Timeout: time.Minute}
+}
+
+type capturingErrorReporter struct {
+	error_reporting.ErrorReporter
+	captured []error
+}
+
+func (r *capturingErrorReporter) CaptureErrorAndReportAsIssue(_ string, err error) bool {
+	r.captured = append(r.captured, err)
+	return true
+}
+
+func Test_Scan_TimeoutIsShownAsDiagnosticButNotReported(t *testing.T) {
+	testutil.UnitTest(t)
+	errorReporter := &capturingErrorReporter{ErrorReporter: error_reporting.NewTestErrorReporter()}
+	notifier := notification.NewMockNotifier()
+	executor := timeoutExecutor{TestExecutor: cli.NewTestExecutor()}
+	scanner := New(performance.NewInstrumentor(), errorReporter, ux2.NewTestAnalytics(), executor, notifier)
+
+	_, _ = scanner.Scan(context.Background(), "fake.yml", "")
+
+	assert.Empty(t, errorReporter.captured)
+	assert.Equal(t, 1, notifier.SendErrorDiagnosticCount())
+}
@@ -158,6 +158,11 @@ func (s *serviceImpl) maintainCache() func() {
 
 func (s *serviceImpl) GetAllLessons() (lessons []Lesson, err error) {
 	logger := s.logger.With().Str("method", "GetAllLessons").Logger()
+	if s.conf.IsOfflineMode() {
+		// learn is served from the API host, but it's no scan endpoint, so it isn't contacted in offline mode
+		logger.Debug().Msg("offline mode, not retrieving lessons")
+		return lessons, nil
+	}
 	learnEndpoint, err := s.LearnEndpoint(s.conf)
 	if err != nil {
 		return lessons, err
@@ -17,6 +17,7 @@
 package learn
 
 import (
+	"net/http"
 	"strings"
 	"testing"
 
@@ -40,6 +41,18 @@ func Test_GetLearnEndpoint(t *testing.T) {
 	assert.Equal(t, "https://api.vulnmap.khulnasoft.com/v1/learn", endpoint)
 }
 
+func Test_New_OfflineMode_DoesNotRetrieveLessons(t *testing.T) {
+	c := testutil.UnitTest(t)
+	c.SetOfflineMode(true)
+	httpClient := func() *http.Client { return testutil.NoDialHttpClient(t) }
+
+	cut := New(c, httpClient, errorreporting.NewTestErrorReporter())
+	lessons, err := cut.GetAllLessons()
+
+	assert.NoError(t, err)
+	assert.Empty(t, lessons)
+}
+
 func getRealOSSLookupParams() *LessonLookupParams {
 	params := &LessonLookupParams{
 		CWEs:      []string{"CWE-1321"},
@@ -0,0 +1,102 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package network guards the HTTP clients of the language server, so that offline mode can be enforced in one place
+package network
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
+)
+
+// ErrOffline is returned for requests that offline mode doesn't allow
+var ErrOffline = errors.New("outbound request blocked by offline mode")
+
+// Allowed returns true if a request to the given URL may be sent. With offline mode enabled, only the configured scan
+// endpoints and loopback addresses can be reached.
+func Allowed(u *url.URL) bool {
+	c := config.CurrentConfig()
+	if !c.IsOfflineMode() {
+		return true
+	}
+	host := u.Hostname()
+	if isLoopback(host) {
+		return true
+	}
+	for _, endpoint := range []string{c.VulnmapApi(), c.VulnmapCodeApi()} {
+		endpointUrl, err := url.Parse(endpoint)
+		if err == nil && endpointUrl.Hostname() != "" && strings.EqualFold(endpointUrl.Hostname(), host) {
+			return true
+		}
+	}
+	return false
+}
+
+func isLoopback(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// GuardTransport returns a round tripper that fails requests that aren't Allowed before they reach next
+func GuardTransport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if _, ok := next.(*guardedTransport); ok {
+		return next
+	}
+	return &guardedTransport{next: next}
+}
+
+// GuardClient returns a copy of the client that only sends requests that are Allowed
+func GuardClient(client *http.Client) *http.Client {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	clientCopy := *client
+	clientCopy.Transport = GuardTransport(client.Transport)
+	return &clientCopy
+}
+
+// GuardClientFactory wraps an http client factory, so that all clients it creates are guarded
+func GuardClientFactory(factory func() *http.Client) func() *http.Client {
+	return func() *http.Client {
+		return GuardClient(factory())
+	}
+}
+
+type guardedTransport struct {
+	next http.RoundTripper
+}
+
+func (t *guardedTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	if !Allowed(request.URL) {
+		log.Debug().Str("method", "network.RoundTrip").Str("host", request.URL.Host).Msg("blocked outbound request")
+		return nil, fmt.Errorf("%w: %s", ErrOffline, request.URL.Host)
+	}
+	return t.next.RoundTrip(request)
+}
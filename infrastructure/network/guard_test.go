@@ -0,0 +1,97 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package network
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/testutil"
+)
+
+func Test_GuardClient_OfflineMode_BlocksOtherHosts(t *testing.T) {
+	c := testutil.UnitTest(t)
+	c.SetOfflineMode(true)
+	client := GuardClient(testutil.NoDialHttpClient(t))
+
+	for _, u := range []string{"https://api2.amplitude.com/2/httpapi", "https://sentry.io/api", "https://static.vulnmap.khulnasoft.com/cli/latest/version"} {
+		response, err := client.Get(u)
+		if response != nil {
+			_ = response.Body.Close()
+		}
+
+		assert.True(t, errors.Is(err, ErrOffline), u)
+	}
+}
+
+func Test_GuardClient_OfflineMode_AllowsScanEndpointsAndLoopback(t *testing.T) {
+	c := testutil.UnitTest(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+	c.UpdateApiEndpoints("https://api.vulnmap.example.com")
+	c.SetOfflineMode(true)
+	client := GuardClient(server.Client())
+
+	response, err := client.Get(server.URL)
+	require.NoError(t, err)
+	_ = response.Body.Close()
+	assert.Equal(t, http.StatusNoContent, response.StatusCode)
+
+	assert.True(t, Allowed(mustParse(t, "https://api.vulnmap.example.com/v1/test")))
+	assert.True(t, Allowed(mustParse(t, c.VulnmapCodeApi()+"/bundle")))
+}
+
+func Test_GuardClient_OnlineMode_PassesRequestsOn(t *testing.T) {
+	c := testutil.UnitTest(t)
+	c.SetOfflineMode(false)
+
+	assert.True(t, Allowed(mustParse(t, "https://api2.amplitude.com/2/httpapi")))
+}
+
+func Test_GuardClient_DoesNotGuardTwice(t *testing.T) {
+	transport := GuardTransport(http.DefaultTransport)
+
+	assert.Same(t, transport, GuardTransport(transport))
+}
+
+func Test_GuardClientFactory_GuardsEveryClient(t *testing.T) {
+	c := testutil.UnitTest(t)
+	c.SetOfflineMode(true)
+	factory := GuardClientFactory(func() *http.Client { return testutil.NoDialHttpClient(t) })
+
+	response, err := factory().Get("https://api.segment.io/v1/batch")
+	if response != nil {
+		_ = response.Body.Close()
+	}
+
+	assert.True(t, errors.Is(err, ErrOffline))
+}
+
+func mustParse(t *testing.T, rawUrl string) *url.URL {
+	t.Helper()
+	u, err := url.Parse(rawUrl)
+	require.NoError(t, err)
+	return u
+}
@@ -0,0 +1,202 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opentelemetry
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/performance"
+	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/network"
+)
+
+const (
+	instrumentationName = "github.com/khulnasoft-lab/vulnmap-ls"
+	// defaultTracesPath is appended to collector endpoints without path, as OTLP/HTTP collectors receive traces there
+	defaultTracesPath = "/v1/traces"
+	shutdownTimeout   = 5 * time.Second
+)
+
+// instrumentor exports spans with OpenTelemetry, to an OTLP/HTTP collector and/or a local file. The tracing settings
+// are checked whenever a span starts, so they can change at runtime. Without settings, spans are not exported.
+type instrumentor struct {
+	mutex    sync.Mutex
+	settings config.TracingSettings
+	offline  bool
+	provider *sdktrace.TracerProvider
+	tracer   trace.Tracer
+	file     *os.File
+}
+
+func NewInstrumentor() performance.Instrumentor {
+	return &instrumentor{}
+}
+
+func (i *instrumentor) StartSpan(ctx context.Context, operation string) performance.Span {
+	s := i.createSpan("", operation)
+	s.StartSpan(ctx)
+	return s
+}
+
+func (i *instrumentor) NewTransaction(ctx context.Context, txName string, operation string) performance.Span {
+	s := i.createSpan(txName, operation)
+	s.StartSpan(ctx)
+	return s
+}
+
+func (i *instrumentor) Finish(span performance.Span) {
+	span.Finish()
+}
+
+// Flush exports the finished spans and closes the exporters, e.g. before the language server exits. The settings are
+// kept, so that later spans don't recreate the exporters unless the settings change.
+func (i *instrumentor) Flush() {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	i.shutdown()
+}
+
+func (i *instrumentor) createSpan(txName string, operation string) performance.Span {
+	var s performance.Span
+	if tracer := i.currentTracer(); tracer != nil {
+		s = &span{tracer: tracer, operation: operation}
+	} else {
+		s = &performance.NoopSpan{Operation: operation}
+	}
+	s.SetTransactionName(txName)
+	return s
+}
+
+// currentTracer returns the tracer for the current tracing settings, nil if tracing is disabled
+func (i *instrumentor) currentTracer() trace.Tracer {
+	c := config.CurrentConfig()
+	if c == nil {
+		return nil
+	}
+	settings := c.TracingSettings()
+	offline := c.IsOfflineMode()
+
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	if settings == i.settings && offline == i.offline {
+		return i.tracer
+	}
+	i.shutdown()
+	i.settings = settings
+	i.offline = offline
+	if !settings.Enabled() {
+		return nil
+	}
+	if err := i.setupProvider(settings); err != nil {
+		log.Err(err).Str("method", "opentelemetry.currentTracer").Msg("couldn't set up trace export")
+		i.shutdown()
+		return nil
+	}
+	return i.tracer
+}
+
+func (i *instrumentor) setupProvider(settings config.TracingSettings) error {
+	method := "opentelemetry.setupProvider"
+	var options []sdktrace.TracerProviderOption
+	if settings.Endpoint != "" && !endpointAllowed(settings.Endpoint) {
+		log.Info().Str("method", method).Msgf("not exporting traces to %s in offline mode", settings.Endpoint)
+	} else if settings.Endpoint != "" {
+		exporter, err := otlptracehttp.New(context.Background(), otlptracehttp.WithEndpointURL(tracesUrl(settings.Endpoint)))
+		if err != nil {
+			return err
+		}
+		options = append(options, sdktrace.WithBatcher(exporter))
+		log.Info().Str("method", method).Msgf("exporting traces to %s", settings.Endpoint)
+	}
+	if settings.File != "" {
+		if err := os.MkdirAll(filepath.Dir(settings.File), 0700); err != nil {
+			return err
+		}
+		file, err := os.OpenFile(settings.File, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+		if err != nil {
+			return err
+		}
+		i.file = file
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(file))
+		if err != nil {
+			return err
+		}
+		options = append(options, sdktrace.WithBatcher(exporter))
+		log.Info().Str("method", method).Msgf("exporting traces to %s", settings.File)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName("vulnmap-ls"),
+		semconv.ServiceVersion(config.Version),
+		attribute.String("vulnmap.integration", config.CurrentConfig().IntegrationName()),
+	))
+	if err != nil {
+		return err
+	}
+	options = append(options, sdktrace.WithResource(res))
+	i.provider = sdktrace.NewTracerProvider(options...)
+	i.tracer = i.provider.Tracer(instrumentationName, trace.WithInstrumentationVersion(config.Version))
+	return nil
+}
+
+// shutdown exports the remaining spans of the current provider and closes its exporters. The mutex must be held.
+func (i *instrumentor) shutdown() {
+	var err error
+	if i.provider != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		err = i.provider.Shutdown(ctx)
+	}
+	if i.file != nil {
+		err = errors.Join(err, i.file.Close())
+	}
+	if err != nil {
+		log.Warn().Err(err).Str("method", "opentelemetry.shutdown").Msg("couldn't export all spans")
+	}
+	i.provider, i.tracer, i.file = nil, nil, nil
+}
+
+// endpointAllowed returns false if offline mode doesn't allow exporting to the collector, e.g. if it isn't local
+func endpointAllowed(endpoint string) bool {
+	u, err := url.Parse(tracesUrl(endpoint))
+	return err == nil && network.Allowed(u)
+}
+
+// tracesUrl adds the default path of OTLP/HTTP collectors to endpoints without path
+func tracesUrl(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Path != "" && u.Path != "/") {
+		return endpoint
+	}
+	u.Path = defaultTracesPath
+	return u.String()
+}
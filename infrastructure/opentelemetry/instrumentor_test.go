@@ -0,0 +1,108 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opentelemetry
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/performance"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/testutil"
+)
+
+func TestStartSpan_TracingDisabled_ReturnsNoopSpan(t *testing.T) {
+	testutil.UnitTest(t)
+	i := &instrumentor{}
+
+	s := i.StartSpan(context.Background(), "testOp")
+
+	noopSpan, ok := s.(*performance.NoopSpan)
+	require.True(t, ok)
+	assert.True(t, noopSpan.Started)
+}
+
+func TestNewTransaction_FileConfigured_ExportsSpansToFile(t *testing.T) {
+	testutil.UnitTest(t)
+	file := filepath.Join(t.TempDir(), "traces", "traces.json")
+	config.CurrentConfig().SetTracingSettings(config.TracingSettings{File: file})
+	i := &instrumentor{}
+
+	tx := i.NewTransaction(context.Background(), "testTransaction", "testOp")
+	child := i.StartSpan(tx.Context(), "childOp")
+	i.Finish(child)
+	i.Finish(tx)
+	i.Flush()
+
+	assert.NotEmpty(t, tx.GetTraceId())
+	assert.Equal(t, tx.GetTraceId(), child.GetTraceId())
+	bytes, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Contains(t, string(bytes), "testOp")
+	assert.Contains(t, string(bytes), "childOp")
+	assert.Contains(t, string(bytes), "testTransaction")
+}
+
+func TestCurrentTracer_SettingsChange_RecreatesProvider(t *testing.T) {
+	testutil.UnitTest(t)
+	c := config.CurrentConfig()
+	c.SetTracingSettings(config.TracingSettings{File: filepath.Join(t.TempDir(), "traces.json")})
+	i := &instrumentor{}
+	defer i.Flush()
+
+	assert.NotNil(t, i.currentTracer())
+
+	c.SetTracingSettings(config.TracingSettings{})
+	assert.Nil(t, i.currentTracer())
+	assert.Nil(t, i.provider)
+}
+
+func TestFlush_KeepsExportersClosedUntilSettingsChange(t *testing.T) {
+	testutil.UnitTest(t)
+	c := config.CurrentConfig()
+	c.SetTracingSettings(config.TracingSettings{File: filepath.Join(t.TempDir(), "traces.json")})
+	i := &instrumentor{}
+	defer i.Flush()
+	require.NotNil(t, i.currentTracer())
+
+	i.Flush()
+
+	assert.IsType(t, &performance.NoopSpan{}, i.StartSpan(context.Background(), "afterFlush"))
+	assert.Nil(t, i.provider)
+
+	c.SetTracingSettings(config.TracingSettings{File: filepath.Join(t.TempDir(), "traces.json")})
+	assert.NotNil(t, i.currentTracer())
+}
+
+func TestTracesUrl(t *testing.T) {
+	assert.Equal(t, "http://localhost:4318/v1/traces", tracesUrl("http://localhost:4318"))
+	assert.Equal(t, "http://localhost:4318/v1/traces", tracesUrl("http://localhost:4318/"))
+	assert.Equal(t, "https://collector/custom/traces", tracesUrl("https://collector/custom/traces"))
+}
+
+func TestEndpointAllowed_OfflineMode_OnlyLocalCollectors(t *testing.T) {
+	c := testutil.UnitTest(t)
+	c.SetOfflineMode(true)
+
+	assert.True(t, endpointAllowed("http://localhost:4318"))
+	assert.False(t, endpointAllowed("https://collector.example.com"))
+}
@@ -0,0 +1,91 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opentelemetry
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/performance"
+)
+
+type span struct {
+	tracer     trace.Tracer
+	span       trace.Span
+	txName     string
+	operation  string
+	ctx        context.Context
+	startTime  int64
+	finishTime int64
+}
+
+func (s *span) GetDurationMs() int64 { return s.finishTime - s.startTime }
+
+func (s *span) GetTxName() string {
+	return s.txName
+}
+
+func (s *span) GetOperation() string {
+	return s.operation
+}
+
+func (s *span) GetTraceId() string {
+	traceId, _ := performance.GetTraceId(s.ctx)
+	return traceId
+}
+
+func (s *span) Context() context.Context {
+	return s.ctx
+}
+
+func (s *span) StartSpan(ctx context.Context) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	var options []trace.SpanStartOption
+	if s.txName != "" {
+		options = append(options, trace.WithAttributes(attribute.String("vulnmap.transaction", s.txName)))
+	}
+	s.startTime = time.Now().UnixMilli()
+	ctx, s.span = s.tracer.Start(ctx, s.operation, options...)
+	s.ctx = performance.GetContextWithTraceId(ctx, s.span.SpanContext().TraceID().String())
+
+	log.Trace().
+		Str("method", "opentelemetry.span.StartSpan").
+		Str("operation", s.operation).
+		Str("txName", s.txName).
+		Msg("starting span")
+}
+
+func (s *span) Finish() {
+	log.Trace().
+		Str("method", "opentelemetry.span.Finish").
+		Str("operation", s.operation).
+		Msg("finishing span")
+	s.finishTime = time.Now().UnixMilli()
+	s.span.End()
+}
+
+func (s *span) SetTransactionName(name string) {
+	if name != "" && s.txName == "" {
+		s.txName = name
+	}
+}
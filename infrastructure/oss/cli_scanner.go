@@ -21,6 +21,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -75,15 +76,17 @@ type CLIScanner struct {
 	learnService            learn.Service
 	notifier                noti.Notifier
 	inlineValues            inlineValueMap
+	inlineValueMutex        *sync.Mutex
 	supportedFiles          map[string]bool
 	packageIssueCache       map[string][]vulnmap.Issue
+	resultCache             *cli.ResultCache
 	config                  *config.Config
 }
 
 func NewCLIScanner(instrumentor performance.Instrumentor,
 	errorReporter error_reporting.ErrorReporter,
 	analytics ux2.Analytics,
-	cli cli.Executor,
+	executor cli.Executor,
 	learnService learn.Service,
 	notifier noti.Notifier,
 	c *config.Config,
@@ -92,7 +95,7 @@ func NewCLIScanner(instrumentor performance.Instrumentor,
 		instrumentor:            instrumentor,
 		errorReporter:           errorReporter,
 		analytics:               analytics,
-		cli:                     cli,
+		cli:                     executor,
 		mutex:                   &sync.Mutex{},
 		packageScanMutex:        &sync.Mutex{},
 		scheduledScanMtx:        &sync.Mutex{},
@@ -102,7 +105,9 @@ func NewCLIScanner(instrumentor performance.Instrumentor,
 		learnService:            learnService,
 		notifier:                notifier,
 		inlineValues:            make(inlineValueMap),
+		inlineValueMutex:        &sync.Mutex{},
 		packageIssueCache:       make(map[string][]vulnmap.Issue),
+		resultCache:             cli.NewResultCache(),
 		config:                  c,
 		supportedFiles: map[string]bool{
 			"yarn.lock":               true,
@@ -138,6 +143,10 @@ func (cliScanner *CLIScanner) IsEnabled() bool {
 	return config.CurrentConfig().IsVulnmapOssEnabled()
 }
 
+func (cliScanner *CLIScanner) ClearResultCache() {
+	cliScanner.resultCache.Clear()
+}
+
 func (cliScanner *CLIScanner) Product() product.Product {
 	return product.ProductOpenSource
 }
@@ -170,7 +179,7 @@ func (cliScanner *CLIScanner) scanInternal(
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	p := progress.NewTracker(false)
+	p := progress.NewTrackerFromContext(ctx, false)
 	p.BeginUnquantifiableLength("Scanning for Vulnmap Open Source issues", path)
 	defer p.EndWithMessage("Vulnmap Open Source scan completed.")
 
@@ -200,20 +209,36 @@ func (cliScanner *CLIScanner) scanInternal(
 	cliScanner.runningScans[workDir] = newScan
 	cliScanner.mutex.Unlock()
 
-	cmd := commandFunc([]string{workDir})
-	res, err := cliScanner.cli.Execute(ctx, cmd, workDir)
-	noCancellation := ctx.Err() == nil
-	if err != nil {
-		if noCancellation {
-			if cliScanner.handleError(path, err, res, cmd) {
-				return nil, err
+	cmd := addPythonInterpreter(commandFunc([]string{workDir}), workDir)
+	cacheKey := cli.ResultCacheKey(cmd, workDir, workDir, cliScanner.affectsResults)
+	res, cached := cliScanner.resultCache.Get(ctx, workDir, cacheKey)
+	var streamedIssues []vulnmap.Issue
+	streamed := false
+	if cached {
+		log.Debug().Str("method", method).Str("workDir", workDir).Msg("manifests unchanged, using cached CLI results")
+	} else {
+		timeout := config.CurrentConfig().CliSettings().ScanTimeout(product.ProductOpenSource)
+		res, streamedIssues, streamed, err = cliScanner.execute(cli.WithTimeout(ctx, timeout), cmd, workDir, path)
+		noCancellation := ctx.Err() == nil
+		if err != nil {
+			if noCancellation {
+				if cliScanner.handleError(path, err, res, cmd) {
+					return nil, err
+				}
+			} else { // If scan was cancelled, return empty results
+				return []vulnmap.Issue{}, nil
 			}
-		} else { // If scan was cancelled, return empty results
-			return []vulnmap.Issue{}, nil
+		}
+		if noCancellation {
+			cliScanner.resultCache.Put(workDir, cacheKey, res)
 		}
 	}
 
-	issues = cliScanner.unmarshallAndRetrieveAnalysis(ctx, res, workDir, path)
+	if streamed {
+		issues = streamedIssues
+	} else {
+		issues = cliScanner.unmarshallAndRetrieveAnalysis(ctx, res, workDir, path)
+	}
 	cliScanner.trackResult(true)
 
 	cliScanner.mutex.Lock()
@@ -227,6 +252,34 @@ func (cliScanner *CLIScanner) scanInternal(
 	return issues, nil
 }
 
+// execute runs the CLI and, if the executor supports it, decodes the output while the CLI writes it and publishes the
+// issues of each decoded project as partial results. `vulnmap test --json` writes its results when all projects are
+// scanned, so the projects are decoded and published right before the CLI exits, not while it is scanning. streamed is
+// false if the output couldn't be decoded.
+func (cliScanner *CLIScanner) execute(ctx context.Context, cmd []string, workDir string, path string) (
+	res []byte,
+	issues []vulnmap.Issue,
+	streamed bool,
+	err error,
+) {
+	streamingCli, ok := cliScanner.cli.(cli.StreamingExecutor)
+	if !ok {
+		res, err = cliScanner.cli.Execute(ctx, cmd, workDir)
+		return res, nil, false, err
+	}
+
+	res, err = streamingCli.ExecuteStreaming(ctx, cmd, workDir, func(stdout io.Reader) error {
+		decodeErr := decodeScanResults(stdout, func(result scanResult) {
+			projectIssues := cliScanner.issuesFromScanResult(&result, workDir, path)
+			issues = append(issues, projectIssues...)
+			vulnmap.PublishPartialResults(ctx, product.ProductOpenSource, projectIssues)
+		})
+		streamed = decodeErr == nil
+		return decodeErr
+	})
+	return res, issues, streamed, err
+}
+
 func (cliScanner *CLIScanner) prepareScanCommand(args []string) []string {
 	cmd := cliScanner.cli.ExpandParametersFromConfig([]string{
 		config.CurrentConfig().CliSettings().Path(),
@@ -234,6 +287,7 @@ func (cliScanner *CLIScanner) prepareScanCommand(args []string) []string {
 	})
 	cmd = append(cmd, args...)
 	cmd = append(cmd, "--json")
+	cmd = append(cmd, cli.OssFlags(config.CurrentConfig().CliSettings().Options)...)
 	additionalParams := config.CurrentConfig().CliSettings().AdditionalOssParameters
 	for _, parameter := range additionalParams {
 		if parameter == "" {
@@ -248,6 +302,51 @@ func (cliScanner *CLIScanner) isSupported(path string) bool {
 	return uri.IsDirectory(path) || cliScanner.supportedFiles[filepath.Base(path)]
 }
 
+// addPythonInterpreter passes the interpreter of the Python environment of workDir to the CLI, unless the user
+// configured a command
+func addPythonInterpreter(cmd []string, workDir string) []string {
+	for _, param := range cmd {
+		if param == "--command" || strings.HasPrefix(param, "--command=") {
+			return cmd
+		}
+	}
+	if interpreter := config.CurrentConfig().PythonInterpreter(workDir); interpreter != "" {
+		return append(cmd, "--command="+interpreter)
+	}
+	return cmd
+}
+
+// resultFiles are files the CLI reads in addition to the supported manifests, e.g. the policy file with ignores
+var resultFiles = map[string]bool{
+	".vulnmap":            true,
+	"Pipfile.lock":        true,
+	"pyproject.toml":      true,
+	"setup.py":            true,
+	"gradle.lockfile":     true,
+	"settings.gradle":     true,
+	"settings.gradle.kts": true,
+	"go.sum":              true,
+	"composer.json":       true,
+	"paket.lock":          true,
+	"Cargo.toml":          true,
+	"Cargo.lock":          true,
+	"Package.swift":       true,
+	"Package.resolved":    true,
+}
+
+// affectsResults returns true for the files the CLI results depend on, a change of any other file doesn't require
+// a new scan
+func (cliScanner *CLIScanner) affectsResults(relativePath string) bool {
+	base := filepath.Base(relativePath)
+	parentAndBase := filepath.Base(filepath.Dir(relativePath)) + "/" + base
+	switch filepath.Ext(base) {
+	case ".csproj", ".fsproj", ".vbproj", ".gemspec":
+		return true
+	}
+	return cliScanner.supportedFiles[base] || cliScanner.supportedFiles[parentAndBase] || resultFiles[base] ||
+		strings.HasPrefix(base, "requirements") && strings.HasSuffix(base, ".txt")
+}
+
 func (cliScanner *CLIScanner) unmarshallAndRetrieveAnalysis(ctx context.Context,
 	res []byte,
 	workDir string,
@@ -264,22 +363,26 @@ func (cliScanner *CLIScanner) unmarshallAndRetrieveAnalysis(ctx context.Context,
 	}
 
 	for _, scanResult := range scanResults {
-		targetFilePath := path
-		targetFile := cliScanner.determineTargetFile(scanResult.DisplayTargetFile)
-		if targetFile != "" {
-			targetFilePath = filepath.Join(workDir, targetFile)
-		}
-		fileContent, err := os.ReadFile(targetFilePath)
-		if err != nil {
-			// don't fail the scan if we can't read the file. No annotations with ranges, though.
-			fileContent = []byte{}
-		}
-		issues = append(issues, cliScanner.retrieveIssues(&scanResult, targetFilePath, fileContent)...)
+		issues = append(issues, cliScanner.issuesFromScanResult(&scanResult, workDir, path)...)
 	}
 
 	return issues
 }
 
+func (cliScanner *CLIScanner) issuesFromScanResult(scanResult *scanResult, workDir string, path string) []vulnmap.Issue {
+	targetFilePath := path
+	targetFile := cliScanner.determineTargetFile(scanResult.DisplayTargetFile)
+	if targetFile != "" {
+		targetFilePath = filepath.Join(workDir, targetFile)
+	}
+	fileContent, err := os.ReadFile(targetFilePath)
+	if err != nil {
+		// don't fail the scan if we can't read the file. No annotations with ranges, though.
+		fileContent = []byte{}
+	}
+	return cliScanner.retrieveIssues(scanResult, targetFilePath, fileContent)
+}
+
 func (cliScanner *CLIScanner) unmarshallOssJson(res []byte) (scanResults []scanResult, err error) {
 	output := string(res)
 	if strings.HasPrefix(output, "[") {
@@ -303,7 +406,12 @@ func (cliScanner *CLIScanner) unmarshallOssJson(res []byte) (scanResults []scanR
 // Returns true if CLI run failed, false otherwise
 func (cliScanner *CLIScanner) handleError(path string, err error, res []byte, cmd []string) bool {
 	var errorType *exec.ExitError
+	var timeoutError *cli.TimeoutError
 	switch {
+	case errors.As(err, &timeoutError):
+		// the user can fix timeouts in the settings, so we notify them but don't send it to sentry
+		cliScanner.notifier.SendErrorDiagnostic(path, timeoutError)
+		return true
 	case errors.As(err, &errorType):
 		// Exit codes
 		//  Possible exit codes and their meaning:
@@ -317,6 +425,12 @@ func (cliScanner *CLIScanner) handleError(path string, err error, res []byte, cm
 		errorOutput := string(res) + "\n\n\nSTDERR:\n" + string(exitError.Stderr)
 		newError := fmt.Errorf("Vulnmap CLI error returned status code > 0 for command %v. Output: %s", cmd, errorOutput)
 		newError = errors.Join(newError, err)
+		if errorType.ExitCode() > 1 {
+			if scanError := cli.ParseScanError(res, exitError.Stderr, errorType.ExitCode()); scanError != nil {
+				cliScanner.handleScanError(path, scanError)
+				return true
+			}
+		}
 		switch errorType.ExitCode() {
 		case 1:
 			return false
@@ -341,6 +455,16 @@ func (cliScanner *CLIScanner) handleError(path string, err error, res []byte, cm
 	return true
 }
 
+// handleScanError notifies the user about CLI errors they can fix, these are not sent to sentry
+func (cliScanner *CLIScanner) handleScanError(path string, scanError *cli.ScanError) {
+	if scanError.Type == cli.ErrorTypeNoSupportedFiles {
+		log.Debug().Str("method", "cliScanner.Scan").Msg("no supported projects/files detected.")
+		return
+	}
+	log.Warn().Str("method", "cliScanner.Scan").Str("errorType", string(scanError.Type)).Msg(scanError.Message)
+	cliScanner.notifier.SendErrorDiagnostic(scanError.DiagnosticPath(path), scanError)
+}
+
 func (cliScanner *CLIScanner) determineTargetFile(displayTargetFile string) string {
 	targetFile := lockFilesToManifestMap[displayTargetFile]
 	if targetFile == "" {
@@ -422,6 +546,8 @@ func (cliScanner *CLIScanner) scheduleRefreshScan(ctx context.Context, path stri
 			defer cliScanner.instrumentor.Finish(span)
 
 			logger.Info().Msg("Starting scheduled scan")
+			// the refresh scan is meant to pick up new advisories, so it must not use cached results
+			cliScanner.resultCache.Clear()
 			_, _ = cliScanner.Scan(span.Context(), path, "")
 		case <-ctx.Done():
 			logger.Info().Msg("Scheduled scan cancelled")
@@ -28,6 +28,8 @@ func (cliScanner *CLIScanner) GetInlineValues(path string, myRange vulnmap.Range
 	logger := log.With().Str("method", "CLIScanner.GetInlineValues").Logger()
 	logger.Debug().Str("path", path).Msg("called")
 
+	cliScanner.inlineValueMutex.Lock()
+	defer cliScanner.inlineValueMutex.Unlock()
 	inlineValues := cliScanner.inlineValues[path]
 	result = filterInlineValuesForRange(inlineValues, myRange)
 	logger.Debug().Str("path", path).Msgf("%d inlineValues found", len(result))
@@ -37,10 +39,30 @@ func (cliScanner *CLIScanner) GetInlineValues(path string, myRange vulnmap.Range
 func (cliScanner *CLIScanner) ClearInlineValues(path string) {
 	logger := log.With().Str("method", "CLIScanner.ClearInlineValues").Logger()
 
+	cliScanner.inlineValueMutex.Lock()
 	cliScanner.inlineValues[path] = nil
+	cliScanner.inlineValueMutex.Unlock()
 	logger.Debug().Str("path", path).Msg("called")
 }
 
+func (cliScanner *CLIScanner) MoveInlineValues(oldPath string, newPath string) {
+	logger := log.With().Str("method", "CLIScanner.MoveInlineValues").Logger()
+
+	cliScanner.inlineValueMutex.Lock()
+	defer cliScanner.inlineValueMutex.Unlock()
+	inlineValues := cliScanner.inlineValues[oldPath]
+	for _, inlineValue := range inlineValues {
+		if vci, ok := inlineValue.(*VulnerabilityCountInformation); ok {
+			vci.path = newPath
+		}
+	}
+	delete(cliScanner.inlineValues, oldPath)
+	if len(inlineValues) > 0 {
+		cliScanner.inlineValues[newPath] = inlineValues
+	}
+	logger.Debug().Str("oldPath", oldPath).Str("newPath", newPath).Msgf("moved %d inline values", len(inlineValues))
+}
+
 func filterInlineValuesForRange(inlineValues []vulnmap.InlineValue, myRange vulnmap.Range) (result []vulnmap.InlineValue) {
 	if len(inlineValues) == 0 {
 		return nil
@@ -22,7 +22,6 @@ import (
 	"net/url"
 	"strings"
 
-	"github.com/gomarkdown/markdown"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 
@@ -87,40 +86,39 @@ func (i *ossIssue) AddVulnmapLearnAction(learnService learn.Service, ep error_re
 	return action
 }
 
-func (i *ossIssue) GetExtendedMessage(issue ossIssue) string {
-	title := issue.Title
-	description := issue.Description
-
-	if config.CurrentConfig().Format() == config.FormatHtml {
-		title = string(markdown.ToHTML([]byte(title), nil, nil))
-		description = string(markdown.ToHTML([]byte(description), nil, nil))
+// details returns the structured content of the hover, it is rendered for the client at presentation time
+func (i *ossIssue) details(remediation string) *vulnmap.IssueDetails {
+	fields := []vulnmap.DetailField{
+		{Label: "Package", Value: i.PackageName + "@" + i.Version},
+		{Label: "Fixed in", Value: i.fixedIn()},
+	}
+	if i.Exploit != "" {
+		fields = append(fields, vulnmap.DetailField{Label: "Exploit maturity", Value: i.Exploit})
+	}
+	var sections []vulnmap.DetailSection
+	if i.Description != "" {
+		sections = append(sections, vulnmap.DetailSection{Content: i.Description})
+	}
+	return &vulnmap.IssueDetails{
+		Title:       fmt.Sprintf("%s: %s affecting %s package", i.Id, i.Title, i.PackageName),
+		Links:       i.links(),
+		Fields:      fields,
+		Remediation: remediation,
+		UpgradePath: i.upgradePath(),
+		Sections:    sections,
 	}
-	summary := fmt.Sprintf("### Vulnerability %s %s %s \n **Fixed in: %s | Exploit maturity: %s**",
-		issue.createCveLink(),
-		issue.createCweLink(),
-		issue.createIssueUrlMarkdown(),
-		issue.createFixedIn(),
-		strings.ToUpper(issue.Severity),
-	)
-
-	return fmt.Sprintf("\n### %s: %s affecting %s package \n%s \n%s",
-		issue.Id,
-		title,
-		issue.PackageName,
-		summary,
-		description)
 }
 
-func (i *ossIssue) createCveLink() string {
-	var formattedCve string
+func (i *ossIssue) links() []vulnmap.Link {
+	var links []vulnmap.Link
 	for _, c := range i.Identifiers.CVE {
-		formattedCve += fmt.Sprintf("| [%s](https://cve.mitre.org/cgi-bin/cvename.cgi?name=%s)", c, c)
+		links = append(links, vulnmap.Link{Title: c, Url: "https://cve.mitre.org/cgi-bin/cvename.cgi?name=" + c})
 	}
-	return formattedCve
-}
-
-func (i *ossIssue) createIssueUrlMarkdown() string {
-	return fmt.Sprintf("| [%s](%s)", i.Id, i.CreateIssueURL().String())
+	for _, c := range i.Identifiers.CWE {
+		id := strings.Replace(c, "CWE-", "", -1)
+		links = append(links, vulnmap.Link{Title: c, Url: fmt.Sprintf("https://cwe.mitre.org/data/definitions/%s.html", id)})
+	}
+	return append(links, vulnmap.Link{Title: i.Id, Url: i.CreateIssueURL().String()})
 }
 
 func (i *ossIssue) CreateIssueURL() *url.URL {
@@ -131,26 +129,22 @@ func (i *ossIssue) CreateIssueURL() *url.URL {
 	return parse
 }
 
-func (i *ossIssue) createFixedIn() string {
-	var f string
+func (i *ossIssue) fixedIn() string {
 	if len(i.FixedIn) < 1 {
-		f += "Not Fixed"
-	} else {
-		f += "@" + i.FixedIn[0]
-		for _, version := range i.FixedIn[1:] {
-			f += fmt.Sprintf(", %s", version)
-		}
+		return "Not Fixed"
 	}
-	return f
+	return strings.Join(i.FixedIn, ", ")
 }
 
-func (i *ossIssue) createCweLink() string {
-	var formattedCwe string
-	for _, c := range i.Identifiers.CWE {
-		id := strings.Replace(c, "CWE-", "", -1)
-		formattedCwe += fmt.Sprintf("| [%s](https://cwe.mitre.org/data/definitions/%s.html)", c, id)
+// upgradePath returns the packages of the upgrade path, the CLI uses false for packages that aren't upgraded
+func (i *ossIssue) upgradePath() []string {
+	var path []string
+	for _, p := range i.UpgradePath {
+		if pkg, ok := p.(string); ok {
+			path = append(path, pkg)
+		}
 	}
-	return formattedCwe
+	return path
 }
 
 func (i *ossIssue) ToIssueSeverity() vulnmap.Severity {
@@ -171,9 +165,6 @@ func toIssue(
 ) vulnmap.Issue {
 	title := issue.Title
 
-	if config.CurrentConfig().Format() == config.FormatHtml {
-		title = string(markdown.ToHTML([]byte(title), nil, nil))
-	}
 	var action = "No fix available."
 	var resolution = ""
 	if issue.IsUpgradable {
@@ -202,10 +193,11 @@ func toIssue(
 		action,
 		resolution,
 	)
+	remediation := strings.TrimSpace(action + " " + resolution)
 	return vulnmap.Issue{
 		ID:                  issue.Id,
 		Message:             message,
-		FormattedMessage:    issue.GetExtendedMessage(issue),
+		Details:             issue.details(remediation),
 		Range:               issueRange,
 		Severity:            issue.ToIssueSeverity(),
 		AffectedFilePath:    affectedFilePath,
@@ -23,13 +23,10 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
-	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/vulnmap"
 )
 
 func TestNpmRangeFinder_Find(t *testing.T) {
-	config.CurrentConfig().SetFormat(config.FormatHtml)
-
 	var issue = ossIssue{
 		Id:             "testIssue",
 		Name:           "VULNMAP-TEST-ISSUE-1",
@@ -66,8 +63,6 @@ func TestNpmRangeFinder_Find(t *testing.T) {
 }
 
 func TestNpmRangeFinder_Find_Scoped_Packages(t *testing.T) {
-	config.CurrentConfig().SetFormat(config.FormatHtml)
-
 	var issue = ossIssue{
 		Id:             "testIssue",
 		Name:           "VULNMAP-TEST-ISSUE-1",
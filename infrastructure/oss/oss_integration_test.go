@@ -44,9 +44,9 @@ func Test_Scan(t *testing.T) {
 	testutil.IntegTest(t)
 	testutil.CreateDummyProgressListener(t)
 	c := config.CurrentConfig()
-	c.SetFormat(config.FormatHtml)
 	ctx := context.Background()
 	di.Init()
+	t.Cleanup(di.Stop)
 
 	// ensure CLI is downloaded if not already existent
 	if !c.CliSettings().Installed() {
@@ -60,7 +60,7 @@ func Test_Scan(t *testing.T) {
 	instrumentor := performance.NewInstrumentor()
 	er := error_reporting.NewTestErrorReporter()
 	analytics := ux.NewTestAnalytics()
-	cliExecutor := cli.NewExecutor(di.AuthenticationService(), er, analytics, notification.NewNotifier())
+	cliExecutor := cli.NewExecutor(di.AuthenticationService(), er, analytics, notification.NewNotifier(), instrumentor)
 	scanner := oss.NewCLIScanner(
 		instrumentor,
 		er,
@@ -19,6 +19,7 @@ package oss
 import (
 	"context"
 	"os"
+	"os/exec"
 	"path"
 	"path/filepath"
 	"sync"
@@ -36,8 +37,11 @@ import (
 	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/cli"
 	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/learn"
 	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/learn/mock_learn"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/lsp"
 	"github.com/khulnasoft-lab/vulnmap-ls/internal/notification"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/product"
 	"github.com/khulnasoft-lab/vulnmap-ls/internal/testutil"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/uri"
 )
 
 // todo test issue parsing & conversion
@@ -232,6 +236,63 @@ func TestUnmarshalOssJsonArray(t *testing.T) {
 	assert.Len(t, scanResults, 3)
 }
 
+func Test_Scan_PublishesIssuesOfEachProjectWhileScanning(t *testing.T) {
+	c := testutil.UnitTest(t)
+	workDir, err := filepath.Abs("testdata")
+	assert.NoError(t, err)
+	executor := cli.NewTestExecutorWithResponseFromFile(filepath.Join(workDir, "oss-result-array.json"))
+	scanner := NewCLIScanner(performance.NewInstrumentor(),
+		error_reporting.NewTestErrorReporter(),
+		ux2.NewTestAnalytics(),
+		executor,
+		getLearnMock(t),
+		notification.NewNotifier(),
+		c).(*CLIScanner)
+	var partialResults []vulnmap.ScanData
+	ctx := vulnmap.WithPartialResultProcessor(context.Background(), func(scanData vulnmap.ScanData) {
+		partialResults = append(partialResults, scanData)
+	})
+
+	issues, err := scanner.Scan(ctx, workDir, "")
+
+	assert.NoError(t, err)
+	// only one of the three projects has vulnerabilities, projects without issues aren't published
+	assert.Len(t, partialResults, 1)
+	assert.True(t, partialResults[0].Partial)
+	assert.Equal(t, product.ProductOpenSource, partialResults[0].Product)
+	assert.Equal(t, issues, partialResults[0].Issues)
+	assert.Equal(t, filepath.Join(workDir, "maven-slf4j-provider", "pom.xml"), issues[0].AffectedFilePath)
+}
+
+// nonStreamingExecutor hides ExecuteStreaming, like the ExtensionExecutor used within the Vulnmap CLI
+type nonStreamingExecutor struct {
+	cli.Executor
+}
+
+func Test_Scan_WithoutStreamingExecutorPublishesNoPartialResults(t *testing.T) {
+	c := testutil.UnitTest(t)
+	workDir, err := filepath.Abs("testdata")
+	assert.NoError(t, err)
+	executor := nonStreamingExecutor{cli.NewTestExecutorWithResponseFromFile(filepath.Join(workDir, "oss-result-array.json"))}
+	scanner := NewCLIScanner(performance.NewInstrumentor(),
+		error_reporting.NewTestErrorReporter(),
+		ux2.NewTestAnalytics(),
+		executor,
+		getLearnMock(t),
+		notification.NewNotifier(),
+		c).(*CLIScanner)
+	var partialResults []vulnmap.ScanData
+	ctx := vulnmap.WithPartialResultProcessor(context.Background(), func(scanData vulnmap.ScanData) {
+		partialResults = append(partialResults, scanData)
+	})
+
+	issues, err := scanner.Scan(ctx, workDir, "")
+
+	assert.NoError(t, err)
+	assert.Empty(t, partialResults)
+	assert.NotEmpty(t, issues)
+}
+
 func TestUnmarshalOssErroneousJson(t *testing.T) {
 	c := testutil.UnitTest(t)
 	scanner := NewCLIScanner(performance.NewInstrumentor(),
@@ -256,32 +317,29 @@ func TestUnmarshalOssErroneousJson(t *testing.T) {
 	assert.Nil(t, scanResults)
 }
 
-func Test_toHover_asHTML(t *testing.T) {
-	c := testutil.UnitTest(t)
-	c.SetFormat(config.FormatHtml)
-
-	var issue = sampleIssue()
-	h := issue.GetExtendedMessage(issue)
-
-	assert.Equal(
-		t,
-		"\n### testIssue: <p>THOU SHALL NOT PASS</p>\n affecting  package \n### Vulnerability  | [CWE-123](https://cwe.mitre.org/data/definitions/123.html) | [testIssue](https://vulnmap.khulnasoft.com/vuln/testIssue) \n **Fixed in: Not Fixed | Exploit maturity: LOW** \n<p>Getting into Moria is an issue!</p>\n",
-		h,
-	)
-}
-
-func Test_toHover_asMarkdown(t *testing.T) {
-	c := testutil.UnitTest(t)
-	c.SetFormat(config.FormatMd)
+func Test_details_isStructured(t *testing.T) {
+	testutil.UnitTest(t)
 
 	var issue = sampleIssue()
-	h := issue.GetExtendedMessage(issue)
-
-	assert.Equal(
-		t,
-		"\n### testIssue: THOU SHALL NOT PASS affecting  package \n### Vulnerability  | [CWE-123](https://cwe.mitre.org/data/definitions/123.html) | [testIssue](https://vulnmap.khulnasoft.com/vuln/testIssue) \n **Fixed in: Not Fixed | Exploit maturity: LOW** \nGetting into Moria is an issue!",
-		h,
-	)
+	issue.PackageName = "lodash"
+	issue.Version = "4.17.4"
+	issue.UpgradePath = []any{false, "lodash@4.17.21"}
+	details := issue.details("Upgrade to: lodash@4.17.21")
+
+	assert.Equal(t, &vulnmap.IssueDetails{
+		Title: "testIssue: THOU SHALL NOT PASS affecting lodash package",
+		Links: []vulnmap.Link{
+			{Title: "CWE-123", Url: "https://cwe.mitre.org/data/definitions/123.html"},
+			{Title: "testIssue", Url: "https://vulnmap.khulnasoft.com/vuln/testIssue"},
+		},
+		Fields: []vulnmap.DetailField{
+			{Label: "Package", Value: "lodash@4.17.4"},
+			{Label: "Fixed in", Value: "Not Fixed"},
+		},
+		Remediation: "Upgrade to: lodash@4.17.21",
+		UpgradePath: []string{"lodash@4.17.21"},
+		Sections:    []vulnmap.DetailSection{{Content: "Getting into Moria is an issue!"}},
+	}, details)
 }
 
 func Test_SeveralScansOnSameFolder_DoNotRunAtOnce(t *testing.T) {
@@ -357,6 +415,28 @@ func Test_prepareScanCommand_ExpandsAdditionalParameters(t *testing.T) {
 	assert.Contains(t, cmd, "-d")
 }
 
+func Test_prepareScanCommand_AddsCliOptions(t *testing.T) {
+	c := testutil.UnitTest(t)
+	scanner := NewCLIScanner(performance.NewInstrumentor(),
+		error_reporting.NewTestErrorReporter(),
+		ux2.NewTestAnalytics(),
+		cli.NewTestExecutor(),
+		getLearnMock(t),
+		notification.NewNotifier(),
+		c).(*CLIScanner)
+
+	settings := config.CliSettings{
+		Options: lsp.CliOptions{AllProjects: true, DetectionDepth: 3, Exclude: []string{"dist", "build"}},
+	}
+	c.SetCliSettings(&settings)
+
+	cmd := scanner.prepareScanCommand([]string{"a"})
+
+	assert.Contains(t, cmd, "--all-projects")
+	assert.Contains(t, cmd, "--detection-depth=3")
+	assert.Contains(t, cmd, "--exclude=dist,build")
+}
+
 func Test_Scan_SchedulesNewScan(t *testing.T) {
 	c := testutil.UnitTest(t)
 	// Arrange
@@ -544,6 +624,77 @@ func Test_Scan_missingDisplayTargetFileDoesNotBreakAnalysis(t *testing.T) {
 	assert.Len(t, analysis, 87)
 }
 
+func Test_Scan_ReusesCliResultsUntilManifestChanges(t *testing.T) {
+	c := testutil.UnitTest(t)
+	workingDir, _ := os.Getwd()
+	fakeCli := cli.NewTestExecutorWithResponseFromFile(path.Join(workingDir, "testdata/oss-result.json"))
+	scanner := NewCLIScanner(performance.NewInstrumentor(),
+		error_reporting.NewTestErrorReporter(),
+		ux2.NewTestAnalytics(),
+		fakeCli,
+		getLearnMock(t),
+		notification.NewNotifier(),
+		c).(*CLIScanner)
+	dir := t.TempDir()
+	manifest := filepath.Join(dir, "package.json")
+	assert.NoError(t, os.WriteFile(manifest, []byte(`{"dependencies": {}}`), 0o600))
+
+	firstIssues, err := scanner.Scan(context.Background(), dir, "")
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("changed"), 0o600))
+	cachedIssues, err := scanner.Scan(context.Background(), dir, "")
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1, fakeCli.GetFinishedScans())
+	assert.Equal(t, len(firstIssues), len(cachedIssues))
+
+	assert.NoError(t, os.WriteFile(manifest, []byte(`{"dependencies": {"lodash": "4.17.20"}}`), 0o600))
+	_, err = scanner.Scan(context.Background(), dir, "")
+	assert.NoError(t, err)
+
+	assert.Equal(t, 2, fakeCli.GetFinishedScans())
+}
+
+func Test_addPythonInterpreter(t *testing.T) {
+	testutil.UnitTest(t)
+	testutil.NotOnWindows(t, "virtual environments use Scripts\\python.exe on windows")
+	t.Setenv("VIRTUAL_ENV", "")
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "requirements.txt"), []byte("flask"), 0o600))
+	interpreter := filepath.Join(dir, ".venv", "bin", "python")
+	assert.NoError(t, os.MkdirAll(filepath.Dir(interpreter), 0o700))
+	assert.NoError(t, os.WriteFile(interpreter, []byte{}, 0o700))
+
+	assert.Equal(t, []string{"vulnmap", "test", "--command=" + interpreter}, addPythonInterpreter([]string{"vulnmap", "test"}, dir))
+	assert.Equal(t, []string{"vulnmap", "test", "--command=python3"}, addPythonInterpreter([]string{"vulnmap", "test", "--command=python3"}, dir))
+}
+
+func Test_handleError_NotifiesAboutTypedCliErrorsOnManifest(t *testing.T) {
+	c := testutil.UnitTest(t)
+	testutil.NotOnWindows(t, "uses sh to create an exit error")
+	notifier := notification.NewMockNotifier()
+	scanner := NewCLIScanner(performance.NewInstrumentor(),
+		error_reporting.NewTestErrorReporter(),
+		ux2.NewTestAnalytics(),
+		cli.NewTestExecutor(),
+		getLearnMock(t),
+		notifier,
+		c).(*CLIScanner)
+	workDir, err := filepath.Abs("testdata")
+	assert.NoError(t, err)
+	exitErr := exec.Command("sh", "-c", "exit 2").Run()
+	output := []byte(`{"ok": false, "error": "JAVA_HOME is not set", "path": "pom.xml"}`)
+
+	isError := scanner.handleError(workDir, exitErr, output, []string{"vulnmap", "test"})
+
+	assert.True(t, isError)
+	assert.Equal(t, 1, notifier.SendErrorDiagnosticCount())
+	params := notifier.SentMessages()[0].(lsp.PublishDiagnosticsParams)
+	assert.Equal(t, uri.PathToUri(filepath.Join(workDir, "pom.xml")), params.URI)
+	assert.Equal(t, string(cli.ErrorTypeMissingBuildTool), params.Diagnostics[0].Code)
+	assert.Equal(t, map[string]string{"tool": "java"}, params.Diagnostics[0].Data)
+}
+
 func getLearnMock(t *testing.T) learn.Service {
 	learnMock := mock_learn.NewMockService(gomock.NewController(t))
 	learnMock.
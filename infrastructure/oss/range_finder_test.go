@@ -23,14 +23,12 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
-	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/vulnmap"
 	"github.com/khulnasoft-lab/vulnmap-ls/internal/testutil"
 )
 
 func TestDefaultFinder_Find(t *testing.T) {
 	testutil.UnitTest(t)
-	config.CurrentConfig().SetFormat(config.FormatHtml)
 
 	var issue = ossIssue{
 		Id:             "testIssue",
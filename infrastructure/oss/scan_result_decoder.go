@@ -0,0 +1,80 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package oss
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"unicode"
+)
+
+// decodeScanResults decodes the results of the CLI one by one while they are read, so that every project is processed
+// as soon as it is decoded. The output can be a JSON array of results (e.g. with --all-projects), a single result or
+// one result per line (JSON lines). The CLI writes the array only when the scan is done, so results are only available
+// before the CLI exits if they are written as JSON lines.
+func decodeScanResults(reader io.Reader, onResult func(result scanResult)) error {
+	bufferedReader := bufio.NewReader(reader)
+	first, err := peekFirstNonSpace(bufferedReader)
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bufferedReader)
+	if first == '[' {
+		if _, err = decoder.Token(); err != nil {
+			return err
+		}
+		for decoder.More() {
+			var result scanResult
+			if err = decoder.Decode(&result); err != nil {
+				return err
+			}
+			onResult(result)
+		}
+		_, err = decoder.Token()
+		return err
+	}
+
+	for {
+		var result scanResult
+		err = decoder.Decode(&result)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		onResult(result)
+	}
+}
+
+func peekFirstNonSpace(reader *bufio.Reader) (byte, error) {
+	for {
+		b, err := reader.ReadByte()
+		if errors.Is(err, io.EOF) {
+			return 0, io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return 0, err
+		}
+		if !unicode.IsSpace(rune(b)) {
+			return b, reader.UnreadByte()
+		}
+	}
+}
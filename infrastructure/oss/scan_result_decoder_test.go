@@ -0,0 +1,74 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package oss
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_decodeScanResults(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+	}{
+		{name: "JSON array", output: ` [{"displayTargetFile": "a/pom.xml"}, {"displayTargetFile": "b/package.json"}]`},
+		{name: "JSON lines", output: "{\"displayTargetFile\": \"a/pom.xml\"}\n{\"displayTargetFile\": \"b/package.json\"}\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var targetFiles []string
+
+			err := decodeScanResults(strings.NewReader(test.output), func(result scanResult) {
+				targetFiles = append(targetFiles, result.DisplayTargetFile)
+			})
+
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"a/pom.xml", "b/package.json"}, targetFiles)
+		})
+	}
+}
+
+func Test_decodeScanResults_SingleResult(t *testing.T) {
+	var results []scanResult
+
+	err := decodeScanResults(strings.NewReader(`{"displayTargetFile": "pom.xml"}`), func(result scanResult) {
+		results = append(results, result)
+	})
+
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+}
+
+func Test_decodeScanResults_PassesResultsBeforeInvalidOutput(t *testing.T) {
+	var results []scanResult
+
+	err := decodeScanResults(strings.NewReader(`[{"displayTargetFile": "pom.xml"}, oops`), func(result scanResult) {
+		results = append(results, result)
+	})
+
+	assert.Error(t, err)
+	assert.Len(t, results, 1)
+}
+
+func Test_decodeScanResults_EmptyOutputIsAnError(t *testing.T) {
+	err := decodeScanResults(strings.NewReader("  \n"), func(result scanResult) {})
+
+	assert.Error(t, err)
+}
@@ -118,6 +118,8 @@ func (cliScanner *CLIScanner) addVulnerabilityCountsToCache(issues []vulnmap.Iss
 		logger.Err(err).Msg("couldn't get vulnerability counts")
 		cliScanner.errorReporter.CaptureError(err)
 	}
+	cliScanner.inlineValueMutex.Lock()
+	defer cliScanner.inlineValueMutex.Unlock()
 	for _, myRange := range counts {
 		for _, vulnerabilityCountInformation := range myRange {
 			addToCache(vulnerabilityCountInformation, cliScanner.inlineValues)
@@ -130,6 +132,8 @@ func (cliScanner *CLIScanner) addVulnerabilityCountsToCache(issues []vulnmap.Iss
 // When the first issue hits an overlapping vulnerability count, the whole vulnerability count is removed from the cache.
 func (cliScanner *CLIScanner) removeVulnerabilityCountsFromCache(issues []vulnmap.Issue) {
 	logger := cliScanner.config.Logger().With().Str("method", "removeVulnerabilityCountsFromCache").Logger()
+	cliScanner.inlineValueMutex.Lock()
+	defer cliScanner.inlineValueMutex.Unlock()
 	for _, issue := range issues {
 		inlineValues := cliScanner.inlineValues[issue.AffectedFilePath]
 		keptInlineValues := []vulnmap.InlineValue{}
@@ -236,3 +236,25 @@ func testRange() vulnmap.Range {
 		},
 	}
 }
+
+func TestScanner_MoveInlineValues_shouldMoveInlineValuesToNewPath(t *testing.T) {
+	c := testutil.UnitTest(t)
+	scanner := NewCLIScanner(performance.NewInstrumentor(),
+		error_reporting.NewTestErrorReporter(),
+		ux2.NewTestAnalytics(),
+		cli.NewTestExecutor(),
+		getLearnMock(t),
+		notification.NewNotifier(),
+		c).(*CLIScanner)
+	myRange := testRange()
+	scanner.addVulnerabilityCountsToCache(testIssues(vulnCountTestFilePath, myRange))
+	newPath := vulnCountTestFilePath + ".moved"
+
+	scanner.MoveInlineValues(vulnCountTestFilePath, newPath)
+
+	assert.Empty(t, scanner.inlineValues[vulnCountTestFilePath])
+	values, err := scanner.GetInlineValues(newPath, myRange)
+	assert.NoError(t, err)
+	assert.Len(t, values, 1)
+	assert.Equal(t, newPath, values[0].(*VulnerabilityCountInformation).path)
+}
@@ -0,0 +1,256 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rpctrace
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/creachadair/jrpc2/channel"
+	"github.com/rs/zerolog/log"
+	sglsp "github.com/sourcegraph/go-lsp"
+
+	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/lsp"
+)
+
+const (
+	initializeMethod = "initialize"
+	setTraceMethod   = "$/setTrace"
+	logTraceMethod   = "$/logTrace"
+)
+
+// envelope holds the fields of a JSON-RPC message that are needed to describe it
+type envelope struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method,omitempty"`
+	Params json.RawMessage `json:"params,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  json.RawMessage `json:"error,omitempty"`
+}
+
+// Channel wraps the channel of the server, so that all messages are passed to the recorder. While messages are
+// recorded, the client also receives $/logTrace notifications for its requests and notifications and for the
+// responses of the server, with the redacted payload if the trace level is verbose. Messages are only parsed and
+// redacted while they are recorded, apart from the initialize request and response and $/setTrace notifications.
+func Channel(ch channel.Channel, recorder *Recorder) channel.Channel {
+	return &recordingChannel{next: ch, recorder: recorder, methods: map[string]string{}}
+}
+
+type recordingChannel struct {
+	next         channel.Channel
+	recorder     *Recorder
+	sendMutex    sync.Mutex
+	methodsMutex sync.Mutex
+	// methods are the methods of the requests of the client that weren't answered yet, by id
+	methods map[string]string
+	// initializeId is the id of the initialize request until it is answered
+	initializeId string
+}
+
+func (c *recordingChannel) Send(data []byte) error {
+	c.sendMutex.Lock()
+	err := c.next.Send(data)
+	c.sendMutex.Unlock()
+
+	var messages []envelope
+	if c.isInitializing() || c.recorder.IsRecording() {
+		messages = envelopes(data)
+	}
+	if c.answersInitialize(messages) {
+		c.recorder.RecordInitialize(Outgoing, data)
+	} else {
+		c.recorder.Record(Outgoing, data)
+	}
+	c.sendLogTraces(c.traces(Outgoing, messages))
+	return err
+}
+
+func (c *recordingChannel) Recv() ([]byte, error) {
+	data, err := c.next.Recv()
+	if err != nil {
+		return data, err
+	}
+	var messages []envelope
+	if c.recorder.IsRecording() || bytes.Contains(data, []byte(`"`+initializeMethod+`"`)) ||
+		bytes.Contains(data, []byte(setTraceMethod)) {
+		messages = envelopes(data)
+	}
+	c.updateLevel(messages)
+	if c.isInitialize(messages) {
+		c.recorder.RecordInitialize(Incoming, data)
+	} else {
+		c.recorder.Record(Incoming, data)
+	}
+	c.sendLogTraces(c.traces(Incoming, messages))
+	return data, nil
+}
+
+// isInitialize returns true and remembers the id if the messages contain the initialize request
+func (c *recordingChannel) isInitialize(messages []envelope) bool {
+	for _, message := range messages {
+		if message.Method == initializeMethod && len(message.ID) > 0 {
+			c.methodsMutex.Lock()
+			c.initializeId = string(message.ID)
+			c.methodsMutex.Unlock()
+			return true
+		}
+	}
+	return false
+}
+
+func (c *recordingChannel) isInitializing() bool {
+	c.methodsMutex.Lock()
+	defer c.methodsMutex.Unlock()
+	return c.initializeId != ""
+}
+
+// answersInitialize returns true if the messages contain the response to the initialize request
+func (c *recordingChannel) answersInitialize(messages []envelope) bool {
+	c.methodsMutex.Lock()
+	defer c.methodsMutex.Unlock()
+	for _, message := range messages {
+		if c.initializeId != "" && message.Method == "" && string(message.ID) == c.initializeId {
+			c.initializeId = ""
+			return true
+		}
+	}
+	return false
+}
+
+func (c *recordingChannel) sendLogTraces(traces []lsp.LogTraceParams) {
+	if len(traces) == 0 {
+		return
+	}
+	c.sendMutex.Lock()
+	defer c.sendMutex.Unlock()
+	for _, params := range traces {
+		c.sendLogTrace(params)
+	}
+}
+
+func (c *recordingChannel) Close() error {
+	c.recorder.Close()
+	return c.next.Close()
+}
+
+// sendLogTrace sends a $/logTrace notification. The send mutex must be held.
+func (c *recordingChannel) sendLogTrace(params lsp.LogTraceParams) {
+	notification, err := json.Marshal(map[string]any{"jsonrpc": "2.0", "method": logTraceMethod, "params": params})
+	if err == nil {
+		err = c.next.Send(notification)
+	}
+	if err != nil {
+		log.Debug().Err(err).Str("method", "rpctrace.sendLogTrace").Msg("couldn't send trace")
+	}
+}
+
+// updateLevel applies the trace level of the initialize request and of $/setTrace notifications. This happens before
+// the message is recorded, so that a recording starts with the message that switched tracing on.
+func (c *recordingChannel) updateLevel(messages []envelope) {
+	for _, message := range messages {
+		var level sglsp.Trace
+		switch message.Method {
+		case initializeMethod:
+			var params struct {
+				Trace sglsp.Trace `json:"trace"`
+			}
+			if json.Unmarshal(message.Params, &params) != nil || params.Trace == "" {
+				continue
+			}
+			level = params.Trace
+		case setTraceMethod:
+			var params lsp.SetTraceParams
+			if json.Unmarshal(message.Params, &params) != nil {
+				continue
+			}
+			level = params.Value
+		default:
+			continue
+		}
+		c.recorder.SetLevel(level)
+	}
+}
+
+// traces returns the $/logTrace notifications that describe the messages, none if they aren't recorded. Requests and
+// notifications of the server aren't traced, as they are shown by the client anyway.
+func (c *recordingChannel) traces(direction Direction, messages []envelope) []lsp.LogTraceParams {
+	if len(messages) == 0 || !c.recorder.IsRecording() {
+		return nil
+	}
+	level := c.recorder.Level()
+
+	var traces []lsp.LogTraceParams
+	for _, message := range messages {
+		id := strings.Trim(string(message.ID), `"`)
+		var text string
+		var payload json.RawMessage
+		switch {
+		case direction == Incoming && message.Method != "" && id != "":
+			c.methodsMutex.Lock()
+			c.methods[id] = message.Method
+			c.methodsMutex.Unlock()
+			text, payload = fmt.Sprintf("Received request '%s - (%s)'.", message.Method, id), message.Params
+		case direction == Incoming && message.Method != "":
+			text, payload = fmt.Sprintf("Received notification '%s'.", message.Method), message.Params
+		case direction == Incoming:
+			text, payload = fmt.Sprintf("Received response '(%s)'.", id), responsePayload(message)
+		case direction == Outgoing && message.Method == "":
+			c.methodsMutex.Lock()
+			method := c.methods[id]
+			delete(c.methods, id)
+			c.methodsMutex.Unlock()
+			text, payload = fmt.Sprintf("Sending response '%s - (%s)'.", method, id), responsePayload(message)
+		default:
+			continue
+		}
+
+		trace := lsp.LogTraceParams{Message: text}
+		if level == lsp.TraceVerbose && len(payload) > 0 {
+			trace.Verbose = string(Redact(payload, config.CurrentConfig().ScrubbingDict()))
+		}
+		traces = append(traces, trace)
+	}
+	return traces
+}
+
+func responsePayload(message envelope) json.RawMessage {
+	if len(message.Error) > 0 {
+		return message.Error
+	}
+	return message.Result
+}
+
+// envelopes parses a single message or a batch
+func envelopes(data []byte) []envelope {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		var batch []envelope
+		if err := json.Unmarshal(data, &batch); err != nil {
+			return nil
+		}
+		return batch
+	}
+	var message envelope
+	if err := json.Unmarshal(data, &message); err != nil {
+		return nil
+	}
+	return []envelope{message}
+}
@@ -0,0 +1,175 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rpctrace
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/creachadair/jrpc2/channel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/lsp"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/testutil"
+)
+
+// clientReceiver collects the messages the server sends to the client
+type clientReceiver struct {
+	mutex    sync.Mutex
+	messages []envelope
+	done     chan struct{}
+}
+
+func receive(client channel.Channel) *clientReceiver {
+	r := &clientReceiver{done: make(chan struct{})}
+	go func() {
+		defer close(r.done)
+		for {
+			data, err := client.Recv()
+			if err != nil {
+				return
+			}
+			r.mutex.Lock()
+			r.messages = append(r.messages, envelopes(data)...)
+			r.mutex.Unlock()
+		}
+	}()
+	return r
+}
+
+func (r *clientReceiver) logTraces(t *testing.T) []lsp.LogTraceParams {
+	t.Helper()
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	var traces []lsp.LogTraceParams
+	for _, message := range r.messages {
+		if message.Method == logTraceMethod {
+			var params lsp.LogTraceParams
+			require.NoError(t, json.Unmarshal(message.Params, &params))
+			traces = append(traces, params)
+		}
+	}
+	return traces
+}
+
+func TestChannel_RecordsFromInitializeAndSendsLogTraces(t *testing.T) {
+	c := testutil.UnitTest(t)
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	c.SetRpcTraceFile(path)
+	client, server := channel.Direct()
+	traced := Channel(server, NewRecorder())
+	receiver := receive(client)
+
+	go func() {
+		_ = client.Send([]byte(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"trace":"verbose","initializationOptions":{"token":"` + c.Token() + `"}}}`))
+	}()
+	_, err := traced.Recv()
+	require.NoError(t, err)
+	require.NoError(t, traced.Send([]byte(`{"jsonrpc":"2.0","id":1,"result":{"capabilities":{}}}`)))
+	require.NoError(t, traced.Send([]byte(`{"jsonrpc":"2.0","method":"window/logMessage","params":{"message":"hi"}}`)))
+	go func() { _ = client.Send([]byte(`{"jsonrpc":"2.0","method":"$/setTrace","params":{"value":"off"}}`)) }()
+	_, err = traced.Recv()
+	require.NoError(t, err)
+	require.NoError(t, traced.Close())
+	<-receiver.done
+
+	messages, err := Read(path)
+	require.NoError(t, err)
+	require.Len(t, messages, 3)
+	assert.Equal(t, Incoming, messages[0].Direction)
+	assert.Contains(t, string(messages[0].Message), `"initialize"`)
+	assert.NotContains(t, string(messages[0].Message), c.Token())
+
+	traces := receiver.logTraces(t)
+	require.Len(t, traces, 2)
+	assert.Equal(t, "Received request 'initialize - (1)'.", traces[0].Message)
+	assert.NotContains(t, traces[0].Verbose, c.Token())
+	assert.Contains(t, traces[0].Verbose, `"trace":"verbose"`)
+	assert.Equal(t, "Sending response 'initialize - (1)'.", traces[1].Message)
+}
+
+func TestChannel_TracingOff_PassesMessagesOn(t *testing.T) {
+	c := testutil.UnitTest(t)
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	c.SetRpcTraceFile(path)
+	client, server := channel.Direct()
+	traced := Channel(server, NewRecorder())
+	receiver := receive(client)
+
+	go func() { _ = client.Send([]byte(`{"jsonrpc":"2.0","method":"initialized","params":{}}`)) }()
+	data, err := traced.Recv()
+	require.NoError(t, err)
+	require.NoError(t, traced.Close())
+	<-receiver.done
+
+	assert.JSONEq(t, `{"jsonrpc":"2.0","method":"initialized","params":{}}`, string(data))
+	assert.Empty(t, receiver.logTraces(t))
+	assert.NoFileExists(t, path)
+}
+
+func TestChannel_WithoutTraceFile_SendsNoLogTraces(t *testing.T) {
+	testutil.UnitTest(t)
+	client, server := channel.Direct()
+	traced := Channel(server, NewRecorder())
+	receiver := receive(client)
+
+	go func() {
+		_ = client.Send([]byte(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"trace":"verbose"}}`))
+	}()
+	_, err := traced.Recv()
+	require.NoError(t, err)
+	require.NoError(t, traced.Send([]byte(`{"jsonrpc":"2.0","id":1,"result":{"capabilities":{}}}`)))
+	require.NoError(t, traced.Close())
+	<-receiver.done
+
+	assert.Empty(t, receiver.logTraces(t))
+}
+
+func TestChannel_TracingSwitchedOnLater_StartsRecordingWithInitialize(t *testing.T) {
+	c := testutil.UnitTest(t)
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	c.SetRpcTraceFile(path)
+	client, server := channel.Direct()
+	traced := Channel(server, NewRecorder())
+	receiver := receive(client)
+
+	go func() {
+		_ = client.Send([]byte(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`))
+	}()
+	_, err := traced.Recv()
+	require.NoError(t, err)
+	require.NoError(t, traced.Send([]byte(`{"jsonrpc":"2.0","id":1,"result":{"capabilities":{}}}`)))
+	require.NoFileExists(t, path)
+	go func() {
+		_ = client.Send([]byte(`{"jsonrpc":"2.0","method":"$/setTrace","params":{"value":"messages"}}`))
+	}()
+	_, err = traced.Recv()
+	require.NoError(t, err)
+	require.NoError(t, traced.Close())
+	<-receiver.done
+
+	messages, err := Read(path)
+	require.NoError(t, err)
+	require.Len(t, messages, 3)
+	assert.Contains(t, string(messages[0].Message), `"initialize"`)
+	assert.Equal(t, Outgoing, messages[1].Direction)
+	assert.Contains(t, string(messages[1].Message), `"capabilities"`)
+	assert.Contains(t, string(messages[2].Message), `"$/setTrace"`)
+}
@@ -0,0 +1,222 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package rpctrace records the JSON-RPC traffic of the language server with secrets redacted, so that sessions can be
+// replayed to reproduce bugs.
+package rpctrace
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	sglsp "github.com/sourcegraph/go-lsp"
+
+	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/lsp"
+)
+
+// maxMessageSize is the longest line Read accepts, messages can contain whole documents
+const maxMessageSize = 64 * 1024 * 1024
+
+type Direction string
+
+const (
+	// Incoming messages are sent by the client to the server
+	Incoming Direction = "in"
+	// Outgoing messages are sent by the server to the client
+	Outgoing Direction = "out"
+)
+
+// Message is a line of a recording
+type Message struct {
+	Timestamp time.Time       `json:"timestamp"`
+	Direction Direction       `json:"direction"`
+	Message   json.RawMessage `json:"message"`
+}
+
+// Recorder appends redacted JSON-RPC messages as JSON lines to a file, while the trace level isn't off. The path is
+// read for every message, so it can change at runtime. Without a path, nothing is recorded. The initialize request
+// and response are kept, so that a recording that starts later in the session can still be replayed.
+type Recorder struct {
+	mutex    sync.Mutex
+	path     func() string
+	level    sglsp.Trace
+	file     *os.File
+	filePath string
+	// initialize are the recorded lines of the initialize request and response, they start each new trace file
+	initialize [][]byte
+}
+
+// NewRecorder returns a recorder that writes to the configured RPC trace file once the client sets a trace level
+func NewRecorder() *Recorder {
+	return &Recorder{
+		path:  func() string { return config.CurrentConfig().RpcTraceFile() },
+		level: lsp.TraceOff,
+	}
+}
+
+// NewFileRecorder returns a recorder that writes all messages to the given file, regardless of the trace level
+// requested by the client
+func NewFileRecorder(path string) *Recorder {
+	return &Recorder{path: func() string { return path }, level: lsp.TraceVerbose}
+}
+
+func (r *Recorder) Level() sglsp.Trace {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.level
+}
+
+// SetLevel sets the trace level requested by the client with $/setTrace or in the initialize request
+func (r *Recorder) SetLevel(level sglsp.Trace) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	switch level {
+	case lsp.TraceMessages, lsp.TraceVerbose:
+		r.level = level
+	default:
+		r.level = lsp.TraceOff
+		r.close()
+	}
+}
+
+// IsRecording returns true if a trace file is configured and the trace level isn't off
+func (r *Recorder) IsRecording() bool {
+	path := r.path()
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return path != "" && r.level != lsp.TraceOff
+}
+
+// Record appends the redacted message to the trace file
+func (r *Recorder) Record(direction Direction, message []byte) {
+	r.record(direction, message, false)
+}
+
+// RecordInitialize records the initialize request or response. It is kept even if nothing is recorded yet, and it is
+// written first to each new trace file.
+func (r *Recorder) RecordInitialize(direction Direction, message []byte) {
+	r.record(direction, message, true)
+}
+
+func (r *Recorder) record(direction Direction, message []byte, initialize bool) {
+	method := "rpctrace.Record"
+	path := r.path()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	recording := path != "" && r.level != lsp.TraceOff
+	if !recording {
+		r.close()
+		if !initialize {
+			return
+		}
+	}
+	line, err := json.Marshal(Message{
+		Timestamp: time.Now().UTC(),
+		Direction: direction,
+		Message:   Redact(message, config.CurrentConfig().ScrubbingDict()),
+	})
+	if err != nil {
+		log.Err(err).Str("method", method).Msg("couldn't marshal JSON-RPC message")
+		return
+	}
+	line = append(line, '\n')
+	if initialize {
+		defer func() { r.initialize = append(r.initialize, line) }()
+	}
+	if !recording {
+		return
+	}
+	if err = r.open(path); err != nil {
+		log.Err(err).Str("method", method).Msg("couldn't open RPC trace file")
+		return
+	}
+	if _, err = r.file.Write(line); err != nil {
+		log.Err(err).Str("method", method).Msg("couldn't write JSON-RPC message")
+	}
+}
+
+// Close closes the trace file, it is opened again with the next recorded message
+func (r *Recorder) Close() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.close()
+}
+
+// open opens the trace file at path for appending, if it isn't open yet, and starts a new file with the initialize
+// request and response. The mutex must be held.
+func (r *Recorder) open(path string) error {
+	if r.file != nil && r.filePath == path {
+		return nil
+	}
+	r.close()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	r.file, r.filePath = file, path
+	if info, statErr := file.Stat(); statErr == nil && info.Size() == 0 {
+		for _, line := range r.initialize {
+			if _, err = file.Write(line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// close closes the trace file. The mutex must be held.
+func (r *Recorder) close() {
+	if r.file != nil {
+		if err := r.file.Close(); err != nil {
+			log.Warn().Err(err).Str("method", "rpctrace.close").Msg("couldn't close RPC trace file")
+		}
+	}
+	r.file, r.filePath = nil, ""
+}
+
+// Read returns the messages of a recording
+func Read(path string) ([]Message, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	var messages []Message
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxMessageSize)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var message Message
+		if err = json.Unmarshal(scanner.Bytes(), &message); err != nil {
+			return nil, err
+		}
+		messages = append(messages, message)
+	}
+	return messages, scanner.Err()
+}
@@ -0,0 +1,70 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rpctrace
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/lsp"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/testutil"
+)
+
+func TestRecorder_RecordsRedactedMessagesWhileTracing(t *testing.T) {
+	c := testutil.UnitTest(t)
+	path := filepath.Join(t.TempDir(), "trace", "session.jsonl")
+	c.SetRpcTraceFile(path)
+	recorder := NewRecorder()
+
+	recorder.Record(Incoming, []byte(`{"jsonrpc":"2.0","method":"before"}`))
+	recorder.SetLevel(lsp.TraceMessages)
+	recorder.Record(Incoming, []byte(`{"jsonrpc":"2.0","method":"workspace/didChangeConfiguration","params":{"settings":{"token":"`+c.Token()+`"}}}`))
+	recorder.Record(Outgoing, []byte(`{"jsonrpc":"2.0","method":"window/logMessage","params":{"message":"hi"}}`))
+	recorder.SetLevel(lsp.TraceOff)
+	recorder.Record(Incoming, []byte(`{"jsonrpc":"2.0","method":"after"}`))
+
+	messages, err := Read(path)
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	assert.Equal(t, Incoming, messages[0].Direction)
+	assert.NotContains(t, string(messages[0].Message), c.Token())
+	assert.Contains(t, string(messages[0].Message), "workspace/didChangeConfiguration")
+	assert.Equal(t, Outgoing, messages[1].Direction)
+	assert.False(t, messages[1].Timestamp.IsZero())
+}
+
+func TestRecorder_WithoutFile_DoesNotRecord(t *testing.T) {
+	c := testutil.UnitTest(t)
+	c.SetRpcTraceFile("")
+	recorder := NewRecorder()
+	recorder.SetLevel(lsp.TraceVerbose)
+
+	recorder.Record(Incoming, []byte(`{"jsonrpc":"2.0","method":"initialized"}`))
+
+	assert.Nil(t, recorder.file)
+}
+
+func TestRecorder_InvalidLevel_SwitchesTracingOff(t *testing.T) {
+	recorder := NewFileRecorder(filepath.Join(t.TempDir(), "session.jsonl"))
+
+	recorder.SetLevel("everything")
+
+	assert.Equal(t, lsp.TraceOff, recorder.Level())
+}
@@ -0,0 +1,77 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rpctrace
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/logging"
+)
+
+const redacted = "***"
+
+// sensitiveKeys are the lower case JSON keys whose values are removed completely, including nested values
+var sensitiveKeys = map[string]bool{
+	"token":         true,
+	"password":      true,
+	"authorization": true,
+	"apikey":        true,
+	"secret":        true,
+	"cookie":        true,
+	"additionalenv": true,
+	"folderenv":     true,
+}
+
+// Redact returns the JSON-RPC message without secrets: the values of sensitive keys are replaced and all other
+// strings are redacted like log messages. Messages that aren't valid JSON are redacted as text.
+func Redact(message []byte, scrubDict map[string]bool) []byte {
+	decoder := json.NewDecoder(bytes.NewReader(message))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return []byte(logging.Redact(string(message), scrubDict))
+	}
+	redactedMessage, err := json.Marshal(redactValue(value, false, scrubDict))
+	if err != nil {
+		return []byte(logging.Redact(string(message), scrubDict))
+	}
+	return redactedMessage
+}
+
+func redactValue(value any, sensitive bool, scrubDict map[string]bool) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, child := range v {
+			v[key] = redactValue(child, sensitive || sensitiveKeys[strings.ToLower(key)], scrubDict)
+		}
+		return v
+	case []any:
+		for i, child := range v {
+			v[i] = redactValue(child, sensitive, scrubDict)
+		}
+		return v
+	case string:
+		if sensitive && v != "" {
+			return redacted
+		}
+		return logging.Redact(v, scrubDict)
+	default:
+		return v
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/rs/zerolog/log"
 
 	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
+	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/network"
 	"github.com/khulnasoft-lab/vulnmap-ls/internal/concurrency"
 )
 
@@ -41,7 +42,7 @@ func initializeSentry() {
 		BeforeSend:       beforeSend,
 		EnableTracing:    true,
 		TracesSampleRate: 1,
-		HTTPClient:       config.CurrentConfig().Engine().GetNetworkAccess().GetUnauthorizedHttpClient(),
+		HTTPClient:       network.GuardClient(config.CurrentConfig().Engine().GetNetworkAccess().GetUnauthorizedHttpClient()),
 		AttachStacktrace: true,
 	})
 	if err != nil {
@@ -63,7 +64,7 @@ func addUserId() {
 
 func beforeSend(event *sentry.Event, _ *sentry.EventHint) *sentry.Event {
 	c := config.CurrentConfig()
-	if c.IsErrorReportingEnabled() && !c.IsFedramp() {
+	if c.IsErrorReportingEnabled() && !c.IsFedramp() && !c.IsOfflineMode() {
 		return event
 	}
 	return nil
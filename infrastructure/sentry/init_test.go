@@ -54,3 +54,13 @@ func Test_Sentry_BeforeSend(t *testing.T) {
 	result = beforeSend(testEvent, nil)
 	assert.Equal(t, (*sentry.Event)(nil), result)
 }
+
+func Test_Sentry_BeforeSend_OfflineMode(t *testing.T) {
+	c := testutil.UnitTest(t)
+	c.SetErrorReportingEnabled(true)
+	c.SetOfflineMode(true)
+
+	result := beforeSend(sentry.NewEvent(), nil)
+
+	assert.Equal(t, (*sentry.Event)(nil), result)
+}
@@ -0,0 +1,354 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sentry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/ide/notification"
+	"github.com/khulnasoft-lab/vulnmap-ls/domain/observability/error_reporting"
+	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/errorspool"
+)
+
+const (
+	// reachabilityTtl is how long the result of a connectivity check to Sentry is reused
+	reachabilityTtl   = time.Minute
+	reachabilityCheck = 5 * time.Second
+	// flushTimeout is how long sending spooled errors may take before they are kept for the next attempt
+	flushTimeout = 2 * time.Second
+	// spoolFlushInterval is how often spooled errors are sent if Sentry became reachable again
+	spoolFlushInterval = 5 * time.Minute
+)
+
+// spoolingErrorReporter reports errors to Sentry if it can be reached, and spools them to a bounded local directory
+// otherwise, e.g. in offline mode or in restricted networks. Spooled errors are sent once Sentry can be reached again,
+// as long as error reporting is enabled. Errors are only spooled while error reporting is enabled, and they are
+// scrubbed the same way whether they are sent or spooled. Connectivity checks and sending run in the background until
+// the reporter is stopped.
+type spoolingErrorReporter struct {
+	notifier   notification.Notifier
+	spool      *errorspool.Spool
+	httpClient func() *http.Client
+	send       func(event *sentry.Event) *sentry.EventID
+	flush      func(timeout time.Duration) bool
+	mutex      sync.Mutex
+	checkedAt  time.Time
+	reachable  bool
+	checking   bool
+	// ctx is canceled when the reporter is stopped, background tracks the goroutines Stop waits for
+	ctx        context.Context
+	cancel     context.CancelFunc
+	background sync.WaitGroup
+	startOnce  sync.Once
+}
+
+func NewSpoolingErrorReporter(
+	notifier notification.Notifier,
+	spool *errorspool.Spool,
+	httpClient func() *http.Client,
+) error_reporting.ErrorReporter {
+	initializeSentry()
+	return newSpoolingErrorReporter(notifier, spool, httpClient)
+}
+
+// NewPanicErrorReporter returns a reporter for a panic of the process. It checks if Sentry can be reached before it
+// returns, so that the panic is sent right away instead of being spooled.
+func NewPanicErrorReporter(spool *errorspool.Spool, httpClient func() *http.Client) error_reporting.ErrorReporter {
+	initializeSentry()
+	r := newSpoolingErrorReporter(nil, spool, httpClient)
+	if r.isAllowed() {
+		r.awaitReachability()
+	}
+	return r
+}
+
+func newSpoolingErrorReporter(
+	notifier notification.Notifier,
+	spool *errorspool.Spool,
+	httpClient func() *http.Client,
+) *spoolingErrorReporter {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &spoolingErrorReporter{
+		notifier:   notifier,
+		spool:      spool,
+		httpClient: httpClient,
+		send:       sentry.CaptureEvent,
+		flush:      sentry.Flush,
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+// Start sends the spooled errors periodically, once Sentry can be reached, until the reporter is stopped
+func (r *spoolingErrorReporter) Start() {
+	r.startOnce.Do(func() { r.goBackground(r.flushPeriodically) })
+}
+
+// Stop cancels the background work, e.g. a running connectivity check, and waits for it to finish. Errors captured
+// afterwards are still sent or spooled, but no background work is started for them.
+func (r *spoolingErrorReporter) Stop() {
+	r.mutex.Lock()
+	r.cancel()
+	r.mutex.Unlock()
+	r.background.Wait()
+}
+
+// goBackground runs f in a goroutine that Stop waits for, unless the reporter is stopped
+func (r *spoolingErrorReporter) goBackground(f func()) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.goBackgroundLocked(f)
+}
+
+// goBackgroundLocked is goBackground for callers holding the mutex. Stop cancels the context while holding the
+// mutex, so no goroutine is added while Stop waits.
+func (r *spoolingErrorReporter) goBackgroundLocked(f func()) bool {
+	if r.ctx.Err() != nil {
+		return false
+	}
+	r.background.Add(1)
+	go func() {
+		defer r.background.Done()
+		f()
+	}()
+	return true
+}
+
+func (r *spoolingErrorReporter) CaptureError(err error) bool {
+	if r.notifier != nil {
+		r.notifier.SendError(err)
+	}
+	return r.report(err, nil)
+}
+
+func (r *spoolingErrorReporter) CaptureErrorAndReportAsIssue(path string, err error) bool {
+	if r.notifier != nil {
+		r.notifier.SendErrorDiagnostic(path, err)
+	}
+	return r.report(err, map[string]string{"path": path})
+}
+
+// FlushErrorReporting sends the spooled errors and waits for the queued events. If Sentry's reachability isn't known
+// yet, e.g. because the process is about to exit right after starting, it is checked first.
+func (r *spoolingErrorReporter) FlushErrorReporting() {
+	if r.isAllowed() {
+		r.awaitReachability()
+		if r.isReachable() {
+			r.flushSpool()
+		}
+	}
+	r.flush(flushTimeout)
+}
+
+// report sends the error to Sentry, or spools it if Sentry can't be reached. It returns true if the error was sent.
+func (r *spoolingErrorReporter) report(err error, context map[string]string) bool {
+	method := "spoolingErrorReporter.report"
+	c := config.CurrentConfig()
+	if !c.IsErrorReportingEnabled() {
+		return false
+	}
+
+	scrubDict := c.ScrubbingDict()
+	entry := newSpoolEntry(err, context).Scrubbed(scrubDict)
+	if r.canSend() {
+		if eventId := r.send(eventFromSpoolEntry(entry)); eventId != nil {
+			log.Info().Err(err).Str("method", method).Msgf("Sent error to Sentry (ID: %v)", *eventId)
+			r.goBackground(r.flushSpool)
+			return true
+		}
+	}
+
+	if spoolErr := r.spool.Add(entry, scrubDict); spoolErr != nil {
+		log.Err(spoolErr).Str("method", method).Msg("couldn't spool error")
+		return false
+	}
+	log.Info().Err(err).Str("method", method).Msgf("Sentry can't be reached, spooled error to %s", r.spool.Dir())
+	return false
+}
+
+// canSend returns true if error reporting is enabled, allowed and Sentry can be reached
+func (r *spoolingErrorReporter) canSend() bool {
+	return r.isAllowed() && r.isReachable()
+}
+
+// isAllowed returns true if error reporting is enabled and errors may leave the machine
+func (r *spoolingErrorReporter) isAllowed() bool {
+	c := config.CurrentConfig()
+	return c.IsErrorReportingEnabled() && !c.IsOfflineMode() && !c.IsFedramp()
+}
+
+// awaitReachability checks if Sentry can be reached and waits for the result, unless a check already finished
+func (r *spoolingErrorReporter) awaitReachability() {
+	r.mutex.Lock()
+	checked := !r.checkedAt.IsZero()
+	r.mutex.Unlock()
+	if !checked {
+		r.checkReachability()
+	}
+}
+
+// isReachable returns the result of the last connectivity check to Sentry without waiting for the network. If that
+// result is outdated, a new check is started in the background. Until the first check finished, Sentry counts as
+// unreachable, so errors are spooled and sent once the check succeeds or when the errors are flushed.
+func (r *spoolingErrorReporter) isReachable() bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if time.Since(r.checkedAt) >= reachabilityTtl && !r.checking {
+		r.checking = r.goBackgroundLocked(r.checkReachability)
+	}
+	return r.reachable
+}
+
+// checkReachability checks if Sentry answers with the http client of the language server, so proxy and offline
+// settings apply. Any http response counts as reachable. Spooled errors are sent if Sentry became reachable.
+func (r *spoolingErrorReporter) checkReachability() {
+	reachable := r.ping()
+
+	r.mutex.Lock()
+	wasReachable := r.reachable
+	r.reachable = reachable
+	r.checkedAt = time.Now()
+	r.checking = false
+	r.mutex.Unlock()
+
+	if reachable && !wasReachable && r.canSend() {
+		r.flushSpool()
+	}
+}
+
+func (r *spoolingErrorReporter) ping() bool {
+	dsn, err := sentry.NewDsn(sentryDsn)
+	if err != nil {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(r.ctx, reachabilityCheck)
+	defer cancel()
+	url := fmt.Sprintf("%s://%s/", dsn.GetScheme(), dsn.GetHost())
+	request, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return false
+	}
+	response, err := r.httpClient().Do(request)
+	if response != nil {
+		_ = response.Body.Close()
+	}
+	return err == nil
+}
+
+func (r *spoolingErrorReporter) flushPeriodically() {
+	ticker := time.NewTicker(spoolFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-ticker.C:
+			if len(r.spool.Files()) > 0 && r.canSend() {
+				r.flushSpool()
+			}
+		}
+	}
+}
+
+// flushSpool sends the spooled errors and only removes them once Sentry's transport delivered them
+func (r *spoolingErrorReporter) flushSpool() {
+	sent := r.spool.Flush(func(entry errorspool.Entry) bool {
+		return r.send(eventFromSpoolEntry(entry)) != nil
+	}, func() bool {
+		return r.flush(flushTimeout)
+	})
+	if sent > 0 {
+		log.Info().Str("method", "spoolingErrorReporter.flushSpool").Msgf("sent %d spooled errors to Sentry", sent)
+	}
+}
+
+func newSpoolEntry(err error, context map[string]string) errorspool.Entry {
+	stacktrace := sentry.ExtractStacktrace(err)
+	if stacktrace == nil {
+		stacktrace = sentry.NewStacktrace()
+	}
+	var frames []errorspool.Frame
+	if stacktrace != nil {
+		for _, frame := range stacktrace.Frames {
+			frames = append(frames, errorspool.Frame{
+				Function: frame.Function,
+				Module:   frame.Module,
+				File:     frame.Filename,
+				Line:     frame.Lineno,
+			})
+		}
+	}
+
+	entryContext := map[string]string{
+		"version": config.Version,
+		"os":      runtime.GOOS,
+		"arch":    runtime.GOARCH,
+	}
+	for key, value := range context {
+		entryContext[key] = value
+	}
+	return errorspool.Entry{
+		EventId:    newEventId(),
+		Timestamp:  time.Now().UTC(),
+		Type:       fmt.Sprintf("%T", err),
+		Message:    err.Error(),
+		Stacktrace: frames,
+		Context:    entryContext,
+	}
+}
+
+// newEventId returns a random Sentry event id, a UUID as 32 hex characters
+func newEventId() string {
+	return strings.ReplaceAll(uuid.NewString(), "-", "")
+}
+
+func eventFromSpoolEntry(entry errorspool.Entry) *sentry.Event {
+	frames := make([]sentry.Frame, 0, len(entry.Stacktrace))
+	for _, frame := range entry.Stacktrace {
+		frames = append(frames, sentry.Frame{
+			Function: frame.Function,
+			Module:   frame.Module,
+			Filename: frame.File,
+			Lineno:   frame.Line,
+		})
+	}
+
+	event := sentry.NewEvent()
+	event.EventID = sentry.EventID(entry.EventId)
+	event.Level = sentry.LevelError
+	event.Timestamp = entry.Timestamp
+	event.Exception = []sentry.Exception{{
+		Type:       entry.Type,
+		Value:      entry.Message,
+		Stacktrace: &sentry.Stacktrace{Frames: frames},
+	}}
+	for key, value := range entry.Context {
+		event.Tags[key] = value
+	}
+	return event
+}
@@ -0,0 +1,314 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sentry
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	sglsp "github.com/sourcegraph/go-lsp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/errorspool"
+	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/network"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/lsp"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/notification"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/testutil"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/uri"
+)
+
+type roundTripperFunc func(request *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(request *http.Request) (*http.Response, error) {
+	return f(request)
+}
+
+type fakeSentry struct {
+	mutex       sync.Mutex
+	reachable   bool
+	undelivered bool
+	events      []*sentry.Event
+}
+
+func (f *fakeSentry) httpClient() *http.Client {
+	return &http.Client{Transport: roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+		f.mutex.Lock()
+		defer f.mutex.Unlock()
+		if !f.reachable {
+			return nil, errors.New("no route to host")
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: request}, nil
+	})}
+}
+
+func (f *fakeSentry) send(event *sentry.Event) *sentry.EventID {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.events = append(f.events, event)
+	return &event.EventID
+}
+
+func (f *fakeSentry) flush(_ time.Duration) bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return !f.undelivered
+}
+
+func (f *fakeSentry) sentEvents() []*sentry.Event {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return append([]*sentry.Event{}, f.events...)
+}
+
+func setupSpoolingErrorReporter(t *testing.T, reachable bool) (*spoolingErrorReporter, *fakeSentry) {
+	t.Helper()
+	fake := &fakeSentry{reachable: reachable}
+	spool := errorspool.New(t.TempDir(), errorspool.DefaultMaxEntries)
+	reporter := newSpoolingErrorReporter(notification.NewNotifier(), spool, fake.httpClient)
+	reporter.send = fake.send
+	reporter.flush = fake.flush
+	reporter.checkReachability()
+	return reporter, fake
+}
+
+func TestSpoolingErrorReporter_Reachable_SendsError(t *testing.T) {
+	c := testutil.UnitTest(t)
+	c.SetErrorReportingEnabled(true)
+	reporter, fake := setupSpoolingErrorReporter(t, true)
+
+	captured := reporter.CaptureErrorAndReportAsIssue("main.go", errors.New("test error"))
+
+	assert.True(t, captured)
+	require.Len(t, fake.sentEvents(), 1)
+	event := fake.sentEvents()[0]
+	assert.Equal(t, "test error", event.Exception[0].Value)
+	assert.NotEmpty(t, event.Exception[0].Stacktrace.Frames)
+	assert.Equal(t, "main.go", event.Tags["path"])
+	assert.Empty(t, reporter.spool.Files())
+}
+
+func TestSpoolingErrorReporter_Unreachable_SpoolsScrubbedError(t *testing.T) {
+	c := testutil.UnitTest(t)
+	c.SetErrorReportingEnabled(true)
+	reporter, fake := setupSpoolingErrorReporter(t, false)
+
+	captured := reporter.CaptureError(errors.New("failed with token " + c.Token()))
+
+	assert.False(t, captured)
+	assert.Empty(t, fake.sentEvents())
+	require.Len(t, reporter.spool.Files(), 1)
+	reporter.spool.Flush(func(entry errorspool.Entry) bool {
+		assert.NotContains(t, entry.Message, c.Token())
+		assert.NotEmpty(t, entry.Stacktrace)
+		return false
+	}, nil)
+}
+
+func TestSpoolingErrorReporter_OfflineMode_SpoolsWithoutDialing(t *testing.T) {
+	c := testutil.UnitTest(t)
+	c.SetErrorReportingEnabled(true)
+	c.SetOfflineMode(true)
+	spool := errorspool.New(t.TempDir(), errorspool.DefaultMaxEntries)
+	httpClient := network.GuardClientFactory(func() *http.Client { return testutil.NoDialHttpClient(t) })
+	reporter := newSpoolingErrorReporter(notification.NewNotifier(), spool, httpClient)
+
+	captured := reporter.CaptureError(errors.New("test error"))
+
+	assert.False(t, captured)
+	assert.Len(t, spool.Files(), 1)
+}
+
+func TestSpoolingErrorReporter_ErrorReportingDisabled_NeitherSendsNorSpools(t *testing.T) {
+	c := testutil.UnitTest(t)
+	c.SetErrorReportingEnabled(false)
+	reporter, fake := setupSpoolingErrorReporter(t, true)
+
+	captured := reporter.CaptureError(errors.New("test error"))
+
+	assert.False(t, captured)
+	assert.Empty(t, fake.sentEvents())
+	assert.Empty(t, reporter.spool.Files())
+}
+
+func TestSpoolingErrorReporter_SendsSpooledErrorsWhenReachableAgain(t *testing.T) {
+	c := testutil.UnitTest(t)
+	c.SetErrorReportingEnabled(true)
+	reporter, fake := setupSpoolingErrorReporter(t, false)
+	reporter.CaptureError(errors.New("first error"))
+	reporter.CaptureError(errors.New("second error"))
+	require.Len(t, reporter.spool.Files(), 2)
+
+	fake.mutex.Lock()
+	fake.reachable = true
+	fake.mutex.Unlock()
+	reporter.checkReachability()
+
+	require.Len(t, fake.sentEvents(), 2)
+	assert.Equal(t, "first error", fake.sentEvents()[0].Exception[0].Value)
+	assert.Equal(t, "second error", fake.sentEvents()[1].Exception[0].Value)
+	assert.Empty(t, reporter.spool.Files())
+}
+
+func TestSpoolingErrorReporter_FlushErrorReporting_KeepsSpooledErrorsIfNotDelivered(t *testing.T) {
+	c := testutil.UnitTest(t)
+	c.SetErrorReportingEnabled(true)
+	reporter, fake := setupSpoolingErrorReporter(t, true)
+	require.NoError(t, reporter.spool.Add(errorspool.Entry{Timestamp: time.Now(), Message: "spooled error"}, nil))
+	fake.mutex.Lock()
+	fake.undelivered = true
+	fake.mutex.Unlock()
+
+	reporter.FlushErrorReporting()
+
+	assert.Len(t, fake.sentEvents(), 1)
+	assert.Len(t, reporter.spool.Files(), 1)
+}
+
+func TestSpoolingErrorReporter_FlushErrorReporting_ChecksReachabilityFirst(t *testing.T) {
+	c := testutil.UnitTest(t)
+	c.SetErrorReportingEnabled(true)
+	fake := &fakeSentry{reachable: true}
+	spool := errorspool.New(t.TempDir(), errorspool.DefaultMaxEntries)
+	reporter := newSpoolingErrorReporter(notification.NewNotifier(), spool, fake.httpClient)
+	reporter.send = fake.send
+	reporter.flush = fake.flush
+	require.NoError(t, spool.Add(newSpoolEntry(errors.New("spooled error"), nil), nil))
+
+	reporter.FlushErrorReporting()
+
+	require.Len(t, fake.sentEvents(), 1)
+	assert.Equal(t, "spooled error", fake.sentEvents()[0].Exception[0].Value)
+	assert.Empty(t, spool.Files())
+}
+
+func TestSpoolingErrorReporter_FlushErrorReporting_ResendsWithSameEventId(t *testing.T) {
+	c := testutil.UnitTest(t)
+	c.SetErrorReportingEnabled(true)
+	reporter, fake := setupSpoolingErrorReporter(t, true)
+	require.NoError(t, reporter.spool.Add(newSpoolEntry(errors.New("spooled error"), nil), nil))
+	fake.mutex.Lock()
+	fake.undelivered = true
+	fake.mutex.Unlock()
+	reporter.FlushErrorReporting()
+	fake.mutex.Lock()
+	fake.undelivered = false
+	fake.mutex.Unlock()
+
+	reporter.FlushErrorReporting()
+
+	events := fake.sentEvents()
+	require.Len(t, events, 2)
+	assert.Len(t, events[0].EventID, 32)
+	assert.Equal(t, events[0].EventID, events[1].EventID)
+	assert.Empty(t, reporter.spool.Files())
+}
+
+func TestSpoolingErrorReporter_IsReachable_ChecksInBackground(t *testing.T) {
+	testutil.UnitTest(t)
+	requests := make(chan struct{}, 10)
+	release := make(chan struct{})
+	httpClient := func() *http.Client {
+		return &http.Client{Transport: roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+			requests <- struct{}{}
+			<-release
+			return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: request}, nil
+		})}
+	}
+	spool := errorspool.New(t.TempDir(), errorspool.DefaultMaxEntries)
+	reporter := newSpoolingErrorReporter(notification.NewNotifier(), spool, httpClient)
+
+	assert.False(t, reporter.isReachable())
+	<-requests
+	assert.False(t, reporter.isReachable())
+	close(release)
+
+	assert.Eventually(t, reporter.isReachable, time.Second, 10*time.Millisecond)
+	assert.Empty(t, requests)
+}
+
+func TestSpoolingErrorReporter_Stop_CancelsBackgroundWork(t *testing.T) {
+	testutil.UnitTest(t)
+	requests := make(chan struct{}, 10)
+	httpClient := func() *http.Client {
+		return &http.Client{Transport: roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+			requests <- struct{}{}
+			<-request.Context().Done()
+			return nil, request.Context().Err()
+		})}
+	}
+	spool := errorspool.New(t.TempDir(), errorspool.DefaultMaxEntries)
+	reporter := newSpoolingErrorReporter(notification.NewNotifier(), spool, httpClient)
+	reporter.Start()
+	reporter.isReachable()
+	<-requests
+
+	stopped := make(chan struct{})
+	go func() {
+		reporter.Stop()
+		close(stopped)
+	}()
+
+	require.Eventually(t, func() bool {
+		select {
+		case <-stopped:
+			return true
+		default:
+			return false
+		}
+	}, time.Second, 10*time.Millisecond, "Stop waits for the running connectivity check only until it is canceled")
+	reporter.isReachable()
+	assert.Empty(t, requests, "no connectivity check is started after Stop")
+}
+
+func TestSpoolingErrorReporter_CaptureError_NotifiesClient(t *testing.T) {
+	c := testutil.UnitTest(t)
+	c.SetErrorReportingEnabled(true)
+	reporter, _ := setupSpoolingErrorReporter(t, true)
+	notifier := notification.NewMockNotifier()
+	reporter.notifier = notifier
+
+	captured := reporter.CaptureError(errors.New("test error"))
+
+	assert.True(t, captured)
+	require.Len(t, notifier.SentMessages(), 1)
+	showMessageParams := notifier.SentMessages()[0].(sglsp.ShowMessageParams)
+	assert.Equal(t, "Vulnmap encountered an error: test error", showMessageParams.Message)
+}
+
+func TestSpoolingErrorReporter_CaptureErrorAndReportAsIssue_PublishesDiagnostic(t *testing.T) {
+	c := testutil.UnitTest(t)
+	c.SetErrorReportingEnabled(true)
+	reporter, _ := setupSpoolingErrorReporter(t, true)
+	notifier := notification.NewMockNotifier()
+	reporter.notifier = notifier
+	path := "testPath"
+
+	captured := reporter.CaptureErrorAndReportAsIssue(path, errors.New("test error"))
+
+	assert.True(t, captured)
+	require.Len(t, notifier.SentMessages(), 1)
+	diagnosticsParams := notifier.SentMessages()[0].(lsp.PublishDiagnosticsParams)
+	assert.Equal(t, uri.PathToUri(path), diagnosticsParams.URI)
+	assert.Equal(t, "test error", diagnosticsParams.Diagnostics[0].Message)
+	assert.Equal(t, lsp.DiagnosticsSeverityWarning, diagnosticsParams.Diagnostics[0].Severity)
+	assert.Equal(t, lsp.Uri("https://vulnmap.khulnasoft.com/user-hub"), diagnosticsParams.Diagnostics[0].CodeDescription.Href)
+}
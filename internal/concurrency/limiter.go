@@ -0,0 +1,105 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package concurrency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// limiterPollInterval is how often waiting callers re-check the limit and are told how long they've been waiting
+const limiterPollInterval = time.Second
+
+// Limiter limits the number of concurrent executions. In contrast to a buffered channel used as semaphore, the limit
+// is read on every acquisition, so that it can be changed at runtime.
+type Limiter struct {
+	mutex    sync.Mutex
+	limit    func() int
+	running  int
+	waiting  int
+	released chan struct{}
+}
+
+func NewLimiter(limit func() int) *Limiter {
+	return &Limiter{
+		limit:    limit,
+		released: make(chan struct{}),
+	}
+}
+
+// Acquire blocks until the execution is allowed or the context is done. While waiting, onWait is called about every
+// second with the time spent waiting so far. The returned function must be called when the execution is finished.
+func (l *Limiter) Acquire(ctx context.Context, onWait func(waited time.Duration)) (release func(), err error) {
+	start := time.Now()
+	queued := false
+	defer func() {
+		if queued {
+			l.mutex.Lock()
+			l.waiting--
+			l.mutex.Unlock()
+		}
+	}()
+
+	for {
+		l.mutex.Lock()
+		if l.running < max(l.limit(), 1) {
+			l.running++
+			l.mutex.Unlock()
+			once := sync.Once{}
+			return func() { once.Do(l.release) }, nil
+		}
+		if !queued {
+			queued = true
+			l.waiting++
+		}
+		released := l.released
+		l.mutex.Unlock()
+
+		select {
+		case <-released:
+		case <-time.After(limiterPollInterval):
+			if onWait != nil {
+				onWait(time.Since(start))
+			}
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (l *Limiter) release() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.running--
+	close(l.released)
+	l.released = make(chan struct{})
+}
+
+// Running returns the number of executions currently running
+func (l *Limiter) Running() int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.running
+}
+
+// Waiting returns the number of executions currently waiting
+func (l *Limiter) Waiting() int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.waiting
+}
@@ -0,0 +1,76 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package concurrency
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Limiter_BlocksWhenLimitIsReached(t *testing.T) {
+	limiter := NewLimiter(func() int { return 1 })
+	release, err := limiter.Acquire(context.Background(), nil)
+	require.NoError(t, err)
+
+	acquired := make(chan func())
+	go func() {
+		secondRelease, _ := limiter.Acquire(context.Background(), nil)
+		acquired <- secondRelease
+	}()
+
+	assert.Eventually(t, func() bool { return limiter.Waiting() == 1 }, time.Second, 10*time.Millisecond)
+	release()
+	release() // releasing twice must not free a second slot
+
+	secondRelease := <-acquired
+	assert.Equal(t, 1, limiter.Running())
+	assert.Equal(t, 0, limiter.Waiting())
+	secondRelease()
+	assert.Equal(t, 0, limiter.Running())
+}
+
+func Test_Limiter_ReadsLimitOnEveryAcquisition(t *testing.T) {
+	limit := 1
+	limiter := NewLimiter(func() int { return limit })
+	_, err := limiter.Acquire(context.Background(), nil)
+	require.NoError(t, err)
+
+	limit = 2
+	_, err = limiter.Acquire(context.Background(), nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, limiter.Running())
+}
+
+func Test_Limiter_StopsWaitingWhenContextIsDone(t *testing.T) {
+	limiter := NewLimiter(func() int { return 1 })
+	_, err := limiter.Acquire(context.Background(), nil)
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 1500*time.Millisecond)
+	defer cancel()
+
+	var waited time.Duration
+	_, err = limiter.Acquire(ctx, func(w time.Duration) { waited = w })
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.GreaterOrEqual(t, waited, time.Second, "waiting callers are told how long they wait")
+	assert.Equal(t, 0, limiter.Waiting())
+}
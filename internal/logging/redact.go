@@ -0,0 +1,106 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logging
+
+import (
+	"math"
+	"regexp"
+	"unicode"
+)
+
+const redacted = "***"
+
+// redactionPattern matches a secret. The text of the groups named "keep" and "tail" is kept, e.g. the name of a
+// header or variable, and the rest of the match is replaced.
+type redactionPattern struct {
+	name   string
+	regexp *regexp.Regexp
+}
+
+// tokenPatterns match well-known token formats and places where credentials are used, so they are redacted even
+// if they are not in the scrubbing dictionary, e.g. tokens of other tools or of a previous session.
+var tokenPatterns = []redactionPattern{
+	{"private key", regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?(?:-----END [A-Z ]*PRIVATE KEY-----|$)`)},
+	{"authorization header", regexp.MustCompile(`(?i)(?P<keep>authorization"?\s*[:=]\s*"?(?:bearer|token|basic)?\s*)[^\s",}\]]+`)},
+	{"token in json", regexp.MustCompile(
+		`(?i)(?P<keep>"(?:access_token|refresh_token|id_token|token|api_?key|password|secret|client_secret)"\s*:\s*")[^"]+`)},
+	{"secret variable", regexp.MustCompile(
+		`(?i)(?P<keep>\b(?:[A-Z0-9_]*(?:TOKEN|SECRET|PASSWORD|PASSWD|API_?KEY|CREDENTIALS?|PRIVATE_KEY)[A-Z0-9_]*|[A-Z0-9_]*_AUTH)=)[^\s",;]+`)},
+	{"url credentials", regexp.MustCompile(`(?P<keep>[a-zA-Z][a-zA-Z0-9+.-]*://[^:/@\s]+:)[^@/\s]+(?P<tail>@)`)},
+	{"json web token", regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)},
+	{"github token", regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})`)},
+	{"gitlab token", regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20,}`)},
+	{"slack token", regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`)},
+	{"aws access key", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"google api key", regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}`)},
+	{"npm token", regexp.MustCompile(`\bnpm_[A-Za-z0-9]{36}`)},
+	{"stripe key", regexp.MustCompile(`\b(?:sk|rk)_(?:live|test)_[A-Za-z0-9]{16,}`)},
+}
+
+const (
+	// minEntropyCandidateLength is the minimum length of a word that is considered as random secret
+	minEntropyCandidateLength = 20
+	// minSecretEntropy is the Shannon entropy (bits per character) above which a word is considered random
+	minSecretEntropy = 3.5
+)
+
+// entropyCandidate matches words that can be tokens, i.e. base62 or url-safe base64 strings. Slashes are not part
+// of a word, so paths are not mistaken for tokens.
+var entropyCandidate = regexp.MustCompile(`[A-Za-z0-9+_=-]{20,}`)
+
+// Redact removes the terms of the scrubbing dictionary, well-known token formats and random looking words from s.
+func Redact(s string, scrubDict map[string]bool) string {
+	s = scrubTerms(s, scrubDict)
+	for _, pattern := range tokenPatterns {
+		s = pattern.regexp.ReplaceAllString(s, "${keep}"+redacted+"${tail}")
+	}
+	return entropyCandidate.ReplaceAllStringFunc(s, func(word string) string {
+		if looksRandom(word) {
+			return redacted
+		}
+		return word
+	})
+}
+
+// looksRandom returns true for words with upper case letters, lower case letters and digits and a high entropy,
+// which excludes identifiers, paths, hex hashes and UUIDs
+func looksRandom(word string) bool {
+	if len(word) < minEntropyCandidateLength {
+		return false
+	}
+	var upper, lower, digit bool
+	for _, r := range word {
+		upper = upper || unicode.IsUpper(r)
+		lower = lower || unicode.IsLower(r)
+		digit = digit || unicode.IsDigit(r)
+	}
+	return upper && lower && digit && shannonEntropy(word) >= minSecretEntropy
+}
+
+func shannonEntropy(s string) float64 {
+	counts := map[rune]int{}
+	for _, r := range s {
+		counts[r]++
+	}
+	entropy := 0.0
+	length := float64(len(s))
+	for _, count := range counts {
+		p := float64(count) / length
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
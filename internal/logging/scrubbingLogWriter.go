@@ -42,10 +42,14 @@ func (w *scrubbingWriter) Write(p []byte) (n int, err error) {
 	return w.writer.Write(w.scrub(p))
 }
 
+// scrub removes the terms of the dictionary and anything that looks like a secret, see Redact
 func (w *scrubbingWriter) scrub(p []byte) []byte {
-	s := string(p)
-	for term := range w.scrubDict {
+	return []byte(Redact(string(p), w.scrubDict))
+}
+
+func scrubTerms(s string, scrubDict map[string]bool) string {
+	for term := range scrubDict {
 		s = strings.Replace(s, term, "***", -1)
 	}
-	return []byte(s)
+	return s
 }
@@ -64,3 +64,13 @@ func TestScrubbingWriter_WriteLevel(t *testing.T) {
 
 	require.NotContainsf(t, mockWriter.written, "password", "password should be scrubbed")
 }
+
+func TestScrubbingWriter_RedactsTokenPatterns(t *testing.T) {
+	mockWriter := &mockWriter{}
+
+	writer := NewScrubbingWriter(mockWriter, map[string]bool{})
+
+	_, _ = writer.Write([]byte("Authorization: Bearer abc"))
+
+	require.Equal(t, "Authorization: Bearer ***", string(mockWriter.written))
+}
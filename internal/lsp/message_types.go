@@ -469,6 +469,7 @@ type WorkspaceFoldersServerCapabilities struct {
 type FileOperationsServerCapabilities struct {
 	WillDeleteBool bool                             `json:"willDeleteBool,omitempty"`
 	WillDelete     FileOperationRegistrationOptions `json:"willDelete,omitempty"`
+	DidRename      FileOperationRegistrationOptions `json:"didRename,omitempty"`
 }
 
 type FileOperationPattern struct {
@@ -491,6 +492,32 @@ type FileDelete struct {
 	Uri sglsp.DocumentURI `json:"uri,omitempty"`
 }
 
+/**
+ * The parameters sent in notifications/requests for user-initiated renames of
+ * files.
+ *
+ * @since 3.16.0
+ */
+type RenameFilesParams struct {
+	/**
+	 * An array of all files/folders renamed in this operation. When a folder
+	 * is renamed, only the folder will be included, and not its children.
+	 */
+	Files []FileRename `json:"files,omitempty"`
+}
+
+type FileRename struct {
+	/**
+	 * A file:// URI for the original location of the file/folder being renamed.
+	 */
+	OldUri sglsp.DocumentURI `json:"oldUri,omitempty"`
+
+	/**
+	 * A file:// URI for the new location of the file/folder being renamed.
+	 */
+	NewUri sglsp.DocumentURI `json:"newUri,omitempty"`
+}
+
 type WorkspaceFolder struct {
 	// The associated Uri for this workspace folder.
 	Uri sglsp.DocumentURI `json:"uri,omitempty"`
@@ -529,6 +556,24 @@ type Settings struct {
 	EnableTelemetry             string               `json:"enableTelemetry,omitempty"`
 	ManageBinariesAutomatically string               `json:"manageBinariesAutomatically,omitempty"`
 	CliPath                     string               `json:"cliPath,omitempty"`
+	CliConcurrency              string               `json:"cliConcurrency,omitempty"`
+	OssScanTimeout              string               `json:"ossScanTimeout,omitempty"`
+	IacScanTimeout              string               `json:"iacScanTimeout,omitempty"`
+	CliReleaseSource            string               `json:"cliReleaseSource,omitempty"`
+	CliVersion                  string               `json:"cliVersion,omitempty"`
+	CliReleaseChannel           string               `json:"cliReleaseChannel,omitempty"`
+	CliEnvAllowlist             string               `json:"cliEnvAllowlist,omitempty"`
+	CliEnvDenylist              string               `json:"cliEnvDenylist,omitempty"`
+	FolderEnv                   map[string]string    `json:"folderEnv,omitempty"`
+	FolderCliVersion            map[string]string    `json:"folderCliVersion,omitempty"`
+	CliResultCacheTtl           string               `json:"cliResultCacheTtl,omitempty"`
+	CliOptions                  CliOptions           `json:"cliOptions,omitempty"`
+	TracingEndpoint             string               `json:"tracingEndpoint,omitempty"`
+	TracingFile                 string               `json:"tracingFile,omitempty"`
+	MetricsEndpoint             string               `json:"metricsEndpoint,omitempty"`
+	AuditLogPath                string               `json:"auditLogPath,omitempty"`
+	OfflineMode                 string               `json:"offlineMode,omitempty"`
+	RpcTraceFile                string               `json:"rpcTraceFile,omitempty"`
 	Token                       string               `json:"token,omitempty"`
 	IntegrationName             string               `json:"integrationName,omitempty"`
 	IntegrationVersion          string               `json:"integrationVersion,omitempty"`
@@ -550,6 +595,16 @@ type Settings struct {
 	EnableAnalytics             bool                 `json:"enableAnalytics,omitempty"`
 }
 
+// CliOptions are typed options of Open Source and IaC scans that are passed to the CLI as flags
+type CliOptions struct {
+	AllProjects       bool     `json:"allProjects,omitempty"`
+	Dev               bool     `json:"dev,omitempty"`
+	DetectionDepth    int      `json:"detectionDepth,omitempty"`
+	Exclude           []string `json:"exclude,omitempty"`
+	SeverityThreshold string   `json:"severityThreshold,omitempty"`
+	Command           string   `json:"command,omitempty"`
+}
+
 type AuthenticationMethod string
 
 const TokenAuthentication AuthenticationMethod = "token"
@@ -1000,10 +1055,27 @@ type LogTraceParams struct {
 	Verbose string `json:"verbose"`
 }
 
+const (
+	TraceOff      sglsp.Trace = "off"
+	TraceMessages sglsp.Trace = "messages"
+	TraceVerbose  sglsp.Trace = "verbose"
+)
+
+type SetTraceParams struct {
+	/**
+	 * The new value that should be assigned to the trace setting.
+	 */
+	Value sglsp.Trace `json:"value"`
+}
+
 type VulnmapTrustedFoldersParams struct {
 	TrustedFolders []string `json:"trustedFolders"`
 }
 
+type VulnmapOrganizationParams struct {
+	Organization string `json:"organization"`
+}
+
 type ScanStatus string
 
 const (
@@ -1024,15 +1096,84 @@ type VulnmapScanParams struct {
 	Issues []ScanIssue `json:"issues"`
 }
 
+// VulnmapSummaryParams is the type for the $/vulnmap.summary message, it summarizes the cached issues of a folder
+type VulnmapSummaryParams struct {
+	// FolderPath is the root-folder the summary is about
+	FolderPath string `json:"folderPath"`
+	// Products contains a summary for each product that has issues or has been scanned successfully
+	Products []ProductSummary `json:"products"`
+}
+
+type ProductSummary struct {
+	// Product is the product codename (code, oss, iac)
+	Product       string        `json:"product"`
+	SeverityCount SeverityCount `json:"severityCount"`
+	Total         int           `json:"total"`
+	// Fixable is the number of issues that can be fixed by an upgrade, a patch or an autofix
+	Fixable int `json:"fixable"`
+	// Ignored is the number of issues that are ignored
+	Ignored int `json:"ignored"`
+	// LastSuccessfulScan is the time the last successful scan of the product finished (RFC 3339), empty if never
+	LastSuccessfulScan string `json:"lastSuccessfulScan,omitempty"`
+}
+
+type SeverityCount struct {
+	Critical int `json:"critical"`
+	High     int `json:"high"`
+	Medium   int `json:"medium"`
+	Low      int `json:"low"`
+}
+
 type ScanIssue struct { // TODO - convert this to a generic type
 	// Unique key identifying an issue in the whole result set. Not the same as the Vulnmap issue ID.
 	Id             string `json:"id"`
 	Title          string `json:"title"`
 	Severity       string `json:"severity"`
 	FilePath       string `json:"filePath"`
+	IsIgnored      bool   `json:"isIgnored"`
 	AdditionalData any    `json:"additionalData,omitempty"`
 }
 
+const (
+	IssuesSortBySeverity = "severity"
+	IssuesSortByPriority = "priority"
+)
+
+// IssuesParams is the type for the vulnmap/issues request. All filters are optional, an empty filter matches all issues.
+type IssuesParams struct {
+	// FolderPath restricts the query to a single workspace folder
+	FolderPath string `json:"folderPath,omitempty"`
+	// Products restricts the result to the given product codenames (oss, code, iac)
+	Products []string `json:"products,omitempty"`
+	// Severities restricts the result to the given severities (critical, high, medium, low)
+	Severities []string `json:"severities,omitempty"`
+	// FileGlob is a gitignore-style pattern matched against the file path relative to its workspace folder
+	FileGlob string `json:"fileGlob,omitempty"`
+	// CWEs restricts the result to issues with at least one of the given CWEs, e.g. CWE-79
+	CWEs []string `json:"cwes,omitempty"`
+	// CVEs restricts the result to issues with at least one of the given CVEs
+	CVEs []string `json:"cves,omitempty"`
+	// Fixable restricts the result to issues that can (true) or cannot (false) be fixed
+	Fixable *bool `json:"fixable,omitempty"`
+	// Ignored restricts the result to issues that are (true) or are not (false) ignored
+	Ignored *bool `json:"ignored,omitempty"`
+	// SortBy is either severity (default) or priority
+	SortBy string `json:"sortBy,omitempty"`
+	// Cursor is the NextCursor of the previous page, empty for the first page
+	Cursor string `json:"cursor,omitempty"`
+	// Limit is the maximum page size, defaults to 100
+	Limit int `json:"limit,omitempty"`
+}
+
+// IssuesResult is the response to the vulnmap/issues request
+type IssuesResult struct {
+	Issues []ScanIssue `json:"issues"`
+	// Total is the number of issues matching the filter across all pages
+	Total int `json:"total"`
+	// NextCursor is passed to the next request to get the next page, it is empty on the last page
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
 // Vulnmap Open Source
 type OssIssueData struct {
 	License           string         `json:"license,omitempty"`
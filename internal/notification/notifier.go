@@ -1,6 +1,7 @@
 package notification
 
 import (
+	"errors"
 	"fmt"
 
 	sglsp "github.com/sourcegraph/go-lsp"
@@ -38,16 +39,37 @@ func (n *notifierImpl) SendError(err error) {
 }
 
 func (n *notifierImpl) SendErrorDiagnostic(path string, err error) {
-	n.Send(lsp.PublishDiagnosticsParams{
+	n.Send(errorDiagnosticParams(path, err))
+}
+
+// diagnosticCodeError is implemented by errors that are shown with their own diagnostic code, e.g. to offer code
+// actions for them
+type diagnosticCodeError interface {
+	error
+	DiagnosticCode() string
+	// DiagnosticData returns additional data that the client sends back when requesting code actions
+	DiagnosticData() any
+}
+
+func errorDiagnosticParams(path string, err error) lsp.PublishDiagnosticsParams {
+	code := "Vulnmap Error"
+	var data any
+	var codeError diagnosticCodeError
+	if errors.As(err, &codeError) {
+		code = codeError.DiagnosticCode()
+		data = codeError.DiagnosticData()
+	}
+	return lsp.PublishDiagnosticsParams{
 		URI: uri.PathToUri(path),
 		Diagnostics: []lsp.Diagnostic{{
 			Range:           sglsp.Range{},
 			Severity:        lsp.DiagnosticsSeverityWarning,
-			Code:            "Vulnmap Error",
+			Code:            code,
 			CodeDescription: lsp.CodeDescription{Href: "https://vulnmap.khulnasoft.com/user-hub"},
 			Message:         err.Error(),
+			Data:            data,
 		}},
-	})
+	}
 }
 
 func (n *notifierImpl) Receive() (payload any, stop bool) {
@@ -6,8 +6,6 @@ import (
 	sglsp "github.com/sourcegraph/go-lsp"
 
 	"github.com/khulnasoft-lab/vulnmap-ls/domain/ide/notification"
-	"github.com/khulnasoft-lab/vulnmap-ls/internal/lsp"
-	"github.com/khulnasoft-lab/vulnmap-ls/internal/uri"
 )
 
 var _ notification.Notifier = &MockNotifier{}
@@ -64,17 +62,7 @@ func (m *MockNotifier) SendError(err error) {
 
 func (m *MockNotifier) SendErrorDiagnostic(path string, err error) {
 	m.sendErrorDiagnosticCounter++
-	msg := lsp.PublishDiagnosticsParams{
-		URI: uri.PathToUri(path),
-		Diagnostics: []lsp.Diagnostic{{
-			Range:           sglsp.Range{},
-			Severity:        lsp.DiagnosticsSeverityWarning,
-			Code:            "Vulnmap Error",
-			CodeDescription: lsp.CodeDescription{Href: "https://vulnmap.khulnasoft.com/user-hub"},
-			Message:         err.Error(),
-		}},
-	}
-	m.sentMessages = append(m.sentMessages, msg)
+	m.sentMessages = append(m.sentMessages, errorDiagnosticParams(path, err))
 }
 
 func (m *MockNotifier) SendShowMessageCount() int { return m.sendShowMessageCounter }
@@ -0,0 +1,302 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package progress
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/product"
+)
+
+const (
+	// defaultStepEstimate is used for steps without a recorded duration
+	defaultStepEstimate = 30 * time.Second
+	// maxRunningStepFraction keeps running steps from being reported as complete when they take longer than estimated
+	maxRunningStepFraction = 0.95
+)
+
+var productLabels = map[product.Product]string{
+	product.ProductOpenSource:           "OSS",
+	product.ProductCode:                 "Code",
+	product.ProductInfrastructureAsCode: "IaC",
+	product.ProductContainer:            "Container",
+}
+
+type stepKey struct {
+	folderPath string
+	product    product.Product
+}
+
+type aggregateStep struct {
+	estimate time.Duration
+	started  time.Time
+	// position is the 1-based order in which the step was started, 0 if it has not been started yet
+	position int
+	fraction float64
+	done     bool
+	// message is the last message reported for the step, e.g. that it waits for other scans
+	message string
+}
+
+// Aggregate combines the progress of many steps, i.e. every product scanning every workspace folder, into a single
+// progress reported to the client. Steps are weighted by their estimated duration taken from the DurationHistory.
+type Aggregate struct {
+	mutex        sync.Mutex
+	tracker      *Tracker
+	title        string
+	history      *DurationHistory
+	steps        map[stepKey]*aggregateStep
+	order        []stepKey
+	startedSteps int
+	begin        time.Time
+	ended        bool
+	stop         chan bool
+	now          func() time.Time
+}
+
+func NewAggregate(title string, history *DurationHistory) *Aggregate {
+	return newAggregate(NewTracker(false), title, history)
+}
+
+func newAggregate(tracker *Tracker, title string, history *DurationHistory) *Aggregate {
+	if history == nil {
+		history = NewDurationHistory("")
+	}
+	return &Aggregate{
+		tracker: tracker,
+		title:   title,
+		history: history,
+		steps:   map[stepKey]*aggregateStep{},
+		stop:    make(chan bool),
+		now:     time.Now,
+	}
+}
+
+// AddStep registers a product scan of a folder, so that it is part of the total before it starts
+func (a *Aggregate) AddStep(folderPath string, p product.Product) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.addStep(stepKey{folderPath: folderPath, product: p})
+}
+
+func (a *Aggregate) addStep(key stepKey) *aggregateStep {
+	if step, ok := a.steps[key]; ok {
+		return step
+	}
+	estimate, ok := a.history.Estimate(key.folderPath, string(key.product))
+	if !ok || estimate <= 0 {
+		estimate = defaultStepEstimate
+	}
+	step := &aggregateStep{estimate: estimate}
+	a.steps[key] = step
+	a.order = append(a.order, key)
+	return step
+}
+
+// Begin sends the begin progress to the client and keeps reporting the estimated progress until End is called
+func (a *Aggregate) Begin() {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.begin = a.now()
+	a.tracker.BeginWithMessage(a.title, "")
+
+	go func() {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				a.report()
+			case <-a.stop:
+				return
+			}
+		}
+	}()
+}
+
+// StartStep marks a product scan of a folder as running. Unknown steps are added.
+func (a *Aggregate) StartStep(folderPath string, p product.Product) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	step := a.addStep(stepKey{folderPath: folderPath, product: p})
+	if step.position == 0 {
+		a.startedSteps++
+		step.position = a.startedSteps
+	}
+	step.started = a.now()
+	a.reportLocked()
+}
+
+// ReportStep passes on the progress and the message a product reports for a step
+func (a *Aggregate) ReportStep(folderPath string, p product.Product, percentage int, message string) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	step, ok := a.steps[stepKey{folderPath: folderPath, product: p}]
+	if !ok || step.done {
+		return
+	}
+	step.fraction = max(step.fraction, min(float64(percentage)/100, maxRunningStepFraction))
+	step.message = message
+	a.reportLocked()
+}
+
+// FinishStep marks a step as done and records its duration for future estimates
+func (a *Aggregate) FinishStep(folderPath string, p product.Product) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	step, ok := a.steps[stepKey{folderPath: folderPath, product: p}]
+	if !ok || step.done {
+		return
+	}
+	step.done = true
+	step.fraction = 1
+	if !step.started.IsZero() {
+		a.history.Record(folderPath, string(p), a.now().Sub(step.started))
+	}
+	a.reportLocked()
+}
+
+// End ends the progress on the client, it can safely be called more than once
+func (a *Aggregate) End() {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if a.ended {
+		return
+	}
+	a.ended = true
+	close(a.stop)
+	a.tracker.EndWithMessage("Vulnmap workspace scan completed.")
+}
+
+func (a *Aggregate) report() {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.reportLocked()
+}
+
+func (a *Aggregate) reportLocked() {
+	if a.begin.IsZero() || a.ended {
+		return
+	}
+	a.tracker.ReportWithMessage(a.percentage(), a.message())
+}
+
+// percentage returns the progress weighted by the estimated duration of each step. It never reaches 100 before End.
+func (a *Aggregate) percentage() int {
+	return min(int(a.fraction()*100), 99)
+}
+
+func (a *Aggregate) fraction() float64 {
+	var total, completed float64
+	for _, key := range a.order {
+		step := a.steps[key]
+		weight := step.estimate.Seconds()
+		total += weight
+		completed += weight * a.stepFraction(step)
+	}
+	if total == 0 {
+		return 0
+	}
+	return completed / total
+}
+
+func (a *Aggregate) stepFraction(step *aggregateStep) float64 {
+	if step.done || step.position == 0 {
+		return step.fraction
+	}
+	elapsed := a.now().Sub(step.started)
+	return max(step.fraction, min(elapsed.Seconds()/step.estimate.Seconds(), maxRunningStepFraction))
+}
+
+// message describes the last started running step (e.g. "OSS: folder api (2/5)") and the estimated remaining time
+func (a *Aggregate) message() string {
+	var current *stepKey
+	for i := range a.order {
+		step := a.steps[a.order[i]]
+		if step.position > 0 && !step.done && (current == nil || step.position > a.steps[*current].position) {
+			current = &a.order[i]
+		}
+	}
+	if current == nil {
+		return ""
+	}
+
+	label, ok := productLabels[current.product]
+	if !ok {
+		label = string(current.product)
+	}
+	step := a.steps[*current]
+	message := fmt.Sprintf("%s: folder %s (%d/%d)", label, filepath.Base(current.folderPath), step.position, len(a.order))
+	if step.message != "" {
+		message += ", " + step.message
+	}
+
+	if remaining, ok := a.remaining(); ok {
+		message += fmt.Sprintf(", about %s remaining", remaining)
+	}
+	return message
+}
+
+// remaining extrapolates the remaining time from the elapsed time and the weighted progress, as steps run in parallel
+func (a *Aggregate) remaining() (time.Duration, bool) {
+	fraction := a.fraction()
+	elapsed := a.now().Sub(a.begin)
+	if fraction <= 0 || elapsed <= 0 {
+		return 0, false
+	}
+	remaining := time.Duration(float64(elapsed) * (1 - fraction) / fraction)
+	return remaining.Round(time.Second), true
+}
+
+type aggregateContextKey struct{}
+type stepContextKey struct{}
+
+// WithAggregate returns a context that makes scans report their progress to the given aggregate
+func WithAggregate(ctx context.Context, aggregate *Aggregate) context.Context {
+	return context.WithValue(ctx, aggregateContextKey{}, aggregate)
+}
+
+// AggregateFromContext returns the aggregate of the context, nil if there's none
+func AggregateFromContext(ctx context.Context) *Aggregate {
+	aggregate, _ := ctx.Value(aggregateContextKey{}).(*Aggregate)
+	return aggregate
+}
+
+// WithStep returns a context that binds trackers created by NewTrackerFromContext to the step of the aggregate
+func WithStep(ctx context.Context, folderPath string, p product.Product) context.Context {
+	if AggregateFromContext(ctx) == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, stepContextKey{}, stepKey{folderPath: folderPath, product: p})
+}
+
+// NewTrackerFromContext returns a tracker that reports to the aggregated progress of the step bound to the context.
+// Without a step it returns a tracker that reports to the client like NewTracker.
+func NewTrackerFromContext(ctx context.Context, cancellable bool) *Tracker {
+	tracker := NewTracker(cancellable)
+	aggregate := AggregateFromContext(ctx)
+	key, ok := ctx.Value(stepContextKey{}).(stepKey)
+	if aggregate != nil && ok {
+		tracker.aggregate = aggregate
+		tracker.step = key
+	}
+	return tracker
+}
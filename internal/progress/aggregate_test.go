@@ -0,0 +1,148 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package progress
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/lsp"
+	"github.com/khulnasoft-lab/vulnmap-ls/internal/product"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func newTestAggregate(t *testing.T, history *DurationHistory) (*Aggregate, *fakeClock, chan lsp.ProgressParams) {
+	t.Helper()
+	channel := make(chan lsp.ProgressParams, 100)
+	clock := &fakeClock{now: time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)}
+	aggregate := newAggregate(NewTestTracker(channel, make(chan lsp.ProgressToken, 1)), "Scanning", history)
+	aggregate.now = clock.Now
+	t.Cleanup(aggregate.End)
+	return aggregate, clock, channel
+}
+
+func Test_Aggregate_WeightsStepsByHistoricalDuration(t *testing.T) {
+	history := NewDurationHistory("")
+	history.Record("/api", string(product.ProductOpenSource), 30*time.Second)
+	history.Record("/web", string(product.ProductOpenSource), 90*time.Second)
+	aggregate, clock, _ := newTestAggregate(t, history)
+	aggregate.AddStep("/api", product.ProductOpenSource)
+	aggregate.AddStep("/web", product.ProductOpenSource)
+	aggregate.Begin()
+
+	aggregate.StartStep("/api", product.ProductOpenSource)
+	clock.now = clock.now.Add(30 * time.Second)
+	aggregate.FinishStep("/api", product.ProductOpenSource)
+
+	assert.Equal(t, 25, aggregate.percentage())
+}
+
+func Test_Aggregate_MessageShowsRunningStepAndRemainingTime(t *testing.T) {
+	aggregate, clock, _ := newTestAggregate(t, nil)
+	aggregate.AddStep("/workspace/api", product.ProductCode)
+	aggregate.AddStep("/workspace/api", product.ProductOpenSource)
+	aggregate.Begin()
+
+	aggregate.StartStep("/workspace/api", product.ProductCode)
+	aggregate.StartStep("/workspace/api", product.ProductOpenSource)
+	clock.now = clock.now.Add(15 * time.Second)
+
+	// both steps are estimated with 30s and run for 15s, so half of the work is done after 15s
+	assert.Equal(t, 50, aggregate.percentage())
+	assert.Equal(t, "OSS: folder api (2/2), about 15s remaining", aggregate.message())
+}
+
+func Test_Aggregate_RunningStepsNeverComplete(t *testing.T) {
+	aggregate, clock, _ := newTestAggregate(t, nil)
+	aggregate.AddStep("/api", product.ProductInfrastructureAsCode)
+	aggregate.Begin()
+
+	aggregate.StartStep("/api", product.ProductInfrastructureAsCode)
+	clock.now = clock.now.Add(time.Hour)
+
+	assert.Equal(t, 95, aggregate.percentage())
+}
+
+func Test_Aggregate_UsesProgressReportedByProductTrackers(t *testing.T) {
+	aggregate, _, channel := newTestAggregate(t, nil)
+	aggregate.AddStep("/api", product.ProductCode)
+	aggregate.Begin()
+	<-channel // create
+	<-channel // begin
+	aggregate.StartStep("/api", product.ProductCode)
+
+	ctx := WithStep(WithAggregate(context.Background(), aggregate), "/api", product.ProductCode)
+	tracker := NewTrackerFromContext(ctx, false)
+	tracker.BeginWithMessage("Vulnmap Code analysis", "")
+	tracker.Report(60)
+	tracker.End()
+
+	assert.Equal(t, 60, aggregate.percentage())
+	assert.Empty(t, channel, "product trackers must not report to the client")
+}
+
+func Test_NewTrackerFromContext_WithoutStepReportsToClient(t *testing.T) {
+	tracker := NewTrackerFromContext(context.Background(), false)
+
+	assert.Nil(t, tracker.aggregate)
+	assert.Equal(t, Channel, tracker.channel)
+}
+
+func Test_Aggregate_EndRecordsDurationsAndEndsProgress(t *testing.T) {
+	historyFile := filepath.Join(t.TempDir(), "durations.json")
+	aggregate, clock, channel := newTestAggregate(t, NewDurationHistory(historyFile))
+	aggregate.Begin()
+	aggregate.StartStep("/api", product.ProductOpenSource) // steps that weren't added upfront are added on start
+	clock.now = clock.now.Add(20 * time.Second)
+	aggregate.FinishStep("/api", product.ProductOpenSource)
+	aggregate.End()
+	aggregate.End()
+
+	estimate, ok := NewDurationHistory(historyFile).Estimate("/api", string(product.ProductOpenSource))
+	assert.True(t, ok)
+	assert.Equal(t, 20*time.Second, estimate)
+
+	var last lsp.ProgressParams
+	for len(channel) > 0 {
+		last = <-channel
+	}
+	assert.Equal(t, "end", last.Value.(lsp.WorkDoneProgressEnd).Kind)
+}
+
+func Test_Aggregate_MessageContainsMessageOfStep(t *testing.T) {
+	aggregate, _, _ := newTestAggregate(t, nil)
+	aggregate.Begin()
+	aggregate.StartStep("/workspace/api", product.ProductOpenSource)
+	ctx := WithStep(WithAggregate(context.Background(), aggregate), "/workspace/api", product.ProductOpenSource)
+	tracker := NewTrackerFromContext(ctx, false)
+	tracker.BeginUnquantifiableLength("Waiting for the Vulnmap CLI", "")
+
+	tracker.ReportWithMessage(0, "waiting for the Vulnmap CLI for 12s")
+	assert.Equal(t, "OSS: folder api (1/1), waiting for the Vulnmap CLI for 12s", aggregate.message())
+
+	tracker.End()
+	assert.Equal(t, "OSS: folder api (1/1)", aggregate.message())
+}
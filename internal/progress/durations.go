@@ -0,0 +1,124 @@
+/*
+ * © 2023 Khulnasoft Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package progress
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// DurationHistory remembers how long a step (e.g. a product scanning a folder) took in the past, to estimate how
+// long it will take next time. If a file path is given, the history is persisted across restarts.
+type DurationHistory struct {
+	mutex     sync.Mutex
+	filePath  string
+	loaded    bool
+	durations map[string]time.Duration
+}
+
+func NewDurationHistory(filePath string) *DurationHistory {
+	return &DurationHistory{
+		filePath:  filePath,
+		durations: map[string]time.Duration{},
+	}
+}
+
+// Estimate returns the expected duration of the step, false if the step has never been recorded
+func (h *DurationHistory) Estimate(folderPath string, productName string) (time.Duration, bool) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.load()
+	duration, ok := h.durations[historyKey(folderPath, productName)]
+	return duration, ok
+}
+
+// Record adds the duration of a finished step to the history. Older durations are weighted less, so estimates
+// follow a growing code base.
+func (h *DurationHistory) Record(folderPath string, productName string, duration time.Duration) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.load()
+	key := historyKey(folderPath, productName)
+	if previous, ok := h.durations[key]; ok {
+		duration = (previous + duration) / 2
+	}
+	h.durations[key] = duration
+	h.save()
+}
+
+// All returns the recorded durations by folder and product
+func (h *DurationHistory) All() map[string]time.Duration {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.load()
+	durations := make(map[string]time.Duration, len(h.durations))
+	for key, duration := range h.durations {
+		durations[key] = duration
+	}
+	return durations
+}
+
+func (h *DurationHistory) load() {
+	if h.loaded || h.filePath == "" {
+		return
+	}
+	h.loaded = true
+	data, err := os.ReadFile(h.filePath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Warn().Err(err).Str("method", "DurationHistory.load").Msg("couldn't read scan duration history")
+		}
+		return
+	}
+	var durationsMs map[string]int64
+	if err = json.Unmarshal(data, &durationsMs); err != nil {
+		log.Warn().Err(err).Str("method", "DurationHistory.load").Msg("couldn't parse scan duration history")
+		return
+	}
+	for key, ms := range durationsMs {
+		h.durations[key] = time.Duration(ms) * time.Millisecond
+	}
+}
+
+func (h *DurationHistory) save() {
+	if h.filePath == "" {
+		return
+	}
+	durationsMs := make(map[string]int64, len(h.durations))
+	for key, duration := range h.durations {
+		durationsMs[key] = duration.Milliseconds()
+	}
+	data, err := json.Marshal(durationsMs)
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(h.filePath), 0755)
+	}
+	if err == nil {
+		err = os.WriteFile(h.filePath, data, 0644)
+	}
+	if err != nil {
+		log.Warn().Err(err).Str("method", "DurationHistory.save").Msg("couldn't persist scan duration history")
+	}
+}
+
+func historyKey(folderPath string, productName string) string {
+	return folderPath + "|" + productName
+}
@@ -35,7 +35,11 @@ type Tracker struct {
 	cancellable          bool
 	lastReport           time.Time
 	lastReportPercentage int
+	lastReportMessage    string
 	finished             bool
+	// aggregate receives the reports instead of the client, if the tracker is part of an aggregated progress
+	aggregate *Aggregate
+	step      stepKey
 }
 
 func NewTestTracker(channel chan lsp.ProgressParams, cancelChannel chan lsp.ProgressToken) *Tracker {
@@ -63,6 +67,9 @@ func (t *Tracker) BeginUnquantifiableLength(title, message string) {
 }
 
 func (t *Tracker) begin(title string, message string, unquantifiableLength bool) {
+	if t.aggregate != nil {
+		return
+	}
 	params := newProgressParams(title, message, t.cancellable, unquantifiableLength)
 	t.token = params.Token
 
@@ -83,8 +90,15 @@ func (t *Tracker) BeginWithMessage(title, message string) {
 	t.begin(title, message, false)
 }
 
+// ReportWithMessage sends the progress at most once a second. Reports are dropped if the percentage decreases, or if
+// neither the percentage nor the message changed.
 func (t *Tracker) ReportWithMessage(percentage int, message string) {
-	if time.Now().Before(t.lastReport.Add(time.Second)) || percentage <= t.lastReportPercentage {
+	if t.aggregate != nil {
+		t.aggregate.ReportStep(t.step.folderPath, t.step.product, percentage, message)
+		return
+	}
+	unchanged := percentage == t.lastReportPercentage && message == t.lastReportMessage
+	if time.Now().Before(t.lastReport.Add(time.Second)) || percentage < t.lastReportPercentage || unchanged {
 		return
 	}
 	progress := lsp.ProgressParams{
@@ -98,6 +112,7 @@ func (t *Tracker) ReportWithMessage(percentage int, message string) {
 	t.send(progress)
 	t.lastReport = time.Now()
 	t.lastReportPercentage = percentage
+	t.lastReportMessage = message
 }
 
 func (t *Tracker) Report(percentage int) {
@@ -113,6 +128,11 @@ func (t *Tracker) EndWithMessage(message string) {
 		panic("Called end progress twice. This breaks LSP in Eclipse fix me now and avoid headaches later")
 	}
 	t.finished = true
+	if t.aggregate != nil {
+		// the step is finished by the scanner running it, only the message of this tracker is removed
+		t.aggregate.ReportStep(t.step.folderPath, t.step.product, 0, "")
+		return
+	}
 	progress := lsp.ProgressParams{
 		Token: t.token,
 		Value: lsp.WorkDoneProgressEnd{
@@ -18,8 +18,10 @@ package progress
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/khulnasoft-lab/vulnmap-ls/internal/lsp"
 )
@@ -72,6 +74,24 @@ func TestReportProgress(t *testing.T) {
 	assert.Equal(t, output, <-channel)
 }
 
+func TestReportProgress_SendsChangedMessages(t *testing.T) {
+	channel := make(chan lsp.ProgressParams, 10)
+	progress := NewTestTracker(channel, nil)
+
+	for _, report := range []struct {
+		percentage int
+		message    string
+	}{{50, "waiting for 1m0s"}, {50, "waiting for 1m0s"}, {40, "waiting for 1m1s"}, {50, "waiting for 1m2s"}} {
+		// skip the rate limit
+		progress.lastReport = time.Time{}
+		progress.ReportWithMessage(report.percentage, report.message)
+	}
+
+	require.Len(t, channel, 2)
+	<-channel
+	assert.Equal(t, "waiting for 1m2s", (<-channel).Value.(lsp.WorkDoneProgressReport).Message)
+}
+
 func TestEndProgress(t *testing.T) {
 	output := lsp.ProgressParams{
 		Token: "token",
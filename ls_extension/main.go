@@ -18,11 +18,13 @@ package ls_extension
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 
 	"github.com/rs/zerolog/log"
 
+	"github.com/khulnasoft-lab/vulnmap-ls/application/doctor"
 	"github.com/khulnasoft-lab/vulnmap-ls/application/entrypoint"
 	"github.com/khulnasoft-lab/vulnmap-ls/application/server"
 	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/cli"
@@ -30,6 +32,7 @@ import (
 
 	"github.com/rs/zerolog"
 
+	"github.com/khulnasoft-lab/go-application-framework/pkg/configuration"
 	"github.com/khulnasoft-lab/go-application-framework/pkg/workflow"
 	"github.com/spf13/pflag"
 
@@ -48,7 +51,7 @@ func Init(engine workflow.Engine) error {
 		"formatFlag",
 		"o",
 		config.FormatMd,
-		"sets format of diagnostics. Accepted values \""+config.FormatMd+"\" and \""+config.FormatHtml+"\"")
+		"deprecated and ignored, the format of hovers is determined by the client capabilities")
 	flags.StringP(
 		"configfile",
 		"c",
@@ -58,6 +61,14 @@ func Init(engine workflow.Engine) error {
 		"licenses",
 		false,
 		"displays license information")
+	flags.Bool(
+		"doctor",
+		false,
+		"checks the setup of the language server and prints a report")
+	flags.Bool(
+		"json",
+		false,
+		"prints the doctor report as JSON")
 
 	config := workflow.ConfigurationOptionsFromFlagset(flags)
 	entry, _ := engine.Register(WORKFLOWID_LS, config, lsWorkflow)
@@ -85,7 +96,6 @@ func lsWorkflow(
 	c.Load()
 	c.SetLogLevel(extensionConfig.GetString("logLevelFlag"))
 	c.SetLogPath(extensionConfig.GetString("logPathFlag"))
-	c.SetFormat(extensionConfig.GetString("formatFlag"))
 
 	defaultConfig := c.Engine().GetConfiguration()
 	defaultConfig.Set(cli_constants.EXECUTION_MODE_KEY, cli_constants.EXECUTION_MODE_VALUE_EXTENSION)
@@ -100,8 +110,19 @@ func lsWorkflow(
 		fmt.Println(string(about))
 
 		return output, err
+	} else if extensionConfig.GetBool("doctor") {
+		doctor.UseToken(c, extensionConfig.GetString(configuration.AUTHENTICATION_TOKEN))
+		report := doctor.Run(context.Background(), c)
+		if err = report.Print(os.Stdout, extensionConfig.GetBool("json")); err != nil {
+			return output, err
+		}
+		if !report.Passed {
+			return output, errors.New("doctor: some checks failed")
+		}
+		return output, nil
 	} else {
-		log.Trace().Interface("environment", os.Environ()).Msg("start environment")
+		c.ConfigureStderrLogging()
+		log.Trace().Interface("environment", cli.RedactEnvironment(os.Environ())).Msg("start environment")
 		server.Start(c)
 	}
 
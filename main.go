@@ -18,9 +18,12 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"time"
 
 	"github.com/khulnasoft-lab/go-application-framework/pkg/utils"
 	"github.com/khulnasoft-lab/go-application-framework/pkg/workflow"
@@ -33,7 +36,10 @@ import (
 	"github.com/rs/zerolog/log"
 
 	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
+	"github.com/khulnasoft-lab/vulnmap-ls/application/doctor"
 	"github.com/khulnasoft-lab/vulnmap-ls/application/server"
+	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/cli"
+	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/rpctrace"
 )
 
 func main() {
@@ -41,6 +47,12 @@ func main() {
 
 	zerolog.SetGlobalLevel(zerolog.InfoLevel)
 	c := config.CurrentConfig()
+	if len(os.Args) > 1 && os.Args[1] == doctorCommand {
+		os.Exit(runDoctor(os.Args[1:], c, os.Stdout))
+	}
+	if len(os.Args) > 1 && os.Args[1] == replayCommand {
+		os.Exit(runReplay(os.Args[1:], c, os.Stderr))
+	}
 	output, err := parseFlags(os.Args, c)
 	if err != nil {
 		fmt.Println(err, output)
@@ -50,11 +62,83 @@ func main() {
 		entrypoint.PrintLicenseText(output)
 	}
 
-	log.Trace().Interface("environment", os.Environ()).Msg("start environment")
+	c.ConfigureStderrLogging()
+	log.Trace().Interface("environment", cli.RedactEnvironment(os.Environ())).Msg("start environment")
 	server.Start(c)
 	log.Info().Msg("Exiting...")
 }
 
+// doctorCommand is the subcommand that checks the setup and prints a report instead of starting the server
+const doctorCommand = "doctor"
+
+// runDoctor runs the checks of the doctor subcommand and returns the exit code, 1 if a check failed
+func runDoctor(args []string, c *config.Config, w io.Writer) int {
+	flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	flags.SetOutput(w)
+	jsonFlag := flags.Bool("json", false, "prints the report as JSON")
+	configFlag := flags.String(
+		"c",
+		"",
+		"provide the full path of a config file to use. format VARIABLENAME=VARIABLEVALUE")
+	logLevelFlag := flags.String("l", "error", "sets the log-level to <trace|debug|info|warn|error|fatal>")
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	c.SetConfigFile(*configFlag)
+	c.Load()
+	c.SetLogLevel(*logLevelFlag)
+	config.SetCurrentConfig(c)
+	doctor.UseToken(c, os.Getenv(cli.TokenEnvVar))
+
+	report := doctor.Run(context.Background(), c)
+	if err := report.Print(w, *jsonFlag); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if !report.Passed {
+		return 1
+	}
+	return 0
+}
+
+// replayCommand is the subcommand that replays a recorded JSON-RPC session against fake backends
+const replayCommand = "replay"
+
+// runReplay replays the recording given as argument and returns the exit code
+func runReplay(args []string, c *config.Config, w io.Writer) int {
+	flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	flags.SetOutput(w)
+	flags.Usage = func() {
+		fmt.Fprintf(w, "Usage: vulnmap-ls %s [flags] <recording>\n", replayCommand)
+		flags.PrintDefaults()
+	}
+	outputFlag := flags.String("o", "", "records the replayed session to the given file")
+	waitFlag := flags.Duration("wait", 30*time.Second, "how long to wait for each response and for the server to settle")
+	logLevelFlag := flags.String("l", "info", "sets the log-level to <trace|debug|info|warn|error|fatal>")
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	messages, err := rpctrace.Read(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintln(w, err)
+		return 1
+	}
+	c.SetLogLevel(*logLevelFlag)
+	c.ConfigureStderrLogging()
+	config.SetCurrentConfig(c)
+	if err = server.Replay(c, messages, *outputFlag, *waitFlag); err != nil {
+		fmt.Fprintln(w, err)
+		return 1
+	}
+	return 0
+}
+
 func parseFlags(args []string, c *config.Config) (string, error) {
 	flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
 	var buf bytes.Buffer
@@ -63,10 +147,10 @@ func parseFlags(args []string, c *config.Config) (string, error) {
 	versionFlag := flags.Bool("v", false, "prints the version")
 	logLevelFlag := flags.String("l", "info", "sets the log-level to <trace|debug|info|warn|error|fatal>")
 	logPathFlag := flags.String("f", "", "sets the log file for the language server")
-	formatFlag := flags.String(
+	_ = flags.String(
 		"o",
 		config.FormatMd,
-		"sets format of diagnostics. Accepted values \""+config.FormatMd+"\" and \""+config.FormatHtml+"\"")
+		"deprecated and ignored, the format of hovers is determined by the client capabilities")
 	configFlag := flags.String(
 		"c",
 		"",
@@ -101,7 +185,6 @@ func parseFlags(args []string, c *config.Config) (string, error) {
 	c.Load()
 	c.SetLogLevel(*logLevelFlag)
 	c.SetLogPath(*logPathFlag)
-	c.SetFormat(*formatFlag)
 	if os.Getenv(config.SendErrorReportsKey) == "" {
 		c.SetErrorReportingEnabled(*reportErrorsFlag)
 	}
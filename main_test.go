@@ -17,7 +17,11 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
@@ -27,8 +31,11 @@ import (
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/khulnasoft-lab/vulnmap-ls/application/config"
+	"github.com/khulnasoft-lab/vulnmap-ls/application/doctor"
+	"github.com/khulnasoft-lab/vulnmap-ls/infrastructure/cli"
 	"github.com/khulnasoft-lab/vulnmap-ls/internal/testutil"
 )
 
@@ -53,10 +60,44 @@ func Test_shouldSetLogFileViaFlag(t *testing.T) {
 	assert.Equal(t, config.CurrentConfig().LogPath(), "a.txt")
 }
 
-func Test_shouldSetOutputFormatViaFlag(t *testing.T) {
+func Test_shouldAcceptDeprecatedOutputFormatFlag(t *testing.T) {
 	args := []string{"vulnmap-ls", "-o", config.FormatHtml}
-	_, _ = parseFlags(args, config.New())
-	assert.Equal(t, config.FormatHtml, config.CurrentConfig().Format())
+	_, err := parseFlags(args, config.New())
+	assert.NoError(t, err)
+}
+
+func Test_runDoctor_ShowsUsageOnUnknownFlag(t *testing.T) {
+	var buf bytes.Buffer
+
+	exitCode := runDoctor([]string{doctorCommand, "-unknown"}, config.New(), &buf)
+
+	assert.Equal(t, 2, exitCode)
+	assert.Contains(t, buf.String(), "-json")
+}
+
+func Test_runDoctor_AuthenticatesWithTokenFromEnvironment(t *testing.T) {
+	c := testutil.UnitTest(t)
+	c.SetToken("")
+	token := "00000000-0000-0000-0000-000000000002"
+	t.Setenv(cli.TokenEnvVar, token)
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/user/me" {
+			authorization = r.Header.Get("Authorization")
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+	c.UpdateApiEndpoints(server.URL)
+	var buf bytes.Buffer
+
+	_ = runDoctor([]string{doctorCommand, "-json"}, c, &buf)
+
+	var report doctor.Report
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &report))
+	require.Equal(t, "Authentication", report.Checks[1].Name)
+	assert.Equal(t, doctor.Pass, report.Checks[1].Status, report.Checks[1].Message)
+	assert.Contains(t, authorization, token)
 }
 
 func Test_shouldShowUsageOnUnknownFlag(t *testing.T) {
@@ -138,3 +179,22 @@ func Test_ConfigureLoggingShouldAddFileLogger(t *testing.T) {
 		return len(bytes) > 0
 	}, 2*time.Second, 10*time.Millisecond, "didn't write to logfile")
 }
+
+func Test_runReplay_ShowsUsageWithoutRecording(t *testing.T) {
+	var buf bytes.Buffer
+
+	exitCode := runReplay([]string{replayCommand}, config.New(), &buf)
+
+	assert.Equal(t, 2, exitCode)
+	assert.Contains(t, buf.String(), "<recording>")
+	assert.Contains(t, buf.String(), "-wait")
+}
+
+func Test_runReplay_FailsOnMissingRecording(t *testing.T) {
+	var buf bytes.Buffer
+
+	exitCode := runReplay([]string{replayCommand, filepath.Join(t.TempDir(), "missing.jsonl")}, config.New(), &buf)
+
+	assert.Equal(t, 1, exitCode)
+	assert.NotEmpty(t, buf.String())
+}